@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+// JWKSHandler publish public keys so other service can verify token issued by this service
+func JWKSHandler(jwt interface{ JWKS() []mjwt.JWK }) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		env := web.Envelope{
+			"keys": jwt.JWKS(),
+		}
+
+		err := web.WriteJSON(w, http.StatusOK, env, nil)
+		if err != nil {
+			web.ServerErrorResponse(w, r, err)
+		}
+	}
+}
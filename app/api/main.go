@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"github.com/go-playground/validator/v10"
+	notifModel "github.com/muchlist/moneymagnet/business/notification/model"
 	"github.com/muchlist/moneymagnet/cfg"
 	"github.com/muchlist/moneymagnet/pkg/cache"
 	"github.com/muchlist/moneymagnet/pkg/db"
@@ -53,6 +55,9 @@ type application struct {
 func main() {
 	config := cfg.Load()
 	ctx := context.Background()
+	if err := config.Validate(); err != nil {
+		panic(err.Error())
+	}
 
 	// init log
 	contextField := map[string]any{"request_id": global.RequestIDKey}
@@ -105,13 +110,16 @@ func main() {
 		}
 	}()
 
-	// init firebase app
-	firebaseApp, err := mfirebase.InitFirebase(mfirebase.Config{
-		CredLocation: config.Google.CredentialLocation,
-	})
-	if err != nil {
-		log.Error("init firebase", err)
-		panic(err.Error())
+	// init firebase app, only required by push notification channel
+	var firebaseApp *firebase.App
+	if slices.Contains(config.NotificationChannels(), notifModel.ChannelPush) {
+		firebaseApp, err = mfirebase.InitFirebase(mfirebase.Config{
+			CredLocation: config.Google.CredentialLocation,
+		})
+		if err != nil {
+			log.Error("init firebase", err)
+			panic(err.Error())
+		}
 	}
 
 	// init validator
@@ -119,6 +127,9 @@ func main() {
 		{
 			Key:       "custom_date",
 			Translate: "{0} must be valid date format",
+			Translations: map[string]string{
+				"id": "{0} harus berupa format tanggal yang valid",
+			},
 			ValidFunc: func(fl validator.FieldLevel) bool {
 				str := fl.Field().String()
 				layout := "2006-01-02 15:04:05"
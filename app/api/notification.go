@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	notifchan "github.com/muchlist/moneymagnet/business/notification/channel"
+	notifModel "github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/mailer"
+	"github.com/muchlist/moneymagnet/pkg/mfirebase"
+)
+
+// fcmClient return nil when firebase is not initialized, firebase is only needed by push channel
+func (app *application) fcmClient() (mfirebase.FCMSender, error) {
+	if app.firebase == nil {
+		return nil, nil
+	}
+	fcmClient, err := mfirebase.NewFcmClient(app.firebase)
+	if err != nil {
+		return nil, fmt.Errorf("error get fcm client: %w", err)
+	}
+	return fcmClient, nil
+}
+
+// notificationChannels create channel enabled by NOTIFICATION_CHANNELS.
+// push channel send pocket message to pocket topic when topicPockets is not nil
+func (app *application) notificationChannels(mailSender mailer.Mailer, tokenEditor port.FCMTokenEditor, fcmClient mfirebase.FCMSender, topicPockets port.PocketReader, webhookSender notifchan.WebhookSender) ([]port.Channel, error) {
+	names := app.config.NotificationChannels()
+	channels := make([]port.Channel, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case notifModel.ChannelPush:
+			if fcmClient == nil {
+				return nil, fmt.Errorf("push channel require firebase")
+			}
+			push := notifchan.NewPush(fcmClient, tokenEditor)
+			if topicPockets != nil {
+				push.EnableTopic(topicPockets)
+			}
+			channels = append(channels, push)
+		case notifModel.ChannelEmail:
+			channels = append(channels, notifchan.NewEmail(mailSender))
+		case notifModel.ChannelWebhook:
+			channels = append(channels, notifchan.NewWebhook(webhookSender))
+		case notifModel.ChannelLog:
+			channels = append(channels, notifchan.NewLog(app.logger))
+		default:
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+	}
+	return channels, nil
+}
@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
+	achand "github.com/muchlist/moneymagnet/business/account/handler"
+	acrepo "github.com/muchlist/moneymagnet/business/account/repo"
+	acserv "github.com/muchlist/moneymagnet/business/account/service"
 	cyhand "github.com/muchlist/moneymagnet/business/category/handler"
 	cyrepo "github.com/muchlist/moneymagnet/business/category/repo"
 	cyserv "github.com/muchlist/moneymagnet/business/category/service"
+	jobhand "github.com/muchlist/moneymagnet/business/job/handler"
+	jobModel "github.com/muchlist/moneymagnet/business/job/model"
+	jobrepo "github.com/muchlist/moneymagnet/business/job/repo"
+	jobserv "github.com/muchlist/moneymagnet/business/job/service"
+	notifhand "github.com/muchlist/moneymagnet/business/notification/handler"
+	notifport "github.com/muchlist/moneymagnet/business/notification/port"
+	notifrepo "github.com/muchlist/moneymagnet/business/notification/repo"
 	notifserv "github.com/muchlist/moneymagnet/business/notification/service"
 	pthand "github.com/muchlist/moneymagnet/business/pocket/handler"
 	ptrepo "github.com/muchlist/moneymagnet/business/pocket/repo"
 	ptserv "github.com/muchlist/moneymagnet/business/pocket/service"
+	rthand "github.com/muchlist/moneymagnet/business/realtime/handler"
+	rtserv "github.com/muchlist/moneymagnet/business/realtime/service"
 	reqhand "github.com/muchlist/moneymagnet/business/request/handler"
 	reqrepo "github.com/muchlist/moneymagnet/business/request/repo"
 	reqserv "github.com/muchlist/moneymagnet/business/request/service"
@@ -20,12 +34,20 @@ import (
 	urhand "github.com/muchlist/moneymagnet/business/user/handler"
 	urrepo "github.com/muchlist/moneymagnet/business/user/repo"
 	urserv "github.com/muchlist/moneymagnet/business/user/service"
+	whhand "github.com/muchlist/moneymagnet/business/webhook/handler"
+	whrepo "github.com/muchlist/moneymagnet/business/webhook/repo"
+	whserv "github.com/muchlist/moneymagnet/business/webhook/service"
+	"github.com/muchlist/moneymagnet/pkg/bg"
 	"github.com/muchlist/moneymagnet/pkg/cache"
 	"github.com/muchlist/moneymagnet/pkg/db"
 	"github.com/muchlist/moneymagnet/pkg/lrucache"
-	"github.com/muchlist/moneymagnet/pkg/mfirebase"
+	"github.com/muchlist/moneymagnet/pkg/mailer"
 	"github.com/muchlist/moneymagnet/pkg/mid"
 	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+	"github.com/muchlist/moneymagnet/pkg/oidc"
+	"github.com/muchlist/moneymagnet/pkg/pubsub"
+	"github.com/muchlist/moneymagnet/pkg/web"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"github.com/muchlist/moneymagnet/pkg/mcrypto"
@@ -37,47 +59,129 @@ func (app *application) routes() (http.Handler, error) {
 	r := chi.NewRouter()
 
 	// dependency
-	jwt := mjwt.New(app.config.App.Secret)
-	bcrypt := mcrypto.New()
+	keyring, err := mjwt.NewKeyringFromOption(app.config.JWTOption())
+	if err != nil {
+		return r, fmt.Errorf("error load jwt key: %w", err)
+	}
+	jwt := mjwt.NewWithKeyring(keyring)
+	crypter := mcrypto.New()
 	lruCacheObj := lrucache.NewLRUCache()
 	int64Cache := cache.NewCache[int64](app.redis, true)
-	fcmClient, err := mfirebase.NewFcmClient(app.firebase)
+	loginAttempts := cache.NewCounter(app.redis)
+	mailSender, err := mailer.New(mailer.Option{
+		Driver:   app.config.Mail.Driver,
+		Host:     app.config.Mail.Host,
+		Port:     app.config.Mail.Port,
+		Username: app.config.Mail.Username,
+		Password: app.config.Mail.Password,
+		From:     app.config.Mail.From,
+		FileDir:  app.config.Mail.FileDir,
+	}, app.logger)
 	if err != nil {
-		return r, fmt.Errorf("error get fcm client: %w", err)
+		return r, fmt.Errorf("error create mailer: %w", err)
 	}
 
 	// middleware
 	idempo := mid.NewIdempotencyMiddleware(lruCacheObj)
-	r.Use(mid.EndpoitnCounter)
+	r.Use(web.Timeout(10 * time.Second))
 
 	userRepo := urrepo.NewRepo(app.db, app.logger)
 	pocketRepo := ptrepo.NewRepo(app.db, app.logger)
 	categoryRepo := cyrepo.NewRepo(app.db, app.logger)
 	requestRepo := reqrepo.NewRepo(app.db, app.logger)
 	spendRepo := spnrepo.NewRepo(app.db, app.logger)
+	accountRepo := acrepo.NewRepo(app.db, app.logger)
+	jobRepo := jobrepo.NewRepo(app.db, app.logger)
+	webhookRepo := whrepo.NewRepo(app.db, app.logger)
+	notificationRepo := notifrepo.NewRepo(app.db, app.logger)
 	rTagCacheRepo := spnrepo.NewETagCache(int64Cache,
 		app.config.Redis.RedisDefDuration,
 		app.logger,
 	)
 	txManager := db.NewTxManager(app.db, app.logger)
 
-	notificaionService := notifserv.NewCore(app.logger, fcmClient, userRepo)
+	jobService := jobserv.NewCore(app.logger, jobRepo, jobserv.DefaultOption)
+	jobHandler := jobhand.NewJobHandler(app.logger, jobService)
+
+	fcmClient, err := app.fcmClient()
+	if err != nil {
+		return r, err
+	}
+	// pocket topic is only used when push channel is enabled
+	pushTopic := app.config.Notif.PushTopic && fcmClient != nil
+	var topicPockets notifport.PocketReader
+	if pushTopic {
+		topicPockets = pocketRepo
+	}
+	// pocket webhook and notification webhook channel share client refusing private address
+	webhookClient := mwebhook.NewClient(10*time.Second, app.config.Webhook.AllowPrivateNetwork)
+	notificationChannels, err := app.notificationChannels(mailSender, userRepo, fcmClient, topicPockets, webhookClient)
+	if err != nil {
+		return r, err
+	}
+	notificaionService := notifserv.NewCore(app.logger, notificationChannels, app.config.NotificationDefaultChannels(), app.config.Notif.DigestWindow,
+		userRepo, notificationRepo, notificationRepo, notificationRepo, pocketRepo, jobService, txManager)
+	notificationHandler := notifhand.NewNotificationHandler(app.logger, app.validator, notificaionService)
+	topicService := notifserv.NewTopicCore(app.logger, pushTopic, userRepo, pocketRepo, notificationRepo, fcmClient, jobService)
+
+	webhookService := whserv.NewCore(app.logger, webhookRepo, pocketRepo, jobService, webhookClient)
+	webhookHandler := whhand.NewWebhookHandler(app.logger, app.validator, webhookService)
+
+	// realtime event is shared between instance through redis
+	var eventHub pubsub.Hub = pubsub.NewMemoryHub()
+	if app.config.Toggle.PubSubRedis {
+		eventHub = pubsub.NewRedisHub(app.redis, app.logger)
+	}
+	// end open event stream, so server shutdown does not wait for them
+	web.OnShutdown(func() {
+		_ = eventHub.Close()
+	})
+	realtimeService := rtserv.NewCore(app.logger, eventHub, pocketRepo)
+	realtimeHandler := rthand.NewRealtimeHandler(app.logger, realtimeService)
 
-	userService := urserv.NewCore(app.logger, userRepo, bcrypt, jwt)
+	userService := urserv.NewCore(app.logger, userRepo, crypter, jwt, mailSender, topicService, txManager, loginAttempts, app.config.Mail.ResetPasswordURL)
 	userHandler := urhand.NewUserHandler(app.logger, app.validator, userService)
+	mid.SetSessionChecker(userService)
+	mid.SetTokenAuthenticator(userService)
+	mid.SetLocaleReader(userService)
+	if app.config.OIDCEnabled() {
+		userService.EnableOIDC(oidc.NewProvider(app.config.OIDCProviderConfig()), app.config.OIDC.ProviderName, app.config.OIDC.AllowSignup)
+	}
 
-	pocketService := ptserv.NewCore(app.logger, pocketRepo, userRepo, categoryRepo, txManager)
+	pocketService := ptserv.NewCore(app.logger, pocketRepo, userRepo, categoryRepo, webhookService, realtimeService, topicService, txManager)
 	pocketHandler := pthand.NewPocketHandler(app.logger, app.validator, lruCacheObj, pocketService)
 
-	categoryService := cyserv.NewCore(app.logger, categoryRepo, pocketRepo)
+	categoryService := cyserv.NewCore(app.logger, categoryRepo, pocketRepo, realtimeService, txManager)
 	categoryHandler := cyhand.NewCatHandler(app.logger, app.validator, categoryService)
 
-	requestService := reqserv.NewCore(app.logger, requestRepo, pocketRepo, txManager)
+	requestService := reqserv.NewCore(app.logger, requestRepo, pocketRepo, webhookService, topicService, txManager)
 	requestHandler := reqhand.NewRequestHandler(app.logger, app.validator, requestService)
 
-	spendService := spnserv.NewCore(app.logger, spendRepo, pocketRepo, rTagCacheRepo, notificaionService, txManager)
+	spendService := spnserv.NewCore(app.logger, spendRepo, pocketRepo, rTagCacheRepo, jobService, webhookService, realtimeService, userRepo, txManager)
 	spendHandler := spnhand.NewSpendHandler(app.logger, app.validator, lruCacheObj, spendService)
 
+	accountService := acserv.NewCore(app.logger, accountRepo, userRepo, pocketRepo, categoryRepo, spendRepo, topicService, txManager)
+	accountHandler := achand.NewAccountHandler(app.logger, app.validator, accountService)
+
+	// durable job, enqueued by service inside their transaction
+	jobserv.Handle(jobService, jobModel.TypeSendNotification, notificaionService.SendNotificationToUser)
+	jobserv.Handle(jobService, jobModel.TypeFlushDigest, notificaionService.FlushDigest)
+	jobserv.Handle(jobService, jobModel.TypeSyncPushTopic, topicService.SyncUserTopics)
+	jobserv.Handle(jobService, jobModel.TypeSetPocketETag, spendService.SetPocketETag)
+	jobserv.Handle(jobService, jobModel.TypeDeliverWebhook, webhookService.Deliver)
+	bg.RunUntilShutdown(context.Background(), bg.BackgroundJob{
+		JobTitle: "durable job worker",
+		Execute:  jobService.Run,
+	})
+
+	// delete account which grace period is over
+	bg.RunUntilShutdown(context.Background(), bg.BackgroundJob{
+		JobTitle: "purge deleted account",
+		Execute: func(ctx context.Context) {
+			accountService.RunPurgeLoop(ctx, time.Hour)
+		},
+	})
+
 	// swagger endpoint
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8081/swagger/doc.json"),
@@ -85,8 +189,14 @@ func (app *application) routes() (http.Handler, error) {
 
 	// Endpoint with no auth required
 	r.Get("/healthcheck", HealthCheckHandler)
+	r.Get("/.well-known/jwks.json", JWKSHandler(jwt))
 	r.Post("/user/login", userHandler.Login)
+	r.Post("/user/login/2fa", userHandler.LoginTOTP)
+	r.Get("/user/oidc/authorize", userHandler.OIDCAuthorize)
+	r.Post("/user/oidc/callback", userHandler.OIDCCallback)
 	r.Post("/user/refresh", userHandler.RefreshToken)
+	r.Post("/user/forgot-password", userHandler.ForgotPassword)
+	r.Post("/user/reset-password", userHandler.ResetPassword)
 
 	// Endpoint with fresh auth admin
 	r.Group(func(r chi.Router) {
@@ -94,6 +204,9 @@ func (app *application) routes() (http.Handler, error) {
 		r.Post("/register", userHandler.Register)
 		r.Patch("/edit-user/{id}", userHandler.EditUser)
 		r.Delete("/user/{id}", userHandler.DeleteUser)
+		r.Post("/user/unlock", userHandler.UnlockLogin)
+		r.Get("/admin/jobs", jobHandler.FindJobs)
+		r.Post("/admin/jobs/{id}/retry", jobHandler.RetryJob)
 	})
 
 	// Endpoint with auth
@@ -101,6 +214,18 @@ func (app *application) routes() (http.Handler, error) {
 		r.Use(mid.RequiredRoles())
 		r.Route("/user", func(r chi.Router) {
 			r.Get("/profile", userHandler.Profile)
+			r.Get("/profile/export", accountHandler.Export)
+			r.Get("/profile/deletion", accountHandler.GetDeletion)
+			r.Delete("/profile/deletion", accountHandler.CancelDeletion)
+			r.Get("/sessions", userHandler.FindSessions)
+			r.Delete("/sessions", userHandler.RevokeAllSessions)
+			r.Delete("/sessions/{id}", userHandler.RevokeSession)
+			r.Post("/logout", userHandler.Logout)
+			r.Get("/tokens", userHandler.FindAccessTokens)
+			r.Get("/login-history", userHandler.FindLoginHistory)
+			r.Get("/preferences", userHandler.GetPreference)
+			r.Patch("/preferences", userHandler.PatchPreference)
+			r.Delete("/tokens/{id}", userHandler.RevokeAccessToken)
 			r.Get("/{id}", userHandler.GetByID)
 			r.Get("/", userHandler.FindByName)
 			r.Post("/fcm/{id}", userHandler.UpdateFCM)
@@ -109,17 +234,40 @@ func (app *application) routes() (http.Handler, error) {
 		r.Route("/pockets", func(r chi.Router) {
 			r.Get("/{id}", pocketHandler.GetByID)
 			r.Get("/", pocketHandler.FindUserPocket)
+			r.Get("/{id}/webhooks", webhookHandler.FindPocketWebhooks)
+			r.Post("/{id}/webhooks", webhookHandler.CreateWebhook)
 
 			i := r.With(idempo.IdempotentCheck)
 			i.Post("/", pocketHandler.CreatePocket)
 			i.Patch("/{id}", pocketHandler.UpdatePocket)
 		})
 
+		r.Route("/notifications", func(r chi.Router) {
+			r.Get("/", notificationHandler.FindNotifications)
+			r.Get("/unread-count", notificationHandler.CountUnread)
+			r.Post("/read-all", notificationHandler.MarkAllRead)
+			r.Post("/{id}/read", notificationHandler.MarkRead)
+			r.Get("/preferences", notificationHandler.GetPreference)
+			r.Patch("/preferences", notificationHandler.UpdatePreference)
+			r.Put("/preferences/pockets/{id}", notificationHandler.SetPocketPreference)
+			r.Delete("/preferences/pockets/{id}", notificationHandler.ResetPocketPreference)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Patch("/{id}", webhookHandler.UpdateWebhook)
+			r.Delete("/{id}", webhookHandler.DeleteWebhook)
+			r.Get("/{id}/deliveries", webhookHandler.FindDeliveries)
+			r.Post("/{id}/deliveries/{delivery_id}/redeliver", webhookHandler.Redeliver)
+		})
+
 		r.Route("/categories", func(r chi.Router) {
 			r.Post("/", categoryHandler.CreateCategory)
 			r.Get("/from-pocket/{id}", categoryHandler.FindPocketCategory)
 			r.Put("/{id}", categoryHandler.EditCategory)
 			r.Delete("/{id}", categoryHandler.DeleteCategory)
+			r.Post("/{id}/merge", categoryHandler.MergeCategory)
+			r.Put("/{id}/override", categoryHandler.SetOverride)
+			r.Delete("/{id}/override", categoryHandler.ResetOverride)
 		})
 
 		r.Route("/request", func(r chi.Router) {
@@ -133,6 +281,7 @@ func (app *application) routes() (http.Handler, error) {
 			r.Get("/", spendHandler.SearchSpends)
 			r.Get("/from-pocket/{id}/with-cursor", spendHandler.FindSpendByCursor)
 			r.Get("/from-pocket/{id}/with-cursor-auto", spendHandler.FindSpendAutoDateByCursor)
+			r.Get("/from-pocket/{id}/summary-category", spendHandler.SummaryByCategory)
 			r.Get("/from-pocket/{id}", spendHandler.FindSpend)
 			r.Get("/{id}", spendHandler.GetByID)
 			r.Post("/sync/{id}", spendHandler.SyncBalance)
@@ -150,9 +299,21 @@ func (app *application) routes() (http.Handler, error) {
 	r.Group(func(r chi.Router) {
 		r.Use(mid.RequiredFreshRoles())
 		r.Patch("/user/profile", userHandler.EditSelfUser)
+		r.Post("/user/profile/deletion", accountHandler.ScheduleDeletion)
+		r.Post("/user/2fa/setup", userHandler.SetupTOTP)
+		r.Post("/user/2fa/enable", userHandler.EnableTOTP)
+		r.Post("/user/2fa/disable", userHandler.DisableTOTP)
+		r.Post("/user/2fa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+		r.Post("/user/tokens", userHandler.CreateAccessToken)
 	})
 
-	return r, nil
+	// event stream is kept open by client, so it is routed outside of request timeout
+	root := chi.NewRouter()
+	root.Use(mid.EndpoitnCounter)
+	root.With(mid.RequiredRoles()).Get("/pockets/{id}/events", realtimeHandler.StreamPocketEvents)
+	root.Mount("/", r)
+
+	return root, nil
 }
 
 // =============================================================================
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muchlist/moneymagnet/migrations"
+	"github.com/muchlist/moneymagnet/pkg/migrate"
+)
+
+// usage : admin migrate up | down [-steps 1] | version
+func runMigration(ctx context.Context, app *admin, args []string) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: admin migrate up | down [-steps 1] | version", errUsage)
+	}
+
+	action := args[0]
+	if action != "up" && action != "down" && action != "version" {
+		return nil, fmt.Errorf("%w: unknown migrate action %q", errUsage, action)
+	}
+
+	fs := newFlagSet("migrate " + action)
+	steps := fs.Int("steps", 1, "count of migration to rollback, only for down")
+	if err := app.parseFlags(fs, args[1:]); err != nil {
+		return nil, err
+	}
+	if *steps < 1 {
+		return nil, fmt.Errorf("%w: -steps must be greater than 0", errUsage)
+	}
+
+	migrator, err := migrate.New(app.db, migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("read migration: %w", err)
+	}
+
+	var applied []migrate.Result
+	switch action {
+	case "up":
+		applied, err = migrator.Up(ctx)
+	case "down":
+		applied, err = migrator.Down(ctx, *steps)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return nil, fmt.Errorf("migrate %s: %w", action, err)
+	}
+
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{
+		"version": version,
+		"dirty":   dirty,
+	}
+	if action != "version" {
+		result["applied"] = applied
+	}
+	return result, nil
+}
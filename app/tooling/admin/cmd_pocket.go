@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// usage : admin recompute-balances
+func recomputeBalances(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("recompute-balances")
+	if err := app.parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	results, err := app.spendService.SyncAllBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("recompute balance after %d pocket: %w", len(results), err)
+	}
+	return map[string]any{
+		"count":   len(results),
+		"pockets": results,
+	}, nil
+}
+
+// usage : admin export-pocket -pocket <id> [-out file.json]
+// without -out the export is printed as data of the output
+func exportPocket(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("export-pocket")
+	pocket := fs.String("pocket", "", "pocket id")
+	out := fs.String("out", "", "write export to this file instead of stdout")
+	if err := app.parseFlags(fs, args, "pocket"); err != nil {
+		return nil, err
+	}
+
+	pocketID, err := xulid.Parse(*pocket)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not valid pocket id", *pocket)
+	}
+
+	result, err := app.accountService.ExportPocket(ctx, pocketID)
+	if err != nil {
+		return nil, fmt.Errorf("export pocket: %w", err)
+	}
+	if *out == "" {
+		return result, nil
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return nil, fmt.Errorf("create export file: %w", err)
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return nil, fmt.Errorf("write export file: %w", err)
+	}
+	return map[string]any{
+		"file":       *out,
+		"pocket_id":  result.Pocket.ID,
+		"categories": len(result.Categories),
+		"spends":     len(result.Spends),
+	}, nil
+}
+
+// usage : admin import-pocket -user <id|email> -in file.json
+// -in accept "-" to read from stdin, both raw export and {"data": export} output is accepted
+func importPocket(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("import-pocket")
+	target := fs.String("user", "", "owner of new pocket, user id or email")
+	in := fs.String("in", "", "export file, - for stdin")
+	if err := app.parseFlags(fs, args, "user", "in"); err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = os.Stdin
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return nil, fmt.Errorf("open export file: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	data, err := decodePocketExport(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := app.findUser(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := app.accountService.ImportPocket(ctx, user.ID, data)
+	if err != nil {
+		return nil, fmt.Errorf("import pocket: %w", err)
+	}
+	return result, nil
+}
+
+// decodePocketExport read export written by -out or printed in data of export-pocket output
+func decodePocketExport(r io.Reader) (model.PocketExport, error) {
+	var wrapped struct {
+		Data *model.PocketExport `json:"data"`
+		model.PocketExport
+	}
+	if err := json.NewDecoder(r).Decode(&wrapped); err != nil {
+		return model.PocketExport{}, fmt.Errorf("decode export: %w", err)
+	}
+	data := wrapped.PocketExport
+	if wrapped.Data != nil {
+		data = *wrapped.Data
+	}
+	if data.Pocket.PocketName == "" {
+		return model.PocketExport{}, errors.New("export does not contain pocket")
+	}
+	return data, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	acmodel "github.com/muchlist/moneymagnet/business/account/model"
+	ctmodel "github.com/muchlist/moneymagnet/business/category/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	spmodel "github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// usage : admin seed [-email demo@moneymagnet.local] [-password ...] [-days 30]
+// user is created if email is not registered, a new demo pocket is added on every run
+func seedDemo(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("seed")
+	name := fs.String("name", "Demo User", "name of demo user")
+	email := fs.String("email", "demo@moneymagnet.local", "email of demo user")
+	password := fs.String("password", "Demo-Magnet-2024!", "password of demo user when created")
+	days := fs.Int("days", 30, "generate spend for this many days back")
+	if err := app.parseFlags(fs, args, "email"); err != nil {
+		return nil, err
+	}
+	if *days < 1 || *days > 366 {
+		return nil, fmt.Errorf("%w: -days must be between 1 and 366", errUsage)
+	}
+
+	user, created, err := findOrCreateUser(ctx, app, model.UserRegisterReq{
+		Name:     *name,
+		Email:    *email,
+		Password: *password,
+		Roles:    []string{"user"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := app.accountService.ImportPocket(ctx, user.ID, demoPocket(time.Now(), *days))
+	if err != nil {
+		return nil, fmt.Errorf("import demo pocket: %w", err)
+	}
+
+	return map[string]any{
+		"user":         user,
+		"user_created": created,
+		"pocket":       pocket,
+	}, nil
+}
+
+func findOrCreateUser(ctx context.Context, app *admin, req model.UserRegisterReq) (model.UserResp, bool, error) {
+	existing, err := app.userRepo.GetByEmail(ctx, req.Email)
+	if err == nil {
+		return existing.ToUserResp(), false, nil
+	}
+	if !errors.Is(err, db.ErrDBNotFound) {
+		return model.UserResp{}, false, fmt.Errorf("get user by email: %w", err)
+	}
+
+	user, err := app.userService.InsertUser(ctx, req)
+	if err != nil {
+		return model.UserResp{}, false, fmt.Errorf("register demo user: %w", err)
+	}
+	return user, true, nil
+}
+
+// demoPocket build pocket export with few categories and daily spend,
+// so it can be inserted with the same code as import-pocket
+func demoPocket(now time.Time, days int) acmodel.PocketExport {
+	food := xulid.Instance().NewULID()
+	coffee := xulid.Instance().NewULID()
+	transport := xulid.Instance().NewULID()
+	salary := xulid.Instance().NewULID()
+
+	categories := []ctmodel.CategoryResp{
+		{ID: food, CategoryName: "Makan", CategoryIcon: 1, DefaultSpendType: 1},
+		{ID: coffee, ParentID: xulid.NullULID{ULID: food, Valid: true}, CategoryName: "Kopi", CategoryIcon: 2, DefaultSpendType: 2},
+		{ID: transport, CategoryName: "Transport", CategoryIcon: 3, DefaultSpendType: 1},
+		{ID: salary, CategoryName: "Gaji", CategoryIcon: 4, IsIncome: true},
+	}
+
+	daily := []struct {
+		name      string
+		category  xulid.ULID
+		price     int64
+		spendType int
+	}{
+		{name: "Makan siang", category: food, price: -35000, spendType: 1},
+		{name: "Kopi susu", category: coffee, price: -22000, spendType: 2},
+		{name: "Ojek", category: transport, price: -15000, spendType: 1},
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, now.Location())
+	spends := make([]spmodel.SpendResp, 0, days*len(daily)+1)
+	spends = append(spends, spmodel.SpendResp{
+		CategoryID: xulid.NullULID{ULID: salary, Valid: true},
+		Name:       "Gaji bulanan",
+		Price:      7500000,
+		IsIncome:   true,
+		Date:       today.AddDate(0, 0, -days+1),
+	})
+	for day := days - 1; day >= 0; day-- {
+		date := today.AddDate(0, 0, -day)
+		for i, item := range daily {
+			// skip some coffee and ride so the data is not too uniform
+			if i > 0 && (day+i)%3 == 0 {
+				continue
+			}
+			spends = append(spends, spmodel.SpendResp{
+				CategoryID: xulid.NullULID{ULID: item.category, Valid: true},
+				Name:       item.name,
+				Price:      item.price,
+				SpendType:  item.spendType,
+				Date:       date.Add(time.Duration(i) * time.Hour),
+			})
+		}
+	}
+
+	return acmodel.PocketExport{
+		ExportedAt: now,
+		Pocket: ptmodel.PocketResp{
+			PocketName: "Dompet Demo",
+			Currency:   "Rp",
+			Icon:       1,
+		},
+		Categories: categories,
+		Spends:     spends,
+	}
+}
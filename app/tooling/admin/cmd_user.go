@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+)
+
+var grantType = []string{
+	"user",
+	"admin",
+}
+
+// usage : admin users [-q name] [-page 1] [-page-size 50] [-sort name]
+func listUsers(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("users")
+	query := fs.String("q", "", "part of user name, empty mean all user")
+	page := fs.Int("page", 1, "page number")
+	pageSize := fs.Int("page-size", 50, "user per page, max 100")
+	sort := fs.String("sort", "", "name, -name, updated_at or -updated_at")
+	if err := app.parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	users, metadata, err := app.userService.FindUserByName(ctx, *query, paging.Filters{
+		Page:     *page,
+		PageSize: *pageSize,
+		Sort:     *sort,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find user: %w", err)
+	}
+	return map[string]any{
+		"users":    users,
+		"metadata": metadata,
+	}, nil
+}
+
+// usage : admin user -user <id|email>
+func getUser(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("user")
+	target := fs.String("user", "", "user id or email")
+	if err := app.parseFlags(fs, args, "user"); err != nil {
+		return nil, err
+	}
+
+	user, err := app.findUser(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToUserResp(), nil
+}
+
+// usage : [ADMIN_PASSWORD=<password>] admin create-user -name <name> -email <email> [-password-stdin] [-roles user]
+func createUser(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("create-user")
+	name := fs.String("name", "", "name of user")
+	email := fs.String("email", "", "email of user")
+	passwordStdin := fs.Bool("password-stdin", false, "read password from first line of stdin instead of "+passwordEnv+" env")
+	roles := fs.String("roles", "user", "comma separated roles: "+strings.Join(grantType, ", "))
+	if err := app.parseFlags(fs, args, "name", "email"); err != nil {
+		return nil, err
+	}
+
+	password, err := readPassword(*passwordStdin)
+	if err != nil {
+		return nil, err
+	}
+	roleList, err := parseRoles(*roles)
+	if err != nil {
+		return nil, err
+	}
+	req := model.UserRegisterReq{
+		Name:     *name,
+		Email:    *email,
+		Password: password,
+		Roles:    roleList,
+	}
+	if _, err := app.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("input not valid: %w", err)
+	}
+
+	result, err := app.userService.InsertUser(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("register user: %w", err)
+	}
+	return result, nil
+}
+
+// usage : [ADMIN_PASSWORD=<password>] admin reset-password -user <id|email> [-password-stdin]
+func resetPassword(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("reset-password")
+	target := fs.String("user", "", "user id or email")
+	passwordStdin := fs.Bool("password-stdin", false, "read new password from first line of stdin instead of "+passwordEnv+" env")
+	if err := app.parseFlags(fs, args, "user"); err != nil {
+		return nil, err
+	}
+
+	password, err := readPassword(*passwordStdin)
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.findUser(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.userService.SetPassword(ctx, user.ID, password); err != nil {
+		return nil, fmt.Errorf("set password: %w", err)
+	}
+	return map[string]any{
+		"user_id":          user.ID,
+		"sessions_revoked": true,
+	}, nil
+}
+
+// usage : admin set-roles -user <id|email> -roles admin,user
+func setRoles(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("set-roles")
+	target := fs.String("user", "", "user id or email")
+	roles := fs.String("roles", "", "comma separated roles: "+strings.Join(grantType, ", "))
+	if err := app.parseFlags(fs, args, "user", "roles"); err != nil {
+		return nil, err
+	}
+
+	roleList, err := parseRoles(*roles)
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.findUser(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := app.userService.PatchUser(ctx, model.UserUpdate{
+		ID:    user.ID,
+		Roles: roleList,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("edit user: %w", err)
+	}
+	return result, nil
+}
+
+// usage : admin revoke-sessions -user <id|email>
+func revokeSessions(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("revoke-sessions")
+	target := fs.String("user", "", "user id or email")
+	if err := app.parseFlags(fs, args, "user"); err != nil {
+		return nil, err
+	}
+
+	user, err := app.findUser(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+	count, err := app.userService.RevokeUserSessions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"user_id": user.ID,
+		"revoked": count,
+	}, nil
+}
+
+// usage : admin unlock -email <email> [-ip <ip>]
+func unlockLogin(ctx context.Context, app *admin, args []string) (any, error) {
+	fs := newFlagSet("unlock")
+	email := fs.String("email", "", "email locked by failed login")
+	ip := fs.String("ip", "", "also unlock this ip address")
+	if err := app.parseFlags(fs, args, "email"); err != nil {
+		return nil, err
+	}
+
+	if err := app.userService.UnlockLogin(ctx, *email, *ip); err != nil {
+		return nil, fmt.Errorf("unlock login: %w", err)
+	}
+	return map[string]any{
+		"email": *email,
+		"ip":    *ip,
+	}, nil
+}
+
+// passwordEnv hold password for create-user and reset-password. password is never taken
+// from flag so it does not end up in shell history or process list
+const passwordEnv = "ADMIN_PASSWORD"
+
+// readPassword read password from first line of stdin when fromStdin, otherwise from passwordEnv
+func readPassword(fromStdin bool) (string, error) {
+	if !fromStdin {
+		password := os.Getenv(passwordEnv)
+		if password == "" {
+			return "", fmt.Errorf("%w: set %s env or pipe password with -password-stdin", errUsage, passwordEnv)
+		}
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read password from stdin: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%w: password from stdin is empty", errUsage)
+	}
+	return password, nil
+}
+
+// parseRoles split comma separated roles and make sure every role is known
+func parseRoles(input string) ([]string, error) {
+	roles := make([]string, 0)
+	for _, role := range strings.Split(input, ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !isValidRole(role) {
+			return nil, fmt.Errorf("role must be one of: %v", grantType)
+		}
+		roles = append(roles, role)
+	}
+	if len(roles) == 0 {
+		return nil, errors.New("at least one role is required")
+	}
+	return roles, nil
+}
+
+func isValidRole(role string) bool {
+	for _, v := range grantType {
+		if role == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	acrepo "github.com/muchlist/moneymagnet/business/account/repo"
+	acserv "github.com/muchlist/moneymagnet/business/account/service"
+	cyrepo "github.com/muchlist/moneymagnet/business/category/repo"
+	jobrepo "github.com/muchlist/moneymagnet/business/job/repo"
+	jobserv "github.com/muchlist/moneymagnet/business/job/service"
+	notifModel "github.com/muchlist/moneymagnet/business/notification/model"
+	notifrepo "github.com/muchlist/moneymagnet/business/notification/repo"
+	notifserv "github.com/muchlist/moneymagnet/business/notification/service"
+	ptrepo "github.com/muchlist/moneymagnet/business/pocket/repo"
+	rtserv "github.com/muchlist/moneymagnet/business/realtime/service"
+	spnrepo "github.com/muchlist/moneymagnet/business/spend/repo"
+	spnserv "github.com/muchlist/moneymagnet/business/spend/service"
+	"github.com/muchlist/moneymagnet/business/user/model"
+	urrepo "github.com/muchlist/moneymagnet/business/user/repo"
+	urserv "github.com/muchlist/moneymagnet/business/user/service"
+	whrepo "github.com/muchlist/moneymagnet/business/webhook/repo"
+	whserv "github.com/muchlist/moneymagnet/business/webhook/service"
+	"github.com/muchlist/moneymagnet/cfg"
+	"github.com/muchlist/moneymagnet/pkg/cache"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mailer"
+	"github.com/muchlist/moneymagnet/pkg/mcrypto"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+	"github.com/muchlist/moneymagnet/pkg/pubsub"
+	"github.com/muchlist/moneymagnet/pkg/validate"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// admin hold dependency shared by every command.
+// it is empty until connect is called, so flag error and -h work without database
+type admin struct {
+	connected bool
+	log       mlogger.Logger
+	db        *pgxpool.Pool
+	redis     *redis.Client
+	validator interface {
+		Struct(any) (map[string]string, error)
+	}
+	userRepo       *urrepo.Repo
+	userService    *urserv.Core
+	spendService   *spnserv.Core
+	accountService *acserv.Core
+}
+
+// connect open database and build every service, calling it again is no-op
+func (a *admin) connect() error {
+	if a.connected {
+		return nil
+	}
+
+	// config
+	config := cfg.Load()
+
+	// log to stderr, stdout is for json result
+	log := mlogger.New(mlogger.Options{
+		Level:  mlogger.LevelInfo,
+		Output: "stderr",
+	})
+
+	// init database
+	database, err := db.OpenDB(db.Config{
+		DSN:          config.DB.DSN,
+		MaxOpenConns: config.DB.MaxOpenCons,
+		MinOpenConns: config.DB.MinOpenCons,
+	})
+	if err != nil {
+		return fmt.Errorf("connection to database: %w", err)
+	}
+
+	keyring, err := mjwt.NewKeyringFromOption(config.JWTOption())
+	if err != nil {
+		database.Close()
+		return fmt.Errorf("load jwt key: %w", err)
+	}
+	jwt := mjwt.NewWithKeyring(keyring)
+	crypter := mcrypto.New()
+
+	// login lock and etag is stored in redis, connection is opened on first use
+	redisClient := cache.InitRedis(config)
+
+	userRepo := urrepo.NewRepo(database, log)
+	pocketRepo := ptrepo.NewRepo(database, log)
+	categoryRepo := cyrepo.NewRepo(database, log)
+	spendRepo := spnrepo.NewRepo(database, log)
+	accountRepo := acrepo.NewRepo(database, log)
+	eTagRepo := spnrepo.NewETagCache(cache.NewCache[int64](redisClient, true), config.Redis.RedisDefDuration, log)
+	txManager := db.NewTxManager(database, log)
+
+	// job is only enqueued here, it is executed by worker in api server
+	jobService := jobserv.NewCore(log, jobrepo.NewRepo(database, log), jobserv.DefaultOption)
+	// webhook is only enqueued here, the api server deliver it
+	webhookService := whserv.NewCore(log, whrepo.NewRepo(database, log), pocketRepo, jobService, mwebhook.NewClient(10*time.Second, config.Webhook.AllowPrivateNetwork))
+	// admin tool has no connected client to push realtime event to
+	realtimeService := rtserv.NewCore(log, pubsub.NewMemoryHub(), pocketRepo)
+
+	// push topic sync is only enqueued here, the api server subscribe the token
+	pushTopic := config.Notif.PushTopic && slices.Contains(config.NotificationChannels(), notifModel.ChannelPush)
+	topicService := notifserv.NewTopicCore(log, pushTopic, userRepo, pocketRepo, notifrepo.NewRepo(database, log), nil, jobService)
+
+	// admin tool does not send email, print it to log instead
+	userService := urserv.NewCore(log, userRepo, crypter, jwt, mailer.NewLogMailer(log), topicService, txManager, cache.NewCounter(redisClient), "")
+	spendService := spnserv.NewCore(log, spendRepo, pocketRepo, eTagRepo, jobService, webhookService, realtimeService, userRepo, txManager)
+	accountService := acserv.NewCore(log, accountRepo, userRepo, pocketRepo, categoryRepo, spendRepo, topicService, txManager)
+
+	a.log = log
+	a.db = database
+	a.redis = redisClient
+	a.validator = validate.New()
+	a.userRepo = userRepo
+	a.userService = userService
+	a.spendService = spendService
+	a.accountService = accountService
+	a.connected = true
+	return nil
+}
+
+func (a *admin) close() {
+	if !a.connected {
+		return
+	}
+	_ = a.redis.Close()
+	a.db.Close()
+}
+
+// findUser get user by ulid or email
+func (a *admin) findUser(ctx context.Context, idOrEmail string) (model.User, error) {
+	if strings.Contains(idOrEmail, "@") {
+		user, err := a.userRepo.GetByEmail(ctx, idOrEmail)
+		if err != nil {
+			return model.User{}, fmt.Errorf("get user by email %s: %w", idOrEmail, err)
+		}
+		return user, nil
+	}
+
+	id, err := xulid.Parse(idOrEmail)
+	if err != nil {
+		return model.User{}, fmt.Errorf("%q is not valid user id or email", idOrEmail)
+	}
+	user, err := a.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return model.User{}, fmt.Errorf("get user by id %s: %w", idOrEmail, err)
+	}
+	return user, nil
+}
@@ -1,117 +1,129 @@
+// Admin tools for operating moneymagnet from terminal or script.
+// every command is non-interactive, configured with flag and print json to stdout :
+//
+//	{"data": ...} on success, exit code 0
+//	{"error": "..."} on failure, exit code 1
+//
+// log is written to stderr so stdout can be piped to jq.
 package main
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/muchlist/moneymagnet/business/user/model"
-	urrepo "github.com/muchlist/moneymagnet/business/user/repo"
-	urserv "github.com/muchlist/moneymagnet/business/user/service"
-	"github.com/muchlist/moneymagnet/cfg"
-	"github.com/muchlist/moneymagnet/pkg/db"
-	"github.com/muchlist/moneymagnet/pkg/mcrypto"
-	"github.com/muchlist/moneymagnet/pkg/mjwt"
-	"github.com/muchlist/moneymagnet/pkg/mlogger"
-	"github.com/muchlist/moneymagnet/pkg/validate"
+	"github.com/muchlist/moneymagnet/pkg/bg"
 )
 
-var grantType = []string{
-	"user",
-	"admin",
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, app *admin, args []string) (any, error)
 }
 
-func main() {
-	// config
-	config := cfg.Load()
+var commands = []command{
+	{name: "users", usage: "list or search user by name", run: listUsers},
+	{name: "user", usage: "show one user by id or email", run: getUser},
+	{name: "create-user", usage: "register new user", run: createUser},
+	{name: "reset-password", usage: "set new password and logout user from every device", run: resetPassword},
+	{name: "set-roles", usage: "replace roles of user", run: setRoles},
+	{name: "revoke-sessions", usage: "logout user from every device", run: revokeSessions},
+	{name: "unlock", usage: "unlock login locked by too many failed attempt", run: unlockLogin},
+	{name: "recompute-balances", usage: "recompute balance of every pocket from its spends", run: recomputeBalances},
+	{name: "migrate", usage: "apply (up), rollback (down) or show (version) embedded migration", run: runMigration},
+	{name: "seed", usage: "create demo user with demo pocket", run: seedDemo},
+	{name: "export-pocket", usage: "write pocket, categories and spends as json", run: exportPocket},
+	{name: "import-pocket", usage: "create new pocket from export-pocket output", run: importPocket},
+}
 
-	// init log
-	log := mlogger.New(mlogger.Options{
-		Level:  mlogger.LevelInfo,
-		Output: "stdout",
-	})
+var errUsage = errors.New("invalid usage")
 
-	// dependency
-	// init database
-	database, err := db.OpenDB(db.Config{
-		DSN:          config.DB.DSN,
-		MaxOpenConns: config.DB.MaxOpenCons,
-		MinOpenConns: config.DB.MinOpenCons,
-	})
-	if err != nil {
-		log.Error("connection to database", err)
-		panic(err.Error())
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		printUsage()
+		os.Exit(2)
 	}
-	defer database.Close()
-
-	jwt := mjwt.New(config.App.Secret)
-	bcrypt := mcrypto.New()
-
-	// middleware
-	userRepo := urrepo.NewRepo(database, log)
 
-	userService := urserv.NewCore(log, userRepo, bcrypt, jwt)
-
-	inputHint := []string{"name", "email", "password", "roles"}
-	inputValue := make([]string, len(inputHint))
+	cmd, ok := findCommand(os.Args[1])
+	if !ok {
+		printUsage()
+		exitError(fmt.Errorf("unknown command %q", os.Args[1]))
+	}
 
-	fmt.Println("=====================================")
-	fmt.Println("Tools to inject user")
-	fmt.Println("Fill in the data below!")
-	fmt.Println("=====================================")
+	app := &admin{}
+	result, err := cmd.run(context.Background(), app, os.Args[2:])
+	// wait for job like email sending before connection is closed
+	bgCtx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	if bgErr := bg.Shutdown(bgCtx); bgErr != nil {
+		fmt.Fprintln(os.Stderr, "background job is not finished:", bgErr)
+	}
+	cancel()
+	app.close()
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		exitError(err)
+	}
+	writeJSON(map[string]any{"data": result})
+}
 
-	// colect input from terminal
-	reader := bufio.NewReader(os.Stdin)
-	for i := 0; i < len(inputHint); i++ {
-		fmt.Printf("input %s\t:", inputHint[i])
-		input, err := reader.ReadString('\n') // read input until hit enter
-		if err != nil {
-			panic(fmt.Sprintf("error read input %v", err))
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
-		input = strings.TrimSpace(input)
-		inputValue[i] = input
 	}
+	return command{}, false
+}
 
-	// validating input
-	req := model.UserRegisterReq{
-		Name:     inputValue[0],
-		Email:    inputValue[1],
-		Password: inputValue[2],
-		Roles:    []string{inputValue[3]},
+func printUsage() {
+	var sb strings.Builder
+	sb.WriteString("usage: admin <command> [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		sb.WriteString(fmt.Sprintf("  %-20s %s\n", cmd.name, cmd.usage))
 	}
+	sb.WriteString("\nrun admin <command> -h to see flags of command\n")
+	fmt.Fprint(os.Stderr, sb.String())
+}
 
-	if err = validateRoles(inputValue[3]); err != nil {
-		fmt.Println("=====================================")
-		fmt.Println("input not valid: ", err)
-		return
-	}
+// newFlagSet create flag set that return error instead of exit, usage is printed to stderr
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
 
-	validator := validate.New()
-	_, err = validator.Struct(req)
-	if err != nil {
-		fmt.Println("=====================================")
-		fmt.Println("input not valid: ", err)
-		return
+// parseFlags parse args, make sure every required flag is not empty then connect app
+func (a *admin) parseFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
 	}
-
-	result, err := userService.InsertUser(context.Background(), req)
-	if err != nil {
-		fmt.Println("=====================================")
-		fmt.Println("error register user: ", err)
-		return
+	for _, name := range required {
+		f := fs.Lookup(name)
+		if f == nil || f.Value.String() == "" {
+			fs.Usage()
+			return fmt.Errorf("%w: flag -%s is required", errUsage, name)
+		}
 	}
-	fmt.Println("=====================================")
-	fmt.Printf("Success register user : %v", result)
-	fmt.Println("=====================================")
+	return a.connect()
 }
 
-func validateRoles(role string) error {
-	for _, v := range grantType {
-		if role == v {
-			return nil
-		}
+func writeJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "error write output:", err)
+		os.Exit(1)
 	}
-	return fmt.Errorf("role must be one of: %v", grantType)
+}
+
+func exitError(err error) {
+	writeJSON(map[string]any{"error": err.Error()})
+	os.Exit(1)
 }
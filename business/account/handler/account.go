@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	"github.com/muchlist/moneymagnet/business/account/service"
+	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mid"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/validate"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+func NewAccountHandler(log mlogger.Logger,
+	validator validate.Validator,
+	accountService *service.Core) accountHandler {
+	return accountHandler{
+		log:       log,
+		validator: validator,
+		service:   accountService,
+	}
+}
+
+type accountHandler struct {
+	log       mlogger.Logger
+	validator validate.Validator
+	service   *service.Core
+}
+
+// @Summary      Export Data
+// @Description  Download profile, owned pockets, categories and spends. zip by default, use format=json for single json
+// @Tags         Account
+// @Produce      application/zip
+// @Produce      json
+// @Param        format query string false "zip or json"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.Export}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /user/profile/export [get]
+func (ah accountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-Export")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "zip" && format != "json" {
+		web.ErrorResponse(w, http.StatusBadRequest, errr.T("error.export_format", nil))
+		return
+	}
+
+	result, err := ah.service.Export(ctx, claims)
+	if err != nil {
+		ah.log.ErrorT(ctx, "error export data", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	if format == "json" {
+		env := web.Envelope{
+			"data": result,
+		}
+		err = web.WriteJSON(w, http.StatusOK, env, nil)
+		if err != nil {
+			web.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("moneymagnet-export-%s.zip", result.ExportedAt.Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	if err := writeExportZip(w, result); err != nil {
+		// header already sent, only can be logged
+		ah.log.ErrorT(ctx, "error write export zip", err)
+	}
+}
+
+// writeExportZip write every part of export as separate json file
+func writeExportZip(w http.ResponseWriter, export model.Export) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data any
+	}{
+		{name: "profile.json", data: export.Profile},
+		{name: "pockets.json", data: export.Pockets},
+		{name: "categories.json", data: export.Categories},
+		{name: "spends.json", data: export.Spends},
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("create %s: %w", f.name, err)
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return fmt.Errorf("write %s: %w", f.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// @Summary      Schedule Account Deletion
+// @Description  Account is deleted after grace period. owned pocket is given to chosen member, other editor, or deleted
+// @Tags         Account
+// @Accept       json
+// @Produce      json
+// @Param		 Body body model.DeletionReq true "Request Body"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.DeletionResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /user/profile/deletion [post]
+func (ah accountHandler) ScheduleDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-ScheduleDeletion")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	var req model.DeletionReq
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		ah.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	errMap, err := ah.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		ah.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := ah.service.ScheduleDeletion(ctx, claims, req)
+	if err != nil {
+		ah.log.ErrorT(ctx, "error schedule account deletion", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Get Account Deletion
+// @Description  Get scheduled account deletion
+// @Tags         Account
+// @Produce      json
+// @Success      200  {object}  misc.ResponseSuccess{data=model.DeletionResp}
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /user/profile/deletion [get]
+func (ah accountHandler) GetDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-GetDeletion")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	result, err := ah.service.GetDeletion(ctx, claims)
+	if err != nil {
+		ah.log.ErrorT(ctx, "error get account deletion", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Cancel Account Deletion
+// @Description  Cancel scheduled account deletion during grace period
+// @Tags         Account
+// @Produce      json
+// @Success      200  {object}  misc.ResponseMessage
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /user/profile/deletion [delete]
+func (ah accountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-CancelDeletion")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = ah.service.CancelDeletion(ctx, claims)
+	if err != nil {
+		ah.log.ErrorT(ctx, "error cancel account deletion", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "account deletion canceled",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
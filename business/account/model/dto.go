@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	ctmodel "github.com/muchlist/moneymagnet/business/category/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	spmodel "github.com/muchlist/moneymagnet/business/spend/model"
+	urmodel "github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// Export is all data of user, written as json or as zip with one file per field
+type Export struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Profile    urmodel.UserResp       `json:"profile"`
+	Pockets    []ptmodel.PocketResp   `json:"pockets"`    // owned pocket
+	Categories []ctmodel.CategoryResp `json:"categories"` // custom category of owned pocket
+	Spends     []spmodel.SpendResp    `json:"spends"`     // spend in owned pocket and spend created in other pocket
+}
+
+// PocketTransfer choose new owner of pocket when account is deleted
+type PocketTransfer struct {
+	PocketID   xulid.ULID `json:"pocket_id" validate:"required"`
+	NewOwnerID xulid.ULID `json:"new_owner_id" validate:"required"`
+}
+
+// DeletionReq schedule account deletion.
+// owned pocket without transfer is given to other editor, or deleted if there is none
+type DeletionReq struct {
+	Transfers []PocketTransfer `json:"transfers" validate:"dive"`
+}
+
+type DeletionResp struct {
+	Transfers   []PocketTransfer `json:"transfers"`
+	RequestedAt time.Time        `json:"requested_at"`
+	PurgeAfter  time.Time        `json:"purge_after"`
+}
+
+// PocketExport is one pocket with its custom categories and spends.
+// it is written by admin tools and can be imported back as new pocket
+type PocketExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Pocket     ptmodel.PocketResp     `json:"pocket"`
+	Categories []ctmodel.CategoryResp `json:"categories"`
+	Spends     []spmodel.SpendResp    `json:"spends"`
+}
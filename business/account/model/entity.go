@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// Deletion is account deletion waiting for grace period to end
+type Deletion struct {
+	UserID      xulid.ULID
+	Transfers   []PocketTransfer
+	RequestedAt time.Time
+	PurgeAfter  time.Time
+}
+
+func (d *Deletion) ToDeletionResp() DeletionResp {
+	return DeletionResp{
+		Transfers:   d.Transfers,
+		RequestedAt: d.RequestedAt,
+		PurgeAfter:  d.PurgeAfter,
+	}
+}
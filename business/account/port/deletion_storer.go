@@ -0,0 +1,16 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type DeletionStorer interface {
+	UpsertDeletion(ctx context.Context, deletion *model.Deletion) error
+	GetDeletion(ctx context.Context, userID xulid.ULID) (model.Deletion, error)
+	DeleteDeletion(ctx context.Context, userID xulid.ULID) error
+	FindDueDeletions(ctx context.Context, now time.Time, limit uint64) ([]model.Deletion, error)
+}
@@ -0,0 +1,56 @@
+package port
+
+import (
+	"context"
+
+	ctmodel "github.com/muchlist/moneymagnet/business/category/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	spmodel "github.com/muchlist/moneymagnet/business/spend/model"
+	urmodel "github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// UserStorer implemented by user repo
+type UserStorer interface {
+	GetByID(ctx context.Context, ulid xulid.ULID) (urmodel.User, error)
+	Delete(ctx context.Context, id xulid.ULID) error
+}
+
+// PocketStorer implemented by pocket repo
+type PocketStorer interface {
+	GetByID(ctx context.Context, id xulid.ULID) (ptmodel.Pocket, error)
+	Insert(ctx context.Context, pocket *ptmodel.Pocket) error
+	InsertPocketUser(ctx context.Context, userIDs []string, pocketID xulid.ULID) error
+	UpdateBalance(ctx context.Context, pocketid xulid.ULID, balance int64, isSetOperaton bool) (int64, error)
+	FindAllByOwner(ctx context.Context, ownerID xulid.ULID) ([]ptmodel.Pocket, error)
+	Edit(ctx context.Context, pocket *ptmodel.Pocket) error
+	Delete(ctx context.Context, id xulid.ULID) error
+	RemoveMember(ctx context.Context, userID xulid.ULID) error
+}
+
+// CategoryStorer implemented by category repo
+type CategoryStorer interface {
+	Insert(ctx context.Context, category *ctmodel.Category) error
+	FindByPocketIDs(ctx context.Context, pocketIDs []xulid.ULID) ([]ctmodel.Category, error)
+	DeleteByPocket(ctx context.Context, pocketID xulid.ULID) (int64, error)
+}
+
+// SpendStorer implemented by spend repo
+type SpendStorer interface {
+	Insert(ctx context.Context, spend *spmodel.Spend) error
+	CountAllPrice(ctx context.Context, pocketID xulid.ULID) (int64, error)
+	FindForExport(ctx context.Context, userID xulid.ULID, pocketIDs []xulid.ULID) ([]spmodel.Spend, error)
+	DeleteByPocket(ctx context.Context, pocketID xulid.ULID) (int64, error)
+	AnonymizeUser(ctx context.Context, userID xulid.ULID) (int64, error)
+}
+
+// Transactor run fn in single database transaction
+type Transactor interface {
+	WithAtomic(ctx context.Context, tFunc func(ctx context.Context) error) error
+}
+
+// TopicSyncer implemented by notification topic service, it enqueue push topic sync of user
+// whose pocket membership changed in the same transaction when ctx carry one
+type TopicSyncer interface {
+	SyncTopics(ctx context.Context, userIDs []string) error
+}
@@ -0,0 +1,220 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	"github.com/muchlist/moneymagnet/business/account/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	keyTable       = "account_deletions"
+	keyUserID      = "user_id"
+	keyTransfers   = "transfers"
+	keyRequestedAt = "requested_at"
+	keyPurgeAfter  = "purge_after"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.DeletionStorer = (*Repo)(nil)
+
+// Repo manages the set of APIs for account access.
+type Repo struct {
+	db  *pgxpool.Pool
+	log mlogger.Logger
+	sb  sq.StatementBuilderType
+}
+
+// NewRepo constructs a data for api access..
+func NewRepo(sqlDB *pgxpool.Pool, log mlogger.Logger) *Repo {
+	return &Repo{
+		db:  sqlDB,
+		log: log,
+		sb:  sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// =========================================================================
+// MANIPULATOR
+
+// UpsertDeletion insert deletion request, existing request of the user is replaced
+func (r *Repo) UpsertDeletion(ctx context.Context, deletion *model.Deletion) error {
+	ctx, span := observ.GetTracer().Start(ctx, "account-repo-UpsertDeletion")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if deletion.Transfers == nil {
+		deletion.Transfers = []model.PocketTransfer{}
+	}
+
+	sqlStatement, args, err := r.sb.Insert(keyTable).
+		Columns(
+			keyUserID,
+			keyTransfers,
+			keyRequestedAt,
+			keyPurgeAfter,
+		).
+		Values(
+			deletion.UserID,
+			deletion.Transfers,
+			deletion.RequestedAt,
+			deletion.PurgeAfter,
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s",
+			keyUserID,
+			keyTransfers, keyTransfers,
+			keyRequestedAt, keyRequestedAt,
+			keyPurgeAfter, keyPurgeAfter,
+		)).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query upsert account deletion: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// DeleteDeletion remove deletion request of user
+func (r *Repo) DeleteDeletion(ctx context.Context, userID xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "account-repo-DeleteDeletion")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyTable).
+		Where(sq.Eq{keyUserID: userID}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query delete account deletion: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// =========================================================================
+// GETTER
+
+// GetDeletion get deletion request of user
+func (r *Repo) GetDeletion(ctx context.Context, userID xulid.ULID) (model.Deletion, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-repo-GetDeletion")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyUserID,
+		keyTransfers,
+		keyRequestedAt,
+		keyPurgeAfter,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyUserID: userID}).ToSql()
+
+	if err != nil {
+		return model.Deletion{}, fmt.Errorf("build query get account deletion: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var deletion model.Deletion
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&deletion.UserID,
+		&deletion.Transfers,
+		&deletion.RequestedAt,
+		&deletion.PurgeAfter,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Deletion{}, db.ParseError(err)
+	}
+
+	return deletion, nil
+}
+
+// FindDueDeletions get deletion request which grace period is over, oldest first
+func (r *Repo) FindDueDeletions(ctx context.Context, now time.Time, limit uint64) ([]model.Deletion, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-repo-FindDueDeletions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyUserID,
+		keyTransfers,
+		keyRequestedAt,
+		keyPurgeAfter,
+	).
+		From(keyTable).
+		Where(sq.LtOrEq{keyPurgeAfter: now}).
+		OrderBy(keyPurgeAfter).
+		Limit(limit).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find due account deletion: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	deletions := make([]model.Deletion, 0)
+	for rows.Next() {
+		var deletion model.Deletion
+		err := rows.Scan(
+			&deletion.UserID,
+			&deletion.Transfers,
+			&deletion.RequestedAt,
+			&deletion.PurgeAfter,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		deletions = append(deletions, deletion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	"github.com/muchlist/moneymagnet/business/account/port"
+	ctmodel "github.com/muchlist/moneymagnet/business/category/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	spmodel "github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/slicer"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const (
+	deletionGracePeriod = 14 * 24 * time.Hour
+	purgeBatchSize      = 50
+)
+
+var (
+	ErrDeletionNotFound    = errr.NewT("error.deletion_not_scheduled", nil, 404)
+	ErrPersonalTokenDenied = errr.NewT("error.personal_token_denied", nil, 403)
+)
+
+// Core manages the set of APIs for account data export and deletion.
+type Core struct {
+	log          mlogger.Logger
+	repo         port.DeletionStorer
+	userRepo     port.UserStorer
+	pocketRepo   port.PocketStorer
+	categoryRepo port.CategoryStorer
+	spendRepo    port.SpendStorer
+	topicSyncer  port.TopicSyncer
+	txManager    port.Transactor
+}
+
+// NewCore constructs a core for account api access.
+func NewCore(
+	log mlogger.Logger,
+	repo port.DeletionStorer,
+	userRepo port.UserStorer,
+	pocketRepo port.PocketStorer,
+	categoryRepo port.CategoryStorer,
+	spendRepo port.SpendStorer,
+	topicSyncer port.TopicSyncer,
+	txManager port.Transactor,
+) *Core {
+	return &Core{
+		log:          log,
+		repo:         repo,
+		userRepo:     userRepo,
+		pocketRepo:   pocketRepo,
+		categoryRepo: categoryRepo,
+		spendRepo:    spendRepo,
+		topicSyncer:  topicSyncer,
+		txManager:    txManager,
+	}
+}
+
+// Export return profile, owned pockets with their custom categories and spends of user
+func (s *Core) Export(ctx context.Context, claims mjwt.CustomClaim) (model.Export, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-Export")
+	defer span.End()
+
+	// personal token can be limited to some pockets, export always contain everything
+	if claims.Type == mjwt.Personal {
+		return model.Export{}, ErrPersonalTokenDenied
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.GetULID())
+	if err != nil {
+		return model.Export{}, fmt.Errorf("get user by id: %w", err)
+	}
+
+	pockets, err := s.pocketRepo.FindAllByOwner(ctx, user.ID)
+	if err != nil {
+		return model.Export{}, fmt.Errorf("find owned pocket: %w", err)
+	}
+	pocketIDs := make([]xulid.ULID, len(pockets))
+	pocketResps := make([]ptmodel.PocketResp, len(pockets))
+	for i, p := range pockets {
+		pocketIDs[i] = p.ID
+		pocketResps[i] = p.ToPocketResp()
+	}
+
+	categories, err := s.categoryRepo.FindByPocketIDs(ctx, pocketIDs)
+	if err != nil {
+		return model.Export{}, fmt.Errorf("find category: %w", err)
+	}
+	categoryResps := make([]ctmodel.CategoryResp, len(categories))
+	for i, c := range categories {
+		categoryResps[i] = c.ToCategoryResp()
+	}
+
+	spends, err := s.spendRepo.FindForExport(ctx, user.ID, pocketIDs)
+	if err != nil {
+		return model.Export{}, fmt.Errorf("find spend: %w", err)
+	}
+	spendResps := make([]spmodel.SpendResp, len(spends))
+	for i, sp := range spends {
+		spendResps[i] = sp.ToResp()
+	}
+
+	return model.Export{
+		ExportedAt: time.Now(),
+		Profile:    user.ToUserResp(),
+		Pockets:    pocketResps,
+		Categories: categoryResps,
+		Spends:     spendResps,
+	}, nil
+}
+
+// ScheduleDeletion mark account to be deleted after grace period.
+// calling it again replace the transfers but keep the grace period running
+func (s *Core) ScheduleDeletion(ctx context.Context, claims mjwt.CustomClaim, req model.DeletionReq) (model.DeletionResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-ScheduleDeletion")
+	defer span.End()
+
+	if claims.Type == mjwt.Personal {
+		return model.DeletionResp{}, ErrPersonalTokenDenied
+	}
+	userID := claims.GetULID()
+
+	for _, transfer := range req.Transfers {
+		pocket, err := s.pocketRepo.GetByID(ctx, transfer.PocketID)
+		if err != nil {
+			if errors.Is(err, db.ErrDBNotFound) {
+				return model.DeletionResp{}, errr.NewT("error.pocket_not_found", map[string]string{"pocket": transfer.PocketID.String()}, 400)
+			}
+			return model.DeletionResp{}, fmt.Errorf("get pocket by id: %w", err)
+		}
+		if err := validateTransfer(pocket, userID, transfer.NewOwnerID); err != nil {
+			return model.DeletionResp{}, err
+		}
+	}
+
+	timeNow := time.Now()
+	deletion := model.Deletion{
+		UserID:      userID,
+		Transfers:   req.Transfers,
+		RequestedAt: timeNow,
+		PurgeAfter:  timeNow.Add(deletionGracePeriod),
+	}
+
+	existing, err := s.repo.GetDeletion(ctx, userID)
+	if err == nil {
+		deletion.RequestedAt = existing.RequestedAt
+		deletion.PurgeAfter = existing.PurgeAfter
+	} else if !errors.Is(err, db.ErrDBNotFound) {
+		return model.DeletionResp{}, fmt.Errorf("get account deletion: %w", err)
+	}
+
+	if err := s.repo.UpsertDeletion(ctx, &deletion); err != nil {
+		return model.DeletionResp{}, fmt.Errorf("upsert account deletion: %w", err)
+	}
+
+	return deletion.ToDeletionResp(), nil
+}
+
+// GetDeletion return scheduled deletion of user
+func (s *Core) GetDeletion(ctx context.Context, claims mjwt.CustomClaim) (model.DeletionResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-GetDeletion")
+	defer span.End()
+
+	deletion, err := s.repo.GetDeletion(ctx, claims.GetULID())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.DeletionResp{}, ErrDeletionNotFound
+		}
+		return model.DeletionResp{}, fmt.Errorf("get account deletion: %w", err)
+	}
+	return deletion.ToDeletionResp(), nil
+}
+
+// CancelDeletion stop scheduled deletion during grace period
+func (s *Core) CancelDeletion(ctx context.Context, claims mjwt.CustomClaim) error {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-CancelDeletion")
+	defer span.End()
+
+	if claims.Type == mjwt.Personal {
+		return ErrPersonalTokenDenied
+	}
+
+	err := s.repo.DeleteDeletion(ctx, claims.GetULID())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return ErrDeletionNotFound
+		}
+		return fmt.Errorf("delete account deletion: %w", err)
+	}
+	return nil
+}
+
+// PurgeDueAccounts delete account which grace period is over.
+// return count of deleted account, failed account is retried on next call
+func (s *Core) PurgeDueAccounts(ctx context.Context) (int, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-PurgeDueAccounts")
+	defer span.End()
+
+	deletions, err := s.repo.FindDueDeletions(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("find due account deletion: %w", err)
+	}
+
+	purged := 0
+	for _, deletion := range deletions {
+		if err := s.purgeAccount(ctx, deletion); err != nil {
+			s.log.ErrorT(ctx, fmt.Sprintf("error purge account %s", deletion.UserID.String()), err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunPurgeLoop call PurgeDueAccounts every interval until ctx is done
+func (s *Core) RunPurgeLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDueAccounts(ctx)
+			if err != nil {
+				s.log.ErrorT(ctx, "error purge account", err)
+				continue
+			}
+			if purged > 0 {
+				s.log.InfoT(ctx, fmt.Sprintf("%d account purged", purged))
+			}
+		}
+	}
+}
+
+// purgeAccount transfer or delete owned pocket, anonymize spends and delete the user
+func (s *Core) purgeAccount(ctx context.Context, deletion model.Deletion) error {
+	userID := deletion.UserID
+
+	chosen := make(map[xulid.ULID]xulid.ULID, len(deletion.Transfers))
+	for _, transfer := range deletion.Transfers {
+		chosen[transfer.PocketID] = transfer.NewOwnerID
+	}
+
+	return s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		pockets, err := s.pocketRepo.FindAllByOwner(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("find owned pocket: %w", err)
+		}
+
+		for _, pocket := range pockets {
+			newOwner, ok := choosePocketOwner(pocket, userID, chosen[pocket.ID])
+			if !ok {
+				if err := s.deletePocket(ctx, pocket.ID); err != nil {
+					return err
+				}
+				continue
+			}
+			pocket.OwnerID = newOwner
+			pocket.EditorID = append(pocket.EditorID, newOwner.String())
+			if err := s.pocketRepo.Edit(ctx, &pocket); err != nil {
+				return fmt.Errorf("transfer pocket %s: %w", pocket.ID.String(), err)
+			}
+		}
+
+		if err := s.pocketRepo.RemoveMember(ctx, userID); err != nil {
+			return fmt.Errorf("remove pocket member: %w", err)
+		}
+		if _, err := s.spendRepo.AnonymizeUser(ctx, userID); err != nil {
+			return fmt.Errorf("anonymize spend: %w", err)
+		}
+		// session, token and deletion request is removed by foreign key cascade
+		if err := s.userRepo.Delete(ctx, userID); err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		// token of deleted user is unsubscribed from every push topic
+		if err := s.topicSyncer.SyncTopics(ctx, []string{userID.String()}); err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *Core) deletePocket(ctx context.Context, pocketID xulid.ULID) error {
+	if _, err := s.spendRepo.DeleteByPocket(ctx, pocketID); err != nil {
+		return fmt.Errorf("delete spend of pocket %s: %w", pocketID.String(), err)
+	}
+	if _, err := s.categoryRepo.DeleteByPocket(ctx, pocketID); err != nil {
+		return fmt.Errorf("delete category of pocket %s: %w", pocketID.String(), err)
+	}
+	if err := s.pocketRepo.Delete(ctx, pocketID); err != nil {
+		return fmt.Errorf("delete pocket %s: %w", pocketID.String(), err)
+	}
+	return nil
+}
+
+// validateTransfer make sure pocket is owned by userID and newOwnerID is other member of the pocket
+func validateTransfer(pocket ptmodel.Pocket, userID xulid.ULID, newOwnerID xulid.ULID) error {
+	if pocket.OwnerID != userID {
+		return errr.NewT("error.pocket_not_owned", map[string]string{"pocket": pocket.ID.String()}, 400)
+	}
+	if newOwnerID == userID || !pocket.IsMember(newOwnerID.String()) {
+		return errr.NewT("error.new_owner_not_member", map[string]string{"pocket": pocket.ID.String()}, 400)
+	}
+	return nil
+}
+
+// choosePocketOwner return new owner of pocket when userID is deleted.
+// chosen owner is used if still member, otherwise the first other editor.
+// false mean nobody can take the pocket and it must be deleted
+func choosePocketOwner(pocket ptmodel.Pocket, userID xulid.ULID, chosen xulid.ULID) (xulid.ULID, bool) {
+	var zero xulid.ULID
+	if chosen != zero && chosen != userID && pocket.IsMember(chosen.String()) {
+		return chosen, true
+	}
+	for _, editor := range slicer.RemoveFrom(userID.String(), pocket.EditorID) {
+		id, err := xulid.Parse(editor)
+		if err != nil {
+			continue
+		}
+		return id, true
+	}
+	return zero, false
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	ctmodel "github.com/muchlist/moneymagnet/business/category/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	spmodel "github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/constant"
+	"github.com/muchlist/moneymagnet/pkg/ctype"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// ExportPocket return pocket with its custom categories and spends, used by admin tools.
+// sub-pocket is not included
+func (s *Core) ExportPocket(ctx context.Context, pocketID xulid.ULID) (model.PocketExport, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-ExportPocket")
+	defer span.End()
+
+	pocket, err := s.pocketRepo.GetByID(ctx, pocketID)
+	if err != nil {
+		return model.PocketExport{}, fmt.Errorf("get pocket by id: %w", err)
+	}
+
+	categories, err := s.categoryRepo.FindByPocketIDs(ctx, []xulid.ULID{pocket.ID})
+	if err != nil {
+		return model.PocketExport{}, fmt.Errorf("find category: %w", err)
+	}
+	categoryResps := make([]ctmodel.CategoryResp, len(categories))
+	for i, c := range categories {
+		categoryResps[i] = c.ToCategoryResp()
+	}
+
+	var anyUser xulid.ULID
+	spends, err := s.spendRepo.FindForExport(ctx, anyUser, []xulid.ULID{pocket.ID})
+	if err != nil {
+		return model.PocketExport{}, fmt.Errorf("find spend: %w", err)
+	}
+	spendResps := make([]spmodel.SpendResp, len(spends))
+	for i, sp := range spends {
+		spendResps[i] = sp.ToResp()
+	}
+
+	return model.PocketExport{
+		ExportedAt: time.Now(),
+		Pocket:     pocket.ToPocketResp(),
+		Categories: categoryResps,
+		Spends:     spendResps,
+	}, nil
+}
+
+// ImportPocket create new root pocket owned by ownerID from exported data.
+// every id is regenerated and every spend is recorded as created by ownerID
+func (s *Core) ImportPocket(ctx context.Context, ownerID xulid.ULID, data model.PocketExport) (ptmodel.PocketResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "account-service-ImportPocket")
+	defer span.End()
+
+	if _, err := s.userRepo.GetByID(ctx, ownerID); err != nil {
+		return ptmodel.PocketResp{}, fmt.Errorf("get owner by id: %w", err)
+	}
+
+	pocket, categories, spends := rebuildPocket(data, ownerID, time.Now())
+
+	txErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		if err := s.pocketRepo.Insert(ctx, &pocket); err != nil {
+			return fmt.Errorf("insert pocket: %w", err)
+		}
+		if err := s.pocketRepo.InsertPocketUser(ctx, []string{ownerID.String()}, pocket.ID); err != nil {
+			return fmt.Errorf("insert pocket user: %w", err)
+		}
+		if err := s.topicSyncer.SyncTopics(ctx, []string{ownerID.String()}); err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
+		for i := range categories {
+			if err := s.categoryRepo.Insert(ctx, &categories[i]); err != nil {
+				return fmt.Errorf("insert category %s: %w", categories[i].CategoryName, err)
+			}
+		}
+		for i := range spends {
+			if err := s.spendRepo.Insert(ctx, &spends[i]); err != nil {
+				return fmt.Errorf("insert spend %s: %w", spends[i].Name, err)
+			}
+		}
+
+		balance, err := s.spendRepo.CountAllPrice(ctx, pocket.ID)
+		if err != nil {
+			return fmt.Errorf("aggregate all price on pocket: %w", err)
+		}
+		pocket.Balance, err = s.pocketRepo.UpdateBalance(ctx, pocket.ID, balance, true)
+		if err != nil {
+			return fmt.Errorf("update balance: %w", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return ptmodel.PocketResp{}, txErr
+	}
+
+	pocket.TotalBalance = pocket.Balance
+	return pocket.ToPocketResp(), nil
+}
+
+// rebuildPocket convert exported data to new entity with new id.
+// category is ordered so parent is inserted before its children,
+// spend category pointing to category outside export (system category) is kept
+func rebuildPocket(data model.PocketExport, ownerID xulid.ULID, timeNow time.Time) (ptmodel.Pocket, []ctmodel.Category, []spmodel.Spend) {
+	currency := data.Pocket.Currency
+	if currency == "" {
+		currency = "Rp"
+	}
+	pocket := ptmodel.Pocket{
+		ID:         xulid.Instance().NewULID(),
+		OwnerID:    ownerID,
+		EditorID:   []string{ownerID.String()},
+		WatcherID:  []string{ownerID.String()},
+		PocketName: data.Pocket.PocketName,
+		Currency:   currency,
+		Icon:       data.Pocket.Icon,
+		Level:      constant.POCK_ROOT_LEVEL,
+		CreatedAt:  timeNow,
+		UpdatedAt:  timeNow,
+		Version:    1,
+	}
+
+	newIDs := make(map[xulid.ULID]xulid.ULID, len(data.Categories))
+	for _, c := range data.Categories {
+		newIDs[c.ID] = xulid.Instance().NewULID()
+	}
+
+	categories := make([]ctmodel.Category, 0, len(data.Categories))
+	for _, c := range parentFirst(data.Categories) {
+		parentID := xulid.NullULID{}
+		if newParent, ok := newIDs[c.ParentID.ULID]; ok && c.ParentID.Valid {
+			parentID = xulid.NullULID{ULID: newParent, Valid: true}
+		}
+		categories = append(categories, ctmodel.Category{
+			ID:               newIDs[c.ID],
+			PocketID:         pocket.ID,
+			ParentID:         parentID,
+			CategoryName:     c.CategoryName,
+			CategoryIcon:     c.CategoryIcon,
+			IsIncome:         c.IsIncome,
+			DefaultSpendType: c.DefaultSpendType,
+			CreatedAt:        timeNow,
+			UpdatedAt:        timeNow,
+		})
+	}
+
+	spends := make([]spmodel.Spend, 0, len(data.Spends))
+	for _, sp := range data.Spends {
+		categoryID := sp.CategoryID
+		if newCategory, ok := newIDs[sp.CategoryID.ULID]; ok && sp.CategoryID.Valid {
+			categoryID = xulid.NullULID{ULID: newCategory, Valid: true}
+		}
+		spends = append(spends, spmodel.Spend{
+			ID:               xulid.Instance().NewULID(),
+			UserID:           ownerID,
+			PocketID:         pocket.ID,
+			CategoryID:       categoryID,
+			Name:             ctype.ToUppercaseString(sp.Name),
+			Price:            sp.Price,
+			BalanceSnapshoot: sp.BalanceSnapshoot,
+			IsIncome:         sp.IsIncome,
+			SpendType:        sp.SpendType,
+			Date:             sp.Date,
+			CreatedAt:        timeNow,
+			UpdatedAt:        timeNow,
+			Version:          1,
+		})
+	}
+
+	return pocket, categories, spends
+}
+
+// parentFirst order categories so every parent come before its children.
+// category with missing or cyclic parent is treated as root
+func parentFirst(categories []ctmodel.CategoryResp) []ctmodel.CategoryResp {
+	exist := make(map[xulid.ULID]bool, len(categories))
+	for _, c := range categories {
+		exist[c.ID] = true
+	}
+
+	result := make([]ctmodel.CategoryResp, 0, len(categories))
+	done := make(map[xulid.ULID]bool, len(categories))
+	for progress := true; progress; {
+		progress = false
+		for _, c := range categories {
+			if done[c.ID] {
+				continue
+			}
+			if c.ParentID.Valid && exist[c.ParentID.ULID] && !done[c.ParentID.ULID] {
+				continue
+			}
+			result = append(result, c)
+			done[c.ID] = true
+			progress = true
+		}
+	}
+
+	// the rest is in a cycle, break it by dropping the parent
+	for _, c := range categories {
+		if !done[c.ID] {
+			c.ParentID = xulid.NullULID{}
+			result = append(result, c)
+			done[c.ID] = true
+		}
+	}
+	return result
+}
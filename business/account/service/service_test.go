@@ -0,0 +1,150 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/account/model"
+	ctmodel "github.com/muchlist/moneymagnet/business/category/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	spmodel "github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+var (
+	owner   = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEP1")
+	editor  = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEP2")
+	watcher = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEP3")
+	other   = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEP4")
+)
+
+func TestChoosePocketOwner(t *testing.T) {
+	shared := ptmodel.Pocket{
+		OwnerID:   owner,
+		EditorID:  []string{owner.String(), editor.String()},
+		WatcherID: []string{owner.String(), watcher.String()},
+	}
+	private := ptmodel.Pocket{
+		OwnerID:   owner,
+		EditorID:  []string{owner.String()},
+		WatcherID: []string{owner.String(), watcher.String()},
+	}
+
+	tests := []struct {
+		name   string
+		pocket ptmodel.Pocket
+		chosen xulid.ULID
+		want   xulid.ULID
+		wantOK bool
+	}{
+		{name: "chosen watcher", pocket: shared, chosen: watcher, want: watcher, wantOK: true},
+		{name: "not chosen use editor", pocket: shared, want: editor, wantOK: true},
+		{name: "chosen no longer member", pocket: shared, chosen: other, want: editor, wantOK: true},
+		{name: "chosen self ignored", pocket: shared, chosen: owner, want: editor, wantOK: true},
+		{name: "no other editor", pocket: private, wantOK: false},
+		{name: "no other editor but chosen", pocket: private, chosen: watcher, want: watcher, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := choosePocketOwner(tt.pocket, owner, tt.chosen)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("choosePocketOwner() = %v %v, want %v %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateTransfer(t *testing.T) {
+	pocket := ptmodel.Pocket{
+		OwnerID:   owner,
+		EditorID:  []string{owner.String(), editor.String()},
+		WatcherID: []string{owner.String()},
+	}
+
+	if err := validateTransfer(pocket, owner, editor); err != nil {
+		t.Errorf("validateTransfer() to editor error = %v", err)
+	}
+	if err := validateTransfer(pocket, owner, other); err == nil {
+		t.Error("validateTransfer() to non member error = nil")
+	}
+	if err := validateTransfer(pocket, owner, owner); err == nil {
+		t.Error("validateTransfer() to self error = nil")
+	}
+	if err := validateTransfer(pocket, editor, owner); err == nil {
+		t.Error("validateTransfer() by non owner error = nil")
+	}
+}
+
+func TestParentFirst(t *testing.T) {
+	root := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC1")
+	child := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC2")
+	grandChild := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC3")
+	loopA := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC4")
+	loopB := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC5")
+	system := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC6")
+
+	categories := []ctmodel.CategoryResp{
+		{ID: grandChild, ParentID: xulid.NullULID{ULID: child, Valid: true}},
+		{ID: child, ParentID: xulid.NullULID{ULID: root, Valid: true}},
+		{ID: root},
+		{ID: loopA, ParentID: xulid.NullULID{ULID: loopB, Valid: true}},
+		{ID: loopB, ParentID: xulid.NullULID{ULID: loopA, Valid: true}},
+		{ID: other, ParentID: xulid.NullULID{ULID: system, Valid: true}},
+	}
+
+	got := parentFirst(categories)
+	if len(got) != len(categories) {
+		t.Fatalf("parentFirst() returned %d category, want %d", len(got), len(categories))
+	}
+	position := make(map[xulid.ULID]int, len(got))
+	for i, c := range got {
+		position[c.ID] = i
+	}
+	if position[root] > position[child] || position[child] > position[grandChild] {
+		t.Errorf("parentFirst() parent must come before child, got %v", position)
+	}
+	for _, c := range got {
+		if (c.ID == loopA || c.ID == loopB) && c.ParentID.Valid {
+			t.Errorf("parentFirst() cyclic category %s must be made root", c.ID)
+		}
+		if c.ID == other && !c.ParentID.Valid {
+			t.Errorf("parentFirst() parent outside the list must be kept")
+		}
+	}
+}
+
+func TestRebuildPocket(t *testing.T) {
+	custom := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC7")
+	system := xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC8")
+	data := model.PocketExport{
+		Pocket:     ptmodel.PocketResp{ID: other, PocketName: "wallet", Level: 2},
+		Categories: []ctmodel.CategoryResp{{ID: custom, PocketID: other, CategoryName: "snack"}},
+		Spends: []spmodel.SpendResp{
+			{ID: other, UserID: editor, CategoryID: xulid.NullULID{ULID: custom, Valid: true}, Name: "chips", Price: -5000},
+			{ID: other, UserID: watcher, CategoryID: xulid.NullULID{ULID: system, Valid: true}, Name: "rent", Price: -100000},
+		},
+	}
+
+	pocket, categories, spends := rebuildPocket(data, owner, time.Now())
+
+	if pocket.ID == other || pocket.OwnerID != owner || pocket.Level != 1 || pocket.Currency == "" {
+		t.Errorf("rebuildPocket() unexpected pocket %+v", pocket)
+	}
+	if len(categories) != 1 || categories[0].ID == custom || categories[0].PocketID != pocket.ID {
+		t.Fatalf("rebuildPocket() unexpected categories %+v", categories)
+	}
+	if len(spends) != 2 {
+		t.Fatalf("rebuildPocket() returned %d spend, want 2", len(spends))
+	}
+	if spends[0].CategoryID.ULID != categories[0].ID {
+		t.Errorf("custom category of spend must be remapped, got %s", spends[0].CategoryID.ULID)
+	}
+	if spends[1].CategoryID.ULID != system {
+		t.Errorf("system category of spend must be kept, got %s", spends[1].CategoryID.ULID)
+	}
+	for _, sp := range spends {
+		if sp.UserID != owner || sp.PocketID != pocket.ID || sp.ID == other {
+			t.Errorf("rebuildPocket() unexpected spend %+v", sp)
+		}
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"github.com/muchlist/moneymagnet/business/category/model"
 	"github.com/muchlist/moneymagnet/business/category/service"
 	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/convert"
+	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/mid"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
 	"github.com/muchlist/moneymagnet/pkg/observ"
 	"github.com/muchlist/moneymagnet/pkg/paging"
 	"github.com/muchlist/moneymagnet/pkg/validate"
 	"github.com/muchlist/moneymagnet/pkg/web"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
 )
 
 func NewCatHandler(log mlogger.Logger,
@@ -55,7 +58,7 @@ func (ch catHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := ch.validator.Struct(req)
+	errMap, err := ch.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		ch.log.WarnT(r.Context(), "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -113,7 +116,7 @@ func (ch catHandler) EditCategory(w http.ResponseWriter, r *http.Request) {
 	}
 	req.ID = categoryID
 
-	errMap, err := ch.validator.Struct(req)
+	errMap, err := ch.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		ch.log.WarnT(r.Context(), "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -143,6 +146,8 @@ func (ch catHandler) EditCategory(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 pocket_id path string true "pocket_id"
+// @Param 		 tree query bool false "nest sub-categories under their parent"
+// @Param 		 include_hidden query bool false "include default category hidden in this pocket"
 // @Success      200  {object}  misc.ResponseSuccessList{data=[]model.CategoryResp}
 // @Failure      400  {object}  misc.ResponseErr
 // @Failure      500  {object}  misc.Response500Err
@@ -158,8 +163,13 @@ func (ch catHandler) FindPocketCategory(w http.ResponseWriter, r *http.Request)
 	sort := web.ReadString(r.URL.Query(), "sort", "")
 	page := web.ReadInt(r.URL.Query(), "page", 0)
 	pageSize := web.ReadInt(r.URL.Query(), "page_size", 0)
+	catFilter := model.CategoryFilter{
+		PocketID:      pocketID,
+		AsTree:        convert.StringToBool(web.ReadString(r.URL.Query(), "tree", "")),
+		IncludeHidden: convert.StringToBool(web.ReadString(r.URL.Query(), "include_hidden", "")),
+	}
 
-	result, metadata, err := ch.service.FindAllCategory(r.Context(), pocketID, paging.Filters{
+	result, metadata, err := ch.service.FindAllCategory(r.Context(), catFilter, paging.Filters{
 		Page:     page,
 		PageSize: pageSize,
 		Sort:     sort,
@@ -182,12 +192,13 @@ func (ch catHandler) FindPocketCategory(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary      Delete Category
-// @Description  Delete category by id
+// @Description  Delete category by id. spends that use the category are moved to replace_with category
 // @Tags         Category
 // @Accept       json
 // @Produce      json
 // @Param 		 category_id path string true "category_id"
-// @Success      200  {object}  misc.ResponseMessage
+// @Param 		 replace_with query string false "replacement category id, required if category is used by spends"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.MergeCategoryResp}
 // @Failure      400  {object}  misc.ResponseErr
 // @Failure      500  {object}  misc.Response500Err
 // @Router       /categories/{category_id} [delete]
@@ -195,6 +206,12 @@ func (ch catHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	ctx, span := observ.GetTracer().Start(r.Context(), "handler-DeleteCategory")
 	defer span.End()
 
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
 	// extract url query
 	categoryID, err := web.ReadULIDParam(r)
 	if err != nil {
@@ -203,13 +220,196 @@ func (ch catHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ch.service.DeleteCategory(ctx, categoryID)
+	var replacementID xulid.NullULID
+	if replaceWith := web.ReadString(r.URL.Query(), "replace_with", ""); replaceWith != "" {
+		replacementID.ULID, err = xulid.Parse(replaceWith)
+		if err != nil {
+			web.ErrorResponse(w, http.StatusBadRequest, errr.T("error.invalid_id_param", map[string]string{"name": "replace_with"}))
+			return
+		}
+		replacementID.Valid = true
+	}
+
+	result, err := ch.service.DeleteCategory(ctx, claims, categoryID, replacementID)
 	if err != nil {
 		ch.log.ErrorT(ctx, "error delete categories", err)
 		statusCode, msg := zhelper.ParseError(err)
 		web.ErrorResponse(w, statusCode, msg)
 		return
 	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Merge Category
+// @Description  Move all spends from category to target category then delete the category
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param 		 category_id path string true "category_id"
+// @Param		 Body body model.MergeCategory true "Request Body"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.MergeCategoryResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /categories/{category_id}/merge [post]
+func (ch catHandler) MergeCategory(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-MergeCategory")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	categoryID, err := web.ReadULIDParam(r)
+	if err != nil {
+		ch.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req model.MergeCategory
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		ch.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.SourceID = categoryID
+
+	errMap, err := ch.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		ch.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := ch.service.MergeCategory(ctx, claims, req)
+	if err != nil {
+		ch.log.ErrorT(ctx, "error merge category", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Override Default Category
+// @Description  Hide, rename, re-icon or change default spend type of default category for one pocket
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param 		 category_id path string true "category_id"
+// @Param		 Body body model.CategoryOverrideReq true "Request Body"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.CategoryOverrideResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /categories/{category_id}/override [put]
+func (ch catHandler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-SetOverride")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	categoryID, err := web.ReadULIDParam(r)
+	if err != nil {
+		ch.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req model.CategoryOverrideReq
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		ch.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.CategoryID = categoryID
+
+	errMap, err := ch.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		ch.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := ch.service.SetOverride(ctx, claims, req)
+	if err != nil {
+		ch.log.ErrorT(ctx, "error override category", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Reset Default Category Override
+// @Description  Remove pocket override so default category is shown as is
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param 		 category_id path string true "category_id"
+// @Param 		 pocket_id query string true "pocket_id"
+// @Success      200  {object}  misc.ResponseMessage
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /categories/{category_id}/override [delete]
+func (ch catHandler) ResetOverride(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-ResetOverride")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	categoryID, err := web.ReadULIDParam(r)
+	if err != nil {
+		ch.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pocketID, err := xulid.Parse(web.ReadString(r.URL.Query(), "pocket_id", ""))
+	if err != nil {
+		web.ErrorResponse(w, http.StatusBadRequest, errr.T("error.invalid_id_param", map[string]string{"name": "pocket_id"}))
+		return
+	}
+
+	err = ch.service.ResetOverride(ctx, claims, categoryID, pocketID)
+	if err != nil {
+		ch.log.ErrorT(ctx, "error reset category override", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
 	env := web.Envelope{
 		"data": "success",
 	}
@@ -7,27 +7,72 @@ import (
 )
 
 type NewCategory struct {
-	PocketID         xulid.ULID `json:"pocket_id" validate:"required" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
-	CategoryName     string     `json:"category_name" validate:"required" example:"gaji"`
-	CategoryIcon     int        `json:"category_icon" example:"0"`
-	DefaultSpendType int        `json:"default_spend_type" example:"0"`
-	IsIncome         bool       `json:"is_income" example:"true"`
+	PocketID         xulid.ULID     `json:"pocket_id" validate:"required" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	ParentID         xulid.NullULID `json:"parent_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+	CategoryName     string         `json:"category_name" validate:"required" example:"gaji"`
+	CategoryIcon     int            `json:"category_icon" example:"0"`
+	DefaultSpendType int            `json:"default_spend_type" example:"0"`
+	IsIncome         bool           `json:"is_income" example:"true"`
 }
 
 type UpdateCategory struct {
-	ID               xulid.ULID `json:"-" validate:"required"`
-	CategoryName     string     `json:"category_name" validate:"required" example:"gaji_2"`
-	DefaultSpendType int        `json:"default_spend_type" example:"0"`
-	CategoryIcon     int        `json:"category_icon" example:"0"`
+	ID               xulid.ULID     `json:"-" validate:"required"`
+	CategoryName     string         `json:"category_name" validate:"required" example:"gaji_2"`
+	DefaultSpendType int            `json:"default_spend_type" example:"0"`
+	CategoryIcon     int            `json:"category_icon" example:"0"`
+	ParentID         xulid.NullULID `json:"parent_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+	DetachParent     bool           `json:"detach_parent" example:"false"` // move category to root
 }
 
 type CategoryResp struct {
-	ID               xulid.ULID `json:"id" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+	ID               xulid.ULID     `json:"id" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+	PocketID         xulid.ULID     `json:"pocket_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	ParentID         xulid.NullULID `json:"parent_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+	CategoryName     string         `json:"category_name" example:"gaji"`
+	CategoryIcon     int            `json:"category_icon" example:"0"`
+	IsIncome         bool           `json:"is_income" example:"true"`
+	DefaultSpendType int            `json:"default_spend_type" example:"0"`
+	IsHidden         bool           `json:"is_hidden" example:"false"`
+	IsOverridden     bool           `json:"is_overridden" example:"false"`
+	CreatedAt        time.Time      `json:"created_at" example:"2022-09-10T17:03:15.091267+08:00"`
+	UpdatedAt        time.Time      `json:"update_at" example:"2022-09-10T17:03:15.091267+08:00"`
+	Children         []CategoryResp `json:"children,omitempty"`
+}
+
+type MergeCategory struct {
+	SourceID xulid.ULID `json:"-" validate:"required"`
+	TargetID xulid.ULID `json:"target_id" validate:"required" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+}
+
+type MergeCategoryResp struct {
+	SourceID        xulid.ULID `json:"source_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	TargetID        xulid.ULID `json:"target_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FZZ"`
+	SpendsMoved     int64      `json:"spends_moved" example:"12"`
+	CategoryDeleted bool       `json:"category_deleted" example:"true"`
+}
+
+type CategoryOverrideReq struct {
+	CategoryID       xulid.ULID `json:"-" validate:"required"`
+	PocketID         xulid.ULID `json:"pocket_id" validate:"required" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	CategoryName     *string    `json:"category_name" validate:"omitempty,min=1,max=100" example:"gaji bulanan"`
+	CategoryIcon     *int       `json:"category_icon" example:"1"`
+	DefaultSpendType *int       `json:"default_spend_type" example:"0"`
+	IsHidden         bool       `json:"is_hidden" example:"false"`
+}
+
+type CategoryFilter struct {
+	PocketID      xulid.ULID
+	AsTree        bool // nest sub-categories under their parent
+	IncludeHidden bool // include system category hidden by pocket override
+}
+
+type CategoryOverrideResp struct {
 	PocketID         xulid.ULID `json:"pocket_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
-	CategoryName     string     `json:"category_name" example:"gaji"`
-	CategoryIcon     int        `json:"category_icon" example:"0"`
-	IsIncome         bool       `json:"is_income" example:"true"`
-	DefaultSpendType int        `json:"default_spend_type" example:"0"`
+	CategoryID       xulid.ULID `json:"category_id" example:"01J7QVGPM105H3BTRWSMRMQQ8F"`
+	CategoryName     *string    `json:"category_name" example:"gaji bulanan"`
+	CategoryIcon     *int       `json:"category_icon" example:"1"`
+	DefaultSpendType *int       `json:"default_spend_type" example:"0"`
+	IsHidden         bool       `json:"is_hidden" example:"false"`
 	CreatedAt        time.Time  `json:"created_at" example:"2022-09-10T17:03:15.091267+08:00"`
 	UpdatedAt        time.Time  `json:"update_at" example:"2022-09-10T17:03:15.091267+08:00"`
 }
@@ -10,23 +10,98 @@ import (
 type Category struct {
 	ID               xulid.ULID
 	PocketID         xulid.ULID
+	ParentID         xulid.NullULID
 	CategoryName     string
 	CategoryIcon     int
 	IsIncome         bool
 	DefaultSpendType int
+	IsHidden         bool // from pocket override, system category only
+	IsOverridden     bool // from pocket override, system category only
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 }
 
+// CategoryOverride change how system category is shown in one pocket.
+// nil field means system value is used
+type CategoryOverride struct {
+	PocketID         xulid.ULID
+	CategoryID       xulid.ULID
+	CategoryName     *string
+	CategoryIcon     *int
+	DefaultSpendType *int
+	IsHidden         bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (c *CategoryOverride) ToResp() CategoryOverrideResp {
+	return CategoryOverrideResp{
+		PocketID:         c.PocketID,
+		CategoryID:       c.CategoryID,
+		CategoryName:     c.CategoryName,
+		CategoryIcon:     c.CategoryIcon,
+		DefaultSpendType: c.DefaultSpendType,
+		IsHidden:         c.IsHidden,
+		CreatedAt:        c.CreatedAt,
+		UpdatedAt:        c.UpdatedAt,
+	}
+}
+
 func (c *Category) ToCategoryResp() CategoryResp {
 	return CategoryResp{
 		ID:               c.ID,
 		PocketID:         c.PocketID,
+		ParentID:         c.ParentID,
 		CategoryName:     c.CategoryName,
 		CategoryIcon:     c.CategoryIcon,
 		IsIncome:         c.IsIncome,
 		DefaultSpendType: c.DefaultSpendType,
+		IsHidden:         c.IsHidden,
+		IsOverridden:     c.IsOverridden,
 		CreatedAt:        c.CreatedAt,
 		UpdatedAt:        c.UpdatedAt,
 	}
 }
+
+// BuildCategoryTree nest categories under their parent.
+// category whose parent is not in the list stay at root
+func BuildCategoryTree(cats []CategoryResp) []CategoryResp {
+	indexByID := make(map[xulid.ULID]int, len(cats))
+	for i := range cats {
+		indexByID[cats[i].ID] = i
+	}
+
+	childrenOf := make(map[xulid.ULID][]int, len(cats))
+	roots := make([]int, 0, len(cats))
+	for i := range cats {
+		parentIdx, found := -1, false
+		if cats[i].ParentID.Valid {
+			parentIdx, found = indexByID[cats[i].ParentID.ULID]
+		}
+		if !found || parentIdx == i {
+			roots = append(roots, i)
+			continue
+		}
+		childrenOf[cats[i].ParentID.ULID] = append(childrenOf[cats[i].ParentID.ULID], i)
+	}
+
+	var build func(idx int, visited map[int]bool) CategoryResp
+	build = func(idx int, visited map[int]bool) CategoryResp {
+		visited[idx] = true
+		node := cats[idx]
+		for _, childIdx := range childrenOf[node.ID] {
+			if visited[childIdx] {
+				continue
+			}
+			node.Children = append(node.Children, build(childIdx, visited))
+		}
+		return node
+	}
+
+	visited := make(map[int]bool, len(cats))
+	result := make([]CategoryResp, 0, len(roots))
+	for _, idx := range roots {
+		result = append(result, build(idx, visited))
+	}
+	return result
+}
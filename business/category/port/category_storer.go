@@ -17,9 +17,20 @@ type CategorySaver interface {
 	InsertMany(ctx context.Context, categories []model.Category) error
 	Edit(ctx context.Context, category *model.Category) error
 	Delete(ctx context.Context, id string) error
+	ReassignSpends(ctx context.Context, fromID string, toID string) (int64, error)
+	UpsertOverride(ctx context.Context, override *model.CategoryOverride) error
+	DeleteOverride(ctx context.Context, pocketID string, categoryID string) error
 }
 
 type CategoryReader interface {
 	GetByID(ctx context.Context, id string) (model.Category, error)
-	Find(ctx context.Context, pocketID string, filter paging.Filters) ([]model.Category, paging.Metadata, error)
+	GetByIDForUpdate(ctx context.Context, id string) (model.Category, error)
+	Find(ctx context.Context, pocketID string, includeHidden bool, filter paging.Filters) ([]model.Category, paging.Metadata, error)
+	FindAll(ctx context.Context, pocketID string, includeHidden bool, filter paging.Filters) ([]model.Category, error)
+	CountSpends(ctx context.Context, id string) (int64, error)
+	FindAncestorIDs(ctx context.Context, id string) ([]string, error)
+}
+
+type Transactor interface {
+	WithAtomic(ctx context.Context, tFunc func(ctx context.Context) error) error
 }
@@ -22,10 +22,21 @@ const (
 	keyCategoryName     = "category_name"
 	keyCategoryIcon     = "category_icon"
 	keyPocketID         = "pocket_id"
+	keyParentID         = "parent_id"
 	keyIsIncome         = "is_income"
 	keyDefaultSpendType = "default_spend_type"
 	keyCreatedAt        = "created_at"
 	keyUpdatedAt        = "updated_at"
+
+	keyIsHidden = "is_hidden"
+
+	keyOverrideTable      = "category_overrides"
+	keyOverrideCategoryID = "category_id"
+
+	keySpendTable      = "spends"
+	keySpendCategoryID = "category_id"
+	keySpendUpdatedAt  = "updated_at"
+	keySpendVersion    = "version"
 )
 
 // make sure the implementation satisfies the interface
@@ -64,6 +75,7 @@ func (r *Repo) Insert(ctx context.Context, category *model.Category) error {
 			keyCategoryName,
 			keyCategoryIcon,
 			keyPocketID,
+			keyParentID,
 			keyIsIncome,
 			keyDefaultSpendType,
 			keyUpdatedAt,
@@ -74,6 +86,7 @@ func (r *Repo) Insert(ctx context.Context, category *model.Category) error {
 			category.CategoryName,
 			category.CategoryIcon,
 			category.PocketID,
+			category.ParentID,
 			category.IsIncome,
 			category.DefaultSpendType,
 			category.CreatedAt,
@@ -162,6 +175,7 @@ func (r *Repo) Edit(ctx context.Context, category *model.Category) error {
 		SetMap(sq.Eq{
 			keyCategoryName:     category.CategoryName,
 			keyCategoryIcon:     category.CategoryIcon,
+			keyParentID:         category.ParentID,
 			keyDefaultSpendType: category.DefaultSpendType,
 			keyUpdatedAt:        time.Now(),
 		}).
@@ -215,28 +229,90 @@ func (r *Repo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// ReassignSpends move all spends from category fromID to category toID.
+// return count of spend moved
+func (r *Repo) ReassignSpends(ctx context.Context, fromID string, toID string) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-ReassignSpends")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 6*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keySpendTable).
+		SetMap(sq.Eq{
+			keySpendCategoryID: toID,
+			keySpendUpdatedAt:  time.Now(),
+			keySpendVersion:    sq.Expr(keySpendVersion + " + 1"),
+		}).
+		Where(sq.Eq{keySpendCategoryID: fromID}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("build query reassign spend category: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
 // =========================================================================
 // GETTER
 
+// squirrel does not support recursive CTE, so ancestor query is written in raw SQL.
+// depth is limited so category cycle cannot make the query run forever
+const queryAncestorIDs = `WITH RECURSIVE ancestor AS (
+	SELECT id, parent_id, 1 AS depth FROM categories WHERE id = $1
+	UNION ALL
+	SELECT c.id, c.parent_id, a.depth + 1 FROM categories c JOIN ancestor a ON c.id = a.parent_id
+	WHERE a.depth < $2
+)
+SELECT id FROM ancestor ORDER BY depth`
+
 // GetByID get one category by id
 func (r *Repo) GetByID(ctx context.Context, id string) (model.Category, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "category-repo-GetByID")
 	defer span.End()
 
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDForUpdate get one category by id and lock it until the transaction end.
+// the lock conflict with foreign key check of spend insert, so no spend can use the
+// category while it is held. must be called inside transaction
+func (r *Repo) GetByIDForUpdate(ctx context.Context, id string) (model.Category, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-GetByIDForUpdate")
+	defer span.End()
+
+	return r.getByID(ctx, id, true)
+}
+
+func (r *Repo) getByID(ctx context.Context, id string, forUpdate bool) (model.Category, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	sqlStatement, args, err := r.sb.Select(
+	builder := r.sb.Select(
 		keyID,
 		keyCategoryName,
 		keyCategoryIcon,
 		keyIsIncome,
 		keyDefaultSpendType,
 		keyPocketID,
+		keyParentID,
 		keyCreatedAt,
 		keyUpdatedAt,
-	).From(keyTable).Where(sq.Eq{keyID: id}).ToSql()
+	).From(keyTable).Where(sq.Eq{keyID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
 
+	sqlStatement, args, err := builder.ToSql()
 	if err != nil {
 		return model.Category{}, fmt.Errorf("build query get category by id: %w", err)
 	}
@@ -252,6 +328,7 @@ func (r *Repo) GetByID(ctx context.Context, id string) (model.Category, error) {
 			&cat.IsIncome,
 			&cat.DefaultSpendType,
 			&cat.PocketID,
+			&cat.ParentID,
 			&cat.CreatedAt,
 			&cat.UpdatedAt,
 		)
@@ -263,11 +340,26 @@ func (r *Repo) GetByID(ctx context.Context, id string) (model.Category, error) {
 	return cat, nil
 }
 
-// Find get all category within pocketID
-func (r *Repo) Find(ctx context.Context, pocketID string, filter paging.Filters) ([]model.Category, paging.Metadata, error) {
+// Find get all category within pocketID.
+// system category is shown with pocket override applied, hidden category is skipped unless includeHidden
+func (r *Repo) Find(ctx context.Context, pocketID string, includeHidden bool, filter paging.Filters) ([]model.Category, paging.Metadata, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "category-repo-Find")
 	defer span.End()
 
+	return r.find(ctx, pocketID, includeHidden, filter, true)
+}
+
+// FindAll get every category within pocketID like Find but without paging, filter is only used to sort.
+// used where the whole set is needed, ex: building category tree
+func (r *Repo) FindAll(ctx context.Context, pocketID string, includeHidden bool, filter paging.Filters) ([]model.Category, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-FindAll")
+	defer span.End()
+
+	cats, _, err := r.find(ctx, pocketID, includeHidden, filter, false)
+	return cats, err
+}
+
+func (r *Repo) find(ctx context.Context, pocketID string, includeHidden bool, filter paging.Filters, paged bool) ([]model.Category, paging.Metadata, error) {
 	// Validation filter
 	filter.SortSafelist = []string{"category_name", "-category_name", "updated_at", "-updated_at"}
 	if err := filter.Validate(); err != nil {
@@ -277,29 +369,42 @@ func (r *Repo) Find(ctx context.Context, pocketID string, filter paging.Filters)
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	where := sq.Or{
-		sq.Eq{keyPocketID: pocketID},
-		sq.Eq{keyPocketID: constant.POCK_MAIN_ID}, // OR 00000000000000000000000000
+	where := sq.And{
+		sq.Or{
+			sq.Eq{db.A(keyPocketID): pocketID},
+			sq.Eq{db.A(keyPocketID): constant.POCK_MAIN_ID}, // OR 00000000000000000000000000
+		},
+	}
+	if !includeHidden {
+		where = append(where, sq.Expr(db.CoalesceBool(db.B(keyIsHidden), false)+" = false"))
 	}
 
-	sqlStatement, args, err := r.sb.Select(
+	// output column is aliased so sort by category_name and updated_at use overridden value
+	builder := r.sb.Select(
 		"count(*) OVER()",
-		keyID,
-		keyCategoryName,
-		keyCategoryIcon,
-		keyIsIncome,
-		keyDefaultSpendType,
-		keyPocketID,
-		keyCreatedAt,
-		keyUpdatedAt,
+		db.A(keyID),
+		fmt.Sprintf("COALESCE(%s, %s) AS %s", db.B(keyCategoryName), db.A(keyCategoryName), keyCategoryName),
+		fmt.Sprintf("COALESCE(%s, %s) AS %s", db.B(keyCategoryIcon), db.A(keyCategoryIcon), keyCategoryIcon),
+		db.A(keyIsIncome),
+		fmt.Sprintf("COALESCE(%s, %s) AS %s", db.B(keyDefaultSpendType), db.A(keyDefaultSpendType), keyDefaultSpendType),
+		db.A(keyPocketID),
+		db.A(keyParentID),
+		db.CoalesceBool(db.B(keyIsHidden), false),
+		db.B(keyPocketID)+" IS NOT NULL",
+		db.A(keyCreatedAt),
+		fmt.Sprintf("GREATEST(%s, %s) AS %s", db.A(keyUpdatedAt), db.B(keyUpdatedAt), keyUpdatedAt),
 	).
-		From(keyTable).
+		From(keyTable+" A").
+		LeftJoin(keyOverrideTable+" B ON B.category_id = A.id AND B.pocket_id = ?", pocketID).
 		Where(where).
-		OrderBy(filter.SortColumnDirection()).
-		Limit(uint64(filter.Limit())).
-		Offset(uint64(filter.Offset())).
-		ToSql()
+		OrderBy(filter.SortColumnDirection())
+	if paged {
+		builder = builder.
+			Limit(uint64(filter.Limit())).
+			Offset(uint64(filter.Offset()))
+	}
 
+	sqlStatement, args, err := builder.ToSql()
 	if err != nil {
 		return nil, paging.Metadata{}, fmt.Errorf("build query find category: %w", err)
 	}
@@ -325,6 +430,9 @@ func (r *Repo) Find(ctx context.Context, pocketID string, filter paging.Filters)
 			&cat.IsIncome,
 			&cat.DefaultSpendType,
 			&cat.PocketID,
+			&cat.ParentID,
+			&cat.IsHidden,
+			&cat.IsOverridden,
 			&cat.CreatedAt,
 			&cat.UpdatedAt)
 		if err != nil {
@@ -342,3 +450,67 @@ func (r *Repo) Find(ctx context.Context, pocketID string, filter paging.Filters)
 
 	return cats, metadata, nil
 }
+
+// CountSpends count spends that use category id
+func (r *Repo) CountSpends(ctx context.Context, id string) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-CountSpends")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select("count(*)").
+		From(keySpendTable).
+		Where(sq.Eq{keySpendCategoryID: id}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("build query count spend by category: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var total int64
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&total)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return total, nil
+}
+
+// FindAncestorIDs return id of category and all of its parents up to the root,
+// start from id itself
+func (r *Repo) FindAncestorIDs(ctx context.Context, id string) ([]string, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-FindAncestorIDs")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, queryAncestorIDs, id, constant.CAT_MAX_DEPTH)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var ancestorID string
+		if err := rows.Scan(&ancestorID); err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		ids = append(ids, ancestorID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/category/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// FindByPocketIDs get custom category of pocketIDs without paging, system category is not included
+func (r *Repo) FindByPocketIDs(ctx context.Context, pocketIDs []xulid.ULID) ([]model.Category, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-FindByPocketIDs")
+	defer span.End()
+
+	if len(pocketIDs) == 0 {
+		return []model.Category{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyID,
+		keyCategoryName,
+		keyCategoryIcon,
+		keyIsIncome,
+		keyDefaultSpendType,
+		keyPocketID,
+		keyParentID,
+		keyCreatedAt,
+		keyUpdatedAt,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyPocketID: pocketIDs}).
+		OrderBy(keyPocketID, keyCategoryName).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find category by pockets: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	cats := make([]model.Category, 0)
+	for rows.Next() {
+		var cat model.Category
+		err := rows.Scan(
+			&cat.ID,
+			&cat.CategoryName,
+			&cat.CategoryIcon,
+			&cat.IsIncome,
+			&cat.DefaultSpendType,
+			&cat.PocketID,
+			&cat.ParentID,
+			&cat.CreatedAt,
+			&cat.UpdatedAt)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		cats = append(cats, cat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cats, nil
+}
+
+// DeleteByPocket delete every custom category of pocketID, return count of deleted category
+func (r *Repo) DeleteByPocket(ctx context.Context, pocketID xulid.ULID) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-DeleteByPocket")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyTable).
+		Where(sq.Eq{keyPocketID: pocketID}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query delete category by pocket: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
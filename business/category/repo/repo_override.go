@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/category/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// UpsertOverride insert or replace pocket override for system category
+func (r *Repo) UpsertOverride(ctx context.Context, override *model.CategoryOverride) error {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-UpsertOverride")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyOverrideTable).
+		Columns(
+			keyPocketID,
+			keyOverrideCategoryID,
+			keyCategoryName,
+			keyCategoryIcon,
+			keyDefaultSpendType,
+			keyIsHidden,
+			keyCreatedAt,
+			keyUpdatedAt,
+		).
+		Values(
+			override.PocketID,
+			override.CategoryID,
+			override.CategoryName,
+			override.CategoryIcon,
+			override.DefaultSpendType,
+			override.IsHidden,
+			override.CreatedAt,
+			override.UpdatedAt,
+		).
+		Suffix(`ON CONFLICT (pocket_id, category_id) DO UPDATE SET
+			category_name = EXCLUDED.category_name,
+			category_icon = EXCLUDED.category_icon,
+			default_spend_type = EXCLUDED.default_spend_type,
+			is_hidden = EXCLUDED.is_hidden,
+			updated_at = EXCLUDED.updated_at`).
+		Suffix(db.Returning(keyCreatedAt)).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query upsert category override: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&override.CreatedAt)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// DeleteOverride remove pocket override, system category is shown as seeded again
+func (r *Repo) DeleteOverride(ctx context.Context, pocketID string, categoryID string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "category-repo-DeleteOverride")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyOverrideTable).
+		Where(sq.Eq{
+			keyPocketID:           pocketID,
+			keyOverrideCategoryID: categoryID,
+		}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query delete category override: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
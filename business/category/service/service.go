@@ -3,11 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/muchlist/moneymagnet/business/category/model"
 	"github.com/muchlist/moneymagnet/business/category/port"
 	pocketPort "github.com/muchlist/moneymagnet/business/pocket/port"
+	rtModel "github.com/muchlist/moneymagnet/business/realtime/model"
+	"github.com/muchlist/moneymagnet/constant"
 	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/mjwt"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
@@ -22,6 +25,8 @@ type Core struct {
 	log          mlogger.Logger
 	repo         port.CategoryStorer
 	pockerReader pocketPort.PocketReader
+	publisher    port.ChangePublisher
+	txManager    port.Transactor
 }
 
 // NewCore constructs a core for category api access.
@@ -29,11 +34,15 @@ func NewCore(
 	log mlogger.Logger,
 	repo port.CategoryStorer,
 	pockerReader pocketPort.PocketReader,
+	publisher port.ChangePublisher,
+	txManager port.Transactor,
 ) *Core {
 	return &Core{
 		log:          log,
 		repo:         repo,
 		pockerReader: pockerReader,
+		publisher:    publisher,
+		txManager:    txManager,
 	}
 }
 
@@ -48,14 +57,16 @@ func (s *Core) CreateCategory(ctx context.Context, claims mjwt.CustomClaim, req
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.CategoryResp{}, errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return model.CategoryResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	timeNow := time.Now()
 	cat := model.Category{
 		ID:               xulid.Instance().NewULID(),
 		PocketID:         req.PocketID,
+		ParentID:         req.ParentID,
 		CategoryName:     req.CategoryName,
 		CategoryIcon:     req.CategoryIcon,
 		IsIncome:         req.IsIncome,
@@ -64,55 +75,102 @@ func (s *Core) CreateCategory(ctx context.Context, claims mjwt.CustomClaim, req
 		UpdatedAt:        timeNow,
 	}
 
+	if cat.ParentID.Valid {
+		if err := s.validateParent(ctx, cat, cat.ParentID.ULID); err != nil {
+			return model.CategoryResp{}, err
+		}
+	}
+
 	if err := s.repo.Insert(ctx, &cat); err != nil {
 		return model.CategoryResp{}, fmt.Errorf("insert category to db: %w", err)
 	}
 
-	return cat.ToCategoryResp(), nil
+	result := cat.ToCategoryResp()
+	s.publisher.Publish(ctx, cat.PocketID, rtModel.EventCategoryCreated, result)
+
+	return result, nil
 }
 
 func (s *Core) EditCategory(ctx context.Context, claims mjwt.CustomClaim, newData model.UpdateCategory) (model.CategoryResp, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "category-service-EditCategory")
 	defer span.End()
 
-	// Get existing Category
-	categoryExisting, err := s.repo.GetByID(ctx, newData.ID.String())
-	if err != nil {
-		return model.CategoryResp{}, fmt.Errorf("get category by id: %w", err)
+	if newData.DetachParent && newData.ParentID.Valid {
+		return model.CategoryResp{}, errr.NewT("error.parent_conflict", nil, 400)
 	}
 
-	// Get existing Pocket
-	pocketExisting, err := s.pockerReader.GetByID(ctx, categoryExisting.PocketID)
-	if err != nil {
-		return model.CategoryResp{}, fmt.Errorf("get pocket by id: %w", err)
-	}
+	var categoryExisting model.Category
+	txErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		// Get existing Category
+		category, err := s.repo.GetByID(ctx, newData.ID.String())
+		if err != nil {
+			return fmt.Errorf("get category by id: %w", err)
+		}
 
-	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.CategoryResp{}, errr.New("not have access to this pocket", 400)
-	}
+		// Get existing Pocket, locked so category of the pocket is re-parented one by one
+		// and two concurrent change cannot create cycle together
+		pocketExisting, err := s.pockerReader.GetByIDForUpdate(ctx, category.PocketID)
+		if err != nil {
+			return fmt.Errorf("get pocket by id: %w", err)
+		}
 
-	// Modify data
-	categoryExisting.CategoryName = newData.CategoryName
-	categoryExisting.CategoryIcon = newData.CategoryIcon
-	categoryExisting.DefaultSpendType = newData.DefaultSpendType
+		// Validate Pocket Roles Editor
+		if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+			!claims.CanAccessPocket(pocketExisting.ID.String()) {
+			return errr.NewT("error.pocket_access_denied", nil, 400)
+		}
 
-	// Edit
-	err = s.repo.Edit(ctx, &categoryExisting)
-	if err != nil {
-		return model.CategoryResp{}, fmt.Errorf("edit category: %w", err)
+		// read again under the lock, parent may be changed while waiting for it
+		categoryExisting, err = s.repo.GetByID(ctx, newData.ID.String())
+		if err != nil {
+			return fmt.Errorf("get category by id: %w", err)
+		}
+
+		// Modify data
+		categoryExisting.CategoryName = newData.CategoryName
+		categoryExisting.CategoryIcon = newData.CategoryIcon
+		categoryExisting.DefaultSpendType = newData.DefaultSpendType
+
+		// Modify parent
+		if newData.DetachParent {
+			categoryExisting.ParentID = xulid.NullULID{}
+		}
+		if newData.ParentID.Valid && newData.ParentID != categoryExisting.ParentID {
+			if err := s.validateParent(ctx, categoryExisting, newData.ParentID.ULID); err != nil {
+				return err
+			}
+			categoryExisting.ParentID = newData.ParentID
+		}
+
+		// Edit
+		err = s.repo.Edit(ctx, &categoryExisting)
+		if err != nil {
+			return fmt.Errorf("edit category: %w", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return model.CategoryResp{}, txErr
 	}
 
-	return categoryExisting.ToCategoryResp(), nil
+	result := categoryExisting.ToCategoryResp()
+	s.publisher.Publish(ctx, categoryExisting.PocketID, rtModel.EventCategoryUpdated, result)
+
+	return result, nil
 }
 
-// FindAllCategory ...
-func (s *Core) FindAllCategory(ctx context.Context, pocketID xulid.ULID, filter paging.Filters) ([]model.CategoryResp, paging.Metadata, error) {
+// FindAllCategory find categories of pocket, if AsTree is true
+// sub-categories are nested under their parent
+func (s *Core) FindAllCategory(ctx context.Context, catFilter model.CategoryFilter, filter paging.Filters) ([]model.CategoryResp, paging.Metadata, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "category-service-FindAllCategory")
 	defer span.End()
 
+	if catFilter.AsTree {
+		return s.findCategoryTree(ctx, catFilter, filter)
+	}
+
 	// Get category
-	cats, metadata, err := s.repo.Find(ctx, pocketID.String(), filter)
+	cats, metadata, err := s.repo.Find(ctx, catFilter.PocketID.String(), catFilter.IncludeHidden, filter)
 	if err != nil {
 		return nil, paging.Metadata{}, fmt.Errorf("find category: %w", err)
 	}
@@ -125,14 +183,261 @@ func (s *Core) FindAllCategory(ctx context.Context, pocketID xulid.ULID, filter
 	return catResults, metadata, nil
 }
 
-// DeleteCategory ...
-func (s *Core) DeleteCategory(ctx context.Context, categoryID xulid.ULID) error {
+// findCategoryTree build tree from every category of pocket, then page the root categories.
+// paging before building would drop child whose parent is on other page
+func (s *Core) findCategoryTree(ctx context.Context, catFilter model.CategoryFilter, filter paging.Filters) ([]model.CategoryResp, paging.Metadata, error) {
+	cats, err := s.repo.FindAll(ctx, catFilter.PocketID.String(), catFilter.IncludeHidden, filter)
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("find category: %w", err)
+	}
+
+	catResults := make([]model.CategoryResp, len(cats))
+	for i := range cats {
+		catResults[i] = cats[i].ToCategoryResp()
+	}
+
+	roots, metadata := paging.PageSlice(model.BuildCategoryTree(catResults), filter)
+	return roots, metadata, nil
+}
+
+// DeleteCategory delete category, spends that still use the category must be moved to replacementID.
+// replacementID can be empty only when category is not used by any spend
+func (s *Core) DeleteCategory(ctx context.Context, claims mjwt.CustomClaim, categoryID xulid.ULID, replacementID xulid.NullULID) (model.MergeCategoryResp, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "category-service-DeleteCategory")
 	defer span.End()
 
-	err := s.repo.Delete(ctx, categoryID.String())
+	if replacementID.Valid {
+		return s.MergeCategory(ctx, claims, model.MergeCategory{
+			SourceID: categoryID,
+			TargetID: replacementID.ULID,
+		})
+	}
+
+	source, err := s.getEditableCategory(ctx, claims, categoryID)
+	if err != nil {
+		return model.MergeCategoryResp{}, err
+	}
+
+	txErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		// locked so no spend can be added to the category between count and delete
+		if _, err := s.repo.GetByIDForUpdate(ctx, source.ID.String()); err != nil {
+			return fmt.Errorf("lock category: %w", err)
+		}
+
+		used, err := s.repo.CountSpends(ctx, source.ID.String())
+		if err != nil {
+			return fmt.Errorf("count spend by category: %w", err)
+		}
+		if used > 0 {
+			return errr.NewT("error.category_in_use", map[string]string{"count": strconv.FormatInt(used, 10)}, 400)
+		}
+
+		err = s.repo.Delete(ctx, source.ID.String())
+		if err != nil {
+			return fmt.Errorf("delete category: %w", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return model.MergeCategoryResp{}, txErr
+	}
+
+	result := model.MergeCategoryResp{
+		SourceID:        source.ID,
+		CategoryDeleted: true,
+	}
+	s.publisher.Publish(ctx, source.PocketID, rtModel.EventCategoryDeleted, result)
+
+	return result, nil
+}
+
+// MergeCategory move all spends from source category to target category then delete source category
+func (s *Core) MergeCategory(ctx context.Context, claims mjwt.CustomClaim, req model.MergeCategory) (model.MergeCategoryResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-service-MergeCategory")
+	defer span.End()
+
+	if req.SourceID == req.TargetID {
+		return model.MergeCategoryResp{}, errr.NewT("error.merge_self", nil, 400)
+	}
+
+	source, err := s.getEditableCategory(ctx, claims, req.SourceID)
+	if err != nil {
+		return model.MergeCategoryResp{}, err
+	}
+
+	target, err := s.repo.GetByID(ctx, req.TargetID.String())
+	if err != nil {
+		return model.MergeCategoryResp{}, fmt.Errorf("get target category by id: %w", err)
+	}
+
+	// target must be available in the same pocket, either owned by pocket or system category
+	if target.PocketID != source.PocketID && target.PocketID.String() != constant.POCK_MAIN_ID {
+		return model.MergeCategoryResp{}, errr.NewT("error.merge_target_unavailable", nil, 400)
+	}
+	if target.IsIncome != source.IsIncome {
+		return model.MergeCategoryResp{}, errr.NewT("error.merge_type_mismatch", nil, 400)
+	}
+
+	var moved int64
+	txErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		// locked so spend added while merging is not left without category
+		if _, err := s.repo.GetByIDForUpdate(ctx, source.ID.String()); err != nil {
+			return fmt.Errorf("lock category: %w", err)
+		}
+
+		moved, err = s.repo.ReassignSpends(ctx, source.ID.String(), target.ID.String())
+		if err != nil {
+			return fmt.Errorf("reassign spend category: %w", err)
+		}
+
+		err = s.repo.Delete(ctx, source.ID.String())
+		if err != nil {
+			return fmt.Errorf("delete category: %w", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return model.MergeCategoryResp{}, txErr
+	}
+
+	result := model.MergeCategoryResp{
+		SourceID:        source.ID,
+		TargetID:        target.ID,
+		SpendsMoved:     moved,
+		CategoryDeleted: true,
+	}
+	s.publisher.Publish(ctx, source.PocketID, rtModel.EventCategoryDeleted, result)
+
+	return result, nil
+}
+
+// getEditableCategory get category and make sure user is editor of the pocket.
+// system category cannot be modified by user
+func (s *Core) getEditableCategory(ctx context.Context, claims mjwt.CustomClaim, categoryID xulid.ULID) (model.Category, error) {
+	categoryExisting, err := s.repo.GetByID(ctx, categoryID.String())
 	if err != nil {
-		return fmt.Errorf("delete category: %w", err)
+		return model.Category{}, fmt.Errorf("get category by id: %w", err)
 	}
+
+	if categoryExisting.PocketID.String() == constant.POCK_MAIN_ID {
+		return model.Category{}, errr.NewT("error.default_category_readonly", nil, 400)
+	}
+
+	pocketExisting, err := s.pockerReader.GetByID(ctx, categoryExisting.PocketID)
+	if err != nil {
+		return model.Category{}, fmt.Errorf("get pocket by id: %w", err)
+	}
+
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return model.Category{}, errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
+	return categoryExisting, nil
+}
+
+// validateParent make sure parentID can be used as parent of cat.
+// parent must be available in the same pocket, have same income type and not create a cycle
+func (s *Core) validateParent(ctx context.Context, cat model.Category, parentID xulid.ULID) error {
+	if parentID == cat.ID {
+		return errr.NewT("error.category_self_parent", nil, 400)
+	}
+
+	parent, err := s.repo.GetByID(ctx, parentID.String())
+	if err != nil {
+		return fmt.Errorf("get parent category by id: %w", err)
+	}
+
+	if parent.PocketID != cat.PocketID && parent.PocketID.String() != constant.POCK_MAIN_ID {
+		return errr.NewT("error.parent_category_unavailable", nil, 400)
+	}
+	if parent.IsIncome != cat.IsIncome {
+		return errr.NewT("error.parent_category_type", nil, 400)
+	}
+
+	ancestorIDs, err := s.repo.FindAncestorIDs(ctx, parent.ID.String())
+	if err != nil {
+		return fmt.Errorf("find ancestor category: %w", err)
+	}
+	if slicer.In(cat.ID.String(), ancestorIDs) {
+		return errr.NewT("error.parent_category_cycle", nil, 400)
+	}
+
+	return nil
+}
+
+// SetOverride hide, rename, re-icon or change default spend type of system category for one pocket
+func (s *Core) SetOverride(ctx context.Context, claims mjwt.CustomClaim, req model.CategoryOverrideReq) (model.CategoryOverrideResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "category-service-SetOverride")
+	defer span.End()
+
+	if err := s.validateOverride(ctx, claims, req.CategoryID, req.PocketID); err != nil {
+		return model.CategoryOverrideResp{}, err
+	}
+
+	timeNow := time.Now()
+	override := model.CategoryOverride{
+		PocketID:         req.PocketID,
+		CategoryID:       req.CategoryID,
+		CategoryName:     req.CategoryName,
+		CategoryIcon:     req.CategoryIcon,
+		DefaultSpendType: req.DefaultSpendType,
+		IsHidden:         req.IsHidden,
+		CreatedAt:        timeNow,
+		UpdatedAt:        timeNow,
+	}
+
+	if err := s.repo.UpsertOverride(ctx, &override); err != nil {
+		return model.CategoryOverrideResp{}, fmt.Errorf("upsert category override: %w", err)
+	}
+
+	result := override.ToResp()
+	s.publisher.Publish(ctx, override.PocketID, rtModel.EventCategoryUpdated, result)
+
+	return result, nil
+}
+
+// ResetOverride remove pocket override so system category is shown as seeded
+func (s *Core) ResetOverride(ctx context.Context, claims mjwt.CustomClaim, categoryID xulid.ULID, pocketID xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "category-service-ResetOverride")
+	defer span.End()
+
+	if err := s.validateOverride(ctx, claims, categoryID, pocketID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteOverride(ctx, pocketID.String(), categoryID.String()); err != nil {
+		return fmt.Errorf("delete category override: %w", err)
+	}
+
+	s.publisher.Publish(ctx, pocketID, rtModel.EventCategoryUpdated, model.CategoryOverrideResp{
+		PocketID:   pocketID,
+		CategoryID: categoryID,
+	})
+
+	return nil
+}
+
+// validateOverride make sure category is system category and user is editor of pocket
+func (s *Core) validateOverride(ctx context.Context, claims mjwt.CustomClaim, categoryID xulid.ULID, pocketID xulid.ULID) error {
+	categoryExisting, err := s.repo.GetByID(ctx, categoryID.String())
+	if err != nil {
+		return fmt.Errorf("get category by id: %w", err)
+	}
+
+	if categoryExisting.PocketID.String() != constant.POCK_MAIN_ID {
+		return errr.NewT("error.override_not_default", nil, 400)
+	}
+
+	pocketExisting, err := s.pockerReader.GetByID(ctx, pocketID)
+	if err != nil {
+		return fmt.Errorf("get pocket by id: %w", err)
+	}
+
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
 	return nil
 }
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/muchlist/moneymagnet/business/category/model"
+	"github.com/muchlist/moneymagnet/business/category/port"
+	pocketModel "github.com/muchlist/moneymagnet/business/pocket/model"
+	pocketPort "github.com/muchlist/moneymagnet/business/pocket/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+var (
+	editorID  = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEP1")
+	pocketID  = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEP2")
+	expenseID = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC1")
+	foodID    = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC2")
+	incomeID  = xulid.MustParse("01J4EXF94QDMR5XT9KN527XEC3")
+)
+
+// fakeRepo keep spend count per category and record call made inside transaction
+type fakeRepo struct {
+	port.CategoryStorer
+	categories map[xulid.ULID]model.Category
+	spends     map[string]int64
+	calls      []string
+	inTx       bool
+}
+
+func (f *fakeRepo) record(call string) {
+	if f.inTx {
+		call = "tx:" + call
+	}
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeRepo) GetByID(ctx context.Context, id string) (model.Category, error) {
+	cat, ok := f.categories[xulid.MustParse(id)]
+	if !ok {
+		return model.Category{}, db.ErrDBNotFound
+	}
+	return cat, nil
+}
+
+func (f *fakeRepo) GetByIDForUpdate(ctx context.Context, id string) (model.Category, error) {
+	f.record("lock")
+	return f.GetByID(ctx, id)
+}
+
+func (f *fakeRepo) CountSpends(ctx context.Context, id string) (int64, error) {
+	f.record("count")
+	return f.spends[id], nil
+}
+
+func (f *fakeRepo) ReassignSpends(ctx context.Context, fromID string, toID string) (int64, error) {
+	f.record("reassign")
+	moved := f.spends[fromID]
+	f.spends[toID] += moved
+	delete(f.spends, fromID)
+	return moved, nil
+}
+
+func (f *fakeRepo) FindAll(ctx context.Context, pocketID string, includeHidden bool, filter paging.Filters) ([]model.Category, error) {
+	cats := make([]model.Category, 0, len(f.categories))
+	for _, cat := range f.categories {
+		cats = append(cats, cat)
+	}
+	slices.SortFunc(cats, func(a, b model.Category) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	return cats, nil
+}
+
+func (f *fakeRepo) FindAncestorIDs(ctx context.Context, id string) ([]string, error) {
+	ids := make([]string, 0)
+	for cat, ok := f.categories[xulid.MustParse(id)]; ok && len(ids) < 32; {
+		ids = append(ids, cat.ID.String())
+		if !cat.ParentID.Valid {
+			break
+		}
+		cat, ok = f.categories[cat.ParentID.ULID]
+	}
+	return ids, nil
+}
+
+func (f *fakeRepo) Edit(ctx context.Context, category *model.Category) error {
+	f.record("edit")
+	f.categories[category.ID] = *category
+	return nil
+}
+
+func (f *fakeRepo) Delete(ctx context.Context, id string) error {
+	f.record("delete")
+	delete(f.categories, xulid.MustParse(id))
+	return nil
+}
+
+type fakeTx struct {
+	repo *fakeRepo
+}
+
+func (f fakeTx) WithAtomic(ctx context.Context, tFunc func(ctx context.Context) error) error {
+	f.repo.inTx = true
+	defer func() { f.repo.inTx = false }()
+	return tFunc(ctx)
+}
+
+type fakePockets struct {
+	pocketPort.PocketReader
+	repo *fakeRepo
+}
+
+func (f fakePockets) GetByID(ctx context.Context, id xulid.ULID) (pocketModel.Pocket, error) {
+	return pocketModel.Pocket{ID: id, EditorID: []string{editorID.String()}}, nil
+}
+
+func (f fakePockets) GetByIDForUpdate(ctx context.Context, id xulid.ULID) (pocketModel.Pocket, error) {
+	f.repo.record("lock-pocket")
+	return f.GetByID(ctx, id)
+}
+
+type fakePublisher struct {
+	events []string
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, pocketID xulid.ULID, event string, data any) {
+	f.events = append(f.events, event)
+}
+
+func newTestCore(spends map[string]int64) (*Core, *fakeRepo, *fakePublisher) {
+	repo := &fakeRepo{
+		categories: map[xulid.ULID]model.Category{
+			expenseID: {ID: expenseID, PocketID: pocketID},
+			foodID:    {ID: foodID, PocketID: pocketID, ParentID: xulid.NullULID{ULID: expenseID, Valid: true}},
+			incomeID:  {ID: incomeID, PocketID: pocketID, IsIncome: true},
+		},
+		spends: spends,
+	}
+	publisher := &fakePublisher{}
+	log := mlogger.New(mlogger.Options{Level: mlogger.LevelError, Output: "stderr"})
+	return NewCore(log, repo, fakePockets{repo: repo}, publisher, fakeTx{repo: repo}), repo, publisher
+}
+
+func TestDeleteCategory(t *testing.T) {
+	claims := mjwt.CustomClaim{Identity: editorID.String()}
+
+	t.Run("unused category", func(t *testing.T) {
+		core, repo, publisher := newTestCore(map[string]int64{})
+		result, err := core.DeleteCategory(context.Background(), claims, foodID, xulid.NullULID{})
+		if err != nil {
+			t.Fatalf("DeleteCategory() error = %v", err)
+		}
+		if !result.CategoryDeleted {
+			t.Errorf("DeleteCategory() CategoryDeleted = false")
+		}
+		// count and delete must happen under the same lock
+		want := []string{"tx:lock", "tx:count", "tx:delete"}
+		if !slices.Equal(repo.calls, want) {
+			t.Errorf("calls = %v, want %v", repo.calls, want)
+		}
+		if len(publisher.events) != 1 {
+			t.Errorf("published %d event, want 1", len(publisher.events))
+		}
+	})
+
+	t.Run("used category without replacement", func(t *testing.T) {
+		core, repo, publisher := newTestCore(map[string]int64{foodID.String(): 2})
+		if _, err := core.DeleteCategory(context.Background(), claims, foodID, xulid.NullULID{}); err == nil {
+			t.Fatalf("DeleteCategory() error = nil, want used category error")
+		}
+		if _, ok := repo.categories[foodID]; !ok {
+			t.Errorf("used category is deleted")
+		}
+		if len(publisher.events) != 0 {
+			t.Errorf("published %d event for failed delete", len(publisher.events))
+		}
+	})
+
+	t.Run("used category with replacement", func(t *testing.T) {
+		core, repo, _ := newTestCore(map[string]int64{foodID.String(): 2})
+		result, err := core.DeleteCategory(context.Background(), claims, foodID, xulid.NullULID{ULID: expenseID, Valid: true})
+		if err != nil {
+			t.Fatalf("DeleteCategory() error = %v", err)
+		}
+		if result.SpendsMoved != 2 || repo.spends[expenseID.String()] != 2 {
+			t.Errorf("DeleteCategory() moved %d spend, want 2", result.SpendsMoved)
+		}
+		want := []string{"tx:lock", "tx:reassign", "tx:delete"}
+		if !slices.Equal(repo.calls, want) {
+			t.Errorf("calls = %v, want %v", repo.calls, want)
+		}
+	})
+
+	t.Run("not editor", func(t *testing.T) {
+		core, repo, _ := newTestCore(map[string]int64{})
+		other := mjwt.CustomClaim{Identity: pocketID.String()}
+		if _, err := core.DeleteCategory(context.Background(), other, foodID, xulid.NullULID{}); err == nil {
+			t.Fatalf("DeleteCategory() by non editor error = nil")
+		}
+		if len(repo.calls) != 0 {
+			t.Errorf("calls = %v, want none", repo.calls)
+		}
+	})
+}
+
+func TestMergeCategory(t *testing.T) {
+	claims := mjwt.CustomClaim{Identity: editorID.String()}
+
+	tests := []struct {
+		name   string
+		source xulid.ULID
+		target xulid.ULID
+	}{
+		{name: "into itself", source: foodID, target: foodID},
+		{name: "income into expense", source: incomeID, target: expenseID},
+		{name: "unknown target", source: foodID, target: pocketID},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			core, repo, _ := newTestCore(map[string]int64{tc.source.String(): 1})
+			_, err := core.MergeCategory(context.Background(), claims, model.MergeCategory{SourceID: tc.source, TargetID: tc.target})
+			if err == nil {
+				t.Fatalf("MergeCategory() error = nil")
+			}
+			if repo.spends[tc.source.String()] != 1 {
+				t.Errorf("spend is moved by failed merge")
+			}
+		})
+	}
+}
+
+func TestEditCategoryParent(t *testing.T) {
+	claims := mjwt.CustomClaim{Identity: editorID.String()}
+
+	t.Run("under its sub-category", func(t *testing.T) {
+		core, repo, _ := newTestCore(map[string]int64{})
+		req := model.UpdateCategory{ID: expenseID, CategoryName: "expense", ParentID: xulid.NullULID{ULID: foodID, Valid: true}}
+		if _, err := core.EditCategory(context.Background(), claims, req); err == nil {
+			t.Fatalf("EditCategory() creating cycle error = nil")
+		}
+		// pocket is locked before the parent is validated
+		want := []string{"tx:lock-pocket"}
+		if !slices.Equal(repo.calls, want) {
+			t.Errorf("calls = %v, want %v", repo.calls, want)
+		}
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		core, repo, _ := newTestCore(map[string]int64{})
+		req := model.UpdateCategory{ID: foodID, CategoryName: "food", DetachParent: true}
+		result, err := core.EditCategory(context.Background(), claims, req)
+		if err != nil {
+			t.Fatalf("EditCategory() error = %v", err)
+		}
+		if result.ParentID.Valid || repo.categories[foodID].ParentID.Valid {
+			t.Errorf("EditCategory() parent = %v, want detached", result.ParentID)
+		}
+		want := []string{"tx:lock-pocket", "tx:edit"}
+		if !slices.Equal(repo.calls, want) {
+			t.Errorf("calls = %v, want %v", repo.calls, want)
+		}
+	})
+}
+
+func TestFindCategoryTree(t *testing.T) {
+	core, _, _ := newTestCore(map[string]int64{})
+	catFilter := model.CategoryFilter{PocketID: pocketID, AsTree: true}
+
+	// food is nested under expense even when it would be on other page of flat list
+	result, metadata, err := core.FindAllCategory(context.Background(), catFilter, paging.Filters{Page: 1, PageSize: 1})
+	if err != nil {
+		t.Fatalf("FindAllCategory() error = %v", err)
+	}
+	if len(result) != 1 || result[0].ID != expenseID || len(result[0].Children) != 1 || result[0].Children[0].ID != foodID {
+		t.Errorf("FindAllCategory() page 1 = %+v, want expense with food", result)
+	}
+	if metadata.TotalRecords != 2 || metadata.LastPage != 2 {
+		t.Errorf("FindAllCategory() metadata = %+v, want 2 root in 2 page", metadata)
+	}
+
+	result, _, err = core.FindAllCategory(context.Background(), catFilter, paging.Filters{Page: 2, PageSize: 1})
+	if err != nil {
+		t.Fatalf("FindAllCategory() error = %v", err)
+	}
+	if len(result) != 1 || result[0].ID != incomeID {
+		t.Errorf("FindAllCategory() page 2 = %+v, want income", result)
+	}
+
+	result, _, err = core.FindAllCategory(context.Background(), catFilter, paging.Filters{Page: 3, PageSize: 1})
+	if err != nil || len(result) != 0 {
+		t.Errorf("FindAllCategory() page 3 = %+v, %v, want empty", result, err)
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/business/job/service"
+	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+func NewJobHandler(log mlogger.Logger,
+	jobService *service.Core) jobHandler {
+	return jobHandler{
+		log:     log,
+		service: jobService,
+	}
+}
+
+type jobHandler struct {
+	log     mlogger.Logger
+	service *service.Core
+}
+
+// @Summary      Find Jobs
+// @Description  List durable background job, admin only
+// @Tags         Admin
+// @Produce      json
+// @Param 		 status query string false "pending, running, done or dead"
+// @Param 		 job_type query string false "job type"
+// @Param 		 page query int false "page"
+// @Param 		 page_size query int false "page-size"
+// @Param 		 sort query string false "sort"
+// @Success      200  {object}  misc.ResponseSuccessList{data=[]model.JobResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /admin/jobs [get]
+func (jh jobHandler) FindJobs(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-FindJobs")
+	defer span.End()
+
+	// extract url query
+	status := web.ReadString(r.URL.Query(), "status", "")
+	jobType := web.ReadString(r.URL.Query(), "job_type", "")
+	sort := web.ReadString(r.URL.Query(), "sort", "")
+	page := web.ReadInt(r.URL.Query(), "page", 0)
+	pageSize := web.ReadInt(r.URL.Query(), "page_size", 0)
+
+	result, metadata, err := jh.service.FindJobs(ctx, model.FindBy{
+		Status: status,
+		Type:   jobType,
+	}, paging.Filters{
+		Page:     page,
+		PageSize: pageSize,
+		Sort:     sort,
+	})
+	if err != nil {
+		jh.log.ErrorT(ctx, "error find job", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"metadata": metadata,
+		"data":     result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Retry Job
+// @Description  Put dead job back to queue with fresh attempts, admin only
+// @Tags         Admin
+// @Produce      json
+// @Param 		 job_id path int true "job_id"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.JobResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /admin/jobs/{job_id}/retry [post]
+func (jh jobHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-RetryJob")
+	defer span.End()
+
+	id, err := web.ReadIDParam(r)
+	if err != nil {
+		jh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := jh.service.RetryJob(ctx, id)
+	if err != nil {
+		jh.log.ErrorT(ctx, "error retry job", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobResp struct {
+	ID          uint64          `json:"id" example:"1"`
+	Type        string          `json:"job_type" example:"notification.send"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Status      string          `json:"status" example:"dead"`
+	Attempts    int             `json:"attempts" example:"5"`
+	MaxAttempts int             `json:"max_attempts" example:"5"`
+	LastError   string          `json:"last_error" example:"send message failed: unavailable"`
+	RunAt       time.Time       `json:"run_at" example:"2022-09-10T17:03:15.091267+08:00"`
+	CreatedAt   time.Time       `json:"created_at" example:"2022-09-10T17:03:15.091267+08:00"`
+	UpdatedAt   time.Time       `json:"updated_at" example:"2022-09-10T17:03:15.091267+08:00"`
+}
+
+// FindBy filter job listing, empty field is not filtered
+type FindBy struct {
+	Status string
+	Type   string
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusDead    = "dead" // attempts is exhausted or error is permanent, retried only by admin
+)
+
+// Job type, handler of each type is registered to job service
+const (
+	TypeSendNotification = "notification.send"         // payload notification model.SendMessage
+	TypeFlushDigest      = "notification.flush_digest" // payload notification model.DigestKey
+	TypeSyncPushTopic    = "notification.sync_topic"   // payload notification model.TopicSyncJob
+	TypeSetPocketETag    = "pocket.set_etag"           // payload spend model.ETagJob
+	TypeDeliverWebhook   = "webhook.deliver"           // payload webhook model.DeliverJob
+)
+
+// Job is one unit of durable background work
+type Job struct {
+	ID          uint64
+	Type        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	RunAt       time.Time
+	LockedUntil *time.Time
+	DedupeKey   *string // nil mean job is always inserted
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (j *Job) ToJobResp() JobResp {
+	return JobResp{
+		ID:          j.ID,
+		Type:        j.Type,
+		Payload:     j.Payload,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		LastError:   j.LastError,
+		RunAt:       j.RunAt,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+	}
+}
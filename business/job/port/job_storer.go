@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+)
+
+type JobStorer interface {
+	Insert(ctx context.Context, job *model.Job) error
+	ClaimDue(ctx context.Context, now time.Time, lockedUntil time.Time, limit uint64) ([]model.Job, error)
+	Finish(ctx context.Context, job *model.Job) error
+	Retry(ctx context.Context, id uint64, now time.Time) (model.Job, error)
+	DeleteDone(ctx context.Context, before time.Time) (int64, error)
+	GetByID(ctx context.Context, id uint64) (model.Job, error)
+	Find(ctx context.Context, findBy model.FindBy, filter paging.Filters) ([]model.Job, paging.Metadata, error)
+}
@@ -0,0 +1,414 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/business/job/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	keyTable       = "jobs"
+	keyID          = "id"
+	keyType        = "job_type"
+	keyPayload     = "payload"
+	keyStatus      = "status"
+	keyAttempts    = "attempts"
+	keyMaxAttempts = "max_attempts"
+	keyLastError   = "last_error"
+	keyRunAt       = "run_at"
+	keyLockedUntil = "locked_until"
+	keyDedupeKey   = "dedupe_key"
+	keyCreatedAt   = "created_at"
+	keyUpdatedAt   = "updated_at"
+)
+
+var allColumns = []string{
+	keyID,
+	keyType,
+	keyPayload,
+	keyStatus,
+	keyAttempts,
+	keyMaxAttempts,
+	keyLastError,
+	keyRunAt,
+	keyLockedUntil,
+	keyCreatedAt,
+	keyUpdatedAt,
+}
+
+// make sure the implementation satisfies the interface
+var _ port.JobStorer = (*Repo)(nil)
+
+// Repo manages the set of APIs for job access.
+type Repo struct {
+	db  *pgxpool.Pool
+	log mlogger.Logger
+	sb  sq.StatementBuilderType
+}
+
+// NewRepo constructs a data for api access..
+func NewRepo(sqlDB *pgxpool.Pool, log mlogger.Logger) *Repo {
+	return &Repo{
+		db:  sqlDB,
+		log: log,
+		sb:  sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// =========================================================================
+// MANIPULATOR
+
+// Insert add pending job, it use transaction in ctx so job is only visible
+// to worker after the transaction is committed.
+// job with DedupeKey already saved is not inserted again, its ID is left 0
+func (r *Repo) Insert(ctx context.Context, job *model.Job) error {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-Insert")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyTable).
+		Columns(
+			keyType,
+			keyPayload,
+			keyStatus,
+			keyMaxAttempts,
+			keyRunAt,
+			keyDedupeKey,
+			keyCreatedAt,
+			keyUpdatedAt,
+		).
+		Values(
+			job.Type,
+			job.Payload,
+			job.Status,
+			job.MaxAttempts,
+			job.RunAt,
+			job.DedupeKey,
+			job.CreatedAt,
+			job.UpdatedAt,
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO NOTHING RETURNING %s", keyDedupeKey, keyID)).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query insert job: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&job.ID)
+	if err != nil {
+		// no row returned when job with same dedupe key exists
+		if errors.Is(err, pgx.ErrNoRows) && job.DedupeKey != nil {
+			return nil
+		}
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// ClaimDue lock up to limit job which is due, or which lock is expired because its worker died,
+// then mark them running until lockedUntil. job locked by other worker is skipped
+func (r *Repo) ClaimDue(ctx context.Context, now time.Time, lockedUntil time.Time, limit uint64) ([]model.Job, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-ClaimDue")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := claimQuery(r.sb, now, lockedUntil, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query claim job: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	jobs := make([]model.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Finish save result of running job : status, attempts, last_error and run_at of next attempt.
+// its lock is released
+func (r *Repo) Finish(ctx context.Context, job *model.Job) error {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-Finish")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		SetMap(sq.Eq{
+			keyStatus:      job.Status,
+			keyAttempts:    job.Attempts,
+			keyLastError:   job.LastError,
+			keyRunAt:       job.RunAt,
+			keyLockedUntil: nil,
+			keyUpdatedAt:   job.UpdatedAt,
+		}).
+		Where(sq.Eq{keyID: job.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query finish job: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// Retry reset dead job to pending with fresh attempts
+func (r *Repo) Retry(ctx context.Context, id uint64, now time.Time) (model.Job, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-Retry")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		SetMap(sq.Eq{
+			keyStatus:      model.StatusPending,
+			keyAttempts:    0,
+			keyRunAt:       now,
+			keyLockedUntil: nil,
+			keyUpdatedAt:   now,
+		}).
+		Where(sq.Eq{keyID: id, keyStatus: model.StatusDead}).
+		Suffix(db.Returning(allColumns...)).
+		ToSql()
+	if err != nil {
+		return model.Job{}, fmt.Errorf("build query retry job: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	job, err := scanJob(dbtx.QueryRow(ctx, sqlStatement, args...))
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Job{}, db.ParseError(err)
+	}
+
+	return job, nil
+}
+
+// DeleteDone delete succeeded job last updated before given time
+func (r *Repo) DeleteDone(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-DeleteDone")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyTable).
+		Where(sq.And{
+			sq.Eq{keyStatus: model.StatusDone},
+			sq.Lt{keyUpdatedAt: before},
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query delete done job: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// =========================================================================
+// GETTER
+
+// GetByID get one job by id
+func (r *Repo) GetByID(ctx context.Context, id uint64) (model.Job, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-GetByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(allColumns...).
+		From(keyTable).
+		Where(sq.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return model.Job{}, fmt.Errorf("build query get job by id: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	job, err := scanJob(dbtx.QueryRow(ctx, sqlStatement, args...))
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Job{}, db.ParseError(err)
+	}
+
+	return job, nil
+}
+
+// Find get job filtered by status and type
+func (r *Repo) Find(ctx context.Context, findBy model.FindBy, filter paging.Filters) ([]model.Job, paging.Metadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "job-repo-Find")
+	defer span.End()
+
+	// Validation filter
+	filter.SortSafelist = []string{"-id", "id", "-run_at", "run_at", "-updated_at", "updated_at"}
+	if err := filter.Validate(); err != nil {
+		return nil, paging.Metadata{}, db.ErrDBSortFilter
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	where := sq.Eq{}
+	if findBy.Status != "" {
+		where[keyStatus] = findBy.Status
+	}
+	if findBy.Type != "" {
+		where[keyType] = findBy.Type
+	}
+
+	sqlStatement, args, err := r.sb.Select(append([]string{"count(*) OVER()"}, allColumns...)...).
+		From(keyTable).
+		Where(where).
+		OrderBy(filter.SortColumnDirection(), keyID+" DESC").
+		Limit(uint64(filter.Limit())).
+		Offset(uint64(filter.Offset())).
+		ToSql()
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("build query find job: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, paging.Metadata{}, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	jobs := make([]model.Job, 0)
+	for rows.Next() {
+		var job model.Job
+		err := rows.Scan(
+			&totalRecords,
+			&job.ID,
+			&job.Type,
+			&job.Payload,
+			&job.Status,
+			&job.Attempts,
+			&job.MaxAttempts,
+			&job.LastError,
+			&job.RunAt,
+			&job.LockedUntil,
+			&job.CreatedAt,
+			&job.UpdatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, paging.Metadata{}, db.ParseError(err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, paging.Metadata{}, err
+	}
+
+	metadata := paging.CalculateMetadata(totalRecords, filter.Page, filter.PageSize)
+
+	return jobs, metadata, nil
+}
+
+// claimQuery build update of due job, selected with FOR UPDATE SKIP LOCKED
+// so concurrent worker never claim the same job
+func claimQuery(sb sq.StatementBuilderType, now time.Time, lockedUntil time.Time, limit uint64) sq.UpdateBuilder {
+	// placeholder of sub query is converted once by outer query
+	due := sq.Select(keyID).
+		From(keyTable).
+		Where(sq.Or{
+			sq.And{sq.Eq{keyStatus: model.StatusPending}, sq.LtOrEq{keyRunAt: now}},
+			sq.And{sq.Eq{keyStatus: model.StatusRunning}, sq.Lt{keyLockedUntil: now}},
+		}).
+		OrderBy(keyRunAt).
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	return sb.Update(keyTable).
+		SetMap(sq.Eq{
+			keyStatus:      model.StatusRunning,
+			keyAttempts:    sq.Expr(keyAttempts + " + 1"),
+			keyLockedUntil: lockedUntil,
+			keyUpdatedAt:   now,
+		}).
+		Where(sq.Expr(keyID+" IN (?)", due)).
+		Suffix(db.Returning(allColumns...))
+}
+
+// scanJob scan row selected with allColumns
+func scanJob(row pgx.Row) (model.Job, error) {
+	var job model.Job
+	err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.RunAt,
+		&job.LockedUntil,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	return job, err
+}
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-playground/assert/v2"
+	"github.com/muchlist/moneymagnet/business/job/model"
+)
+
+// go test -v -timeout 30s -run ^TestClaimQuery$ github.com/muchlist/moneymagnet/business/job/repo
+func TestClaimQuery(t *testing.T) {
+	sb := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	lockedUntil := now.Add(time.Minute)
+
+	expected := `UPDATE jobs SET attempts = attempts + 1, locked_until = $1, status = $2, updated_at = $3 ` +
+		`WHERE id IN (SELECT id FROM jobs WHERE ((status = $4 AND run_at <= $5) OR (status = $6 AND locked_until < $7)) ` +
+		`ORDER BY run_at LIMIT 10 FOR UPDATE SKIP LOCKED) ` +
+		`RETURNING id, job_type, payload, status, attempts, max_attempts, last_error, run_at, locked_until, created_at, updated_at`
+
+	sqlStatement, args, err := claimQuery(sb, now, lockedUntil, 10).ToSql()
+	if err != nil {
+		t.Fatalf("build query claim job: %s", err)
+	}
+
+	assert.Equal(t, expected, sqlStatement)
+	assert.Equal(t, []any{lockedUntil, model.StatusRunning, now, model.StatusPending, now, model.StatusRunning, now}, args)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/business/job/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+)
+
+// Option configure worker and retry, zero value use default
+type Option struct {
+	PollInterval time.Duration // wait between claim when queue is empty
+	BatchSize    uint64        // job claimed at once
+	JobTimeout   time.Duration // deadline of one attempt, job lock is a bit longer than this
+	MaxAttempts  int           // attempt before job is dead
+	BaseBackoff  time.Duration // wait after first failure, doubled on every next failure
+	MaxBackoff   time.Duration
+	KeepDone     time.Duration // succeeded job is deleted after this duration
+}
+
+var DefaultOption = Option{
+	PollInterval: time.Second,
+	BatchSize:    20,
+	JobTimeout:   30 * time.Second,
+	MaxAttempts:  5,
+	BaseBackoff:  10 * time.Second,
+	MaxBackoff:   time.Hour,
+	KeepDone:     7 * 24 * time.Hour,
+}
+
+// Handler execute payload of one job type
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Core manages the set of APIs for durable job queue.
+type Core struct {
+	log  mlogger.Logger
+	repo port.JobStorer
+	opt  Option
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewCore constructs a core for job queue.
+func NewCore(
+	log mlogger.Logger,
+	repo port.JobStorer,
+	opt Option,
+) *Core {
+	if opt.PollInterval <= 0 {
+		opt.PollInterval = DefaultOption.PollInterval
+	}
+	if opt.BatchSize == 0 {
+		opt.BatchSize = DefaultOption.BatchSize
+	}
+	if opt.JobTimeout <= 0 {
+		opt.JobTimeout = DefaultOption.JobTimeout
+	}
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = DefaultOption.MaxAttempts
+	}
+	if opt.BaseBackoff <= 0 {
+		opt.BaseBackoff = DefaultOption.BaseBackoff
+	}
+	if opt.MaxBackoff <= 0 {
+		opt.MaxBackoff = DefaultOption.MaxBackoff
+	}
+	if opt.KeepDone <= 0 {
+		opt.KeepDone = DefaultOption.KeepDone
+	}
+	return &Core{
+		log:      log,
+		repo:     repo,
+		opt:      opt,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle register typed handler of jobType, payload is decoded to T before fn is called.
+// payload that cannot be decoded make the job dead without retry
+func Handle[T any](c *Core, jobType string, fn func(ctx context.Context, payload T) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.handlers[jobType] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Enqueue save job to be executed by worker. when ctx carry transaction from WithAtomic
+// the job is saved in that transaction, so it is never lost nor executed when the transaction is rolled back
+func (c *Core) Enqueue(ctx context.Context, jobType string, payload any) error {
+	return c.EnqueueAt(ctx, jobType, payload, time.Now())
+}
+
+// EnqueueAt is Enqueue for job that must not be executed before runAt
+func (c *Core) EnqueueAt(ctx context.Context, jobType string, payload any, runAt time.Time) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-EnqueueAt")
+	defer span.End()
+
+	return c.enqueue(ctx, jobType, payload, runAt, nil)
+}
+
+// EnqueueOnce is EnqueueAt that does nothing when job with the same dedupeKey was already enqueued,
+// so caller can be retried without enqueueing its job twice
+func (c *Core) EnqueueOnce(ctx context.Context, jobType string, payload any, runAt time.Time, dedupeKey string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-EnqueueOnce")
+	defer span.End()
+
+	return c.enqueue(ctx, jobType, payload, runAt, &dedupeKey)
+}
+
+func (c *Core) enqueue(ctx context.Context, jobType string, payload any, runAt time.Time, dedupeKey *string) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload of %s: %w", jobType, err)
+	}
+
+	timeNow := time.Now()
+	job := model.Job{
+		Type:        jobType,
+		Payload:     raw,
+		Status:      model.StatusPending,
+		MaxAttempts: c.opt.MaxAttempts,
+		RunAt:       runAt,
+		DedupeKey:   dedupeKey,
+		CreatedAt:   timeNow,
+		UpdatedAt:   timeNow,
+	}
+	if err := c.repo.Insert(ctx, &job); err != nil {
+		return fmt.Errorf("insert job %s: %w", jobType, err)
+	}
+	return nil
+}
+
+// FindJobs list job for admin
+func (c *Core) FindJobs(ctx context.Context, findBy model.FindBy, filter paging.Filters) ([]model.JobResp, paging.Metadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindJobs")
+	defer span.End()
+
+	if findBy.Status != "" && !validStatus(findBy.Status) {
+		return nil, paging.Metadata{}, errr.NewT("error.job_invalid_status", nil, 400)
+	}
+
+	jobs, metadata, err := c.repo.Find(ctx, findBy, filter)
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("find job: %w", err)
+	}
+
+	result := make([]model.JobResp, len(jobs))
+	for i := range jobs {
+		result[i] = jobs[i].ToJobResp()
+	}
+	return result, metadata, nil
+}
+
+// RetryJob put dead job back to queue with fresh attempts
+func (c *Core) RetryJob(ctx context.Context, id uint64) (model.JobResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-RetryJob")
+	defer span.End()
+
+	existing, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.JobResp{}, errr.NewT("error.job_not_found", nil, 404)
+		}
+		return model.JobResp{}, fmt.Errorf("get job by id: %w", err)
+	}
+	if existing.Status != model.StatusDead {
+		return model.JobResp{}, errr.NewT("error.job_not_dead", map[string]string{"status": existing.Status}, 400)
+	}
+
+	job, err := c.repo.Retry(ctx, id, time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.JobResp{}, errr.NewT("error.job_already_retried", nil, 409)
+		}
+		return model.JobResp{}, fmt.Errorf("retry job: %w", err)
+	}
+	return job.ToJobResp(), nil
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case model.StatusPending, model.StatusRunning, model.StatusDone, model.StatusDead:
+		return true
+	}
+	return false
+}
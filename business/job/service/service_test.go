@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/job/model"
+)
+
+func TestBackoff(t *testing.T) {
+	base := 10 * time.Second
+	max := time.Minute
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 10 * time.Second},
+		{attempts: 2, want: 20 * time.Second},
+		{attempts: 3, want: 40 * time.Second},
+		{attempts: 4, want: time.Minute},
+		{attempts: 50, want: time.Minute},
+	}
+	for _, tc := range tests {
+		if got := backoff(tc.attempts, base, max); got != tc.want {
+			t.Errorf("backoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestApplyResult(t *testing.T) {
+	c := NewCore(nil, nil, Option{BaseBackoff: 10 * time.Second})
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		job := model.Job{Status: model.StatusRunning, Attempts: 2, MaxAttempts: 5, LastError: "old"}
+		c.applyResult(&job, nil, now)
+		if job.Status != model.StatusDone || job.LastError != "" {
+			t.Errorf("got status %s error %q, want done without error", job.Status, job.LastError)
+		}
+	})
+
+	t.Run("retry with backoff", func(t *testing.T) {
+		job := model.Job{Status: model.StatusRunning, Attempts: 2, MaxAttempts: 5}
+		c.applyResult(&job, errors.New("unavailable"), now)
+		if job.Status != model.StatusPending {
+			t.Errorf("status = %s, want pending", job.Status)
+		}
+		if want := now.Add(20 * time.Second); !job.RunAt.Equal(want) {
+			t.Errorf("run_at = %s, want %s", job.RunAt, want)
+		}
+		if job.LastError != "unavailable" {
+			t.Errorf("last_error = %q, want unavailable", job.LastError)
+		}
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		job := model.Job{Status: model.StatusRunning, Attempts: 5, MaxAttempts: 5}
+		c.applyResult(&job, errors.New("unavailable"), now)
+		if job.Status != model.StatusDead {
+			t.Errorf("status = %s, want dead", job.Status)
+		}
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		job := model.Job{Status: model.StatusRunning, Attempts: 1, MaxAttempts: 5}
+		c.applyResult(&job, Permanent(errors.New("bad payload")), now)
+		if job.Status != model.StatusDead {
+			t.Errorf("status = %s, want dead", job.Status)
+		}
+	})
+}
+
+func TestExecute(t *testing.T) {
+	c := NewCore(nil, nil, Option{})
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+	var got payload
+	Handle(c, "typed", func(ctx context.Context, p payload) error {
+		got = p
+		return nil
+	})
+	Handle(c, "panic", func(ctx context.Context, p payload) error {
+		panic("boom")
+	})
+
+	if err := c.execute(context.Background(), model.Job{Type: "typed", Payload: json.RawMessage(`{"name":"kopi"}`)}); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if got.Name != "kopi" {
+		t.Errorf("decoded payload = %+v, want name kopi", got)
+	}
+
+	var permanent permanentError
+	err := c.execute(context.Background(), model.Job{Type: "typed", Payload: json.RawMessage(`[1]`)})
+	if !errors.As(err, &permanent) {
+		t.Errorf("invalid payload error = %v, want permanent", err)
+	}
+
+	err = c.execute(context.Background(), model.Job{Type: "unknown", Payload: json.RawMessage(`{}`)})
+	if !errors.As(err, &permanent) {
+		t.Errorf("unknown type error = %v, want permanent", err)
+	}
+
+	err = c.execute(context.Background(), model.Job{Type: "panic", Payload: json.RawMessage(`{}`)})
+	if err == nil || errors.As(err, &permanent) {
+		t.Errorf("panic error = %v, want retryable error", err)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/pkg/bg"
+	"github.com/muchlist/moneymagnet/pkg/observ/mmetric"
+)
+
+// permanentError mark error that will not be fixed by retrying
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wrap err so the job is dead immediately instead of retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Run claim and execute due job until ctx is done.
+// it is safe to run in many server at the same time
+func (c *Core) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	lastCleanup := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		processed, err := c.processBatch(ctx)
+		if err != nil {
+			c.log.ErrorT(ctx, "error process job", err)
+		}
+
+		if time.Since(lastCleanup) > time.Hour {
+			lastCleanup = time.Now()
+			c.cleanup(ctx)
+		}
+
+		// queue may still have due job when batch is full
+		if processed == int(c.opt.BatchSize) {
+			timer.Reset(0)
+		} else {
+			timer.Reset(c.opt.PollInterval)
+		}
+	}
+}
+
+// processBatch claim due job and execute them one by one
+func (c *Core) processBatch(ctx context.Context) (int, error) {
+	timeNow := time.Now()
+	// lock cover every job in the batch, so other worker does not take them over
+	lockedUntil := timeNow.Add(time.Duration(c.opt.BatchSize) * c.opt.JobTimeout).Add(time.Minute)
+
+	jobs, err := c.repo.ClaimDue(ctx, timeNow, lockedUntil, c.opt.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim due job: %w", err)
+	}
+
+	for i := range jobs {
+		job := jobs[i]
+
+		// worker is stopping, give back claimed job without spending its attempt
+		if ctx.Err() != nil {
+			c.release(&job, time.Now())
+			if err := c.repo.Finish(bg.NewDetachContext(ctx), &job); err != nil {
+				c.log.ErrorT(ctx, fmt.Sprintf("error release job %d", job.ID), err)
+			}
+			continue
+		}
+
+		// started job is finished even when worker is stopping, shutdown wait for it
+		runErr := c.execute(bg.NewDetachContext(ctx), job)
+		if runErr != nil {
+			c.log.WarnT(ctx, fmt.Sprintf("job %d %s attempt %d failed", job.ID, job.Type, job.Attempts), runErr)
+		}
+
+		c.applyResult(&job, runErr, time.Now())
+		if job.Status == model.StatusDead {
+			mmetric.AddDeadJobCounter(context.Background(), job.Type)
+		}
+
+		// job result must be saved even when worker is stopping
+		if err := c.repo.Finish(bg.NewDetachContext(ctx), &job); err != nil {
+			c.log.ErrorT(ctx, fmt.Sprintf("error save result of job %d", job.ID), err)
+		}
+	}
+	return len(jobs), nil
+}
+
+// execute run handler of job with timeout, panic is returned as error
+func (c *Core) execute(ctx context.Context, job model.Job) (err error) {
+	c.mu.RLock()
+	handler, ok := c.handlers[job.Type]
+	c.mu.RUnlock()
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job type %s", job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opt.JobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job.Payload)
+}
+
+// applyResult set status, last error and next run of job after an attempt
+func (c *Core) applyResult(job *model.Job, runErr error, now time.Time) {
+	job.UpdatedAt = now
+
+	if runErr == nil {
+		job.Status = model.StatusDone
+		job.LastError = ""
+		return
+	}
+
+	job.LastError = runErr.Error()
+
+	var permanent permanentError
+	if errors.As(runErr, &permanent) || job.Attempts >= job.MaxAttempts {
+		job.Status = model.StatusDead
+		return
+	}
+
+	job.Status = model.StatusPending
+	job.RunAt = now.Add(backoff(job.Attempts, c.opt.BaseBackoff, c.opt.MaxBackoff))
+}
+
+// release put claimed job back to pending as if it is never claimed
+func (c *Core) release(job *model.Job, now time.Time) {
+	job.Status = model.StatusPending
+	job.Attempts--
+	job.RunAt = now
+	job.UpdatedAt = now
+}
+
+// backoff return wait before next attempt, base is doubled for every failed attempt up to max
+func backoff(attempts int, base time.Duration, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+	return wait
+}
+
+// cleanup delete old succeeded job so the table does not grow forever
+func (c *Core) cleanup(ctx context.Context) {
+	deleted, err := c.repo.DeleteDone(ctx, time.Now().Add(-c.opt.KeepDone))
+	if err != nil {
+		c.log.ErrorT(ctx, "error delete done job", err)
+		return
+	}
+	if deleted > 0 {
+		c.log.InfoT(ctx, fmt.Sprintf("%d done job deleted", deleted))
+	}
+}
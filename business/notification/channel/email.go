@@ -0,0 +1,46 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/mailer"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.Channel = (*Email)(nil)
+
+// Email send notification to email of user
+type Email struct {
+	mailer mailer.Mailer
+}
+
+func NewEmail(mailSender mailer.Mailer) *Email {
+	return &Email{mailer: mailSender}
+}
+
+func (c *Email) Name() string {
+	return model.ChannelEmail
+}
+
+// Send mail every recipient separately so address is not shared between user
+func (c *Email) Send(ctx context.Context, content model.Content, recipients []model.Recipient) error {
+	var errs []error
+	for _, recipient := range recipients {
+		if recipient.Email == "" {
+			continue
+		}
+		err := c.mailer.Send(ctx, mailer.Message{
+			To:      []string{recipient.Email},
+			Subject: content.Title,
+			Body:    content.Message,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("send mail to %s: %w", recipient.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package channel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.Channel = (*Log)(nil)
+
+// Log only print notification to log, used for local development without firebase
+type Log struct {
+	log mlogger.Logger
+}
+
+func NewLog(log mlogger.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (c *Log) Name() string {
+	return model.ChannelLog
+}
+
+func (c *Log) Send(ctx context.Context, content model.Content, recipients []model.Recipient) error {
+	userIDs := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		userIDs[i] = recipient.UserID.String()
+	}
+	c.log.InfoT(ctx, "notification sent to log",
+		mlogger.String("id", content.ID),
+		mlogger.String("to", strings.Join(userIDs, ",")),
+		mlogger.String("title", content.Title),
+		mlogger.String("message", content.Message),
+	)
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package channel implement notification channel used by notification service.
+package channel
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/mfirebase"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.Channel = (*Push)(nil)
+
+// Push send notification to mobile device through firebase cloud messaging
+type Push struct {
+	sender     port.FCMSender
+	userEditor port.FCMTokenEditor
+	pocketRepo port.PocketReader // not nil when pocket topic is enabled
+}
+
+// NewPush create push channel, token rejected by firebase is removed from user
+func NewPush(sender port.FCMSender, userEditor port.FCMTokenEditor) *Push {
+	return &Push{
+		sender:     sender,
+		userEditor: userEditor,
+	}
+}
+
+// EnableTopic send message of pocket to pocket topic instead of token of every recipient,
+// token must be subscribed to the topic of its pockets by topic sync
+func (c *Push) EnableTopic(pocketRepo port.PocketReader) {
+	c.pocketRepo = pocketRepo
+}
+
+func (c *Push) Name() string {
+	return model.ChannelPush
+}
+
+func (c *Push) Send(ctx context.Context, content model.Content, recipients []model.Recipient) error {
+	payload := mfirebase.Payload{
+		Title:       content.Title,
+		Message:     content.Message,
+		Data:        content.Data,
+		Priority:    mfirebase.PriorityHigh,
+		CollapseKey: content.ID,
+		ThreadID:    content.PocketID,
+	}
+
+	if c.pocketRepo != nil && content.PocketID != "" {
+		sent, err := c.sendToTopic(ctx, content.PocketID, payload, recipients)
+		if err != nil {
+			return err
+		}
+		if sent {
+			return nil
+		}
+	}
+
+	tokens := make([]string, 0)
+	for _, recipient := range recipients {
+		tokens = append(tokens, recipient.FcmTokens...)
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	payload.ReceiverTokens = tokens
+	failedTokens, err := c.sender.SendMessage(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("send message failed: %w", err)
+	}
+
+	if len(failedTokens) == 0 {
+		return nil
+	}
+
+	failedTokenMap := make(map[string]struct{})
+	for _, token := range failedTokens {
+		failedTokenMap[token] = struct{}{}
+	}
+
+	for _, recipient := range recipients {
+		var newTokens []string
+		for _, token := range recipient.FcmTokens {
+			if _, found := failedTokenMap[token]; !found {
+				newTokens = append(newTokens, token)
+			}
+		}
+
+		// if any token changes, update fcm user
+		if len(newTokens) != len(recipient.FcmTokens) {
+			err := c.userEditor.EditFCM(ctx, recipient.UserID, newTokens)
+			if err != nil {
+				return fmt.Errorf("failed modify fcm after invalid tokens detected: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sendToTopic send payload to pocket topic, false is returned when recipients cannot be reached by topic
+func (c *Push) sendToTopic(ctx context.Context, pocketID string, payload mfirebase.Payload, recipients []model.Recipient) (bool, error) {
+	id, err := xulid.Parse(pocketID)
+	if err != nil {
+		return false, fmt.Errorf("parse pocket id: %w", err)
+	}
+	pocket, err := c.pocketRepo.GetByID(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("get pocket by id: %w", err)
+	}
+
+	condition, ok := topicCondition(pocketID, slices.Concat(pocket.EditorID, pocket.WatcherID), recipients)
+	if !ok {
+		return false, nil
+	}
+
+	payload.Condition = condition
+	if err := c.sender.SendCondition(ctx, payload); err != nil {
+		return false, fmt.Errorf("send topic message failed: %w", err)
+	}
+	return true, nil
+}
+
+// topicCondition return firebase condition reaching recipients through pocket topic, member who is not
+// recipient is excluded by user topic. false mean recipient is not member or too many member is excluded
+func topicCondition(pocketID string, members []string, recipients []model.Recipient) (string, bool) {
+	if len(recipients) == 0 {
+		return "", false
+	}
+
+	memberSet := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		memberSet[member] = struct{}{}
+	}
+
+	recipientSet := make(map[string]struct{}, len(recipients))
+	for _, recipient := range recipients {
+		id := recipient.UserID.String()
+		if _, ok := memberSet[id]; !ok {
+			return "", false
+		}
+		recipientSet[id] = struct{}{}
+	}
+
+	excluded := make([]string, 0)
+	for member := range memberSet {
+		if _, ok := recipientSet[member]; !ok {
+			excluded = append(excluded, member)
+		}
+	}
+	if 1+len(excluded) > mfirebase.MaxConditionTopics {
+		return "", false
+	}
+	sort.Strings(excluded)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "'%s' in topics", model.PocketTopic(pocketID))
+	for _, userID := range excluded {
+		fmt.Fprintf(&sb, " && !('%s' in topics)", model.UserTopic(userID))
+	}
+	return sb.String(), true
+}
@@ -0,0 +1,45 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+func TestTopicCondition(t *testing.T) {
+	ids := make([]xulid.ULID, 7)
+	members := make([]string, 7)
+	for i := range ids {
+		ids[i] = xulid.Instance().NewULID()
+		members[i] = ids[i].String()
+	}
+	recipientsOf := func(ids ...xulid.ULID) []model.Recipient {
+		result := make([]model.Recipient, len(ids))
+		for i, id := range ids {
+			result[i] = model.Recipient{UserID: id}
+		}
+		return result
+	}
+
+	condition, ok := topicCondition("p", members[:3], recipientsOf(ids[0], ids[1], ids[2]))
+	if !ok || condition != "'pocket.p' in topics" {
+		t.Errorf("topicCondition() every member = %q, %v", condition, ok)
+	}
+
+	condition, ok = topicCondition("p", []string{members[2], members[0], members[1]}, recipientsOf(ids[0]))
+	want := "'pocket.p' in topics && !('user." + members[1] + "' in topics) && !('user." + members[2] + "' in topics)"
+	if !ok || condition != want {
+		t.Errorf("topicCondition() excluding member = %q, %v, want %q", condition, ok, want)
+	}
+
+	if _, ok := topicCondition("p", members[:2], recipientsOf(ids[5])); ok {
+		t.Errorf("topicCondition() with non member recipient = true, want false")
+	}
+	if _, ok := topicCondition("p", members, recipientsOf(ids[0])); ok {
+		t.Errorf("topicCondition() excluding too many member = true, want false")
+	}
+	if _, ok := topicCondition("p", members, nil); ok {
+		t.Errorf("topicCondition() without recipient = true, want false")
+	}
+}
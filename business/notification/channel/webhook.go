@@ -0,0 +1,79 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.Channel = (*Webhook)(nil)
+
+const webhookEvent = "notification"
+
+// WebhookSender implemented by mwebhook client
+type WebhookSender interface {
+	Send(ctx context.Context, req mwebhook.Request) (mwebhook.Response, error)
+}
+
+// Webhook post notification to chat webhook url of user.
+// request is not signed because user does not have webhook secret
+type Webhook struct {
+	sender WebhookSender
+}
+
+func NewWebhook(sender WebhookSender) *Webhook {
+	return &Webhook{sender: sender}
+}
+
+func (c *Webhook) Name() string {
+	return model.ChannelWebhook
+}
+
+// webhookBody is understood by common chat incoming webhook,
+// text is used by slack and mattermost, content by discord
+type webhookBody struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Text    string            `json:"text"`
+	Content string            `json:"content"`
+	Data    map[string]string `json:"data"`
+}
+
+func (c *Webhook) Send(ctx context.Context, content model.Content, recipients []model.Recipient) error {
+	chatText := fmt.Sprintf("*%s*\n%s", content.Title, content.Message)
+	body, err := json.Marshal(webhookBody{
+		ID:      content.ID,
+		Title:   content.Title,
+		Message: content.Message,
+		Text:    chatText,
+		Content: chatText,
+		Data:    content.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook body: %w", err)
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		if recipient.WebhookURL == "" {
+			continue
+		}
+		_, err := c.sender.Send(ctx, mwebhook.Request{
+			URL:        recipient.WebhookURL,
+			Event:      webhookEvent,
+			DeliveryID: content.ID,
+			Body:       body,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("send webhook of %s: %w", recipient.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
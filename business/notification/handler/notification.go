@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/service"
+	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/mid"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/validate"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+func NewNotificationHandler(log mlogger.Logger,
+	validator validate.Validator,
+	notificationService *service.Core) notificationHandler {
+	return notificationHandler{
+		log:       log,
+		validator: validator,
+		service:   notificationService,
+	}
+}
+
+type notificationHandler struct {
+	log       mlogger.Logger
+	validator validate.Validator
+	service   *service.Core
+}
+
+// @Summary      Find Notifications
+// @Description  Inbox of logged in user, newest first. use cursor_type id for oldest first
+// @Tags         Notification
+// @Produce      json
+// @Param 		 cursor query string false "cursor"
+// @Param 		 cursor_type query string false "-id or id"
+// @Param 		 page_size query int false "page-size"
+// @Success      200  {object}  misc.ResponseSuccessListCursor{data=[]model.NotificationResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications [get]
+func (nh notificationHandler) FindNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-FindNotifications")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	// extract url query
+	queryValues := r.URL.Query()
+	cursor := web.ReadString(queryValues, "cursor", "")
+	cursorType := web.ReadString(queryValues, "cursor_type", "")
+	pageSize := web.ReadInt(queryValues, "page_size", 0)
+
+	cursorDataInput := paging.Cursor{}
+	cursorDataInput.SetCursorList([]string{"-id", "id"})
+	cursorDataInput.SetCursor(cursor)
+	cursorDataInput.SetCursorType(cursorType)
+	cursorDataInput.SetPageSize(pageSize)
+
+	err = cursorDataInput.Validate()
+	if err != nil {
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, metadata, err := nh.service.FindNotifications(ctx, claims, cursorDataInput)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error find notification", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	metadata.GenerateAndApplyPageUri("/notifications", queryValues)
+
+	env := web.Envelope{
+		"metadata": metadata,
+		"data":     result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Count Unread Notifications
+// @Description  Number of notification of logged in user that is not read yet
+// @Tags         Notification
+// @Produce      json
+// @Success      200  {object}  misc.ResponseSuccess{data=model.UnreadCountResp}
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/unread-count [get]
+func (nh notificationHandler) CountUnread(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-CountUnread")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	result, err := nh.service.CountUnread(ctx, claims)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error count unread notification", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Mark Notification Read
+// @Description  Mark one notification of logged in user as read
+// @Tags         Notification
+// @Produce      json
+// @Param 		 id path string true "notification_id"
+// @Success      200  {object}  misc.ResponseMessage
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/{id}/read [post]
+func (nh notificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-MarkRead")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	id, err := web.ReadULIDParam(r)
+	if err != nil {
+		nh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = nh.service.MarkRead(ctx, claims, id)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error mark notification read", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"message": "notification marked as read",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Mark All Notifications Read
+// @Description  Mark every unread notification of logged in user as read
+// @Tags         Notification
+// @Produce      json
+// @Success      200  {object}  misc.ResponseSuccess{data=model.MarkAllReadResp}
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/read-all [post]
+func (nh notificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-MarkAllRead")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	result, err := nh.service.MarkAllRead(ctx, claims)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error mark all notification read", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Get Notification Preference
+// @Description  Notification preference of logged in user with every pocket override, inbox is not affected by preference
+// @Tags         Notification
+// @Produce      json
+// @Success      200  {object}  misc.ResponseSuccess{data=model.PreferenceResp}
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/preferences [get]
+func (nh notificationHandler) GetPreference(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-GetNotificationPreference")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	result, err := nh.service.GetPreference(ctx, claims)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error get notification preference", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Update Notification Preference
+// @Description  Change notification preference of logged in user, field not sent is not changed.
+// @Description  channels choose where notification is sent besides inbox, empty channels use default channels.
+// @Description  push in quiet hours (user timezone) is delayed until quiet hours end
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        Body body model.PreferenceUpdate true "Request Body"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.PreferenceResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      422  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/preferences [patch]
+func (nh notificationHandler) UpdatePreference(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-UpdateNotificationPreference")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	var req model.PreferenceUpdate
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		nh.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	errMap, err := nh.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := nh.service.UpdatePreference(ctx, claims, req)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error update notification preference", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Set Pocket Notification Preference
+// @Description  Mute pocket or set its own amount threshold, watcher or editor only
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "pocket_id"
+// @Param        Body body model.PocketPreferenceUpdate true "Request Body"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.PocketPreferenceResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      422  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/preferences/pockets/{id} [put]
+func (nh notificationHandler) SetPocketPreference(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-SetPocketNotificationPreference")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	pocketID, err := web.ReadULIDParam(r)
+	if err != nil {
+		nh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req model.PocketPreferenceUpdate
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		nh.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	errMap, err := nh.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := nh.service.SetPocketPreference(ctx, claims, pocketID, req)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error set pocket notification preference", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Reset Pocket Notification Preference
+// @Description  Remove pocket override, user preference is used for the pocket
+// @Tags         Notification
+// @Produce      json
+// @Param 		 id path string true "pocket_id"
+// @Success      200  {object}  misc.ResponseMessage
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /notifications/preferences/pockets/{id} [delete]
+func (nh notificationHandler) ResetPocketPreference(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-ResetPocketNotificationPreference")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	pocketID, err := web.ReadULIDParam(r)
+	if err != nil {
+		nh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = nh.service.ResetPocketPreference(ctx, claims, pocketID)
+	if err != nil {
+		nh.log.ErrorT(ctx, "error reset pocket notification preference", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	env := web.Envelope{
+		"message": "pocket notification preference reset",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
@@ -1,7 +1,160 @@
 package model
 
+import (
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/i18n"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
 type SendMessage struct {
+	// ID is the same for every recipient, set by producer so retried send does not duplicate inbox
+	ID      string
 	Title   string
 	Message string
 	UserIds []string
+
+	// TitleKey and MessageKey is i18n catalog key of text rendered in locale of user with Params,
+	// {amount} param is written from Amount and Currency. Title and Message is used when key is empty
+	TitleKey   string
+	MessageKey string
+	Params     map[string]string
+	Currency   string
+
+	// Event, PocketID and Amount are checked against push preference of user,
+	// message without them is only limited by quiet hours
+	Event    string
+	PocketID string
+	Amount   int64
+	// SpendID is sent as deep link data so app can open the record
+	SpendID string
+
+	// PocketName and ActorName is used to write digest of spend notification
+	PocketName string
+	ActorName  string
+
+	// Deferred is set when push is postponed by quiet hours, quiet hours is not checked again
+	Deferred bool
+	// Digest is set on message created from digest, it is not added to inbox nor digested again
+	Digest bool
+}
+
+type Text struct {
+	Title   string
+	Message string
+}
+
+// Content is message rendered in locale of recipients, sent through channel
+type Content struct {
+	ID string // the same on retry
+	Text
+	PocketID string
+	// Data is deep link data of message, ex: event, pocket_id and spend_id
+	Data map[string]string
+}
+
+// ContentFor return message rendered for locale with its deep link data
+func (m SendMessage) ContentFor(locale string) Content {
+	data := map[string]string{"message_id": m.ID}
+	for key, value := range map[string]string{
+		"event":     m.Event,
+		"pocket_id": m.PocketID,
+		"spend_id":  m.SpendID,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	return Content{
+		ID:       m.ID,
+		Text:     m.TextFor(locale),
+		PocketID: m.PocketID,
+		Data:     data,
+	}
+}
+
+// TextFor return title and message for locale
+func (m SendMessage) TextFor(locale string) Text {
+	text := Text{Title: m.Title, Message: m.Message}
+	if m.TitleKey == "" && m.MessageKey == "" {
+		return text
+	}
+
+	params := make(map[string]string, len(m.Params)+1)
+	for name, value := range m.Params {
+		params[name] = value
+	}
+	params["amount"] = i18n.FormatMoney(locale, m.Currency, m.Amount)
+	if m.TitleKey != "" {
+		text.Title = i18n.T(locale, m.TitleKey, params)
+	}
+	if m.MessageKey != "" {
+		text.Message = i18n.T(locale, m.MessageKey, params)
+	}
+	return text
+}
+
+// TopicSyncJob subscribe fcm token of users to topic of their pockets and unsubscribe the rest
+type TopicSyncJob struct {
+	UserIDs []string
+}
+
+type NotificationResp struct {
+	ID        xulid.ULID `json:"id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	Title     string     `json:"title" example:"Pocket Dompet"`
+	Message   string     `json:"message" example:"Budi menambahkan pengeluaran Rp 20.000"`
+	IsRead    bool       `json:"is_read" example:"false"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type UnreadCountResp struct {
+	Unread int64 `json:"unread" example:"3"`
+}
+
+type MarkAllReadResp struct {
+	Updated int64 `json:"updated" example:"3"`
+}
+
+type PreferenceResp struct {
+	Events            []string               `json:"events" example:"spend.created"`
+	MinAmount         int64                  `json:"min_amount" example:"50000"`
+	QuietStart        string                 `json:"quiet_start" example:"22:00"`
+	QuietEnd          string                 `json:"quiet_end" example:"07:00"`
+	Channels          []string               `json:"channels" example:"push"`
+	Digest            string                 `json:"digest" example:"daily"`
+	DigestTime        string                 `json:"digest_time" example:"08:00"`
+	WebhookURL        string                 `json:"webhook_url" example:"https://hooks.slack.com/services/xxx"`
+	AvailableChannels []string               `json:"available_channels" example:"push"` // can be chosen as channels
+	DefaultChannels   []string               `json:"default_channels" example:"push"`   // used when channels is empty
+	Pockets           []PocketPreferenceResp `json:"pockets"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+}
+
+// PreferenceUpdate ignore nil field, empty events mean every event is pushed.
+// quiet_start and quiet_end must be set together, set both empty to disable quiet hours.
+// empty channels mean default channels, webhook channel require webhook_url.
+// digest daily or weekly send new spend as summary at digest_time, set empty to send them shortly after they are added
+type PreferenceUpdate struct {
+	Events     *[]string `json:"events" validate:"omitempty,dive,oneof=spend.created spend.updated spend.deleted"`
+	MinAmount  *int64    `json:"min_amount" validate:"omitempty,min=0"`
+	QuietStart *string   `json:"quiet_start" example:"22:00"`
+	QuietEnd   *string   `json:"quiet_end" example:"07:00"`
+	Channels   *[]string `json:"channels" validate:"omitempty,dive,oneof=push email webhook"`
+	WebhookURL *string   `json:"webhook_url" validate:"omitempty,max=2048" example:"https://hooks.slack.com/services/xxx"`
+	Digest     *string   `json:"digest" example:"daily"`
+	DigestTime *string   `json:"digest_time" example:"08:00"`
+}
+
+type PocketPreferenceResp struct {
+	PocketID  xulid.ULID `json:"pocket_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	IsMuted   bool       `json:"is_muted" example:"false"`
+	MinAmount *int64     `json:"min_amount" example:"100000"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// PocketPreferenceUpdate replace preference of pocket, nil min_amount follow user preference
+type PocketPreferenceUpdate struct {
+	IsMuted   bool   `json:"is_muted"`
+	MinAmount *int64 `json:"min_amount" validate:"omitempty,min=0"`
 }
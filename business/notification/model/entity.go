@@ -0,0 +1,174 @@
+package model
+
+import (
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// event checked against push preference
+const (
+	EventSpendCreated = "spend.created"
+	EventSpendUpdated = "spend.updated"
+	EventSpendDeleted = "spend.deleted"
+)
+
+// channel deliver notification outside inbox
+const (
+	ChannelPush    = "push"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook" // chat webhook url of user, ex: slack or discord
+	ChannelLog     = "log"     // receive every notification, for local development
+)
+
+// digest mode of preference, empty mean burst of spend is digested shortly after it stop
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+
+	DefaultDigestTime = "08:00"
+)
+
+// PocketTopic is firebase topic subscribed by token of every pocket member
+func PocketTopic(pocketID string) string {
+	return "pocket." + pocketID
+}
+
+// UserTopic is firebase topic subscribed by every token of user, used to exclude user from pocket topic
+func UserTopic(userID string) string {
+	return "user." + userID
+}
+
+// TopicSubscription is firebase topic subscribed by fcm token of user
+type TopicSubscription struct {
+	Token     string
+	Topic     string
+	UserID    xulid.ULID
+	CreatedAt time.Time
+}
+
+// Recipient is user receiving notification through channel
+type Recipient struct {
+	UserID     xulid.ULID
+	Name       string
+	Email      string
+	FcmTokens  []string
+	WebhookURL string
+}
+
+// Notification is one message in inbox of user
+type Notification struct {
+	ID        xulid.ULID
+	UserID    xulid.ULID
+	MessageID string
+	Title     string
+	Message   string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (n *Notification) ToResp() NotificationResp {
+	return NotificationResp{
+		ID:        n.ID,
+		Title:     n.Title,
+		Message:   n.Message,
+		IsRead:    n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+// Preference limit notification sent through channel, inbox is not affected
+type Preference struct {
+	UserID     xulid.ULID
+	Events     []string // pushed event, empty mean every event
+	MinAmount  int64    // 0 mean no threshold
+	QuietStart string   // HH:MM in user timezone, empty mean no quiet hours
+	QuietEnd   string
+	Channels   []string // empty mean default channel
+	WebhookURL string   // required by webhook channel
+	Digest     string   // empty, daily or weekly
+	DigestTime string   // HH:MM in user timezone when summary is sent, empty mean DefaultDigestTime
+	UpdatedAt  time.Time
+}
+
+// DefaultPreference push every notification at any time
+func DefaultPreference(userID xulid.ULID) Preference {
+	return Preference{
+		UserID:   userID,
+		Events:   []string{},
+		Channels: []string{},
+	}
+}
+
+func (p *Preference) ToPreferenceResp(pockets []PocketPreference) PreferenceResp {
+	pocketResp := make([]PocketPreferenceResp, len(pockets))
+	for i := range pockets {
+		pocketResp[i] = pockets[i].ToPocketPreferenceResp()
+	}
+	return PreferenceResp{
+		Events:     p.Events,
+		MinAmount:  p.MinAmount,
+		QuietStart: p.QuietStart,
+		QuietEnd:   p.QuietEnd,
+		Channels:   p.Channels,
+		WebhookURL: p.WebhookURL,
+		Digest:     p.Digest,
+		DigestTime: p.DigestTime,
+		Pockets:    pocketResp,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+// PocketPreference override Preference for one pocket
+type PocketPreference struct {
+	UserID    xulid.ULID
+	PocketID  xulid.ULID
+	IsMuted   bool
+	MinAmount *int64 // nil follow Preference
+	UpdatedAt time.Time
+}
+
+func (p *PocketPreference) ToPocketPreferenceResp() PocketPreferenceResp {
+	return PocketPreferenceResp{
+		PocketID:  p.PocketID,
+		IsMuted:   p.IsMuted,
+		MinAmount: p.MinAmount,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+// ClockToMinute convert HH:MM to minute of day
+func ClockToMinute(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, errr.T("error.invalid_clock", map[string]string{"clock": clock})
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// DigestKey identify digest of user, key is pocket id for burst digest or digest mode for summary
+type DigestKey struct {
+	UserID string
+	Key    string
+}
+
+// Digest is spend notification of user waiting to be sent as one message
+type Digest struct {
+	UserID       xulid.ULID
+	Key          string
+	PocketNames  []string
+	ActorNames   []string
+	MessageIDs   []string
+	Count        int
+	Total        int64
+	FirstMessage SendMessage
+	FlushAt      time.Time
+	CreatedAt    time.Time
+}
+
+// IsSummary return true for daily or weekly digest
+func (d *Digest) IsSummary() bool {
+	return d.Key == DigestDaily || d.Key == DigestWeekly
+}
@@ -0,0 +1,21 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type DigestStorer interface {
+	// AddToDigest return number of message in digest, db.ErrDBNotFound when message is already in digest.
+	// flush time of existing digest is pushed back to flushAt
+	AddToDigest(ctx context.Context, key model.DigestKey, msg model.SendMessage, flushAt time.Time) (int, error)
+	// TakeDigest delete and return digest which flush time is not after now
+	TakeDigest(ctx context.Context, userID xulid.ULID, key string, now time.Time) (model.Digest, error)
+}
+
+type Transactor interface {
+	WithAtomic(ctx context.Context, tFunc func(ctx context.Context) error) error
+}
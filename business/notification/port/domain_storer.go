@@ -0,0 +1,22 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// PocketReader implemented by pocket repo
+type PocketReader interface {
+	GetByID(ctx context.Context, id xulid.ULID) (ptmodel.Pocket, error)
+	// FindIDsByMember return id of every pocket where user is editor or watcher
+	FindIDsByMember(ctx context.Context, userID xulid.ULID) ([]xulid.ULID, error)
+}
+
+// JobEnqueuer implemented by job service
+type JobEnqueuer interface {
+	EnqueueAt(ctx context.Context, jobType string, payload any, runAt time.Time) error
+	EnqueueOnce(ctx context.Context, jobType string, payload any, runAt time.Time, dedupeKey string) error
+}
@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+)
+
+// Channel deliver notification outside inbox, ex: push, email or chat
+type Channel interface {
+	// Name is the value chosen in user preference
+	Name() string
+	// Send deliver the same content to every recipient, recipient not reachable by channel is skipped
+	Send(ctx context.Context, content model.Content, recipients []model.Recipient) error
+}
@@ -4,8 +4,16 @@ import (
 	"context"
 
 	"github.com/muchlist/moneymagnet/pkg/mfirebase"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
 )
 
 type FCMSender interface {
 	SendMessage(ctx context.Context, payload mfirebase.Payload) ([]string /*invalid token*/, error)
+	// SendCondition send payload to device subscribed to topics matching payload.Condition
+	SendCondition(ctx context.Context, payload mfirebase.Payload) error
+}
+
+// FCMTokenEditor remove invalid fcm token of user, implemented by user repo
+type FCMTokenEditor interface {
+	EditFCM(ctx context.Context, id xulid.ULID, fcms []string) error
 }
@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type NotificationStorer interface {
+	// InsertMany skip notification which message is already in inbox of the user
+	InsertMany(ctx context.Context, notifications []model.Notification) error
+	MarkRead(ctx context.Context, userID xulid.ULID, id xulid.ULID, now time.Time) error
+	MarkAllRead(ctx context.Context, userID xulid.ULID, now time.Time) (int64, error)
+
+	FindByUser(ctx context.Context, userID xulid.ULID, filter paging.Cursor) ([]model.Notification, error)
+	CountUnread(ctx context.Context, userID xulid.ULID) (int64, error)
+}
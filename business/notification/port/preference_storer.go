@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type PreferenceStorer interface {
+	UpsertPreference(ctx context.Context, pref *model.Preference) error
+	UpsertPocketPreference(ctx context.Context, pref *model.PocketPreference) error
+	DeletePocketPreference(ctx context.Context, userID xulid.ULID, pocketID xulid.ULID) error
+
+	// GetPreference return default preference when user never set it
+	GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error)
+	GetPreferences(ctx context.Context, userIDs []string) (map[string]model.Preference, error)
+	FindPocketPreferences(ctx context.Context, userID xulid.ULID) ([]model.PocketPreference, error)
+	GetPocketPreferences(ctx context.Context, pocketID xulid.ULID, userIDs []string) (map[string]model.PocketPreference, error)
+}
@@ -0,0 +1,21 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type TopicStorer interface {
+	// InsertTopicSubscriptions move existing subscription of the token and topic to the user
+	InsertTopicSubscriptions(ctx context.Context, subs []model.TopicSubscription) error
+	DeleteTopicSubscriptions(ctx context.Context, subs []model.TopicSubscription) error
+	FindTopicSubscriptions(ctx context.Context, userID xulid.ULID) ([]model.TopicSubscription, error)
+}
+
+// TopicManager subscribe fcm token to firebase topic, implemented by mfirebase client
+type TopicManager interface {
+	Subscribe(ctx context.Context, tokens []string, topic string) ([]string /*invalid token*/, error)
+	Unsubscribe(ctx context.Context, tokens []string, topic string) ([]string /*invalid token*/, error)
+}
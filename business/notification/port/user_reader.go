@@ -9,6 +9,7 @@ import (
 
 type UserStorer interface {
 	GetByIDs(ctx context.Context, ulids []string) ([]model.User, error)
+	GetPreferences(ctx context.Context, userIDs []string) (map[string]model.Preference, error)
 
 	// also we need to delete fcm when error send message
 	EditFCM(ctx context.Context, id xulid.ULID, fcms []string) error
@@ -0,0 +1,287 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	keyTable     = "notifications"
+	keyID        = "id"
+	keyUserID    = "user_id"
+	keyMessageID = "message_id"
+	keyTitle     = "title"
+	keyMessage   = "message"
+	keyReadAt    = "read_at"
+	keyCreatedAt = "created_at"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.NotificationStorer = (*Repo)(nil)
+var _ port.PreferenceStorer = (*Repo)(nil)
+var _ port.DigestStorer = (*Repo)(nil)
+var _ port.TopicStorer = (*Repo)(nil)
+
+// Repo manages the set of APIs for notification inbox access.
+type Repo struct {
+	db  *pgxpool.Pool
+	log mlogger.Logger
+	sb  sq.StatementBuilderType
+}
+
+// NewRepo constructs a data for api access..
+func NewRepo(sqlDB *pgxpool.Pool, log mlogger.Logger) *Repo {
+	return &Repo{
+		db:  sqlDB,
+		log: log,
+		sb:  sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// =========================================================================
+// MANIPULATOR
+
+// InsertMany insert notification to inbox, message already in inbox of the user is skipped
+func (r *Repo) InsertMany(ctx context.Context, notifications []model.Notification) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-InsertMany")
+	defer span.End()
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := r.sb.Insert(keyTable).
+		Columns(
+			keyID,
+			keyUserID,
+			keyMessageID,
+			keyTitle,
+			keyMessage,
+			keyCreatedAt,
+		)
+	for _, n := range notifications {
+		query = query.Values(
+			n.ID,
+			n.UserID,
+			n.MessageID,
+			n.Title,
+			n.Message,
+			n.CreatedAt,
+		)
+	}
+
+	sqlStatement, args, err := query.
+		Suffix(fmt.Sprintf("ON CONFLICT (%s, %s) DO NOTHING", keyUserID, keyMessageID)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query insert notification: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// MarkRead set read time of notification owned by user, already read notification keep its read time
+func (r *Repo) MarkRead(ctx context.Context, userID xulid.ULID, id xulid.ULID, now time.Time) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-MarkRead")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		Set(keyReadAt, sq.Expr(fmt.Sprintf("COALESCE(%s, ?)", keyReadAt), now)).
+		Where(sq.Eq{
+			keyID:     id,
+			keyUserID: userID,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query mark read notification: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// MarkAllRead set read time of every unread notification of user, return number of changed notification
+func (r *Repo) MarkAllRead(ctx context.Context, userID xulid.ULID, now time.Time) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-MarkAllRead")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		Set(keyReadAt, now).
+		Where(sq.Eq{
+			keyUserID: userID,
+			keyReadAt: nil,
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query mark all read notification: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// =========================================================================
+// GETTER
+
+// FindByUser get inbox of user with cursor pagination
+func (r *Repo) FindByUser(ctx context.Context, userID xulid.ULID, filter paging.Cursor) ([]model.Notification, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-FindByUser")
+	defer span.End()
+
+	// Validation filter
+	filter.SetCursorList([]string{"-id", "id"})
+	if err := filter.Validate(); err != nil {
+		return nil, db.ErrDBInvalidCursorType
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := r.sb.Select(
+		keyID,
+		keyUserID,
+		keyMessageID,
+		keyTitle,
+		keyMessage,
+		keyReadAt,
+		keyCreatedAt,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyUserID: userID})
+
+	// apply cursor value
+	if filter.GetCursor() != "" {
+		cursorColumn, _ := filter.GetCursorColumn()
+		if filter.GetDirection() == ">" {
+			query = query.Where(sq.Gt{cursorColumn: filter.GetCursor()})
+		} else {
+			query = query.Where(sq.Lt{cursorColumn: filter.GetCursor()})
+		}
+	}
+
+	// apply order by
+	orderByStr, err := filter.GetSortColumnDirection()
+	if err != nil {
+		return nil, db.ErrDBSortFilter
+	}
+
+	sqlStatement, args, err := query.OrderBy(orderByStr).
+		Limit(uint64(filter.GetPageSizePlusOne())).
+		ToSql()
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, fmt.Errorf("build query find notification: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	notifications := make([]model.Notification, 0)
+	for rows.Next() {
+		var n model.Notification
+		err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.MessageID,
+			&n.Title,
+			&n.Message,
+			&n.ReadAt,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// CountUnread count notification of user that is not read yet
+func (r *Repo) CountUnread(ctx context.Context, userID xulid.ULID) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-CountUnread")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select("count(*)").
+		From(keyTable).
+		Where(sq.Eq{
+			keyUserID: userID,
+			keyReadAt: nil,
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query count unread notification: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var count int64
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&count)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyDigestTable        = "notification_digests"
+	keyDigestUserID       = "user_id"
+	keyDigestKey          = "digest_key"
+	keyDigestPocketNames  = "pocket_names"
+	keyDigestActorNames   = "actor_names"
+	keyDigestMessageIDs   = "message_ids"
+	keyDigestCount        = "count"
+	keyDigestTotal        = "total"
+	keyDigestFirstMessage = "first_message"
+	keyDigestFlushAt      = "flush_at"
+	keyDigestCreatedAt    = "created_at"
+)
+
+// AddToDigest add message to digest of user, digest is created when not exist.
+// flush time of existing digest is pushed back to flushAt, so digest is flushed after the last message.
+// return number of message in digest, 1 mean digest is just created.
+// message already in digest is not added and db.ErrDBNotFound is returned
+func (r *Repo) AddToDigest(ctx context.Context, key model.DigestKey, msg model.SendMessage, flushAt time.Time) (int, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-AddToDigest")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	firstMessage, err := json.Marshal(msg)
+	if err != nil {
+		return 0, fmt.Errorf("encode digest message: %w", err)
+	}
+
+	// name is appended only when not in digest yet, message id keep retried message from counted twice
+	appendUnique := func(column string) string {
+		return fmt.Sprintf("%[1]s = CASE WHEN EXCLUDED.%[1]s[1] = ANY(d.%[1]s) THEN d.%[1]s ELSE d.%[1]s || EXCLUDED.%[1]s END", column)
+	}
+	sqlStatement, args, err := r.sb.Insert(keyDigestTable+" AS d").
+		Columns(
+			keyDigestUserID,
+			keyDigestKey,
+			keyDigestPocketNames,
+			keyDigestActorNames,
+			keyDigestMessageIDs,
+			keyDigestCount,
+			keyDigestTotal,
+			keyDigestFirstMessage,
+			keyDigestFlushAt,
+			keyDigestCreatedAt,
+		).
+		Values(
+			key.UserID,
+			key.Key,
+			[]string{msg.PocketName},
+			[]string{msg.ActorName},
+			[]string{msg.ID},
+			1,
+			msg.Amount,
+			json.RawMessage(firstMessage),
+			flushAt,
+			time.Now(),
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s, %s) DO UPDATE SET %s, %s, %s = d.%s || EXCLUDED.%s, %s = d.%s + 1, %s = d.%s + EXCLUDED.%s, %s = GREATEST(d.%s, EXCLUDED.%s) WHERE NOT (EXCLUDED.%s[1] = ANY(d.%s)) RETURNING %s",
+			keyDigestUserID, keyDigestKey,
+			appendUnique(keyDigestPocketNames),
+			appendUnique(keyDigestActorNames),
+			keyDigestMessageIDs, keyDigestMessageIDs, keyDigestMessageIDs,
+			keyDigestCount, keyDigestCount,
+			keyDigestTotal, keyDigestTotal, keyDigestTotal,
+			keyDigestFlushAt, keyDigestFlushAt, keyDigestFlushAt,
+			keyDigestMessageIDs, keyDigestMessageIDs,
+			keyDigestCount,
+		)).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query add to digest: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var count int
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&count)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return count, nil
+}
+
+// TakeDigest delete digest and return it, so it is only sent once.
+// digest which flush time is after now is not taken and db.ErrDBNotFound is returned
+func (r *Repo) TakeDigest(ctx context.Context, userID xulid.ULID, key string, now time.Time) (model.Digest, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-TakeDigest")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyDigestTable).
+		Where(sq.Eq{
+			keyDigestUserID: userID,
+			keyDigestKey:    key,
+		}).
+		Where(sq.LtOrEq{keyDigestFlushAt: now}).
+		Suffix(fmt.Sprintf("RETURNING %s, %s, %s, %s, %s, %s, %s, %s, %s, %s",
+			keyDigestUserID,
+			keyDigestKey,
+			keyDigestPocketNames,
+			keyDigestActorNames,
+			keyDigestMessageIDs,
+			keyDigestCount,
+			keyDigestTotal,
+			keyDigestFirstMessage,
+			keyDigestFlushAt,
+			keyDigestCreatedAt,
+		)).ToSql()
+	if err != nil {
+		return model.Digest{}, fmt.Errorf("build query take digest: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var digest model.Digest
+	var firstMessage []byte
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&digest.UserID,
+		&digest.Key,
+		&digest.PocketNames,
+		&digest.ActorNames,
+		&digest.MessageIDs,
+		&digest.Count,
+		&digest.Total,
+		&firstMessage,
+		&digest.FlushAt,
+		&digest.CreatedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Digest{}, db.ParseError(err)
+	}
+
+	if err := json.Unmarshal(firstMessage, &digest.FirstMessage); err != nil {
+		return model.Digest{}, fmt.Errorf("decode digest message: %w", err)
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,371 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyPrefTable      = "notification_preferences"
+	keyPrefUserID     = "user_id"
+	keyPrefEvents     = "events"
+	keyPrefMinAmount  = "min_amount"
+	keyPrefQuietStart = "quiet_start"
+	keyPrefQuietEnd   = "quiet_end"
+	keyPrefChannels   = "channels"
+	keyPrefWebhookURL = "webhook_url"
+	keyPrefDigest     = "digest"
+	keyPrefDigestTime = "digest_time"
+	keyPrefUpdatedAt  = "updated_at"
+
+	keyPocketPrefTable     = "notification_pocket_preferences"
+	keyPocketPrefUserID    = "user_id"
+	keyPocketPrefPocketID  = "pocket_id"
+	keyPocketPrefIsMuted   = "is_muted"
+	keyPocketPrefMinAmount = "min_amount"
+	keyPocketPrefUpdatedAt = "updated_at"
+)
+
+// UpsertPreference insert or replace push preference of user
+func (r *Repo) UpsertPreference(ctx context.Context, pref *model.Preference) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-UpsertPreference")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyPrefTable).
+		Columns(
+			keyPrefUserID,
+			keyPrefEvents,
+			keyPrefMinAmount,
+			keyPrefQuietStart,
+			keyPrefQuietEnd,
+			keyPrefChannels,
+			keyPrefWebhookURL,
+			keyPrefDigest,
+			keyPrefDigestTime,
+			keyPrefUpdatedAt,
+		).
+		Values(
+			pref.UserID,
+			pref.Events,
+			pref.MinAmount,
+			pref.QuietStart,
+			pref.QuietEnd,
+			pref.Channels,
+			pref.WebhookURL,
+			pref.Digest,
+			pref.DigestTime,
+			pref.UpdatedAt,
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s",
+			keyPrefUserID,
+			keyPrefDigest, keyPrefDigest,
+			keyPrefDigestTime, keyPrefDigestTime,
+			keyPrefChannels, keyPrefChannels,
+			keyPrefWebhookURL, keyPrefWebhookURL,
+			keyPrefEvents, keyPrefEvents,
+			keyPrefMinAmount, keyPrefMinAmount,
+			keyPrefQuietStart, keyPrefQuietStart,
+			keyPrefQuietEnd, keyPrefQuietEnd,
+			keyPrefUpdatedAt, keyPrefUpdatedAt,
+		)).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query upsert notification preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// GetPreference get push preference of user, return default preference if user never set it
+func (r *Repo) GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-GetPreference")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyPrefUserID,
+		keyPrefEvents,
+		keyPrefMinAmount,
+		keyPrefQuietStart,
+		keyPrefQuietEnd,
+		keyPrefChannels,
+		keyPrefWebhookURL,
+		keyPrefDigest,
+		keyPrefDigestTime,
+		keyPrefUpdatedAt,
+	).
+		From(keyPrefTable).
+		Where(sq.Eq{keyPrefUserID: userID}).ToSql()
+	if err != nil {
+		return model.Preference{}, fmt.Errorf("build query get notification preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var pref model.Preference
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&pref.UserID,
+		&pref.Events,
+		&pref.MinAmount,
+		&pref.QuietStart,
+		&pref.QuietEnd,
+		&pref.Channels,
+		&pref.WebhookURL,
+		&pref.Digest,
+		&pref.DigestTime,
+		&pref.UpdatedAt,
+	)
+	if err != nil {
+		err = db.ParseError(err)
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.DefaultPreference(userID), nil
+		}
+		r.log.InfoT(ctx, err.Error())
+		return model.Preference{}, err
+	}
+
+	return pref, nil
+}
+
+// GetPreferences get push preference of many user keyed by user id,
+// user who never set preference is not included
+func (r *Repo) GetPreferences(ctx context.Context, userIDs []string) (map[string]model.Preference, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-GetPreferences")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyPrefUserID,
+		keyPrefEvents,
+		keyPrefMinAmount,
+		keyPrefQuietStart,
+		keyPrefQuietEnd,
+		keyPrefChannels,
+		keyPrefWebhookURL,
+		keyPrefDigest,
+		keyPrefDigestTime,
+		keyPrefUpdatedAt,
+	).
+		From(keyPrefTable).
+		Where(sq.Eq{keyPrefUserID: userIDs}).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query get notification preferences: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]model.Preference, len(userIDs))
+	for rows.Next() {
+		var pref model.Preference
+		err := rows.Scan(
+			&pref.UserID,
+			&pref.Events,
+			&pref.MinAmount,
+			&pref.QuietStart,
+			&pref.QuietEnd,
+			&pref.Channels,
+			&pref.WebhookURL,
+			&pref.Digest,
+			&pref.DigestTime,
+			&pref.UpdatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		prefs[pref.UserID.String()] = pref
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// UpsertPocketPreference insert or replace push preference of user for one pocket
+func (r *Repo) UpsertPocketPreference(ctx context.Context, pref *model.PocketPreference) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-UpsertPocketPreference")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyPocketPrefTable).
+		Columns(
+			keyPocketPrefUserID,
+			keyPocketPrefPocketID,
+			keyPocketPrefIsMuted,
+			keyPocketPrefMinAmount,
+			keyPocketPrefUpdatedAt,
+		).
+		Values(
+			pref.UserID,
+			pref.PocketID,
+			pref.IsMuted,
+			pref.MinAmount,
+			pref.UpdatedAt,
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s, %s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s",
+			keyPocketPrefUserID, keyPocketPrefPocketID,
+			keyPocketPrefIsMuted, keyPocketPrefIsMuted,
+			keyPocketPrefMinAmount, keyPocketPrefMinAmount,
+			keyPocketPrefUpdatedAt, keyPocketPrefUpdatedAt,
+		)).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query upsert notification pocket preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// DeletePocketPreference remove pocket override so user preference is used
+func (r *Repo) DeletePocketPreference(ctx context.Context, userID xulid.ULID, pocketID xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-DeletePocketPreference")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyPocketPrefTable).
+		Where(sq.Eq{
+			keyPocketPrefUserID:   userID,
+			keyPocketPrefPocketID: pocketID,
+		}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query delete notification pocket preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// FindPocketPreferences get every pocket override of user
+func (r *Repo) FindPocketPreferences(ctx context.Context, userID xulid.ULID) ([]model.PocketPreference, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-FindPocketPreferences")
+	defer span.End()
+
+	return r.findPocketPreferences(ctx, sq.Eq{keyPocketPrefUserID: userID})
+}
+
+// GetPocketPreferences get pocket override of many user keyed by user id,
+// user who never set override for the pocket is not included
+func (r *Repo) GetPocketPreferences(ctx context.Context, pocketID xulid.ULID, userIDs []string) (map[string]model.PocketPreference, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-GetPocketPreferences")
+	defer span.End()
+
+	prefs, err := r.findPocketPreferences(ctx, sq.Eq{
+		keyPocketPrefPocketID: pocketID,
+		keyPocketPrefUserID:   userIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]model.PocketPreference, len(prefs))
+	for _, pref := range prefs {
+		result[pref.UserID.String()] = pref
+	}
+	return result, nil
+}
+
+func (r *Repo) findPocketPreferences(ctx context.Context, where sq.Eq) ([]model.PocketPreference, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyPocketPrefUserID,
+		keyPocketPrefPocketID,
+		keyPocketPrefIsMuted,
+		keyPocketPrefMinAmount,
+		keyPocketPrefUpdatedAt,
+	).
+		From(keyPocketPrefTable).
+		Where(where).
+		OrderBy(keyPocketPrefPocketID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query find notification pocket preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	prefs := make([]model.PocketPreference, 0)
+	for rows.Next() {
+		var pref model.PocketPreference
+		err := rows.Scan(
+			&pref.UserID,
+			&pref.PocketID,
+			&pref.IsMuted,
+			&pref.MinAmount,
+			&pref.UpdatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		prefs = append(prefs, pref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
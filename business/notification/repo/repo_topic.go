@@ -0,0 +1,162 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyTopicTable     = "notification_topic_subscriptions"
+	keyTopicToken     = "token"
+	keyTopicTopic     = "topic"
+	keyTopicUserID    = "user_id"
+	keyTopicCreatedAt = "created_at"
+)
+
+// InsertTopicSubscriptions save subscription, token moved to other user is moved with its subscription
+func (r *Repo) InsertTopicSubscriptions(ctx context.Context, subs []model.TopicSubscription) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-InsertTopicSubscriptions")
+	defer span.End()
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := r.sb.Insert(keyTopicTable).
+		Columns(
+			keyTopicToken,
+			keyTopicTopic,
+			keyTopicUserID,
+			keyTopicCreatedAt,
+		)
+	for _, sub := range subs {
+		query = query.Values(
+			sub.Token,
+			sub.Topic,
+			sub.UserID,
+			sub.CreatedAt,
+		)
+	}
+
+	sqlStatement, args, err := query.
+		Suffix(fmt.Sprintf("ON CONFLICT (%s, %s) DO UPDATE SET %s = EXCLUDED.%s",
+			keyTopicToken, keyTopicTopic,
+			keyTopicUserID, keyTopicUserID,
+		)).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query insert topic subscription: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// DeleteTopicSubscriptions remove subscription by token and topic
+func (r *Repo) DeleteTopicSubscriptions(ctx context.Context, subs []model.TopicSubscription) error {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-DeleteTopicSubscriptions")
+	defer span.End()
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	keys := make(sq.Or, len(subs))
+	for i, sub := range subs {
+		keys[i] = sq.Eq{
+			keyTopicToken: sub.Token,
+			keyTopicTopic: sub.Topic,
+		}
+	}
+
+	sqlStatement, args, err := r.sb.Delete(keyTopicTable).
+		Where(keys).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query delete topic subscription: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// FindTopicSubscriptions return every topic subscribed by token of user
+func (r *Repo) FindTopicSubscriptions(ctx context.Context, userID xulid.ULID) ([]model.TopicSubscription, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "notification-repo-FindTopicSubscriptions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyTopicToken,
+		keyTopicTopic,
+		keyTopicUserID,
+		keyTopicCreatedAt,
+	).
+		From(keyTopicTable).
+		Where(sq.Eq{keyTopicUserID: userID}).
+		OrderBy(keyTopicTopic, keyTopicToken).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query find topic subscription: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	subs := make([]model.TopicSubscription, 0)
+	for rows.Next() {
+		var sub model.TopicSubscription
+		err := rows.Scan(
+			&sub.Token,
+			&sub.Topic,
+			&sub.UserID,
+			&sub.CreatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
@@ -3,31 +3,61 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	jobModel "github.com/muchlist/moneymagnet/business/job/model"
 	"github.com/muchlist/moneymagnet/business/notification/model"
 	"github.com/muchlist/moneymagnet/business/notification/port"
-	"github.com/muchlist/moneymagnet/pkg/mfirebase"
+	userModel "github.com/muchlist/moneymagnet/business/user/model"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
 	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
 )
 
-// Core manages the set of APIs for user access.
+// Core manages the set of APIs for notification access.
 type Core struct {
-	log       mlogger.Logger
-	fcmSender port.FCMSender
-	userRepo  port.UserStorer
+	log             mlogger.Logger
+	channels        []port.Channel
+	defaultChannels []string
+	digestWindow    time.Duration
+	userRepo        port.UserStorer
+	repo            port.NotificationStorer
+	prefRepo        port.PreferenceStorer
+	digestRepo      port.DigestStorer
+	pocketRepo      port.PocketReader
+	jobQueue        port.JobEnqueuer
+	txManager       port.Transactor
 }
 
-// NewCore constructs a core for user api access.
+// NewCore constructs a core for notification api access.
+// defaultChannels is used for user who never choose channel.
+// new spend is sent as one digest when no spend is added in digestWindow, 0 disable it
 func NewCore(
 	log mlogger.Logger,
-	fcmSender port.FCMSender,
+	channels []port.Channel,
+	defaultChannels []string,
+	digestWindow time.Duration,
 	userRepo port.UserStorer,
+	repo port.NotificationStorer,
+	prefRepo port.PreferenceStorer,
+	digestRepo port.DigestStorer,
+	pocketRepo port.PocketReader,
+	jobQueue port.JobEnqueuer,
+	txManager port.Transactor,
 ) *Core {
 	return &Core{
-		log:       log,
-		fcmSender: fcmSender,
-		userRepo:  userRepo,
+		log:             log,
+		channels:        channels,
+		defaultChannels: defaultChannels,
+		digestWindow:    digestWindow,
+		userRepo:        userRepo,
+		repo:            repo,
+		prefRepo:        prefRepo,
+		digestRepo:      digestRepo,
+		pocketRepo:      pocketRepo,
+		jobQueue:        jobQueue,
+		txManager:       txManager,
 	}
 }
 
@@ -41,44 +71,279 @@ func (s *Core) SendNotificationToUser(ctx context.Context, payload model.SendMes
 		return fmt.Errorf("get users by ids: %w", err)
 	}
 
-	allTokens := make([]string, 0)
+	// locale and timezone of user
+	prefs, err := s.userRepo.GetPreferences(ctx, payload.UserIds)
+	if err != nil {
+		return fmt.Errorf("get preferences: %w", err)
+	}
+
+	// inbox is filled first, so user without fcm token or with push disabled still get the notification.
+	// message id keep retried job from adding the same notification twice.
+	// deferred message is already in inbox, digest message summarize message already in inbox
+	if payload.ID == "" {
+		payload.ID = xulid.Instance().NewULID().String()
+	}
+	if !payload.Deferred && !payload.Digest {
+		err = s.repo.InsertMany(ctx, buildInbox(payload, users, prefs, time.Now()))
+		if err != nil {
+			return fmt.Errorf("insert notification to inbox: %w", err)
+		}
+	}
+
+	notifPrefs, err := s.prefRepo.GetPreferences(ctx, payload.UserIds)
+	if err != nil {
+		return fmt.Errorf("get notification preferences: %w", err)
+	}
+
+	users, deferred, err := s.filterPush(ctx, payload, users, prefs, notifPrefs)
+	if err != nil {
+		return err
+	}
+
+	users, err = s.addToDigest(ctx, payload, users, prefs, notifPrefs)
+	if err != nil {
+		return err
+	}
+
+	// deferred push is enqueued before fan out, so failed enqueue is retried before anything is sent.
+	// enqueue is deduplicated by message id, retry after failed fan out does not enqueue it again
+	err = s.enqueueDeferred(ctx, payload, deferred)
+	if err != nil {
+		return err
+	}
+
+	return s.fanOut(ctx, payload, routeRecipients(users, prefs, notifPrefs, s.channelNames(), s.defaultChannels))
+}
+
+// fanOut send message through every channel. failed channel is only logged because retrying
+// the job would resend to channel that already succeeded, error is returned when every channel failed
+func (s *Core) fanOut(ctx context.Context, payload model.SendMessage, routes map[string]map[string][]model.Recipient) error {
+	var attempted, failed int
+	var lastErr error
+	for _, channel := range s.channels {
+		for locale, recipients := range routes[channel.Name()] {
+			attempted++
+			err := channel.Send(ctx, payload.ContentFor(locale), recipients)
+			if err != nil {
+				failed++
+				lastErr = err
+				s.log.WarnT(ctx, fmt.Sprintf("send notification through %s channel", channel.Name()), err)
+			}
+		}
+	}
+
+	if attempted > 0 && failed == attempted {
+		return fmt.Errorf("send notification failed on every channel: %w", lastErr)
+	}
+	return nil
+}
+
+func (s *Core) channelNames() []string {
+	names := make([]string, len(s.channels))
+	for i, channel := range s.channels {
+		names[i] = channel.Name()
+	}
+	return names
+}
+
+// filterPush apply push preference of users. return users to be pushed now and
+// id of users in quiet hours grouped by the time their quiet hours end
+func (s *Core) filterPush(ctx context.Context, payload model.SendMessage, users []userModel.User, prefs map[string]userModel.Preference, notifPrefs map[string]model.Preference) ([]userModel.User, map[time.Time][]string, error) {
+	pocketPrefs := make(map[string]model.PocketPreference)
+	if payload.PocketID != "" {
+		pocketID, err := xulid.Parse(payload.PocketID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse pocket id: %w", err)
+		}
+		pocketPrefs, err = s.prefRepo.GetPocketPreferences(ctx, pocketID, payload.UserIds)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get notification pocket preferences: %w", err)
+		}
+	}
+
+	now := time.Now()
+	sendNow := make([]userModel.User, 0, len(users))
+	deferred := make(map[time.Time][]string)
 	for _, user := range users {
-		allTokens = append(allTokens, user.Fcm...)
+		pref, ok := notifPrefs[user.ID.String()]
+		if !ok {
+			pref = model.DefaultPreference(user.ID)
+		}
+		var pocketPref *model.PocketPreference
+		if p, ok := pocketPrefs[user.ID.String()]; ok {
+			pocketPref = &p
+		}
+
+		send, deferUntil := decidePush(payload, pref, pocketPref, now.In(locationOf(user, prefs)))
+		switch {
+		case !send:
+			continue
+		case deferUntil.IsZero():
+			sendNow = append(sendNow, user)
+		default:
+			// job run_at is compared with server clock
+			runAt := deferUntil.Local()
+			deferred[runAt] = append(deferred[runAt], user.ID.String())
+		}
+	}
+
+	return sendNow, deferred, nil
+}
+
+// enqueueDeferred enqueue push of users in quiet hours to be sent when quiet hours end.
+// enqueued once per message and run at, so it is safe to call again when the job is retried
+func (s *Core) enqueueDeferred(ctx context.Context, payload model.SendMessage, deferred map[time.Time][]string) error {
+	for runAt, userIDs := range deferred {
+		msg := payload
+		msg.UserIds = userIDs
+		msg.Deferred = true
+		dedupeKey := fmt.Sprintf("%s:%s:%d", jobModel.TypeSendNotification, payload.ID, runAt.Unix())
+		if err := s.jobQueue.EnqueueOnce(ctx, jobModel.TypeSendNotification, msg, runAt, dedupeKey); err != nil {
+			return fmt.Errorf("enqueue deferred notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// decidePush check message against push preference of user, now must be in user timezone.
+// zero deferUntil mean push now
+func decidePush(msg model.SendMessage, pref model.Preference, pocketPref *model.PocketPreference, now time.Time) (send bool, deferUntil time.Time) {
+	if pocketPref != nil && pocketPref.IsMuted {
+		return false, time.Time{}
+	}
+
+	if msg.Event != "" {
+		if len(pref.Events) != 0 && !slices.Contains(pref.Events, msg.Event) {
+			return false, time.Time{}
+		}
+
+		minAmount := pref.MinAmount
+		if pocketPref != nil && pocketPref.MinAmount != nil {
+			minAmount = *pocketPref.MinAmount
+		}
+		amount := msg.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		if amount < minAmount {
+			return false, time.Time{}
+		}
 	}
 
-	failedTokens, err := s.fcmSender.SendMessage(ctx, mfirebase.Payload{
-		Title:          payload.Title,
-		Message:        payload.Message,
-		ReceiverTokens: allTokens,
-	})
+	if msg.Deferred {
+		return true, time.Time{}
+	}
+	return true, quietHoursEnd(pref.QuietStart, pref.QuietEnd, now)
+}
 
+// quietHoursEnd return end of quiet hours when now is inside it, otherwise zero time.
+// quiet hours pass midnight when start is after end, ex: 22:00 - 07:00
+func quietHoursEnd(quietStart, quietEnd string, now time.Time) time.Time {
+	if quietStart == "" || quietEnd == "" {
+		return time.Time{}
+	}
+	start, err := model.ClockToMinute(quietStart)
 	if err != nil {
-		return fmt.Errorf("send message failed: %w", err)
+		return time.Time{}
+	}
+	end, err := model.ClockToMinute(quietEnd)
+	if err != nil || start == end {
+		return time.Time{}
 	}
 
-	if len(failedTokens) != 0 {
-		failedTokenMap := make(map[string]struct{})
-		for _, token := range failedTokens {
-			failedTokenMap[token] = struct{}{}
+	current := now.Hour()*60 + now.Minute()
+	var inside bool
+	if start < end {
+		inside = current >= start && current < end
+	} else {
+		inside = current >= start || current < end
+	}
+	if !inside {
+		return time.Time{}
+	}
+
+	year, month, day := now.Date()
+	endTime := time.Date(year, month, day, end/60, end%60, 0, 0, now.Location())
+	if !endTime.After(now) {
+		endTime = time.Date(year, month, day+1, end/60, end%60, 0, 0, now.Location())
+	}
+	return endTime
+}
+
+// routeRecipients group users by channel then by locale. user receive channel they choose or
+// defaultChannels, channel not enabled is ignored. log channel receive every user
+func routeRecipients(users []userModel.User, prefs map[string]userModel.Preference, notifPrefs map[string]model.Preference, enabled []string, defaultChannels []string) map[string]map[string][]model.Recipient {
+	result := make(map[string]map[string][]model.Recipient)
+	add := func(channel, locale string, recipient model.Recipient) {
+		if result[channel] == nil {
+			result[channel] = make(map[string][]model.Recipient)
 		}
+		result[channel][locale] = append(result[channel][locale], recipient)
+	}
 
-		for _, user := range users {
-			var newTokens []string
-			for _, token := range user.Fcm {
-				if _, found := failedTokenMap[token]; !found {
-					newTokens = append(newTokens, token)
-				}
-			}
+	for _, user := range users {
+		pref := notifPrefs[user.ID.String()]
+		recipient := model.Recipient{
+			UserID:     user.ID,
+			Name:       user.Name,
+			Email:      user.Email,
+			FcmTokens:  user.Fcm,
+			WebhookURL: pref.WebhookURL,
+		}
+		locale := localeOf(user, prefs)
 
-			// if any token changes, update fcm user
-			if len(newTokens) != len(user.Fcm) {
-				err := s.userRepo.EditFCM(ctx, user.ID, newTokens)
-				if err != nil {
-					return fmt.Errorf("failed modify fcm after invalid tokens detected: %w", err)
-				}
+		chosen := pref.Channels
+		if len(chosen) == 0 {
+			chosen = defaultChannels
+		}
+		for _, channel := range enabled {
+			if channel == model.ChannelLog || slices.Contains(chosen, channel) {
+				add(channel, locale, recipient)
 			}
 		}
 	}
+	return result
+}
 
-	return nil
+// buildInbox create inbox notification for every user, text is in user locale
+func buildInbox(payload model.SendMessage, users []userModel.User, prefs map[string]userModel.Preference, now time.Time) []model.Notification {
+	result := make([]model.Notification, len(users))
+	for i, user := range users {
+		text := payload.TextFor(localeOf(user, prefs))
+		result[i] = model.Notification{
+			ID:        xulid.Instance().NewULID(),
+			UserID:    user.ID,
+			MessageID: payload.ID,
+			Title:     text.Title,
+			Message:   text.Message,
+			CreatedAt: now,
+		}
+	}
+	return result
+}
+
+func locationOf(user userModel.User, prefs map[string]userModel.Preference) *time.Location {
+	timezone := userModel.DefaultTimezone
+	if pref, ok := prefs[user.ID.String()]; ok && pref.Timezone != "" {
+		timezone = pref.Timezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func firstDayOf(user userModel.User, prefs map[string]userModel.Preference) time.Weekday {
+	if pref, ok := prefs[user.ID.String()]; ok {
+		return time.Weekday(pref.FirstDayOfWeek)
+	}
+	return time.Weekday(userModel.DefaultFirstDayOfWeek)
+}
+
+func localeOf(user userModel.User, prefs map[string]userModel.Preference) string {
+	if pref, ok := prefs[user.ID.String()]; ok {
+		return pref.Locale
+	}
+	return userModel.DefaultLocale
 }
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	jobModel "github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	userModel "github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// FlushDigest send digest as one message, digest already sent or pushed back by newer message is ignored
+func (s *Core) FlushDigest(ctx context.Context, key model.DigestKey) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FlushDigest")
+	defer span.End()
+
+	userID, err := xulid.Parse(key.UserID)
+	if err != nil {
+		return fmt.Errorf("parse digest user id: %w", err)
+	}
+
+	// message is enqueued in the same transaction, so digest is never deleted without being sent
+	return s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		digest, err := s.digestRepo.TakeDigest(ctx, userID, key.Key, time.Now())
+		if err != nil {
+			// flush enqueued by newer message will take it
+			if errors.Is(err, db.ErrDBNotFound) {
+				return nil
+			}
+			return fmt.Errorf("take digest: %w", err)
+		}
+
+		err = s.jobQueue.EnqueueAt(ctx, jobModel.TypeSendNotification, buildDigestMessage(digest), time.Now())
+		if err != nil {
+			return fmt.Errorf("enqueue digest message: %w", err)
+		}
+		return nil
+	})
+}
+
+// addToDigest hold spend message of users in digest instead of sending it.
+// return users whose message is sent now
+func (s *Core) addToDigest(ctx context.Context, payload model.SendMessage, users []userModel.User, prefs map[string]userModel.Preference, notifPrefs map[string]model.Preference) ([]userModel.User, error) {
+	if !digestable(payload) {
+		return users, nil
+	}
+
+	now := time.Now()
+	sendNow := make([]userModel.User, 0, len(users))
+	for _, user := range users {
+		pref, ok := notifPrefs[user.ID.String()]
+		if !ok {
+			pref = model.DefaultPreference(user.ID)
+		}
+
+		key, flushAt := digestSchedule(pref, s.digestWindow, payload.PocketID, firstDayOf(user, prefs), now.In(locationOf(user, prefs)))
+		if key == "" {
+			sendNow = append(sendNow, user)
+			continue
+		}
+		// flush_at and job run_at are compared with server clock
+		flushAt = flushAt.Local()
+
+		digestKey := model.DigestKey{UserID: user.ID.String(), Key: key}
+		err := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+			_, err := s.digestRepo.AddToDigest(ctx, digestKey, payload, flushAt)
+			if err != nil {
+				// message of retried job is already in digest
+				if errors.Is(err, db.ErrDBNotFound) {
+					return nil
+				}
+				return fmt.Errorf("add to digest: %w", err)
+			}
+			// every message push flush back, flush enqueued by earlier message find digest not due yet
+			if err := s.jobQueue.EnqueueAt(ctx, jobModel.TypeFlushDigest, digestKey, flushAt); err != nil {
+				return fmt.Errorf("enqueue flush digest: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sendNow, nil
+}
+
+// digestable is new spend that is not postponed nor created from digest
+func digestable(msg model.SendMessage) bool {
+	return msg.Event == model.EventSpendCreated && msg.PocketID != "" && !msg.Digest && !msg.Deferred
+}
+
+// digestSchedule return digest key and flush time of message for user, empty key mean send now.
+// summary is sent at digest time of user, burst digest is sent after window
+func digestSchedule(pref model.Preference, window time.Duration, pocketID string, firstDay time.Weekday, now time.Time) (key string, flushAt time.Time) {
+	switch pref.Digest {
+	case model.DigestDaily, model.DigestWeekly:
+		return pref.Digest, nextSummaryAt(pref.Digest, pref.DigestTime, firstDay, now)
+	}
+	if window <= 0 {
+		return "", time.Time{}
+	}
+	return pocketID, now.Add(window)
+}
+
+// nextSummaryAt return next digest time after now in now location,
+// weekly summary is sent on first day of week of user
+func nextSummaryAt(mode string, digestTime string, firstDay time.Weekday, now time.Time) time.Time {
+	minute, err := model.ClockToMinute(digestTime)
+	if err != nil {
+		minute, _ = model.ClockToMinute(model.DefaultDigestTime)
+	}
+
+	year, month, day := now.Date()
+	for i := 0; i <= 7; i++ {
+		at := time.Date(year, month, day+i, minute/60, minute%60, 0, 0, now.Location())
+		if !at.After(now) {
+			continue
+		}
+		if mode == model.DigestWeekly && at.Weekday() != firstDay {
+			continue
+		}
+		return at
+	}
+	// unreachable, 8 days always contain first day of week after now
+	return now.AddDate(0, 0, 7)
+}
+
+// buildDigestMessage write one message from digest, digest of one message send the original message
+func buildDigestMessage(digest model.Digest) model.SendMessage {
+	if digest.Count == 1 {
+		msg := digest.FirstMessage
+		msg.UserIds = []string{digest.UserID.String()}
+		msg.Digest = true
+		return msg
+	}
+
+	msg := model.SendMessage{
+		ID:       xulid.Instance().NewULID().String(),
+		UserIds:  []string{digest.UserID.String()},
+		Event:    model.EventSpendCreated,
+		Amount:   digest.Total,
+		Currency: digest.FirstMessage.Currency,
+		Digest:   true,
+		Params: map[string]string{
+			"count":   strconv.Itoa(digest.Count),
+			"pockets": strings.Join(digest.PocketNames, ", "),
+			"actors":  strings.Join(digest.ActorNames, ", "),
+		},
+	}
+
+	if digest.IsSummary() {
+		msg.TitleKey = "notification.digest_daily.title"
+		if digest.Key == model.DigestWeekly {
+			msg.TitleKey = "notification.digest_weekly.title"
+		}
+		msg.MessageKey = "notification.digest_summary.message"
+		return msg
+	}
+
+	msg.PocketID = digest.Key
+	msg.PocketName = msg.Params["pockets"]
+	msg.TitleKey = "notification.digest_burst.title"
+	msg.MessageKey = "notification.digest_burst.message"
+	return msg
+}
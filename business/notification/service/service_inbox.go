@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// ErrPersonalTokenDenied returned when personal access token limited to some pockets
+// is used for inbox, inbox rows are not tied to pocket so it cannot be filtered
+var ErrPersonalTokenDenied = errr.NewT("error.pocket_limited_token_denied", nil, 403)
+
+// FindNotifications return inbox of user, newest first by default
+func (s *Core) FindNotifications(ctx context.Context, claims mjwt.CustomClaim, filter paging.Cursor) ([]model.NotificationResp, paging.CursorMetadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindNotifications")
+	defer span.End()
+
+	if len(claims.PocketIDs) != 0 {
+		return nil, paging.CursorMetadata{}, ErrPersonalTokenDenied
+	}
+
+	notifications, err := s.repo.FindByUser(ctx, claims.GetULID(), filter)
+	if err != nil {
+		return nil, paging.CursorMetadata{}, fmt.Errorf("find notification: %w", err)
+	}
+
+	var reverseCursor string
+	var nextCursor string
+	if len(notifications) > 0 {
+		reverseCursor = notifications[0].ID.String()
+	}
+	if len(notifications) > filter.GetPageSize() {
+		nextCursor = notifications[filter.GetPageSize()-1].ID.String()
+		notifications = notifications[:filter.GetPageSize()]
+	}
+
+	result := make([]model.NotificationResp, len(notifications))
+	for i := range notifications {
+		result[i] = notifications[i].ToResp()
+	}
+
+	return result, paging.CursorMetadata{
+		CurrentCursor: filter.GetCursor(),
+		CursorType:    filter.GetCursorType(),
+		PageSize:      filter.GetPageSize(),
+		NextCursor:    nextCursor,
+		ReverseCursor: reverseCursor,
+	}, nil
+}
+
+// CountUnread return number of notification that is not read yet
+func (s *Core) CountUnread(ctx context.Context, claims mjwt.CustomClaim) (model.UnreadCountResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-CountUnread")
+	defer span.End()
+
+	if len(claims.PocketIDs) != 0 {
+		return model.UnreadCountResp{}, ErrPersonalTokenDenied
+	}
+
+	count, err := s.repo.CountUnread(ctx, claims.GetULID())
+	if err != nil {
+		return model.UnreadCountResp{}, fmt.Errorf("count unread notification: %w", err)
+	}
+	return model.UnreadCountResp{Unread: count}, nil
+}
+
+// MarkRead mark one notification of user as read
+func (s *Core) MarkRead(ctx context.Context, claims mjwt.CustomClaim, id xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-MarkRead")
+	defer span.End()
+
+	if len(claims.PocketIDs) != 0 {
+		return ErrPersonalTokenDenied
+	}
+
+	err := s.repo.MarkRead(ctx, claims.GetULID(), id, time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return errr.NewT("error.notification_not_found", nil, 404)
+		}
+		return fmt.Errorf("mark notification read: %w", err)
+	}
+	return nil
+}
+
+// MarkAllRead mark every unread notification of user as read
+func (s *Core) MarkAllRead(ctx context.Context, claims mjwt.CustomClaim) (model.MarkAllReadResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-MarkAllRead")
+	defer span.End()
+
+	if len(claims.PocketIDs) != 0 {
+		return model.MarkAllReadResp{}, ErrPersonalTokenDenied
+	}
+
+	updated, err := s.repo.MarkAllRead(ctx, claims.GetULID(), time.Now())
+	if err != nil {
+		return model.MarkAllReadResp{}, fmt.Errorf("mark all notification read: %w", err)
+	}
+	return model.MarkAllReadResp{Updated: updated}, nil
+}
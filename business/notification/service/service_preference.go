@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/slicer"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// GetPreference return notification preference of user with every pocket override
+func (s *Core) GetPreference(ctx context.Context, claims mjwt.CustomClaim) (model.PreferenceResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-GetPreference")
+	defer span.End()
+
+	pref, err := s.prefRepo.GetPreference(ctx, claims.GetULID())
+	if err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("get notification preference: %w", err)
+	}
+
+	return s.preferenceResp(ctx, pref)
+}
+
+// UpdatePreference change not nil field of notification preference
+func (s *Core) UpdatePreference(ctx context.Context, claims mjwt.CustomClaim, req model.PreferenceUpdate) (model.PreferenceResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-UpdatePreference")
+	defer span.End()
+
+	pref, err := s.prefRepo.GetPreference(ctx, claims.GetULID())
+	if err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("get notification preference: %w", err)
+	}
+
+	if err := applyPreferenceUpdate(&pref, req); err != nil {
+		return model.PreferenceResp{}, errr.Wrap(err, 400)
+	}
+	// channel disabled after chosen is ignored when sending, so only new choice is checked
+	if req.Channels != nil {
+		for _, channel := range pref.Channels {
+			if !slicer.In(channel, s.availableChannels()) {
+				return model.PreferenceResp{}, errr.NewT("error.channel_unavailable", map[string]string{"channel": channel}, 400)
+			}
+		}
+	}
+
+	pref.UpdatedAt = time.Now()
+	if err := s.prefRepo.UpsertPreference(ctx, &pref); err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("upsert notification preference: %w", err)
+	}
+
+	return s.preferenceResp(ctx, pref)
+}
+
+// SetPocketPreference replace push preference of user for one pocket, user must be member of pocket
+func (s *Core) SetPocketPreference(ctx context.Context, claims mjwt.CustomClaim, pocketID xulid.ULID, req model.PocketPreferenceUpdate) (model.PocketPreferenceResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SetPocketPreference")
+	defer span.End()
+
+	pocket, err := s.pocketRepo.GetByID(ctx, pocketID)
+	if err != nil {
+		return model.PocketPreferenceResp{}, fmt.Errorf("get pocket by id: %w", err)
+	}
+	if !isMember(claims, pocket) {
+		return model.PocketPreferenceResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
+	pref := model.PocketPreference{
+		UserID:    claims.GetULID(),
+		PocketID:  pocketID,
+		IsMuted:   req.IsMuted,
+		MinAmount: req.MinAmount,
+		UpdatedAt: time.Now(),
+	}
+	if err := s.prefRepo.UpsertPocketPreference(ctx, &pref); err != nil {
+		return model.PocketPreferenceResp{}, fmt.Errorf("upsert notification pocket preference: %w", err)
+	}
+
+	return pref.ToPocketPreferenceResp(), nil
+}
+
+// ResetPocketPreference remove pocket override so user preference is used for the pocket
+func (s *Core) ResetPocketPreference(ctx context.Context, claims mjwt.CustomClaim, pocketID xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-ResetPocketPreference")
+	defer span.End()
+
+	err := s.prefRepo.DeletePocketPreference(ctx, claims.GetULID(), pocketID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return errr.NewT("error.pocket_preference_not_found", nil, 404)
+		}
+		return fmt.Errorf("delete notification pocket preference: %w", err)
+	}
+	return nil
+}
+
+func (s *Core) preferenceResp(ctx context.Context, pref model.Preference) (model.PreferenceResp, error) {
+	pockets, err := s.prefRepo.FindPocketPreferences(ctx, pref.UserID)
+	if err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("find notification pocket preference: %w", err)
+	}
+	resp := pref.ToPreferenceResp(pockets)
+	resp.AvailableChannels = s.availableChannels()
+	resp.DefaultChannels = s.defaultChannels
+	return resp, nil
+}
+
+// availableChannels is enabled channel that can be chosen by user
+func (s *Core) availableChannels() []string {
+	result := make([]string, 0, len(s.channels))
+	for _, channel := range s.channels {
+		if channel.Name() != model.ChannelLog {
+			result = append(result, channel.Name())
+		}
+	}
+	return result
+}
+
+// applyPreferenceUpdate copy not nil field of req to pref and validate it
+func applyPreferenceUpdate(pref *model.Preference, req model.PreferenceUpdate) error {
+	if req.Events != nil {
+		pref.Events = make([]string, 0, len(*req.Events))
+		for _, event := range *req.Events {
+			if !slicer.In(event, pref.Events) {
+				pref.Events = append(pref.Events, event)
+			}
+		}
+	}
+	if req.MinAmount != nil {
+		pref.MinAmount = *req.MinAmount
+	}
+	if req.QuietStart != nil {
+		pref.QuietStart = *req.QuietStart
+	}
+	if req.QuietEnd != nil {
+		pref.QuietEnd = *req.QuietEnd
+	}
+	if req.Channels != nil {
+		pref.Channels = make([]string, 0, len(*req.Channels))
+		for _, channel := range *req.Channels {
+			if !slicer.In(channel, pref.Channels) {
+				pref.Channels = append(pref.Channels, channel)
+			}
+		}
+	}
+	if req.WebhookURL != nil {
+		pref.WebhookURL = strings.TrimSpace(*req.WebhookURL)
+		if pref.WebhookURL != "" && mwebhook.ValidateURL(pref.WebhookURL) != nil {
+			return errr.T("error.invalid_webhook_url", nil)
+		}
+	}
+	if req.Digest != nil {
+		if *req.Digest != "" && *req.Digest != model.DigestDaily && *req.Digest != model.DigestWeekly {
+			return errr.T("error.invalid_digest", nil)
+		}
+		pref.Digest = *req.Digest
+	}
+	if req.DigestTime != nil {
+		if *req.DigestTime != "" {
+			if _, err := model.ClockToMinute(*req.DigestTime); err != nil {
+				return err
+			}
+		}
+		pref.DigestTime = *req.DigestTime
+	}
+	if slicer.In(model.ChannelWebhook, pref.Channels) && pref.WebhookURL == "" {
+		return errr.T("error.webhook_url_required", nil)
+	}
+
+	if pref.QuietStart == "" && pref.QuietEnd == "" {
+		return nil
+	}
+	if pref.QuietStart == "" || pref.QuietEnd == "" {
+		return errr.T("error.quiet_hours_incomplete", nil)
+	}
+	start, err := model.ClockToMinute(pref.QuietStart)
+	if err != nil {
+		return err
+	}
+	end, err := model.ClockToMinute(pref.QuietEnd)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errr.T("error.quiet_hours_same", nil)
+	}
+	return nil
+}
+
+func isMember(claims mjwt.CustomClaim, pocket ptmodel.Pocket) bool {
+	return (slicer.In(claims.Identity, pocket.EditorID) || slicer.In(claims.Identity, pocket.WatcherID)) &&
+		claims.CanAccessPocket(pocket.ID.String())
+}
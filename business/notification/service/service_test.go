@@ -0,0 +1,641 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	userModel "github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+func TestRouteRecipients(t *testing.T) {
+	idA := xulid.Instance().NewULID()
+	idB := xulid.Instance().NewULID()
+	idC := xulid.Instance().NewULID()
+	users := []userModel.User{
+		{ID: idA, Fcm: []string{"a1", "a2"}},
+		{ID: idB, Fcm: []string{"b1"}, Email: "b@example.com"},
+		{ID: idC},
+	}
+	prefs := map[string]userModel.Preference{
+		idB.String(): {UserID: idB, Locale: "en"},
+	}
+	notifPrefs := map[string]model.Preference{
+		idB.String(): {UserID: idB, Channels: []string{model.ChannelEmail, model.ChannelPush}},
+		idC.String(): {UserID: idC, Channels: []string{model.ChannelWebhook}, WebhookURL: "https://example.com/hook"},
+	}
+	enabled := []string{model.ChannelPush, model.ChannelEmail, model.ChannelLog}
+
+	got := routeRecipients(users, prefs, notifPrefs, enabled, []string{model.ChannelPush})
+
+	push := got[model.ChannelPush]
+	if len(push[userModel.DefaultLocale]) != 1 || push[userModel.DefaultLocale][0].UserID != idA {
+		t.Errorf("push default locale = %v, want user A", push[userModel.DefaultLocale])
+	}
+	if len(push["en"]) != 1 || push["en"][0].FcmTokens[0] != "b1" {
+		t.Errorf("push en = %v, want user B", push["en"])
+	}
+	if email := got[model.ChannelEmail]["en"]; len(email) != 1 || email[0].Email != "b@example.com" {
+		t.Errorf("email en = %v, want user B", email)
+	}
+	// webhook channel is not enabled
+	if _, ok := got[model.ChannelWebhook]; ok {
+		t.Errorf("webhook channel is routed while not enabled")
+	}
+	logCount := 0
+	for _, recipients := range got[model.ChannelLog] {
+		logCount += len(recipients)
+	}
+	if logCount != 3 {
+		t.Errorf("log channel got %d recipient, want every user", logCount)
+	}
+}
+
+type fakeChannel struct {
+	name string
+	err  error
+	sent int
+}
+
+func (c *fakeChannel) Name() string { return c.name }
+
+func (c *fakeChannel) Send(_ context.Context, _ model.Content, recipients []model.Recipient) error {
+	c.sent += len(recipients)
+	return c.err
+}
+
+func TestFanOut(t *testing.T) {
+	log := mlogger.New(mlogger.Options{Level: mlogger.LevelError, Output: "stderr"})
+	recipients := map[string][]model.Recipient{
+		userModel.DefaultLocale: {{UserID: xulid.Instance().NewULID()}},
+	}
+
+	push := &fakeChannel{name: model.ChannelPush, err: errors.New("fcm down")}
+	email := &fakeChannel{name: model.ChannelEmail}
+	s := &Core{log: log, channels: []port.Channel{push, email}}
+	routes := map[string]map[string][]model.Recipient{
+		model.ChannelPush:  recipients,
+		model.ChannelEmail: recipients,
+	}
+	if err := s.fanOut(context.Background(), model.SendMessage{}, routes); err != nil {
+		t.Errorf("fanOut() error = %v, want nil when one channel succeed", err)
+	}
+	if email.sent != 1 {
+		t.Errorf("email sent = %d, want 1 even when push failed", email.sent)
+	}
+
+	s = &Core{log: log, channels: []port.Channel{push}}
+	if err := s.fanOut(context.Background(), model.SendMessage{}, routes); err == nil {
+		t.Errorf("fanOut() error = nil, want error when every channel failed")
+	}
+}
+
+type fakeUsers struct {
+	port.UserStorer
+	users []userModel.User
+	prefs map[string]userModel.Preference
+}
+
+func (f *fakeUsers) GetByIDs(_ context.Context, _ []string) ([]userModel.User, error) {
+	return f.users, nil
+}
+
+func (f *fakeUsers) GetPreferences(_ context.Context, _ []string) (map[string]userModel.Preference, error) {
+	return f.prefs, nil
+}
+
+type fakeInbox struct {
+	port.NotificationStorer
+}
+
+func (f *fakeInbox) InsertMany(_ context.Context, _ []model.Notification) error {
+	return nil
+}
+
+type fakePrefs struct {
+	port.PreferenceStorer
+	prefs map[string]model.Preference
+}
+
+func (f *fakePrefs) GetPreferences(_ context.Context, _ []string) (map[string]model.Preference, error) {
+	return f.prefs, nil
+}
+
+type fakeJob struct {
+	jobType string
+	payload any
+	runAt   time.Time
+}
+
+type fakeQueue struct {
+	jobs       []fakeJob
+	dedupeKeys map[string]bool
+}
+
+func (f *fakeQueue) EnqueueAt(_ context.Context, jobType string, payload any, runAt time.Time) error {
+	f.jobs = append(f.jobs, fakeJob{jobType: jobType, payload: payload, runAt: runAt})
+	return nil
+}
+
+func (f *fakeQueue) EnqueueOnce(ctx context.Context, jobType string, payload any, runAt time.Time, dedupeKey string) error {
+	if f.dedupeKeys[dedupeKey] {
+		return nil
+	}
+	if f.dedupeKeys == nil {
+		f.dedupeKeys = make(map[string]bool)
+	}
+	f.dedupeKeys[dedupeKey] = true
+	return f.EnqueueAt(ctx, jobType, payload, runAt)
+}
+
+func TestSendNotificationDeferred(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	awake := userModel.User{ID: xulid.Instance().NewULID()}
+	sleeping := userModel.User{ID: xulid.Instance().NewULID()}
+
+	// quiet hours of sleeping user is around current time in its timezone
+	hour := time.Now().In(loc).Hour()
+	quietStart := fmt.Sprintf("%02d:00", (hour+23)%24)
+	quietEnd := fmt.Sprintf("%02d:00", (hour+1)%24)
+
+	push := &fakeChannel{name: model.ChannelPush, err: errors.New("fcm down")}
+	queue := &fakeQueue{}
+	s := &Core{
+		log:             mlogger.New(mlogger.Options{Level: mlogger.LevelError, Output: "stderr"}),
+		channels:        []port.Channel{push},
+		defaultChannels: []string{model.ChannelPush},
+		userRepo: &fakeUsers{
+			users: []userModel.User{awake, sleeping},
+			prefs: map[string]userModel.Preference{sleeping.ID.String(): {Timezone: "Asia/Jakarta"}},
+		},
+		repo: &fakeInbox{},
+		prefRepo: &fakePrefs{prefs: map[string]model.Preference{
+			sleeping.ID.String(): {UserID: sleeping.ID, QuietStart: quietStart, QuietEnd: quietEnd},
+		}},
+		jobQueue: queue,
+	}
+	payload := model.SendMessage{ID: "msg-1", UserIds: []string{awake.ID.String(), sleeping.ID.String()}}
+
+	if err := s.SendNotificationToUser(context.Background(), payload); err == nil {
+		t.Fatal("SendNotificationToUser() error = nil, want error when every channel failed")
+	}
+	if len(queue.jobs) != 1 {
+		t.Fatalf("enqueued %d job, want one deferred job", len(queue.jobs))
+	}
+
+	// retried job does not enqueue deferred push again
+	push.err = nil
+	if err := s.SendNotificationToUser(context.Background(), payload); err != nil {
+		t.Fatalf("SendNotificationToUser() error = %v", err)
+	}
+	if len(queue.jobs) != 1 {
+		t.Fatalf("retry enqueued %d job, want deferred job enqueued once", len(queue.jobs))
+	}
+	job := queue.jobs[0]
+	msg, _ := job.payload.(model.SendMessage)
+	if !msg.Deferred || !reflect.DeepEqual(msg.UserIds, []string{sleeping.ID.String()}) {
+		t.Errorf("deferred message = %+v, want sleeping user only", msg)
+	}
+	// run_at is stored without zone and compared with server clock
+	if job.runAt.Location() != time.Local || job.runAt.In(loc).Format("15:04") != quietEnd {
+		t.Errorf("deferred run at = %v, want end of quiet hours %s in server location", job.runAt, quietEnd)
+	}
+}
+
+func TestBuildInbox(t *testing.T) {
+	idA := xulid.Instance().NewULID()
+	idB := xulid.Instance().NewULID()
+	users := []userModel.User{
+		{ID: idA, Fcm: []string{"a1"}},
+		{ID: idB}, // no fcm token, still get inbox
+	}
+	prefs := map[string]userModel.Preference{
+		idB.String(): {UserID: idB, Locale: "en"},
+	}
+	payload := model.SendMessage{
+		ID:         "01J4EXF94QDMR5XT9KN527XEP6",
+		TitleKey:   "notification.spend_created.title",
+		MessageKey: "notification.spend.message",
+		Params:     map[string]string{"pocket": "Dompet", "actor": "Budi", "name": "Makan"},
+		Currency:   "Rp",
+		Amount:     15000,
+	}
+	now := time.Now()
+
+	got := buildInbox(payload, users, prefs, now)
+	if len(got) != 2 {
+		t.Fatalf("buildInbox() got %d notification, want 2", len(got))
+	}
+	if got[0].UserID != idA || got[0].Title != "Penambahan record pada Dompet oleh Budi" {
+		t.Errorf("first notification = %+v, want default locale text for user A", got[0])
+	}
+	if got[1].UserID != idB || got[1].Title != "New record in Dompet by Budi" || got[1].Message != "Makan Rp 15,000" {
+		t.Errorf("second notification = %+v, want en text for user B", got[1])
+	}
+	for _, n := range got {
+		if n.MessageID != payload.ID || !n.CreatedAt.Equal(now) || n.ReadAt != nil {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	}
+	if got[0].ID == got[1].ID {
+		t.Error("expected unique id per recipient")
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name       string
+		start, end string
+		now        time.Time
+		want       time.Time
+	}{
+		{name: "no quiet hours", now: at(10, 23, 0)},
+		{name: "same day inside", start: "12:00", end: "13:00", now: at(10, 12, 30), want: at(10, 13, 0)},
+		{name: "same day outside", start: "12:00", end: "13:00", now: at(10, 13, 0)},
+		{name: "past midnight before midnight", start: "22:00", end: "07:00", now: at(10, 23, 15), want: at(11, 7, 0)},
+		{name: "past midnight after midnight", start: "22:00", end: "07:00", now: at(11, 6, 59), want: at(11, 7, 0)},
+		{name: "past midnight outside", start: "22:00", end: "07:00", now: at(10, 21, 59)},
+		{name: "invalid clock", start: "25:00", end: "07:00", now: at(10, 23, 0)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := quietHoursEnd(tc.start, tc.end, tc.now)
+			if !got.Equal(tc.want) {
+				t.Errorf("quietHoursEnd() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecidePush(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	day := time.Date(2024, 5, 10, 10, 0, 0, 0, loc)
+	night := time.Date(2024, 5, 10, 23, 0, 0, 0, loc)
+	pocketMin := int64(500)
+
+	pref := model.Preference{
+		Events:     []string{model.EventSpendCreated},
+		MinAmount:  100,
+		QuietStart: "22:00",
+		QuietEnd:   "07:00",
+	}
+	msg := model.SendMessage{Event: model.EventSpendCreated, Amount: 200}
+
+	tests := []struct {
+		name       string
+		msg        model.SendMessage
+		pocketPref *model.PocketPreference
+		now        time.Time
+		wantSend   bool
+		wantDefer  bool
+	}{
+		{name: "allowed", msg: msg, now: day, wantSend: true},
+		{name: "muted pocket", msg: msg, pocketPref: &model.PocketPreference{IsMuted: true}, now: day},
+		{name: "event not chosen", msg: model.SendMessage{Event: model.EventSpendDeleted, Amount: 200}, now: day},
+		{name: "below threshold", msg: model.SendMessage{Event: model.EventSpendCreated, Amount: 50}, now: day},
+		{name: "negative amount use absolute", msg: model.SendMessage{Event: model.EventSpendCreated, Amount: -200}, now: day, wantSend: true},
+		{name: "pocket threshold override", msg: msg, pocketPref: &model.PocketPreference{MinAmount: &pocketMin}, now: day},
+		{name: "message without event ignore threshold", msg: model.SendMessage{}, now: day, wantSend: true},
+		{name: "quiet hours deferred", msg: msg, now: night, wantSend: true, wantDefer: true},
+		{name: "already deferred", msg: model.SendMessage{Event: model.EventSpendCreated, Amount: 200, Deferred: true}, now: night, wantSend: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			send, deferUntil := decidePush(tc.msg, pref, tc.pocketPref, tc.now)
+			if send != tc.wantSend {
+				t.Errorf("decidePush() send = %v, want %v", send, tc.wantSend)
+			}
+			if deferUntil.IsZero() == tc.wantDefer {
+				t.Errorf("decidePush() deferUntil = %v, want deferred %v", deferUntil, tc.wantDefer)
+			}
+		})
+	}
+}
+
+func TestApplyPreferenceUpdate(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		pref    model.Preference
+		req     model.PreferenceUpdate
+		wantErr bool
+	}{
+		{name: "set quiet hours", req: model.PreferenceUpdate{QuietStart: str("22:00"), QuietEnd: str("07:00")}},
+		{name: "only start", req: model.PreferenceUpdate{QuietStart: str("22:00")}, wantErr: true},
+		{name: "same start and end", req: model.PreferenceUpdate{QuietStart: str("22:00"), QuietEnd: str("22:00")}, wantErr: true},
+		{name: "invalid clock", req: model.PreferenceUpdate{QuietStart: str("24:30"), QuietEnd: str("07:00")}, wantErr: true},
+		{name: "disable quiet hours", pref: model.Preference{QuietStart: "22:00", QuietEnd: "07:00"}, req: model.PreferenceUpdate{QuietStart: str(""), QuietEnd: str("")}},
+		{name: "webhook channel without url", req: model.PreferenceUpdate{Channels: &[]string{model.ChannelWebhook}}, wantErr: true},
+		{name: "webhook channel with url", req: model.PreferenceUpdate{Channels: &[]string{model.ChannelWebhook}, WebhookURL: str("https://hooks.slack.com/services/x")}},
+		{name: "invalid webhook url", req: model.PreferenceUpdate{WebhookURL: str("ftp://example.com")}, wantErr: true},
+		{name: "daily digest", req: model.PreferenceUpdate{Digest: str(model.DigestDaily), DigestTime: str("20:30")}},
+		{name: "unknown digest", req: model.PreferenceUpdate{Digest: str("hourly")}, wantErr: true},
+		{name: "invalid digest time", req: model.PreferenceUpdate{DigestTime: str("8am")}, wantErr: true},
+		{name: "change end only", pref: model.Preference{QuietStart: "22:00", QuietEnd: "07:00"}, req: model.PreferenceUpdate{QuietEnd: str("06:00")}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := applyPreferenceUpdate(&tc.pref, tc.req)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("applyPreferenceUpdate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	events := []string{model.EventSpendCreated, model.EventSpendCreated}
+	pref := model.Preference{}
+	if err := applyPreferenceUpdate(&pref, model.PreferenceUpdate{Events: &events}); err != nil {
+		t.Fatalf("applyPreferenceUpdate() error = %v", err)
+	}
+	if len(pref.Events) != 1 {
+		t.Errorf("events = %v, want duplicate removed", pref.Events)
+	}
+}
+
+func TestNextSummaryAt(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	// 2024-05-10 is friday
+	now := time.Date(2024, 5, 10, 9, 0, 0, 0, loc)
+
+	tests := []struct {
+		name       string
+		mode       string
+		digestTime string
+		want       time.Time
+	}{
+		{name: "daily later today", mode: model.DigestDaily, digestTime: "20:00", want: time.Date(2024, 5, 10, 20, 0, 0, 0, loc)},
+		{name: "daily passed today", mode: model.DigestDaily, digestTime: "", want: time.Date(2024, 5, 11, 8, 0, 0, 0, loc)},
+		{name: "weekly next monday", mode: model.DigestWeekly, digestTime: "08:00", want: time.Date(2024, 5, 13, 8, 0, 0, 0, loc)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := nextSummaryAt(tc.mode, tc.digestTime, time.Monday, now)
+			if !got.Equal(tc.want) {
+				t.Errorf("nextSummaryAt() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// weekly on the first day of week before digest time is sent the same day
+	monday := time.Date(2024, 5, 13, 7, 0, 0, 0, loc)
+	if got := nextSummaryAt(model.DigestWeekly, "08:00", time.Monday, monday); !got.Equal(monday.Add(time.Hour)) {
+		t.Errorf("nextSummaryAt() = %v, want %v", got, monday.Add(time.Hour))
+	}
+}
+
+func TestDigestSchedule(t *testing.T) {
+	now := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	pocketID := "01J4EXF94QDMR5XT9KN527XEP6"
+
+	key, flushAt := digestSchedule(model.Preference{}, time.Minute, pocketID, time.Monday, now)
+	if key != pocketID || !flushAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("burst digest = %s %v, want %s %v", key, flushAt, pocketID, now.Add(time.Minute))
+	}
+
+	key, _ = digestSchedule(model.Preference{}, 0, pocketID, time.Monday, now)
+	if key != "" {
+		t.Errorf("disabled window key = %s, want empty", key)
+	}
+
+	key, flushAt = digestSchedule(model.Preference{Digest: model.DigestDaily}, 0, pocketID, time.Monday, now)
+	if key != model.DigestDaily || !flushAt.Equal(time.Date(2024, 5, 11, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily digest = %s %v", key, flushAt)
+	}
+}
+
+type fakeTx struct{}
+
+func (fakeTx) WithAtomic(ctx context.Context, tFunc func(ctx context.Context) error) error {
+	return tFunc(ctx)
+}
+
+type fakeDigests struct {
+	port.DigestStorer
+	flushAt map[model.DigestKey]time.Time
+}
+
+func (f *fakeDigests) AddToDigest(_ context.Context, key model.DigestKey, _ model.SendMessage, flushAt time.Time) (int, error) {
+	if flushAt.After(f.flushAt[key]) {
+		f.flushAt[key] = flushAt
+	}
+	return len(f.flushAt), nil
+}
+
+func TestAddToDigest(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	burst := userModel.User{ID: xulid.Instance().NewULID()}
+	daily := userModel.User{ID: xulid.Instance().NewULID()}
+	pocketID := "01J4EXF94QDMR5XT9KN527XEP6"
+
+	digests := &fakeDigests{flushAt: make(map[model.DigestKey]time.Time)}
+	queue := &fakeQueue{}
+	s := &Core{
+		digestWindow: time.Minute,
+		digestRepo:   digests,
+		jobQueue:     queue,
+		txManager:    fakeTx{},
+	}
+	users := []userModel.User{burst, daily}
+	prefs := map[string]userModel.Preference{
+		burst.ID.String(): {Timezone: "Asia/Jakarta"},
+		daily.ID.String(): {Timezone: "Asia/Jakarta"},
+	}
+	notifPrefs := map[string]model.Preference{
+		daily.ID.String(): {Digest: model.DigestDaily, DigestTime: "08:00"},
+	}
+	msg := model.SendMessage{ID: "msg-1", Event: model.EventSpendCreated, PocketID: pocketID}
+
+	start := time.Now()
+	sendNow, err := s.addToDigest(context.Background(), msg, users, prefs, notifPrefs)
+	if err != nil {
+		t.Fatalf("addToDigest() error = %v", err)
+	}
+	if len(sendNow) != 0 {
+		t.Errorf("addToDigest() send now %d user, want every user in digest", len(sendNow))
+	}
+	if len(queue.jobs) != 2 {
+		t.Fatalf("enqueued %d flush, want one per user", len(queue.jobs))
+	}
+
+	// run_at and flush_at are stored without zone and compared with server clock
+	for _, job := range queue.jobs {
+		key, _ := job.payload.(model.DigestKey)
+		if job.runAt.Location() != time.Local || digests.flushAt[key].Location() != time.Local {
+			t.Errorf("flush of %s is scheduled at %v, want server location", key.Key, job.runAt)
+		}
+		switch key.Key {
+		case pocketID:
+			if job.runAt.Before(start.Add(time.Minute)) || job.runAt.After(time.Now().Add(time.Minute)) {
+				t.Errorf("burst flush at %v, want one window after now", job.runAt)
+			}
+		case model.DigestDaily:
+			if job.runAt.In(loc).Format("15:04") != "08:00" {
+				t.Errorf("daily flush at %v, want 08:00 in user timezone", job.runAt.In(loc))
+			}
+		default:
+			t.Errorf("unexpected digest key %s", key.Key)
+		}
+	}
+
+	// newer message push the burst flush back
+	first := digests.flushAt[model.DigestKey{UserID: burst.ID.String(), Key: pocketID}]
+	time.Sleep(time.Millisecond)
+	msg.ID = "msg-2"
+	if _, err := s.addToDigest(context.Background(), msg, users[:1], prefs, notifPrefs); err != nil {
+		t.Fatalf("addToDigest() error = %v", err)
+	}
+	last := queue.jobs[len(queue.jobs)-1]
+	if !last.runAt.After(first) || !digests.flushAt[model.DigestKey{UserID: burst.ID.String(), Key: pocketID}].Equal(last.runAt) {
+		t.Errorf("second flush at %v, want after first flush %v", last.runAt, first)
+	}
+}
+
+func TestBuildDigestMessage(t *testing.T) {
+	userID := xulid.Instance().NewULID()
+	first := model.SendMessage{
+		ID:      "01J4EXF94QDMR5XT9KN527XEP6",
+		Title:   "Penambahan record pada Dompet oleh Budi",
+		UserIds: []string{userID.String(), "other"},
+		Event:   model.EventSpendCreated,
+	}
+
+	single := buildDigestMessage(model.Digest{UserID: userID, Key: "pocket", Count: 1, FirstMessage: first})
+	if single.Title != first.Title || single.ID != first.ID {
+		t.Errorf("single digest = %+v, want original message", single)
+	}
+	if len(single.UserIds) != 1 || single.UserIds[0] != userID.String() || !single.Digest {
+		t.Errorf("single digest user = %v digest = %v, want only digest owner", single.UserIds, single.Digest)
+	}
+
+	burst := buildDigestMessage(model.Digest{
+		UserID:       userID,
+		Key:          "01J4EXF94QDMR5XT9KN527XEP6",
+		PocketNames:  []string{"Dompet"},
+		ActorNames:   []string{"Budi", "Ani"},
+		Count:        5,
+		Total:        250000,
+		FirstMessage: model.SendMessage{Currency: "Rp"},
+	})
+	if burst.TextFor("en").Title != "5 new records in Dompet by Budi, Ani" {
+		t.Errorf("burst title = %q", burst.TextFor("en").Title)
+	}
+	if got := burst.TextFor("id"); got.Title != "5 record baru pada Dompet oleh Budi, Ani" || got.Message != "Total Rp 250.000" {
+		t.Errorf("burst id text = %+v", got)
+	}
+	if got := burst.TextFor("en").Message; got != "Total Rp 250,000" {
+		t.Errorf("burst en message = %q", got)
+	}
+	if burst.PocketID != "01J4EXF94QDMR5XT9KN527XEP6" || burst.Amount != 250000 || !burst.Digest {
+		t.Errorf("burst digest = %+v", burst)
+	}
+
+	summary := buildDigestMessage(model.Digest{
+		UserID:      userID,
+		Key:         model.DigestWeekly,
+		PocketNames: []string{"Dompet", "Tabungan"},
+		Count:       12,
+		Total:       900000,
+	})
+	if summary.TextFor("en").Title != "Weekly summary" || summary.PocketID != "" {
+		t.Errorf("summary digest = %+v", summary)
+	}
+	if got := summary.TextFor("id").Message; got != "12 record baru di Dompet, Tabungan, total 900.000" {
+		t.Errorf("summary id message = %q", got)
+	}
+}
+
+func TestDigestable(t *testing.T) {
+	msg := model.SendMessage{Event: model.EventSpendCreated, PocketID: "pocket"}
+	if !digestable(msg) {
+		t.Errorf("digestable() = false, want true for new spend")
+	}
+	for _, m := range []model.SendMessage{
+		{Event: model.EventSpendDeleted, PocketID: "pocket"},
+		{Event: model.EventSpendCreated},
+		{Event: model.EventSpendCreated, PocketID: "pocket", Digest: true},
+		{Event: model.EventSpendCreated, PocketID: "pocket", Deferred: true},
+	} {
+		if digestable(m) {
+			t.Errorf("digestable(%+v) = true, want false", m)
+		}
+	}
+}
+
+func TestTopicChanges(t *testing.T) {
+	current := []model.TopicSubscription{
+		{Token: "a", Topic: "user.1"},
+		{Token: "a", Topic: "pocket.old"},
+		{Token: "gone", Topic: "user.1"},
+	}
+	subscribe, unsubscribe := topicChanges([]string{"a", "b", "b", ""}, []string{"user.1", "pocket.new"}, current)
+
+	wantSubscribe := map[string][]string{
+		"user.1":     {"b"},
+		"pocket.new": {"a", "b"},
+	}
+	wantUnsubscribe := map[string][]string{
+		"pocket.old": {"a"},
+		"user.1":     {"gone"},
+	}
+	if !reflect.DeepEqual(subscribe, wantSubscribe) {
+		t.Errorf("subscribe = %v, want %v", subscribe, wantSubscribe)
+	}
+	if !reflect.DeepEqual(unsubscribe, wantUnsubscribe) {
+		t.Errorf("unsubscribe = %v, want %v", unsubscribe, wantUnsubscribe)
+	}
+
+	// deleted user has no token and topic, every subscription is removed
+	subscribe, unsubscribe = topicChanges(nil, nil, current)
+	if len(subscribe) != 0 || len(unsubscribe["user.1"]) != 2 || len(unsubscribe["pocket.old"]) != 1 {
+		t.Errorf("topicChanges() of deleted user = %v, %v", subscribe, unsubscribe)
+	}
+}
+
+func TestContentFor(t *testing.T) {
+	msg := model.SendMessage{ID: "msg", Title: "title", Event: model.EventSpendCreated, PocketID: "pocket", SpendID: "spend"}
+	content := msg.ContentFor("en")
+	want := map[string]string{"message_id": "msg", "event": model.EventSpendCreated, "pocket_id": "pocket", "spend_id": "spend"}
+	if !reflect.DeepEqual(content.Data, want) {
+		t.Errorf("ContentFor().Data = %v, want %v", content.Data, want)
+	}
+
+	content = model.SendMessage{ID: "msg"}.ContentFor("en")
+	if len(content.Data) != 1 || content.PocketID != "" {
+		t.Errorf("ContentFor() without pocket = %+v, want only message_id", content)
+	}
+}
+
+func TestInboxDeniedForPocketLimitedToken(t *testing.T) {
+	// fake inbox does not implement read, any call to repo would panic
+	s := &Core{repo: &fakeInbox{}}
+	claims := mjwt.CustomClaim{Identity: xulid.Instance().NewULID().String(), Type: mjwt.Personal, PocketIDs: []string{"pocket"}}
+	ctx := context.Background()
+
+	if _, _, err := s.FindNotifications(ctx, claims, paging.Cursor{}); !errors.Is(err, ErrPersonalTokenDenied) {
+		t.Errorf("FindNotifications() error = %v, want %v", err, ErrPersonalTokenDenied)
+	}
+	if _, err := s.CountUnread(ctx, claims); !errors.Is(err, ErrPersonalTokenDenied) {
+		t.Errorf("CountUnread() error = %v, want %v", err, ErrPersonalTokenDenied)
+	}
+	if err := s.MarkRead(ctx, claims, xulid.Instance().NewULID()); !errors.Is(err, ErrPersonalTokenDenied) {
+		t.Errorf("MarkRead() error = %v, want %v", err, ErrPersonalTokenDenied)
+	}
+	if _, err := s.MarkAllRead(ctx, claims); !errors.Is(err, ErrPersonalTokenDenied) {
+		t.Errorf("MarkAllRead() error = %v, want %v", err, ErrPersonalTokenDenied)
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	jobModel "github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/notification/port"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// TopicCore keep firebase topic subscribed by fcm token of user in sync with pocket membership.
+// every token subscribe to topic of user and topic of each pocket of the user
+type TopicCore struct {
+	log        mlogger.Logger
+	enabled    bool
+	userRepo   port.UserStorer
+	pocketRepo port.PocketReader
+	repo       port.TopicStorer
+	manager    port.TopicManager
+	jobQueue   port.JobEnqueuer
+}
+
+// NewTopicCore constructs a core for push topic sync. SyncTopics do nothing when not enabled,
+// manager is only used by SyncUserTopics and can be nil where job is only enqueued
+func NewTopicCore(
+	log mlogger.Logger,
+	enabled bool,
+	userRepo port.UserStorer,
+	pocketRepo port.PocketReader,
+	repo port.TopicStorer,
+	manager port.TopicManager,
+	jobQueue port.JobEnqueuer,
+) *TopicCore {
+	return &TopicCore{
+		log:        log,
+		enabled:    enabled,
+		userRepo:   userRepo,
+		pocketRepo: pocketRepo,
+		repo:       repo,
+		manager:    manager,
+		jobQueue:   jobQueue,
+	}
+}
+
+// SyncTopics enqueue topic sync of users whose pocket membership or fcm token changed,
+// call it inside the transaction making the change
+func (s *TopicCore) SyncTopics(ctx context.Context, userIDs []string) error {
+	if !s.enabled || len(userIDs) == 0 {
+		return nil
+	}
+	err := s.jobQueue.EnqueueAt(ctx, jobModel.TypeSyncPushTopic, model.TopicSyncJob{UserIDs: userIDs}, time.Now())
+	if err != nil {
+		return fmt.Errorf("enqueue push topic sync: %w", err)
+	}
+	return nil
+}
+
+// SyncUserTopics subscribe token of users to missing topic and unsubscribe it from topic no longer wanted.
+// token of deleted user is unsubscribed from every topic
+func (s *TopicCore) SyncUserTopics(ctx context.Context, job model.TopicSyncJob) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SyncUserTopics")
+	defer span.End()
+
+	users, err := s.userRepo.GetByIDs(ctx, job.UserIDs)
+	if err != nil {
+		return fmt.Errorf("get users by ids: %w", err)
+	}
+	tokensOf := make(map[string][]string, len(users))
+	for _, user := range users {
+		tokensOf[user.ID.String()] = user.Fcm
+	}
+
+	for _, rawID := range job.UserIDs {
+		userID, err := xulid.Parse(rawID)
+		if err != nil {
+			return fmt.Errorf("parse topic sync user id: %w", err)
+		}
+
+		var topics []string
+		tokens, exist := tokensOf[rawID]
+		if exist {
+			pocketIDs, err := s.pocketRepo.FindIDsByMember(ctx, userID)
+			if err != nil {
+				return fmt.Errorf("find pocket id by member: %w", err)
+			}
+			topics = append(topics, model.UserTopic(rawID))
+			for _, pocketID := range pocketIDs {
+				topics = append(topics, model.PocketTopic(pocketID.String()))
+			}
+		}
+
+		current, err := s.repo.FindTopicSubscriptions(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("find topic subscription: %w", err)
+		}
+
+		subscribe, unsubscribe := topicChanges(tokens, topics, current)
+		if err := s.applyTopicChanges(ctx, userID, subscribe, unsubscribe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTopicChanges send change to firebase, subscription is saved per topic so retried job continue from the failed topic
+func (s *TopicCore) applyTopicChanges(ctx context.Context, userID xulid.ULID, subscribe, unsubscribe map[string][]string) error {
+	for _, topic := range sortedKeys(unsubscribe) {
+		tokens := unsubscribe[topic]
+		// token rejected by firebase is no longer subscribed to anything, so it is removed as well
+		if _, err := s.manager.Unsubscribe(ctx, tokens, topic); err != nil {
+			return fmt.Errorf("unsubscribe topic %s: %w", topic, err)
+		}
+		if err := s.repo.DeleteTopicSubscriptions(ctx, toSubscriptions(userID, topic, tokens, nil)); err != nil {
+			return fmt.Errorf("delete topic subscription: %w", err)
+		}
+	}
+
+	for _, topic := range sortedKeys(subscribe) {
+		tokens := subscribe[topic]
+		invalid, err := s.manager.Subscribe(ctx, tokens, topic)
+		if err != nil {
+			return fmt.Errorf("subscribe topic %s: %w", topic, err)
+		}
+		if len(invalid) != 0 {
+			s.log.WarnT(ctx, fmt.Sprintf("%d token rejected when subscribing topic %s", len(invalid), topic), nil)
+		}
+		if err := s.repo.InsertTopicSubscriptions(ctx, toSubscriptions(userID, topic, tokens, invalid)); err != nil {
+			return fmt.Errorf("insert topic subscription: %w", err)
+		}
+	}
+	return nil
+}
+
+// topicChanges compare current subscription with every token subscribing every topic,
+// return token to subscribe and unsubscribe grouped by topic
+func topicChanges(tokens []string, topics []string, current []model.TopicSubscription) (subscribe, unsubscribe map[string][]string) {
+	type pair struct{ token, topic string }
+
+	wanted := make(map[pair]struct{}, len(tokens)*len(topics))
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+		for _, topic := range topics {
+			wanted[pair{token, topic}] = struct{}{}
+		}
+	}
+
+	existing := make(map[pair]struct{}, len(current))
+	unsubscribe = make(map[string][]string)
+	for _, sub := range current {
+		key := pair{sub.Token, sub.Topic}
+		existing[key] = struct{}{}
+		if _, ok := wanted[key]; !ok {
+			unsubscribe[sub.Topic] = append(unsubscribe[sub.Topic], sub.Token)
+		}
+	}
+
+	subscribe = make(map[string][]string)
+	for _, token := range tokens {
+		for _, topic := range topics {
+			key := pair{token, topic}
+			if _, ok := wanted[key]; !ok {
+				continue
+			}
+			if _, ok := existing[key]; !ok {
+				subscribe[topic] = append(subscribe[topic], token)
+				// duplicate token is only subscribed once
+				existing[key] = struct{}{}
+			}
+		}
+	}
+	return subscribe, unsubscribe
+}
+
+func toSubscriptions(userID xulid.ULID, topic string, tokens []string, skip []string) []model.TopicSubscription {
+	skipped := make(map[string]struct{}, len(skip))
+	for _, token := range skip {
+		skipped[token] = struct{}{}
+	}
+
+	now := time.Now()
+	result := make([]model.TopicSubscription, 0, len(tokens))
+	for _, token := range tokens {
+		if _, ok := skipped[token]; ok {
+			continue
+		}
+		result = append(result, model.TopicSubscription{
+			Token:     token,
+			Topic:     topic,
+			UserID:    userID,
+			CreatedAt: now,
+		})
+	}
+	return result
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -63,7 +63,7 @@ func (pt pocketHandler) CreatePocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := pt.validator.Struct(req)
+	errMap, err := pt.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		pt.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -126,7 +126,7 @@ func (pt pocketHandler) UpdatePocket(w http.ResponseWriter, r *http.Request) {
 	}
 	req.ID = pocketID
 
-	errMap, err := pt.validator.Struct(req)
+	errMap, err := pt.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		pt.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
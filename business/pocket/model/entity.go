@@ -4,11 +4,13 @@ import (
 	"time"
 
 	"github.com/muchlist/moneymagnet/pkg/ds"
+	"github.com/muchlist/moneymagnet/pkg/slicer"
 	"github.com/muchlist/moneymagnet/pkg/xulid"
 )
 
 type Pocket struct {
 	ID         xulid.ULID
+	ParentID   xulid.NullULID
 	OwnerID    xulid.ULID
 	EditorID   []string
 	WatcherID  []string
@@ -21,6 +23,52 @@ type Pocket struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 	Version    int
+
+	// aggregated from sub-pockets, not stored in pockets table
+	TotalBalance    int64
+	DescendantCount int
+	SubPockets      []Pocket // not included in get pocket by id
+}
+
+// PocketTotal is aggregation result of pocket and all of its descendants
+type PocketTotal struct {
+	PocketID        xulid.ULID
+	TotalBalance    int64
+	DescendantCount int
+}
+
+// PocketNode is pocket found under RootID by tree query, RootID itself is included
+type PocketNode struct {
+	RootID    xulid.ULID
+	ID        xulid.ULID
+	EditorID  []string
+	WatcherID []string
+	Balance   int64
+}
+
+// IsMember returns true if userID is editor or watcher of the node
+func (n PocketNode) IsMember(userID string) bool {
+	return slicer.In(userID, n.EditorID) || slicer.In(userID, n.WatcherID)
+}
+
+// SumTotals aggregate nodes into total of every root. balance of root is always included,
+// descendant is only included when visible return true, so sub-pocket hidden from user
+// does not leak into the total
+func SumTotals(nodes []PocketNode, visible func(node PocketNode) bool) map[xulid.ULID]PocketTotal {
+	totals := make(map[xulid.ULID]PocketTotal)
+	for _, node := range nodes {
+		total := totals[node.RootID]
+		total.PocketID = node.RootID
+		switch {
+		case node.ID == node.RootID:
+			total.TotalBalance += node.Balance
+		case visible(node):
+			total.TotalBalance += node.Balance
+			total.DescendantCount++
+		}
+		totals[node.RootID] = total
+	}
+	return totals
 }
 
 type PocketUser struct {
@@ -40,6 +88,24 @@ func (p *Pocket) Sanitize() {
 	p.WatcherID = watcherSet.RevealSorted()
 }
 
+// IsMember returns true if userID is editor or watcher of the pocket
+func (p *Pocket) IsMember(userID string) bool {
+	return slicer.In(userID, p.EditorID) || slicer.In(userID, p.WatcherID)
+}
+
+// ApplyTotal set aggregated value to pocket,
+// pocket without descendant have total equal to its own balance
+func (p *Pocket) ApplyTotal(totals map[xulid.ULID]PocketTotal) {
+	total, ok := totals[p.ID]
+	if !ok {
+		p.TotalBalance = p.Balance
+		p.DescendantCount = 0
+		return
+	}
+	p.TotalBalance = total.TotalBalance
+	p.DescendantCount = total.DescendantCount
+}
+
 // GetOtherUsers returns a unique list of user IDs from
 // EditorID, WatcherID, and OwnerID,
 // excluding the given userID.
@@ -66,19 +132,44 @@ func (p *Pocket) GetOtherUsers(excludeUserID string) []string {
 }
 
 func (p *Pocket) ToPocketResp() PocketResp {
+	var subPockets []SubPocketResp
+	if len(p.SubPockets) != 0 {
+		subPockets = make([]SubPocketResp, len(p.SubPockets))
+		for i := range p.SubPockets {
+			subPockets[i] = p.SubPockets[i].ToSubPocketResp()
+		}
+	}
+
 	return PocketResp{
-		ID:         p.ID,
-		OwnerID:    p.OwnerID,
-		EditorID:   p.EditorID,
-		WatcherID:  p.WatcherID,
-		Users:      p.Users,
-		PocketName: p.PocketName,
-		Balance:    p.Balance,
-		Currency:   p.Currency,
-		Icon:       p.Icon,
-		Level:      p.Level,
-		CreatedAt:  p.CreatedAt,
-		UpdatedAt:  p.UpdatedAt,
-		Version:    p.Version,
+		ID:              p.ID,
+		ParentID:        p.ParentID,
+		OwnerID:         p.OwnerID,
+		EditorID:        p.EditorID,
+		WatcherID:       p.WatcherID,
+		Users:           p.Users,
+		PocketName:      p.PocketName,
+		Balance:         p.Balance,
+		TotalBalance:    p.TotalBalance,
+		DescendantCount: p.DescendantCount,
+		SubPockets:      subPockets,
+		Currency:        p.Currency,
+		Icon:            p.Icon,
+		Level:           p.Level,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+		Version:         p.Version,
+	}
+}
+
+func (p *Pocket) ToSubPocketResp() SubPocketResp {
+	return SubPocketResp{
+		ID:              p.ID,
+		PocketName:      p.PocketName,
+		Balance:         p.Balance,
+		TotalBalance:    p.TotalBalance,
+		DescendantCount: p.DescendantCount,
+		Currency:        p.Currency,
+		Icon:            p.Icon,
+		Level:           p.Level,
 	}
 }
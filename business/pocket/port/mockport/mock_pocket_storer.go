@@ -95,10 +95,55 @@ func (mr *MockPocketStorerMockRecorder) Find(ctx, owner, filter interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockPocketStorer)(nil).Find), ctx, owner, filter)
 }
 
+// FindChildren mocks base method.
+func (m *MockPocketStorer) FindChildren(ctx context.Context, parentID xulid.ULID) ([]model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindChildren", ctx, parentID)
+	ret0, _ := ret[0].([]model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindChildren indicates an expected call of FindChildren.
+func (mr *MockPocketStorerMockRecorder) FindChildren(ctx, parentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindChildren", reflect.TypeOf((*MockPocketStorer)(nil).FindChildren), ctx, parentID)
+}
+
+// FindDescendants mocks base method.
+func (m *MockPocketStorer) FindDescendants(ctx context.Context, pocketID xulid.ULID) ([]model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDescendants", ctx, pocketID)
+	ret0, _ := ret[0].([]model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDescendants indicates an expected call of FindDescendants.
+func (mr *MockPocketStorerMockRecorder) FindDescendants(ctx, pocketID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDescendants", reflect.TypeOf((*MockPocketStorer)(nil).FindDescendants), ctx, pocketID)
+}
+
+// FindTrees mocks base method.
+func (m *MockPocketStorer) FindTrees(ctx context.Context, pocketIDs []xulid.ULID) ([]model.PocketNode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTrees", ctx, pocketIDs)
+	ret0, _ := ret[0].([]model.PocketNode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTrees indicates an expected call of FindTrees.
+func (mr *MockPocketStorerMockRecorder) FindTrees(ctx, pocketIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTrees", reflect.TypeOf((*MockPocketStorer)(nil).FindTrees), ctx, pocketIDs)
+}
+
 // FindUserPocketsByRelation mocks base method.
-func (m *MockPocketStorer) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error) {
+func (m *MockPocketStorer) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, pocketIDs []xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUserPocketsByRelation", ctx, owner, filter)
+	ret := m.ctrl.Call(m, "FindUserPocketsByRelation", ctx, owner, pocketIDs, filter)
 	ret0, _ := ret[0].([]model.Pocket)
 	ret1, _ := ret[1].(paging.Metadata)
 	ret2, _ := ret[2].(error)
@@ -106,9 +151,9 @@ func (m *MockPocketStorer) FindUserPocketsByRelation(ctx context.Context, owner
 }
 
 // FindUserPocketsByRelation indicates an expected call of FindUserPocketsByRelation.
-func (mr *MockPocketStorerMockRecorder) FindUserPocketsByRelation(ctx, owner, filter interface{}) *gomock.Call {
+func (mr *MockPocketStorerMockRecorder) FindUserPocketsByRelation(ctx, owner, pocketIDs, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserPocketsByRelation", reflect.TypeOf((*MockPocketStorer)(nil).FindUserPocketsByRelation), ctx, owner, filter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserPocketsByRelation", reflect.TypeOf((*MockPocketStorer)(nil).FindUserPocketsByRelation), ctx, owner, pocketIDs, filter)
 }
 
 // GetByID mocks base method.
@@ -126,6 +171,36 @@ func (mr *MockPocketStorerMockRecorder) GetByID(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPocketStorer)(nil).GetByID), ctx, id)
 }
 
+// GetByIDForUpdate mocks base method.
+func (m *MockPocketStorer) GetByIDForUpdate(ctx context.Context, id xulid.ULID) (model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockPocketStorerMockRecorder) GetByIDForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockPocketStorer)(nil).GetByIDForUpdate), ctx, id)
+}
+
+// GetFirst mocks base method.
+func (m *MockPocketStorer) GetFirst(ctx context.Context, ownerID string) (model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirst", ctx, ownerID)
+	ret0, _ := ret[0].(model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirst indicates an expected call of GetFirst.
+func (mr *MockPocketStorerMockRecorder) GetFirst(ctx, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirst", reflect.TypeOf((*MockPocketStorer)(nil).GetFirst), ctx, ownerID)
+}
+
 // Insert mocks base method.
 func (m *MockPocketStorer) Insert(ctx context.Context, Pocket *model.Pocket) error {
 	m.ctrl.T.Helper()
@@ -141,7 +216,7 @@ func (mr *MockPocketStorerMockRecorder) Insert(ctx, Pocket interface{}) *gomock.
 }
 
 // InsertPocketUser mocks base method.
-func (m *MockPocketStorer) InsertPocketUser(ctx context.Context, userIDs []xulid.ULID, pocketID xulid.ULID) error {
+func (m *MockPocketStorer) InsertPocketUser(ctx context.Context, userIDs []string, pocketID xulid.ULID) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "InsertPocketUser", ctx, userIDs, pocketID)
 	ret0, _ := ret[0].(error)
@@ -154,6 +229,34 @@ func (mr *MockPocketStorerMockRecorder) InsertPocketUser(ctx, userIDs, pocketID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPocketUser", reflect.TypeOf((*MockPocketStorer)(nil).InsertPocketUser), ctx, userIDs, pocketID)
 }
 
+// LockHierarchy mocks base method.
+func (m *MockPocketStorer) LockHierarchy(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockHierarchy", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockHierarchy indicates an expected call of LockHierarchy.
+func (mr *MockPocketStorerMockRecorder) LockHierarchy(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockHierarchy", reflect.TypeOf((*MockPocketStorer)(nil).LockHierarchy), ctx)
+}
+
+// MoveParent mocks base method.
+func (m *MockPocketStorer) MoveParent(ctx context.Context, pocketID xulid.ULID, parentID xulid.NullULID, level int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveParent", ctx, pocketID, parentID, level)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveParent indicates an expected call of MoveParent.
+func (mr *MockPocketStorerMockRecorder) MoveParent(ctx, pocketID, parentID, level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveParent", reflect.TypeOf((*MockPocketStorer)(nil).MoveParent), ctx, pocketID, parentID, level)
+}
+
 // UpdateBalance mocks base method.
 func (m *MockPocketStorer) UpdateBalance(ctx context.Context, pocketID xulid.ULID, balance int64, isSetOperaton bool) (int64, error) {
 	m.ctrl.T.Helper()
@@ -249,7 +352,7 @@ func (mr *MockPocketSaverMockRecorder) Insert(ctx, Pocket interface{}) *gomock.C
 }
 
 // InsertPocketUser mocks base method.
-func (m *MockPocketSaver) InsertPocketUser(ctx context.Context, userIDs []xulid.ULID, pocketID xulid.ULID) error {
+func (m *MockPocketSaver) InsertPocketUser(ctx context.Context, userIDs []string, pocketID xulid.ULID) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "InsertPocketUser", ctx, userIDs, pocketID)
 	ret0, _ := ret[0].(error)
@@ -262,6 +365,34 @@ func (mr *MockPocketSaverMockRecorder) InsertPocketUser(ctx, userIDs, pocketID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPocketUser", reflect.TypeOf((*MockPocketSaver)(nil).InsertPocketUser), ctx, userIDs, pocketID)
 }
 
+// LockHierarchy mocks base method.
+func (m *MockPocketSaver) LockHierarchy(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockHierarchy", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockHierarchy indicates an expected call of LockHierarchy.
+func (mr *MockPocketSaverMockRecorder) LockHierarchy(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockHierarchy", reflect.TypeOf((*MockPocketSaver)(nil).LockHierarchy), ctx)
+}
+
+// MoveParent mocks base method.
+func (m *MockPocketSaver) MoveParent(ctx context.Context, pocketID xulid.ULID, parentID xulid.NullULID, level int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveParent", ctx, pocketID, parentID, level)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveParent indicates an expected call of MoveParent.
+func (mr *MockPocketSaverMockRecorder) MoveParent(ctx, pocketID, parentID, level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveParent", reflect.TypeOf((*MockPocketSaver)(nil).MoveParent), ctx, pocketID, parentID, level)
+}
+
 // UpdateBalance mocks base method.
 func (m *MockPocketSaver) UpdateBalance(ctx context.Context, pocketID xulid.ULID, balance int64, isSetOperaton bool) (int64, error) {
 	m.ctrl.T.Helper()
@@ -316,10 +447,55 @@ func (mr *MockPocketReaderMockRecorder) Find(ctx, owner, filter interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockPocketReader)(nil).Find), ctx, owner, filter)
 }
 
+// FindChildren mocks base method.
+func (m *MockPocketReader) FindChildren(ctx context.Context, parentID xulid.ULID) ([]model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindChildren", ctx, parentID)
+	ret0, _ := ret[0].([]model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindChildren indicates an expected call of FindChildren.
+func (mr *MockPocketReaderMockRecorder) FindChildren(ctx, parentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindChildren", reflect.TypeOf((*MockPocketReader)(nil).FindChildren), ctx, parentID)
+}
+
+// FindDescendants mocks base method.
+func (m *MockPocketReader) FindDescendants(ctx context.Context, pocketID xulid.ULID) ([]model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDescendants", ctx, pocketID)
+	ret0, _ := ret[0].([]model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDescendants indicates an expected call of FindDescendants.
+func (mr *MockPocketReaderMockRecorder) FindDescendants(ctx, pocketID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDescendants", reflect.TypeOf((*MockPocketReader)(nil).FindDescendants), ctx, pocketID)
+}
+
+// FindTrees mocks base method.
+func (m *MockPocketReader) FindTrees(ctx context.Context, pocketIDs []xulid.ULID) ([]model.PocketNode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTrees", ctx, pocketIDs)
+	ret0, _ := ret[0].([]model.PocketNode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTrees indicates an expected call of FindTrees.
+func (mr *MockPocketReaderMockRecorder) FindTrees(ctx, pocketIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTrees", reflect.TypeOf((*MockPocketReader)(nil).FindTrees), ctx, pocketIDs)
+}
+
 // FindUserPocketsByRelation mocks base method.
-func (m *MockPocketReader) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error) {
+func (m *MockPocketReader) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, pocketIDs []xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUserPocketsByRelation", ctx, owner, filter)
+	ret := m.ctrl.Call(m, "FindUserPocketsByRelation", ctx, owner, pocketIDs, filter)
 	ret0, _ := ret[0].([]model.Pocket)
 	ret1, _ := ret[1].(paging.Metadata)
 	ret2, _ := ret[2].(error)
@@ -327,9 +503,9 @@ func (m *MockPocketReader) FindUserPocketsByRelation(ctx context.Context, owner
 }
 
 // FindUserPocketsByRelation indicates an expected call of FindUserPocketsByRelation.
-func (mr *MockPocketReaderMockRecorder) FindUserPocketsByRelation(ctx, owner, filter interface{}) *gomock.Call {
+func (mr *MockPocketReaderMockRecorder) FindUserPocketsByRelation(ctx, owner, pocketIDs, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserPocketsByRelation", reflect.TypeOf((*MockPocketReader)(nil).FindUserPocketsByRelation), ctx, owner, filter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserPocketsByRelation", reflect.TypeOf((*MockPocketReader)(nil).FindUserPocketsByRelation), ctx, owner, pocketIDs, filter)
 }
 
 // GetByID mocks base method.
@@ -347,6 +523,36 @@ func (mr *MockPocketReaderMockRecorder) GetByID(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPocketReader)(nil).GetByID), ctx, id)
 }
 
+// GetByIDForUpdate mocks base method.
+func (m *MockPocketReader) GetByIDForUpdate(ctx context.Context, id xulid.ULID) (model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockPocketReaderMockRecorder) GetByIDForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockPocketReader)(nil).GetByIDForUpdate), ctx, id)
+}
+
+// GetFirst mocks base method.
+func (m *MockPocketReader) GetFirst(ctx context.Context, ownerID string) (model.Pocket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirst", ctx, ownerID)
+	ret0, _ := ret[0].(model.Pocket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirst indicates an expected call of GetFirst.
+func (mr *MockPocketReaderMockRecorder) GetFirst(ctx, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirst", reflect.TypeOf((*MockPocketReader)(nil).GetFirst), ctx, ownerID)
+}
+
 // MockTransactor is a mock of Transactor interface.
 type MockTransactor struct {
 	ctrl     *gomock.Controller
@@ -52,7 +52,7 @@ func (mr *MockUserReaderMockRecorder) GetByID(ctx, ulid interface{}) *gomock.Cal
 }
 
 // GetByIDs mocks base method.
-func (m *MockUserReader) GetByIDs(ctx context.Context, ulids []xulid.ULID) ([]model.User, error) {
+func (m *MockUserReader) GetByIDs(ctx context.Context, ulids []string) ([]model.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetByIDs", ctx, ulids)
 	ret0, _ := ret[0].([]model.User)
@@ -65,3 +65,18 @@ func (mr *MockUserReaderMockRecorder) GetByIDs(ctx, ulids interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockUserReader)(nil).GetByIDs), ctx, ulids)
 }
+
+// GetPreference mocks base method.
+func (m *MockUserReader) GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreference", ctx, userID)
+	ret0, _ := ret[0].(model.Preference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreference indicates an expected call of GetPreference.
+func (mr *MockUserReaderMockRecorder) GetPreference(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreference", reflect.TypeOf((*MockUserReader)(nil).GetPreference), ctx, userID)
+}
@@ -19,6 +19,8 @@ type PocketSaver interface {
 	Edit(ctx context.Context, Pocket *model.Pocket) error
 	Delete(ctx context.Context, id xulid.ULID) error
 	UpdateBalance(ctx context.Context, pocketID xulid.ULID, balance int64, isSetOperaton bool) (int64, error)
+	MoveParent(ctx context.Context, pocketID xulid.ULID, parentID xulid.NullULID, level int) error
+	LockHierarchy(ctx context.Context) error
 
 	// many to many relation
 	InsertPocketUser(ctx context.Context, userIDs []string, pocketID xulid.ULID) error
@@ -27,9 +29,15 @@ type PocketSaver interface {
 
 type PocketReader interface {
 	GetByID(ctx context.Context, id xulid.ULID) (model.Pocket, error)
+	GetByIDForUpdate(ctx context.Context, id xulid.ULID) (model.Pocket, error)
 	GetFirst(ctx context.Context, ownerID string) (model.Pocket, error)
 	Find(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error)
-	FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error)
+	FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, pocketIDs []xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error)
+
+	// hierarchy
+	FindChildren(ctx context.Context, parentID xulid.ULID) ([]model.Pocket, error)
+	FindDescendants(ctx context.Context, pocketID xulid.ULID) ([]model.Pocket, error)
+	FindTrees(ctx context.Context, pocketIDs []xulid.ULID) ([]model.PocketNode, error)
 }
 
 type Transactor interface {
@@ -11,4 +11,5 @@ import (
 type UserReader interface {
 	GetByID(ctx context.Context, ulid xulid.ULID) (model.User, error)
 	GetByIDs(ctx context.Context, ulids []string) ([]model.User, error)
+	GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/muchlist/moneymagnet/business/pocket/model"
 	"github.com/muchlist/moneymagnet/business/pocket/port"
+	"github.com/muchlist/moneymagnet/constant"
 	"github.com/muchlist/moneymagnet/pkg/db"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
 	"github.com/muchlist/moneymagnet/pkg/observ"
@@ -20,6 +21,7 @@ import (
 const (
 	keyTable      = "pockets"
 	keyID         = "id"
+	keyParentID   = "parent_id"
 	keyOwnerID    = "owner_id"
 	keyEditorID   = "editor_id"
 	keyWatcherID  = "watcher_id"
@@ -68,6 +70,7 @@ func (r *Repo) Insert(ctx context.Context, pocket *model.Pocket) error {
 	sqlStatement, args, err := r.sb.Insert(keyTable).
 		Columns(
 			keyID,
+			keyParentID,
 			keyPocketName,
 			keyCurrency,
 			keyOwnerID,
@@ -81,6 +84,7 @@ func (r *Repo) Insert(ctx context.Context, pocket *model.Pocket) error {
 		).
 		Values(
 			pocket.ID,
+			pocket.ParentID,
 			pocket.PocketName,
 			pocket.Currency,
 			pocket.OwnerID,
@@ -202,6 +206,17 @@ func (r *Repo) Delete(ctx context.Context, id xulid.ULID) error {
 
 	dbtx := db.ExtractTx(ctx, r.db)
 
+	// sub-pocket become root by foreign key ON DELETE SET NULL,
+	// so level of it and its descendants is recalculated first
+	if err := r.LockHierarchy(ctx); err != nil {
+		return err
+	}
+	_, err = dbtx.Exec(ctx, queryPromoteChildren, id, constant.POCK_ROOT_LEVEL, time.Now(), maxTreeDepth)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
 	res, err := dbtx.Exec(ctx, sqlStatement, args...)
 	if err != nil {
 		r.log.InfoT(ctx, err.Error())
@@ -223,11 +238,25 @@ func (r *Repo) GetByID(ctx context.Context, id xulid.ULID) (model.Pocket, error)
 	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-GetByID")
 	defer span.End()
 
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDForUpdate get one pocket by id and lock it until the transaction end,
+// must be called inside transaction. the lock does not block spend insert of the pocket
+func (r *Repo) GetByIDForUpdate(ctx context.Context, id xulid.ULID) (model.Pocket, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-GetByIDForUpdate")
+	defer span.End()
+
+	return r.getByID(ctx, id, true)
+}
+
+func (r *Repo) getByID(ctx context.Context, id xulid.ULID, forUpdate bool) (model.Pocket, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	sqlStatement, args, err := r.sb.Select(
+	builder := r.sb.Select(
 		keyID,
+		keyParentID,
 		keyOwnerID,
 		keyEditorID,
 		keyWatcherID,
@@ -239,8 +268,12 @@ func (r *Repo) GetByID(ctx context.Context, id xulid.ULID) (model.Pocket, error)
 		keyCreatedAt,
 		keyUpdatedAt,
 		keyVersion,
-	).From(keyTable).Where(sq.Eq{keyID: id}).ToSql()
+	).From(keyTable).Where(sq.Eq{keyID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR NO KEY UPDATE")
+	}
 
+	sqlStatement, args, err := builder.ToSql()
 	if err != nil {
 		return model.Pocket{}, fmt.Errorf("build query get pocket by id: %w", err)
 	}
@@ -251,6 +284,7 @@ func (r *Repo) GetByID(ctx context.Context, id xulid.ULID) (model.Pocket, error)
 	err = dbtx.QueryRow(ctx, sqlStatement, args...).
 		Scan(
 			&pocket.ID,
+			&pocket.ParentID,
 			&pocket.OwnerID,
 			&pocket.EditorID,
 			&pocket.WatcherID,
@@ -270,9 +304,9 @@ func (r *Repo) GetByID(ctx context.Context, id xulid.ULID) (model.Pocket, error)
 	return pocket, nil
 }
 
-// GetByID get one pocket by id
+// GetFirst get first root pocket owned by user
 func (r *Repo) GetFirst(ctx context.Context, ownerID string) (model.Pocket, error) {
-	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-GetByID")
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-GetFirst")
 	defer span.End()
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
@@ -280,6 +314,7 @@ func (r *Repo) GetFirst(ctx context.Context, ownerID string) (model.Pocket, erro
 
 	sqlStatement, args, err := r.sb.Select(
 		keyID,
+		keyParentID,
 		keyOwnerID,
 		keyEditorID,
 		keyWatcherID,
@@ -292,7 +327,7 @@ func (r *Repo) GetFirst(ctx context.Context, ownerID string) (model.Pocket, erro
 		keyUpdatedAt,
 		keyVersion,
 	).From(keyTable).
-		Where(sq.Eq{"owner_id": ownerID}).
+		Where(sq.Eq{keyOwnerID: ownerID, keyParentID: nil}).
 		Limit(1).
 		OrderBy("id").ToSql()
 
@@ -306,6 +341,7 @@ func (r *Repo) GetFirst(ctx context.Context, ownerID string) (model.Pocket, erro
 	err = dbtx.QueryRow(ctx, sqlStatement, args...).
 		Scan(
 			&pocket.ID,
+			&pocket.ParentID,
 			&pocket.OwnerID,
 			&pocket.EditorID,
 			&pocket.WatcherID,
@@ -342,6 +378,7 @@ func (r *Repo) Find(ctx context.Context, owner xulid.ULID, filter paging.Filters
 	sqlStatement, args, err := r.sb.Select(
 		"count(*) OVER()",
 		keyID,
+		keyParentID,
 		keyOwnerID,
 		keyEditorID,
 		keyWatcherID,
@@ -381,6 +418,7 @@ func (r *Repo) Find(ctx context.Context, owner xulid.ULID, filter paging.Filters
 		err := rows.Scan(
 			&totalRecords,
 			&pocket.ID,
+			&pocket.ParentID,
 			&pocket.OwnerID,
 			&pocket.EditorID,
 			&pocket.WatcherID,
@@ -408,8 +446,9 @@ func (r *Repo) Find(ctx context.Context, owner xulid.ULID, filter paging.Filters
 	return pockets, metadata, nil
 }
 
-// FindUserPockets get all pocket user has uuid in it by relation constrain
-func (r *Repo) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error) {
+// FindUserPockets get all pocket user has uuid in it by relation constrain.
+// pocketIDs limit result to those pockets, nil mean all pocket
+func (r *Repo) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, pocketIDs []xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindUserPocketsByRelation")
 	defer span.End()
 
@@ -422,9 +461,10 @@ func (r *Repo) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID,
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	sqlStatement, args, err := r.sb.Select(
+	builder := r.sb.Select(
 		"count(*) OVER()",
 		db.A(keyID),
+		db.A(keyParentID),
 		db.A(keyOwnerID),
 		db.A(keyEditorID),
 		db.A(keyWatcherID),
@@ -440,7 +480,14 @@ func (r *Repo) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID,
 		From("pockets A").
 		Join("user_pocket B ON A.id = B.pocket_id").
 		Join("users C ON B.user_id = C.id").
-		Where(sq.Eq{"C.id": owner}).
+		Where(sq.Eq{"C.id": owner})
+
+	// filtered before limit so count and paging only cover allowed pockets
+	if pocketIDs != nil {
+		builder = builder.Where(sq.Eq{db.A(keyID): pocketIDs})
+	}
+
+	sqlStatement, args, err := builder.
 		OrderBy(filter.SortColumnDirection()).
 		Limit(uint64(filter.Limit())).
 		Offset(uint64(filter.Offset())).
@@ -466,6 +513,7 @@ func (r *Repo) FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID,
 		err := rows.Scan(
 			&totalRecords,
 			&pocket.ID,
+			&pocket.ParentID,
 			&pocket.OwnerID,
 			&pocket.EditorID,
 			&pocket.WatcherID,
@@ -86,3 +86,81 @@ func (r Repo) DeletePocketUser(ctx context.Context, userID xulid.ULID, pocketID
 
 	return nil
 }
+
+// RemoveMember remove userID from editor and watcher of every pocket, used when user is deleted.
+// ownership is not changed
+func (r Repo) RemoveMember(ctx context.Context, userID xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-RemoveMember")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		Set(keyEditorID, sq.Expr("array_remove("+keyEditorID+", ?)", userID.String())).
+		Set(keyWatcherID, sq.Expr("array_remove("+keyWatcherID+", ?)", userID.String())).
+		Set(keyUpdatedAt, time.Now()).
+		Set(keyVersion, sq.Expr(keyVersion+" + 1")).
+		Where(sq.Or{
+			sq.Expr("? = ANY("+keyEditorID+")", userID.String()),
+			sq.Expr("? = ANY("+keyWatcherID+")", userID.String()),
+		}).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query remove pocket member: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// FindIDsByMember return id of every pocket related to userID
+func (r Repo) FindIDsByMember(ctx context.Context, userID xulid.ULID) ([]xulid.ULID, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindIDsByMember")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(keyPocketUP).
+		From(keyTableUP).
+		Where(sq.Eq{keyUserUP: userID}).
+		OrderBy(keyPocketUP).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find pocket id by member: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	ids := make([]xulid.ULID, 0)
+	for rows.Next() {
+		var id xulid.ULID
+		if err := rows.Scan(&id); err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
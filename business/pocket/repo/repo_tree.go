@@ -0,0 +1,336 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/constant"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// squirrel does not support recursive CTE, so the tree queries below
+// are written in raw SQL and only the values are passed as args.
+// every recursion carry depth limited by maxTreeDepth, so broken data
+// containing cycle cannot make the query run forever.
+
+// maxTreeDepth is the deepest level a descendant can be below its pocket
+const maxTreeDepth = constant.POCK_MAX_LEVEL - constant.POCK_ROOT_LEVEL
+
+// hierarchyLockKey is advisory lock key held by transaction changing pocket parent
+const hierarchyLockKey = 4420260026
+
+const queryDescendants = `WITH RECURSIVE tree AS (
+	SELECT id, parent_id, owner_id, editor_id, watcher_id, pocket_name, balance, currency, icon, level, created_at, updated_at, version, 1 AS depth
+	FROM pockets WHERE parent_id = $1
+	UNION ALL
+	SELECT p.id, p.parent_id, p.owner_id, p.editor_id, p.watcher_id, p.pocket_name, p.balance, p.currency, p.icon, p.level, p.created_at, p.updated_at, p.version, t.depth + 1
+	FROM pockets p JOIN tree t ON p.parent_id = t.id WHERE t.depth < $2
+)
+SELECT id, parent_id, owner_id, editor_id, watcher_id, pocket_name, balance, currency, icon, level, created_at, updated_at, version
+FROM tree ORDER BY level, id`
+
+const queryTrees = `WITH RECURSIVE tree AS (
+	SELECT id AS root_id, id, editor_id, watcher_id, balance, 0 AS depth FROM pockets WHERE id = ANY($1)
+	UNION ALL
+	SELECT t.root_id, p.id, p.editor_id, p.watcher_id, p.balance, t.depth + 1
+	FROM pockets p JOIN tree t ON p.parent_id = t.id WHERE t.depth < $2
+)
+SELECT root_id, id, editor_id, watcher_id, balance FROM tree ORDER BY root_id, depth, id`
+
+const queryShiftLevel = `WITH RECURSIVE tree AS (
+	SELECT id, 0 AS depth FROM pockets WHERE id = $1
+	UNION ALL
+	SELECT p.id, t.depth + 1 FROM pockets p JOIN tree t ON p.parent_id = t.id WHERE t.depth < $4
+)
+UPDATE pockets SET level = $2 + tree.depth, updated_at = $3 FROM tree WHERE pockets.id = tree.id`
+
+const queryPromoteChildren = `WITH RECURSIVE tree AS (
+	SELECT id, 0 AS depth FROM pockets WHERE parent_id = $1
+	UNION ALL
+	SELECT p.id, t.depth + 1 FROM pockets p JOIN tree t ON p.parent_id = t.id WHERE t.depth < $4
+)
+UPDATE pockets SET level = $2 + tree.depth, updated_at = $3 FROM tree WHERE pockets.id = tree.id`
+
+// FindChildren get direct sub-pockets of parentID
+func (r *Repo) FindChildren(ctx context.Context, parentID xulid.ULID) ([]model.Pocket, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindChildren")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyID,
+		keyParentID,
+		keyOwnerID,
+		keyEditorID,
+		keyWatcherID,
+		keyPocketName,
+		keyBalance,
+		keyCurrency,
+		keyIcon,
+		keyLevel,
+		keyCreatedAt,
+		keyUpdatedAt,
+		keyVersion,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyParentID: parentID}).
+		OrderBy(keyPocketName).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find children pocket: %w", err)
+	}
+
+	return r.queryPockets(ctx, sqlStatement, args...)
+}
+
+// FindDescendants get all sub-pockets of pocketID recursively, ordered by level.
+// pocketID itself is not included in result
+func (r *Repo) FindDescendants(ctx context.Context, pocketID xulid.ULID) ([]model.Pocket, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindDescendants")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return r.queryPockets(ctx, queryDescendants, pocketID, maxTreeDepth)
+}
+
+// FindTrees return every pocketIDs and all of its descendants, each with id of the pocket it is found under
+func (r *Repo) FindTrees(ctx context.Context, pocketIDs []xulid.ULID) ([]model.PocketNode, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindTrees")
+	defer span.End()
+
+	nodes := make([]model.PocketNode, 0)
+	if len(pocketIDs) == 0 {
+		return nodes, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	ids := make([]string, len(pocketIDs))
+	for i, id := range pocketIDs {
+		ids[i] = id.String()
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, queryTrees, ids, maxTreeDepth)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node model.PocketNode
+		err := rows.Scan(
+			&node.RootID,
+			&node.ID,
+			&node.EditorID,
+			&node.WatcherID,
+			&node.Balance,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// LockHierarchy serialize transaction changing pocket parent until it end, so two concurrent move
+// cannot validate against the same tree and create cycle together. must be called inside transaction
+func (r *Repo) LockHierarchy(ctx context.Context) error {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-LockHierarchy")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err := dbtx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", hierarchyLockKey)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+	return nil
+}
+
+// MoveParent change parent of pocket and recalculate level for pocket and all of its descendants.
+// level is the new level of pocket itself
+func (r *Repo) MoveParent(ctx context.Context, pocketID xulid.ULID, parentID xulid.NullULID, level int) error {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-MoveParent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	timeNow := time.Now()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		SetMap(sq.Eq{
+			keyParentID:  parentID,
+			keyUpdatedAt: timeNow,
+		}).
+		Where(sq.Eq{keyID: pocketID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query move parent pocket: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	_, err = dbtx.Exec(ctx, queryShiftLevel, pocketID, level, timeNow, maxTreeDepth)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// queryPockets execute query and scan all pocket column without count
+func (r *Repo) queryPockets(ctx context.Context, sqlStatement string, args ...any) ([]model.Pocket, error) {
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	pockets := make([]model.Pocket, 0)
+	for rows.Next() {
+		var pocket model.Pocket
+		err := rows.Scan(
+			&pocket.ID,
+			&pocket.ParentID,
+			&pocket.OwnerID,
+			&pocket.EditorID,
+			&pocket.WatcherID,
+			&pocket.PocketName,
+			&pocket.Balance,
+			&pocket.Currency,
+			&pocket.Icon,
+			&pocket.Level,
+			&pocket.CreatedAt,
+			&pocket.UpdatedAt,
+			&pocket.Version)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		pockets = append(pockets, pocket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pockets, nil
+}
+
+// FindAllByOwner get every pocket owned by ownerID without paging, ordered by level
+func (r *Repo) FindAllByOwner(ctx context.Context, ownerID xulid.ULID) ([]model.Pocket, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindAllByOwner")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyID,
+		keyParentID,
+		keyOwnerID,
+		keyEditorID,
+		keyWatcherID,
+		keyPocketName,
+		keyBalance,
+		keyCurrency,
+		keyIcon,
+		keyLevel,
+		keyCreatedAt,
+		keyUpdatedAt,
+		keyVersion,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyOwnerID: ownerID}).
+		OrderBy(keyLevel, keyPocketName).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find pocket by owner: %w", err)
+	}
+
+	return r.queryPockets(ctx, sqlStatement, args...)
+}
+
+// FindAllIDs return id of every pocket, used by maintenance job
+func (r *Repo) FindAllIDs(ctx context.Context) ([]xulid.ULID, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pocket-repo-FindAllIDs")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(keyID).
+		From(keyTable).
+		OrderBy(keyID).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find all pocket id: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	ids := make([]xulid.ULID, 0)
+	for rows.Next() {
+		var id xulid.ULID
+		if err := rows.Scan(&id); err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
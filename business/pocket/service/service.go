@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/muchlist/moneymagnet/business/pocket/model"
 	"github.com/muchlist/moneymagnet/business/pocket/port"
+	rtModel "github.com/muchlist/moneymagnet/business/realtime/model"
+	whModel "github.com/muchlist/moneymagnet/business/webhook/model"
 	"github.com/muchlist/moneymagnet/constant"
 	"github.com/muchlist/moneymagnet/pkg/db"
 	"github.com/muchlist/moneymagnet/pkg/errr"
@@ -24,7 +27,7 @@ import (
 // Set of error variables for CRUD operations.
 var (
 	ErrNotFound  = errors.New("data not found")
-	ErrInvalidID = errors.New("ID is not in its proper form")
+	ErrInvalidID = errr.T("error.invalid_id", nil)
 )
 
 // Core manages the set of APIs for user access.
@@ -33,6 +36,9 @@ type Core struct {
 	repo         port.PocketStorer
 	userRepo     port.UserReader
 	categoryRepo port.CategorySaver
+	emitter      port.EventEmitter
+	publisher    port.ChangePublisher
+	topicSyncer  port.TopicSyncer
 	txManager    port.Transactor
 }
 
@@ -42,6 +48,9 @@ func NewCore(
 	repo port.PocketStorer,
 	userRepo port.UserReader,
 	categoryRepo port.CategorySaver,
+	emitter port.EventEmitter,
+	publisher port.ChangePublisher,
+	topicSyncer port.TopicSyncer,
 	txManager port.Transactor,
 ) *Core {
 	return &Core{
@@ -49,6 +58,9 @@ func NewCore(
 		repo:         repo,
 		userRepo:     userRepo,
 		categoryRepo: categoryRepo,
+		emitter:      emitter,
+		publisher:    publisher,
+		topicSyncer:  topicSyncer,
 		txManager:    txManager,
 	}
 }
@@ -85,20 +97,21 @@ func (s *Core) CreatePocket(ctx context.Context, claims mjwt.CustomClaim, req mo
 			}
 		}
 		if !found {
-			return model.PocketResp{}, errr.New(fmt.Sprintf("ulid %s is not have valid user", id), 400)
+			return model.PocketResp{}, errr.NewT("error.invalid_user", map[string]string{"id": id}, 400)
 		}
 	}
 
 	timeNow := time.Now()
 	pocket := model.Pocket{
 		ID:         xulid.Instance().NewULID(),
+		ParentID:   req.ParentID,
 		OwnerID:    claims.GetULID(),
 		EditorID:   req.EditorID,
 		WatcherID:  req.WatcherID,
 		PocketName: req.PocketName,
 		Currency:   req.Currency,
 		Icon:       req.Icon,
-		Level:      1,
+		Level:      constant.POCK_ROOT_LEVEL,
 		CreatedAt:  timeNow,
 		UpdatedAt:  timeNow,
 		Version:    1,
@@ -108,6 +121,22 @@ func (s *Core) CreatePocket(ctx context.Context, claims mjwt.CustomClaim, req mo
 	transErr := s.txManager.WithAtomic(
 		ctx, func(ctx context.Context) error {
 
+			// Validate parent pocket if any. hierarchy lock is taken before the parent row lock,
+			// same order as pocket move and delete, so level of parent still hold when child is inserted
+			if req.ParentID.Valid {
+				if err := s.repo.LockHierarchy(ctx); err != nil {
+					return fmt.Errorf("lock pocket hierarchy: %w", err)
+				}
+				parent, err := s.repo.GetByIDForUpdate(ctx, req.ParentID.ULID)
+				if err != nil {
+					return fmt.Errorf("get parent pocket by id: %w", err)
+				}
+				pocket.Level, err = childLevel(claims, parent)
+				if err != nil {
+					return err
+				}
+			}
+
 			// insert pocket
 			err = s.repo.Insert(ctx, &pocket)
 			if err != nil {
@@ -125,6 +154,11 @@ func (s *Core) CreatePocket(ctx context.Context, claims mjwt.CustomClaim, req mo
 				return fmt.Errorf("loop insert pocket_user to db: %w", err)
 			}
 
+			err = s.topicSyncer.SyncTopics(ctx, uniqueUsers)
+			if err != nil {
+				return fmt.Errorf("sync push topic: %w", err)
+			}
+
 			return nil
 		},
 	)
@@ -133,42 +167,202 @@ func (s *Core) CreatePocket(ctx context.Context, claims mjwt.CustomClaim, req mo
 		return model.PocketResp{}, transErr
 	}
 
+	pocket.TotalBalance = pocket.Balance
+
 	return pocket.ToPocketResp(), nil
 }
 
+// childLevel return level of new pocket under parent, user must be editor of parent
+func childLevel(claims mjwt.CustomClaim, parent model.Pocket) (int, error) {
+	if !slicer.In(claims.GetULID().String(), parent.EditorID) {
+		return 0, errr.NewT("error.parent_pocket_access_denied", nil, 400)
+	}
+	level := parent.Level + 1
+	if level > constant.POCK_MAX_LEVEL {
+		return 0, errr.NewT("error.pocket_too_deep", map[string]string{"level": strconv.Itoa(constant.POCK_MAX_LEVEL)}, 400)
+	}
+	return level, nil
+}
+
 func (s *Core) UpdatePocket(ctx context.Context, claims mjwt.CustomClaim, newData model.PocketUpdate) (model.PocketResp, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "service-UpdatePocket")
 	defer span.End()
 
-	// Get existing Pocket
-	pocketExisting, err := s.repo.GetByID(ctx, newData.ID)
+	var pocketExisting model.Pocket
+	transErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		// hierarchy lock is taken before any row lock, same order as pocket delete
+		if newData.DetachParent || newData.ParentID.Valid {
+			if err := s.repo.LockHierarchy(ctx); err != nil {
+				return fmt.Errorf("lock pocket hierarchy: %w", err)
+			}
+		}
+
+		// Get existing Pocket, locked so validation below still hold when edited
+		var err error
+		pocketExisting, err = s.repo.GetByIDForUpdate(ctx, newData.ID)
+		if err != nil {
+			return fmt.Errorf("get pocket by id: %w", err)
+		}
+
+		// Validate Pocket Roles Editor
+		if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+			!claims.CanAccessPocket(pocketExisting.ID.String()) {
+			return errr.NewT("error.pocket_access_denied", nil, 400)
+		}
+
+		// Modify data
+		if newData.PocketName != nil {
+			pocketExisting.PocketName = *newData.PocketName
+		}
+		if newData.Currency != nil {
+			pocketExisting.Currency = *newData.Currency
+		}
+		if newData.Icon != nil {
+			pocketExisting.Icon = *newData.Icon
+		}
+
+		// Validate parent movement if any
+		isMoved, newLevel, err := s.validateMoveParent(ctx, claims, pocketExisting, newData)
+		if err != nil {
+			return err
+		}
+
+		// Edit
+		err = s.repo.Edit(ctx, &pocketExisting)
+		if err != nil {
+			return fmt.Errorf("edit pocket: %w", err)
+		}
+
+		if isMoved {
+			pocketExisting.ParentID = newData.ParentID
+			if newData.DetachParent {
+				pocketExisting.ParentID = xulid.NullULID{}
+			}
+			pocketExisting.Level = newLevel
+
+			err = s.repo.MoveParent(ctx, pocketExisting.ID, pocketExisting.ParentID, newLevel)
+			if err != nil {
+				return fmt.Errorf("move parent pocket: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if transErr != nil {
+		return model.PocketResp{}, transErr
+	}
+
+	totals, err := s.calculateTotals(ctx, claims, []xulid.ULID{pocketExisting.ID})
 	if err != nil {
-		return model.PocketResp{}, fmt.Errorf("get pocket by id: %w", err)
+		return model.PocketResp{}, err
 	}
+	pocketExisting.ApplyTotal(totals)
 
-	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.PocketResp{}, errr.New("not have access to this pocket", 400)
+	result := pocketExisting.ToPocketResp()
+	s.publisher.Publish(ctx, pocketExisting.ID, rtModel.EventPocketUpdated, result)
+
+	return result, nil
+}
+
+// validateMoveParent check if pocket can be moved under new parent (or to root when detached),
+// returning new level for moved pocket. isMoved false mean parent is not changed.
+// must be called inside transaction holding hierarchy lock
+func (s *Core) validateMoveParent(ctx context.Context, claims mjwt.CustomClaim, pocket model.Pocket, newData model.PocketUpdate) (isMoved bool, level int, err error) {
+	isMoved, err = parentChanged(pocket, newData)
+	if err != nil {
+		return false, 0, err
+	}
+	if !isMoved {
+		return false, pocket.Level, nil
+	}
+
+	descendants, err := s.repo.FindDescendants(ctx, pocket.ID)
+	if err != nil {
+		return false, 0, fmt.Errorf("find descendants pocket: %w", err)
+	}
+
+	var parent *model.Pocket
+	if newData.ParentID.Valid {
+		parentPocket, err := s.repo.GetByID(ctx, newData.ParentID.ULID)
+		if err != nil {
+			return false, 0, fmt.Errorf("get parent pocket by id: %w", err)
+		}
+		if !slicer.In(claims.GetULID().String(), parentPocket.EditorID) {
+			return false, 0, errr.NewT("error.parent_pocket_access_denied", nil, 400)
+		}
+		parent = &parentPocket
+	}
+
+	level, err = movedLevel(pocket, descendants, parent)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, level, nil
+}
+
+// parentChanged return true when newData move pocket to other parent or detach it to root
+func parentChanged(pocket model.Pocket, newData model.PocketUpdate) (bool, error) {
+	if !newData.DetachParent && !newData.ParentID.Valid {
+		return false, nil
+	}
+	if newData.DetachParent && newData.ParentID.Valid {
+		return false, errr.NewT("error.parent_conflict", nil, 400)
 	}
 
-	// Modify data
-	if newData.PocketName != nil {
-		pocketExisting.PocketName = *newData.PocketName
+	// nothing change
+	if newData.DetachParent && !pocket.ParentID.Valid {
+		return false, nil
 	}
-	if newData.Currency != nil {
-		pocketExisting.Currency = *newData.Currency
+	if newData.ParentID.Valid && pocket.ParentID.Valid && newData.ParentID.ULID == pocket.ParentID.ULID {
+		return false, nil
 	}
-	if newData.Icon != nil {
-		pocketExisting.Icon = *newData.Icon
+
+	if newData.ParentID.Valid && newData.ParentID.ULID == pocket.ID {
+		return false, errr.NewT("error.pocket_self_parent", nil, 400)
 	}
+	return true, nil
+}
 
-	// Edit
-	err = s.repo.Edit(ctx, &pocketExisting)
+// movedLevel return level of pocket when moved under parent, nil parent mean moved to root.
+// descendants is every sub-pocket of pocket, used to reject cycle and too deep tree
+func movedLevel(pocket model.Pocket, descendants []model.Pocket, parent *model.Pocket) (int, error) {
+	// subtreeDepth is how many level below the pocket
+	subtreeDepth := 0
+	for _, d := range descendants {
+		if parent != nil && d.ID == parent.ID {
+			return 0, errr.NewT("error.pocket_under_sub_pocket", nil, 400)
+		}
+		if d.Level-pocket.Level > subtreeDepth {
+			subtreeDepth = d.Level - pocket.Level
+		}
+	}
+
+	level := constant.POCK_ROOT_LEVEL
+	if parent != nil {
+		level = parent.Level + 1
+	}
+
+	if level+subtreeDepth > constant.POCK_MAX_LEVEL {
+		return 0, errr.NewT("error.pocket_too_deep", map[string]string{"level": strconv.Itoa(constant.POCK_MAX_LEVEL)}, 400)
+	}
+	return level, nil
+}
+
+// calculateTotals aggregate balance of pocketIDs and their sub-pockets visible to claims
+func (s *Core) calculateTotals(ctx context.Context, claims mjwt.CustomClaim, pocketIDs []xulid.ULID) (map[xulid.ULID]model.PocketTotal, error) {
+	nodes, err := s.repo.FindTrees(ctx, pocketIDs)
 	if err != nil {
-		return model.PocketResp{}, fmt.Errorf("edit pocket: %w", err)
+		return nil, fmt.Errorf("find pocket tree: %w", err)
 	}
+	return model.SumTotals(nodes, func(node model.PocketNode) bool {
+		return canSeeSubPocket(claims, node.ID, node.IsMember)
+	}), nil
+}
 
-	return pocketExisting.ToPocketResp(), nil
+// canSeeSubPocket return true when claims is member of sub-pocket and not limited from it,
+// being member of the parent is not enough
+func canSeeSubPocket(claims mjwt.CustomClaim, pocketID xulid.ULID, isMember func(userID string) bool) bool {
+	return isMember(claims.Identity) && claims.CanAccessPocket(pocketID.String())
 }
 
 func (s *Core) AddPerson(ctx context.Context, claims mjwt.CustomClaim, data AddPersonData) (model.PocketResp, error) {
@@ -182,15 +376,16 @@ func (s *Core) AddPerson(ctx context.Context, claims mjwt.CustomClaim, data AddP
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.PocketResp{}, errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return model.PocketResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	// Check if person to add is exist
 	_, err = s.userRepo.GetByID(ctx, data.Person)
 	if err != nil {
 		if errors.Is(err, db.ErrDBNotFound) {
-			return model.PocketResp{}, errr.New("account is not exist", 400)
+			return model.PocketResp{}, errr.NewT("error.account_not_exist", nil, 400)
 		}
 		return model.PocketResp{}, fmt.Errorf("get user by id : %w", err)
 	}
@@ -217,13 +412,33 @@ func (s *Core) AddPerson(ctx context.Context, claims mjwt.CustomClaim, data AddP
 			return fmt.Errorf("insert pocket_user to db: %w", err)
 		}
 
+		role := whModel.RoleEditor
+		if data.IsReadOnly {
+			role = whModel.RoleWatcher
+		}
+		err = s.emitter.Emit(ctx, whModel.EventMemberJoined, pocketExisting.ID, whModel.MemberJoined{
+			UserID: data.Person,
+			Role:   role,
+		})
+		if err != nil {
+			return fmt.Errorf("emit member joined: %w", err)
+		}
+
+		err = s.topicSyncer.SyncTopics(ctx, []string{data.Person.String()})
+		if err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
+
 		return nil
 	})
 	if transErr != nil {
 		return model.PocketResp{}, transErr
 	}
 
-	return pocketExisting.ToPocketResp(), nil
+	result := pocketExisting.ToPocketResp()
+	s.publisher.Publish(ctx, pocketExisting.ID, rtModel.EventPocketUpdated, result)
+
+	return result, nil
 }
 
 // RemovePerson will remove person from both editor and watcher
@@ -238,8 +453,9 @@ func (s *Core) RemovePerson(ctx context.Context, claims mjwt.CustomClaim, data R
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.PocketResp{}, errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return model.PocketResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	pocketExisting.EditorID = slicer.RemoveFrom(data.Person.String(), pocketExisting.EditorID)
@@ -258,13 +474,49 @@ func (s *Core) RemovePerson(ctx context.Context, claims mjwt.CustomClaim, data R
 		if err != nil {
 			return fmt.Errorf("delete pocket_user from db: %w", err)
 		}
+
+		err = s.topicSyncer.SyncTopics(ctx, []string{data.Person.String()})
+		if err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
 		return nil
 	})
 	if transErr != nil {
 		return model.PocketResp{}, transErr
 	}
 
-	return pocketExisting.ToPocketResp(), nil
+	result := pocketExisting.ToPocketResp()
+	s.publisher.Publish(ctx, pocketExisting.ID, rtModel.EventPocketUpdated, result)
+
+	return result, nil
+}
+
+// getMainPocket return default pocket of user preference,
+// fallback to first pocket if not set or no longer accessible
+func (s *Core) getMainPocket(ctx context.Context, claims mjwt.CustomClaim) (model.Pocket, error) {
+	pref, err := s.userRepo.GetPreference(ctx, claims.GetULID())
+	if err != nil {
+		return model.Pocket{}, fmt.Errorf("get preference: %w", err)
+	}
+
+	if pref.DefaultPocketID.Valid && claims.CanAccessPocket(pref.DefaultPocketID.ULID.String()) {
+		pocket, err := s.repo.GetByID(ctx, pref.DefaultPocketID.ULID)
+		if err == nil {
+			userID := claims.GetULID().String()
+			if slicer.In(userID, pocket.EditorID) || slicer.In(userID, pocket.WatcherID) {
+				return pocket, nil
+			}
+		}
+		if err != nil && !errors.Is(err, db.ErrDBNotFound) {
+			return model.Pocket{}, fmt.Errorf("get default pocket detail: %w", err)
+		}
+	}
+
+	pocket, err := s.repo.GetFirst(ctx, claims.Identity)
+	if err != nil {
+		return model.Pocket{}, fmt.Errorf("get first pocket detail: %w", err)
+	}
+	return pocket, nil
 }
 
 // GetDetail ...
@@ -276,10 +528,10 @@ func (s *Core) GetDetail(ctx context.Context, claims mjwt.CustomClaim, pocketID
 	var err error
 
 	if pocketID.String() == constant.POCK_MAIN_ID {
-		// If ULID is default, get first pocket available
-		pocketDetail, err = s.repo.GetFirst(ctx, claims.Identity)
+		// If ULID is default, use default pocket from preference or get first pocket available
+		pocketDetail, err = s.getMainPocket(ctx, claims)
 		if err != nil {
-			return model.PocketResp{}, fmt.Errorf("get first pocket detail: %w", err)
+			return model.PocketResp{}, err
 		}
 	} else {
 		// Get existing Pocket by ID
@@ -290,8 +542,9 @@ func (s *Core) GetDetail(ctx context.Context, claims mjwt.CustomClaim, pocketID
 	}
 
 	// Validate Pocket Roles Watcher or Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketDetail.WatcherID) && !slicer.In(xulid.MustParse(claims.Identity).String(), pocketDetail.EditorID) {
-		return model.PocketResp{}, errr.New("not have access to this pocket", 400)
+	if (!slicer.In(xulid.MustParse(claims.Identity).String(), pocketDetail.WatcherID) && !slicer.In(xulid.MustParse(claims.Identity).String(), pocketDetail.EditorID)) ||
+		!claims.CanAccessPocket(pocketDetail.ID.String()) {
+		return model.PocketResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	// Get all users id
@@ -328,6 +581,35 @@ func (s *Core) GetDetail(ctx context.Context, claims mjwt.CustomClaim, pocketID
 
 	pocketDetail.Users = userEditors
 
+	// Get sub-pockets visible to user and aggregate balances
+	allChildren, err := s.repo.FindChildren(ctx, pocketDetail.ID)
+	if err != nil {
+		return model.PocketResp{}, fmt.Errorf("find sub-pocket: %w", err)
+	}
+	children := make([]model.Pocket, 0, len(allChildren))
+	for _, c := range allChildren {
+		if canSeeSubPocket(claims, c.ID, c.IsMember) {
+			children = append(children, c)
+		}
+	}
+
+	pocketIDs := make([]xulid.ULID, 0, len(children)+1)
+	pocketIDs = append(pocketIDs, pocketDetail.ID)
+	for _, c := range children {
+		pocketIDs = append(pocketIDs, c.ID)
+	}
+
+	totals, err := s.calculateTotals(ctx, claims, pocketIDs)
+	if err != nil {
+		return model.PocketResp{}, err
+	}
+
+	pocketDetail.ApplyTotal(totals)
+	for i := range children {
+		children[i].ApplyTotal(totals)
+	}
+	pocketDetail.SubPockets = children
+
 	return pocketDetail.ToPocketResp(), nil
 }
 
@@ -336,8 +618,8 @@ func (s *Core) FindAllPocket(ctx context.Context, claims mjwt.CustomClaim, filte
 	ctx, span := observ.GetTracer().Start(ctx, "service-FindAllPocket")
 	defer span.End()
 
-	// Get existing Pocket
-	pockets, metadata, err := s.repo.FindUserPocketsByRelation(ctx, claims.GetULID(), filter)
+	// Get existing Pocket, filtered by query so paging is not broken by pockets dropped afterward
+	pockets, metadata, err := s.repo.FindUserPocketsByRelation(ctx, claims.GetULID(), limitedPocketIDs(claims), filter)
 	if err != nil {
 		return nil, paging.Metadata{}, fmt.Errorf("find pocket user: %w", err)
 	}
@@ -381,6 +663,19 @@ func (s *Core) FindAllPocket(ctx context.Context, claims mjwt.CustomClaim, filte
 		pockets[i].Users = userEditors
 	}
 
+	// Aggregate balances from sub-pockets
+	pocketIDs := make([]xulid.ULID, len(pockets))
+	for i := range pockets {
+		pocketIDs[i] = pockets[i].ID
+	}
+	totals, err := s.calculateTotals(ctx, claims, pocketIDs)
+	if err != nil {
+		return nil, paging.Metadata{}, err
+	}
+	for i := range pockets {
+		pockets[i].ApplyTotal(totals)
+	}
+
 	pocketResult := make([]model.PocketResp, len(pockets))
 	for i := range pockets {
 		pocketResult[i] = pockets[i].ToPocketResp()
@@ -388,3 +683,18 @@ func (s *Core) FindAllPocket(ctx context.Context, claims mjwt.CustomClaim, filte
 
 	return pocketResult, metadata, nil
 }
+
+// limitedPocketIDs return pockets personal access token is limited to, nil mean all pocket.
+// token limited only to invalid ids get empty slice so it still see nothing
+func limitedPocketIDs(claims mjwt.CustomClaim) []xulid.ULID {
+	if len(claims.PocketIDs) == 0 {
+		return nil
+	}
+	pocketIDs := make([]xulid.ULID, 0, len(claims.PocketIDs))
+	for _, id := range claims.PocketIDs {
+		if pocketID, err := xulid.Parse(id); err == nil {
+			pocketIDs = append(pocketIDs, pocketID)
+		}
+	}
+	return pocketIDs
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/constant"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+func TestParentChanged(t *testing.T) {
+	parentID := xulid.Instance().NewULID()
+	otherID := xulid.Instance().NewULID()
+	pocket := model.Pocket{ID: xulid.Instance().NewULID(), ParentID: xulid.NullULID{ULID: parentID, Valid: true}}
+	root := model.Pocket{ID: xulid.Instance().NewULID()}
+
+	tests := []struct {
+		name    string
+		pocket  model.Pocket
+		req     model.PocketUpdate
+		want    bool
+		wantErr bool
+	}{
+		{name: "no parent field", pocket: pocket, req: model.PocketUpdate{}},
+		{name: "same parent", pocket: pocket, req: model.PocketUpdate{ParentID: xulid.NullULID{ULID: parentID, Valid: true}}},
+		{name: "detach root", pocket: root, req: model.PocketUpdate{DetachParent: true}},
+		{name: "other parent", pocket: pocket, req: model.PocketUpdate{ParentID: xulid.NullULID{ULID: otherID, Valid: true}}, want: true},
+		{name: "detach sub-pocket", pocket: pocket, req: model.PocketUpdate{DetachParent: true}, want: true},
+		{name: "itself", pocket: pocket, req: model.PocketUpdate{ParentID: xulid.NullULID{ULID: pocket.ID, Valid: true}}, wantErr: true},
+		{name: "both", pocket: pocket, req: model.PocketUpdate{DetachParent: true, ParentID: xulid.NullULID{ULID: otherID, Valid: true}}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parentChanged(tc.pocket, tc.req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parentChanged() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("parentChanged() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMovedLevel(t *testing.T) {
+	pocket := model.Pocket{ID: xulid.Instance().NewULID(), Level: 2}
+	child := model.Pocket{ID: xulid.Instance().NewULID(), Level: 3}
+	grandChild := model.Pocket{ID: xulid.Instance().NewULID(), Level: 4}
+	rootParent := model.Pocket{ID: xulid.Instance().NewULID(), Level: 1}
+	deepParent := model.Pocket{ID: xulid.Instance().NewULID(), Level: 2}
+
+	tests := []struct {
+		name        string
+		descendants []model.Pocket
+		parent      *model.Pocket
+		want        int
+		wantErr     bool
+	}{
+		{name: "to root", descendants: []model.Pocket{child, grandChild}, want: 1},
+		{name: "under root", descendants: []model.Pocket{child}, parent: &rootParent, want: 2},
+		{name: "under its sub-pocket", descendants: []model.Pocket{child, grandChild}, parent: &grandChild, wantErr: true},
+		{name: "too deep", descendants: []model.Pocket{child, grandChild}, parent: &deepParent, wantErr: true},
+		{name: "deepest allowed", descendants: []model.Pocket{child}, parent: &deepParent, want: 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := movedLevel(pocket, tc.descendants, tc.parent)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("movedLevel() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("movedLevel() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChildLevel(t *testing.T) {
+	userID := xulid.Instance().NewULID().String()
+	claims := mjwt.CustomClaim{Identity: userID}
+
+	tests := []struct {
+		name    string
+		parent  model.Pocket
+		want    int
+		wantErr bool
+	}{
+		{name: "under root", parent: model.Pocket{Level: constant.POCK_ROOT_LEVEL, EditorID: []string{userID}}, want: constant.POCK_ROOT_LEVEL + 1},
+		{name: "deepest allowed", parent: model.Pocket{Level: constant.POCK_MAX_LEVEL - 1, EditorID: []string{userID}}, want: constant.POCK_MAX_LEVEL},
+		{name: "too deep", parent: model.Pocket{Level: constant.POCK_MAX_LEVEL, EditorID: []string{userID}}, wantErr: true},
+		{name: "watcher of parent", parent: model.Pocket{Level: constant.POCK_ROOT_LEVEL, WatcherID: []string{userID}}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := childLevel(claims, tc.parent)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("childLevel() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("childLevel() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSumTotals(t *testing.T) {
+	userID := xulid.Instance().NewULID().String()
+	otherID := xulid.Instance().NewULID().String()
+	rootA := xulid.Instance().NewULID()
+	rootB := xulid.Instance().NewULID()
+	shared := xulid.Instance().NewULID()
+	hidden := xulid.Instance().NewULID()
+	limited := xulid.Instance().NewULID()
+
+	nodes := []model.PocketNode{
+		{RootID: rootA, ID: rootA, EditorID: []string{userID}, Balance: 100},
+		{RootID: rootA, ID: shared, WatcherID: []string{userID}, Balance: 20},
+		{RootID: rootA, ID: hidden, EditorID: []string{otherID}, Balance: 5000},
+		{RootID: rootA, ID: limited, EditorID: []string{userID}, Balance: 300},
+		// root is counted even when the node itself does not list user
+		{RootID: rootB, ID: rootB, EditorID: []string{otherID}, Balance: 7},
+	}
+
+	claims := mjwt.CustomClaim{Identity: userID}
+	totals := model.SumTotals(nodes, func(node model.PocketNode) bool {
+		return canSeeSubPocket(claims, node.ID, node.IsMember)
+	})
+	if got := totals[rootA]; got.TotalBalance != 420 || got.DescendantCount != 2 {
+		t.Errorf("total of root A = %+v, want balance 420 and 2 descendant", got)
+	}
+	if got := totals[rootB]; got.TotalBalance != 7 || got.DescendantCount != 0 {
+		t.Errorf("total of root B = %+v, want balance 7 and no descendant", got)
+	}
+
+	// token limited to some pockets does not see the other sub-pockets
+	claims.PocketIDs = []string{rootA.String(), shared.String()}
+	totals = model.SumTotals(nodes, func(node model.PocketNode) bool {
+		return canSeeSubPocket(claims, node.ID, node.IsMember)
+	})
+	if got := totals[rootA]; got.TotalBalance != 120 || got.DescendantCount != 1 {
+		t.Errorf("limited total of root A = %+v, want balance 120 and 1 descendant", got)
+	}
+}
+
+func TestLimitedPocketIDs(t *testing.T) {
+	pocketID := xulid.Instance().NewULID()
+
+	tests := []struct {
+		name      string
+		pocketIDs []string
+		want      []xulid.ULID
+	}{
+		{name: "not limited", pocketIDs: nil, want: nil},
+		{name: "limited", pocketIDs: []string{pocketID.String()}, want: []xulid.ULID{pocketID}},
+		{name: "only invalid id", pocketIDs: []string{"not-an-ulid"}, want: []xulid.ULID{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := limitedPocketIDs(mjwt.CustomClaim{PocketIDs: tc.pocketIDs})
+			if (got == nil) != (tc.want == nil) {
+				t.Fatalf("limitedPocketIDs() = %v, want %v", got, tc.want)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("limitedPocketIDs() len = %d, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("limitedPocketIDs()[%d] = %v, want %v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
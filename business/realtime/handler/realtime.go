@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/realtime/service"
+	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/mid"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+const (
+	// pingInterval keep connection busy so proxy does not close it as idle
+	pingInterval = 25 * time.Second
+	// retryInterval is wait of client before reconnecting
+	retryInterval = 3 * time.Second
+)
+
+func NewRealtimeHandler(log mlogger.Logger,
+	realtimeService *service.Core) realtimeHandler {
+	return realtimeHandler{
+		log:     log,
+		service: realtimeService,
+	}
+}
+
+type realtimeHandler struct {
+	log     mlogger.Logger
+	service *service.Core
+}
+
+// @Summary      Stream Pocket Events
+// @Description  Server-sent event of spend, pocket and category change in pocket, watcher or editor only.
+// @Description  every event has id, send it back as Last-Event-ID header (or last_event_id query) when reconnecting to get missed event.
+// @Description  stream is ended when access token expired or user is removed from pocket
+// @Tags         Pocket
+// @Produce      text/event-stream
+// @Param		 pocket_id path string true "pocket_id"
+// @Param		 Last-Event-ID header string false "id of last received event"
+// @Param		 last_event_id query string false "id of last received event"
+// @Success      200
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /pockets/{pocket_id}/events [get]
+func (rh realtimeHandler) StreamPocketEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-StreamPocketEvents")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	pocketID, err := web.ReadULIDParam(r)
+	if err != nil {
+		rh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = web.ReadString(r.URL.Query(), "last_event_id", "")
+	}
+
+	events, err := rh.service.Subscribe(ctx, claims, pocketID, lastEventID)
+	if err != nil {
+		rh.log.ErrorT(ctx, "error subscribe pocket event", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+
+	stream, err := web.NewEventStream(w)
+	if err != nil {
+		rh.log.WarnT(ctx, "error start event stream", err)
+		return
+	}
+	if err := stream.Retry(retryInterval); err != nil {
+		return
+	}
+
+	// token is only checked when connecting, so stream must not outlive it.
+	// personal access token without expiry has zero exp
+	var expired <-chan time.Time
+	if claims.Exp != 0 {
+		expiredTimer := time.NewTimer(time.Until(time.Unix(claims.Exp, 0)))
+		defer expiredTimer.Stop()
+		expired = expiredTimer.C
+	}
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			return
+		case <-ping.C:
+			if err := stream.Ping(); err != nil {
+				return
+			}
+		case ev, ok := <-events:
+			// closed by server shutdown, membership change or too slow reading,
+			// client reconnect and resume with last event id
+			if !ok {
+				return
+			}
+			if err := stream.Send(ev.ID, ev.Type, ev.Data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// event type pushed to member of pocket
+const (
+	EventSpendCreated    = "spend.created"    // data is spend
+	EventSpendUpdated    = "spend.updated"    // data is spend
+	EventSpendDeleted    = "spend.deleted"    // data is spend before deleted
+	EventPocketUpdated   = "pocket.updated"   // data is pocket
+	EventCategoryCreated = "category.created" // data is category
+	EventCategoryUpdated = "category.updated" // data is category or category override
+	EventCategoryDeleted = "category.deleted" // data is merge category result
+)
+
+// PocketTopic return pubsub topic of pocket event
+func PocketTopic(pocketID xulid.ULID) string {
+	return "pocket:" + pocketID.String()
+}
@@ -0,0 +1,13 @@
+package port
+
+import (
+	"context"
+
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// PocketReader implemented by pocket repo
+type PocketReader interface {
+	GetByID(ctx context.Context, id xulid.ULID) (ptmodel.Pocket, error)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/business/realtime/model"
+	"github.com/muchlist/moneymagnet/business/realtime/port"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/pubsub"
+	"github.com/muchlist/moneymagnet/pkg/slicer"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// Core manages the set of APIs for realtime pocket event.
+type Core struct {
+	log        mlogger.Logger
+	hub        pubsub.Hub
+	pocketRepo port.PocketReader
+}
+
+// NewCore constructs a core for realtime pocket event.
+func NewCore(
+	log mlogger.Logger,
+	hub pubsub.Hub,
+	pocketRepo port.PocketReader,
+) *Core {
+	return &Core{
+		log:        log,
+		hub:        hub,
+		pocketRepo: pocketRepo,
+	}
+}
+
+// Publish push change of pocket to connected member.
+// called after the change is committed, it is best effort so error is only logged
+func (s *Core) Publish(ctx context.Context, pocketID xulid.ULID, event string, data any) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-Publish")
+	defer span.End()
+
+	err := s.hub.Publish(ctx, model.PocketTopic(pocketID), event, data)
+	if err != nil {
+		s.log.WarnT(ctx, fmt.Sprintf("publish %s of pocket %s", event, pocketID), err)
+	}
+}
+
+// Subscribe return event of pocket after lastEventID, claims must be member of pocket.
+// stream is ended when member is removed from pocket
+func (s *Core) Subscribe(ctx context.Context, claims mjwt.CustomClaim, pocketID xulid.ULID, lastEventID string) (<-chan pubsub.Event, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-Subscribe")
+	defer span.End()
+
+	pocket, err := s.pocketRepo.GetByID(ctx, pocketID)
+	if err != nil {
+		return nil, fmt.Errorf("get pocket by id: %w", err)
+	}
+	if !isMember(claims, pocket) {
+		return nil, errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
+	// subscription is also stopped when watch end the stream
+	ctx, cancel := context.WithCancel(ctx)
+	events, err := s.hub.Subscribe(ctx, model.PocketTopic(pocketID), lastEventID)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("subscribe pocket event: %w", err)
+	}
+
+	return s.watchMembership(ctx, cancel, claims, pocketID, events), nil
+}
+
+// watchMembership forward events and stop when pocket.updated show claims is no longer member
+func (s *Core) watchMembership(ctx context.Context, cancel context.CancelFunc, claims mjwt.CustomClaim, pocketID xulid.ULID, events <-chan pubsub.Event) <-chan pubsub.Event {
+	out := make(chan pubsub.Event)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		for ev := range events {
+			if ev.Type == model.EventPocketUpdated {
+				pocket, err := s.pocketRepo.GetByID(ctx, pocketID)
+				if err != nil || !isMember(claims, pocket) {
+					return
+				}
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func isMember(claims mjwt.CustomClaim, pocket ptmodel.Pocket) bool {
+	return (slicer.In(claims.Identity, pocket.EditorID) || slicer.In(claims.Identity, pocket.WatcherID)) &&
+		claims.CanAccessPocket(pocket.ID.String())
+}
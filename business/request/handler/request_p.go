@@ -59,7 +59,7 @@ func (pt requestHandler) CreateRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := pt.validator.Struct(req)
+	errMap, err := pt.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		pt.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -0,0 +1,12 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// EventEmitter implemented by webhook service, it save delivery in the same transaction when ctx carry one
+type EventEmitter interface {
+	Emit(ctx context.Context, event string, pocketID xulid.ULID, data any) error
+}
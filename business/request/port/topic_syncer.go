@@ -0,0 +1,9 @@
+package port
+
+import "context"
+
+// TopicSyncer implemented by notification topic service, it enqueue push topic sync of user
+// whose pocket membership or fcm token changed in the same transaction when ctx carry one
+type TopicSyncer interface {
+	SyncTopics(ctx context.Context, userIDs []string) error
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/muchlist/moneymagnet/business/request/model"
 	"github.com/muchlist/moneymagnet/business/request/port"
+	whModel "github.com/muchlist/moneymagnet/business/webhook/model"
 	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/mjwt"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
@@ -17,10 +18,12 @@ import (
 
 // Core manages the set of APIs for request access.
 type Core struct {
-	log        mlogger.Logger
-	repo       port.RequestStorer
-	pocketRepo port.PocketStorer
-	txManager  port.Transactor
+	log         mlogger.Logger
+	repo        port.RequestStorer
+	pocketRepo  port.PocketStorer
+	emitter     port.EventEmitter
+	topicSyncer port.TopicSyncer
+	txManager   port.Transactor
 }
 
 // NewCore constructs a core for request api access.
@@ -28,13 +31,17 @@ func NewCore(
 	log mlogger.Logger,
 	repo port.RequestStorer,
 	pocketRepo port.PocketStorer,
+	emitter port.EventEmitter,
+	topicSyncer port.TopicSyncer,
 	txManager port.Transactor,
 ) *Core {
 	return &Core{
-		log:        log,
-		repo:       repo,
-		pocketRepo: pocketRepo,
-		txManager:  txManager,
+		log:         log,
+		repo:        repo,
+		pocketRepo:  pocketRepo,
+		emitter:     emitter,
+		topicSyncer: topicSyncer,
+		txManager:   txManager,
 	}
 }
 
@@ -78,12 +85,12 @@ func (s *Core) ApproveRequest(ctx context.Context, claims mjwt.CustomClaim, IsAp
 	}
 
 	if *req.ApproverID != claims.GetULID() {
-		return errr.New("the user does not have access rights to approve this request", 400)
+		return errr.NewT("error.request_approve_denied", nil, 400)
 	}
 
 	// check if either have true value
 	if req.IsApproved || req.IsRejected {
-		return errr.New("This request has been processed before", 400)
+		return errr.NewT("error.request_processed", nil, 400)
 	}
 
 	if IsApproved {
@@ -128,6 +135,19 @@ func (s *Core) ApproveRequest(ctx context.Context, claims mjwt.CustomClaim, IsAp
 			return fmt.Errorf("insert pocket_user to db: %w", err)
 		}
 
+		err = s.emitter.Emit(ctx, whModel.EventMemberJoined, pocketExisting.ID, whModel.MemberJoined{
+			UserID: req.RequesterID,
+			Role:   whModel.RoleEditor,
+		})
+		if err != nil {
+			return fmt.Errorf("emit member joined: %w", err)
+		}
+
+		err = s.topicSyncer.SyncTopics(ctx, []string{req.RequesterID.String()})
+		if err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
+
 		return nil
 	})
 
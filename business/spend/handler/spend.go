@@ -7,6 +7,7 @@ import (
 	"github.com/muchlist/moneymagnet/business/spend/model"
 	"github.com/muchlist/moneymagnet/business/spend/service"
 	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/convert"
 	"github.com/muchlist/moneymagnet/pkg/lrucache"
 	"github.com/muchlist/moneymagnet/pkg/mid"
 	"github.com/muchlist/moneymagnet/pkg/observ"
@@ -64,7 +65,7 @@ func (pt *spendHandler) CreateSpend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := pt.validator.Struct(req)
+	errMap, err := pt.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		pt.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -117,7 +118,7 @@ func (pt *spendHandler) TransferSpend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := pt.validator.Struct(req)
+	errMap, err := pt.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		pt.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -181,7 +182,7 @@ func (pt *spendHandler) EditSpend(w http.ResponseWriter, r *http.Request) {
 
 	req.ID = spendID
 
-	errMap, err := pt.validator.Struct(req)
+	errMap, err := pt.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		pt.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -344,6 +345,7 @@ func (pt *spendHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param 		 page query int false "page"
 // @Param 		 page_size query int false "page-size"
 // @Param 		 sort query string false "sort"
+// @Param 		 include_children query bool false "include spend from sub-pockets"
 // @Param 		 user query string false "user"
 // @Param 		 category query string false "category"
 // @Param 		 is_income query bool false "is_income"
@@ -410,6 +412,7 @@ func (pt *spendHandler) FindSpend(w http.ResponseWriter, r *http.Request) {
 // @Param 		 cursor query string false "cursor"
 // @Param 		 cursor_type query string false "cursor_type"
 // @Param 		 page_size query int false "page-size"
+// @Param 		 include_children query bool false "include spend from sub-pockets"
 // @Param 		 user query string false "user"
 // @Param 		 category query string false "category"
 // @Param 		 is_income query bool false "is_income"
@@ -559,8 +562,9 @@ func (pt *spendHandler) SearchSpends(w http.ResponseWriter, r *http.Request) {
 // @Param 		 cursor query string false "cursor"
 // @Param 		 cursor_type query string false "cursor_type"
 // @Param 		 page_size query int false "page-size"
-// @Param 		 range_type query string true "last-7-days, 2024-1, 2024-2"
-// @Param 		 time_zone query string true "Asia/Makasar"
+// @Param 		 range_type query string true "last-7-days, this-week, last-week, 2024-1, 2024-2"
+// @Param 		 time_zone query string false "Asia/Makasar, default from user preference"
+// @Param 		 include_children query bool false "include spend from sub-pockets"
 // @Success      200  {object}  misc.ResponseSuccessListCursor{data=[]model.SpendResp}
 // @Failure      400  {object}  misc.ResponseErr
 // @Failure      500  {object}  misc.Response500Err
@@ -590,6 +594,7 @@ func (pt *spendHandler) FindSpendAutoDateByCursor(w http.ResponseWriter, r *http
 	rangeType := web.ReadString(queryValues, "range_type", "")
 	timeZone := web.ReadString(queryValues, "time_zone", "")
 	eTag := web.ReadString(queryValues, "etag", "")
+	includeChildren := convert.StringToBool(web.ReadString(queryValues, "include_children", ""))
 
 	cursorDataInput := paging.Cursor{}
 	cursorDataInput.SetCursorList([]string{"-date", "date", "-id", "id"})
@@ -604,12 +609,13 @@ func (pt *spendHandler) FindSpendAutoDateByCursor(w http.ResponseWriter, r *http
 	}
 
 	result, metadata, err := pt.service.FindAllSpendByCursorAutoDateRange(ctx, service.AutoDateRangeParams{
-		PocketID:  pocketID,
-		Claims:    claims,
-		Filter:    cursorDataInput,
-		RangeType: rangeType,
-		TimeZone:  timeZone,
-		ETag:      eTag,
+		PocketID:        pocketID,
+		IncludeChildren: includeChildren,
+		Claims:          claims,
+		Filter:          cursorDataInput,
+		RangeType:       rangeType,
+		TimeZone:        timeZone,
+		ETag:            eTag,
 	})
 	if err != nil {
 		pt.log.ErrorT(ctx, "error find spend by cursor auto date", err)
@@ -630,3 +636,57 @@ func (pt *spendHandler) FindSpendAutoDateByCursor(w http.ResponseWriter, r *http
 		return
 	}
 }
+
+// @Summary      Summary Spend By Category
+// @Description  Sum spend per top-level category, spend in sub-category is rolled up to its parent
+// @Tags         Spend
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "pocket_id"
+// @Param 		 include_children query bool false "include spend from sub-pockets"
+// @Param 		 user query string false "user"
+// @Param 		 category query string false "category"
+// @Param 		 is_income query bool false "is_income"
+// @Param 		 type query string false "type"
+// @Param 		 date_start query int false "date_start"
+// @Param 		 date_end query int false "date_end"
+// @Success      200  {object}  misc.ResponseSuccess{data=[]model.CategorySummaryResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /spends/from-pocket/{id}/summary-category [get]
+func (pt *spendHandler) SummaryByCategory(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-SummaryByCategory")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	pocketID, err := web.ReadULIDParam(r)
+	if err != nil {
+		pt.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := extractSpendFilter(r.URL.Query())
+	filter.PocketID.ULID = pocketID
+
+	result, err := pt.service.SummaryByCategory(ctx, claims, filter)
+	if err != nil {
+		pt.log.ErrorT(ctx, "error summary spend by category", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
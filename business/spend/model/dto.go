@@ -51,3 +51,17 @@ type UpdateSpend struct {
 	SpendType  *int           `json:"type" example:"2"`
 	Date       *time.Time     `json:"date" example:"2022-09-10T17:03:15.091267+08:00"`
 }
+
+type CategorySummaryResp struct {
+	CategoryID   xulid.NullULID `json:"category_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	CategoryName string         `json:"category_name" example:"Bills"`
+	CategoryIcon int            `json:"category_icon" example:"0"`
+	IsIncome     bool           `json:"is_income" example:"false"`
+	TotalPrice   int64          `json:"total_price" example:"-750000"`
+	SpendCount   int            `json:"spend_count" example:"3"`
+}
+
+type BalanceSyncResp struct {
+	PocketID xulid.ULID `json:"pocket_id" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	Balance  int64      `json:"balance" example:"-750000"`
+}
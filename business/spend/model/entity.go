@@ -48,3 +48,29 @@ func (s *Spend) ToResp() SpendResp {
 		Version:          s.Version,
 	}
 }
+
+// CategorySummary is total spend of root category, include its sub-categories
+type CategorySummary struct {
+	CategoryID   xulid.NullULID // null for spend without category
+	CategoryName string
+	CategoryIcon int
+	IsIncome     bool
+	TotalPrice   int64
+	SpendCount   int
+}
+
+func (c *CategorySummary) ToResp() CategorySummaryResp {
+	return CategorySummaryResp{
+		CategoryID:   c.CategoryID,
+		CategoryName: c.CategoryName,
+		CategoryIcon: c.CategoryIcon,
+		IsIncome:     c.IsIncome,
+		TotalPrice:   c.TotalPrice,
+		SpendCount:   c.SpendCount,
+	}
+}
+
+// ETagJob is payload of durable job that refresh eTag of pockets after their spends change
+type ETagJob struct {
+	PocketIDs []xulid.ULID `json:"pocket_ids"`
+}
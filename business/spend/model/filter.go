@@ -10,24 +10,27 @@ import (
 )
 
 type SpendFilter struct {
-	PocketID  xulid.NullULID
-	User      xulid.NullULID
-	Category  xulid.NullULID
-	Name      string
-	IsIncome  *bool
-	Type      []int
-	DateStart *time.Time
-	DateEnd   *time.Time
+	PocketID        xulid.NullULID
+	IncludeChildren bool         // include spend from sub-pockets
+	SubPocketIDs    []xulid.ULID // resolved by service when IncludeChildren is true
+	User            xulid.NullULID
+	Category        xulid.NullULID
+	Name            string
+	IsIncome        *bool
+	Type            []int
+	DateStart       *time.Time
+	DateEnd         *time.Time
 }
 
 type SpendFilterRaw struct {
-	User      string
-	Category  string
-	Name      string
-	IsIncome  string
-	Type      string
-	DateStart string
-	DateEnd   string
+	IncludeChildren string
+	User            string
+	Category        string
+	Name            string
+	IsIncome        string
+	Type            string
+	DateStart       string
+	DateEnd         string
 }
 
 func (p SpendFilterRaw) ToModel() SpendFilter {
@@ -53,6 +56,8 @@ func (p SpendFilterRaw) ToModel() SpendFilter {
 
 	result.Name = strings.ToUpper(p.Name)
 
+	result.IncludeChildren = convert.StringToBool(p.IncludeChildren)
+
 	result.IsIncome = convert.StringToPtrBool(p.IsIncome)
 
 	result.Type, _ = slicer.CsvToSliceInt(p.Type)
@@ -69,3 +74,11 @@ func (p SpendFilterRaw) ToModel() SpendFilter {
 
 	return result
 }
+
+// PocketIDs return pocket id and its sub-pocket ids for where clause
+func (p SpendFilter) PocketIDs() []xulid.ULID {
+	ids := make([]xulid.ULID, 0, len(p.SubPocketIDs)+1)
+	ids = append(ids, p.PocketID.ULID)
+	ids = append(ids, p.SubPocketIDs...)
+	return ids
+}
@@ -0,0 +1,12 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// ChangePublisher implemented by realtime service, called after change is committed
+type ChangePublisher interface {
+	Publish(ctx context.Context, pocketID xulid.ULID, event string, data any)
+}
@@ -0,0 +1,8 @@
+package port
+
+import "context"
+
+// JobEnqueuer save durable job, in the same transaction when ctx carry one
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, jobType string, payload any) error
+}
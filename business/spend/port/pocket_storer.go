@@ -11,7 +11,9 @@ import (
 type PocketStorer interface {
 	GetByID(ctx context.Context, id xulid.ULID) (model.Pocket, error)
 	Find(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error)
-	FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error)
+	FindUserPocketsByRelation(ctx context.Context, owner xulid.ULID, pocketIDs []xulid.ULID, filter paging.Filters) ([]model.Pocket, paging.Metadata, error)
+	FindDescendants(ctx context.Context, pocketID xulid.ULID) ([]model.Pocket, error)
+	FindAllIDs(ctx context.Context) ([]xulid.ULID, error)
 
 	UpdateBalance(ctx context.Context, pocketid xulid.ULID, balance int64, isSetOperaton bool) (int64, error)
 }
@@ -0,0 +1,12 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type PreferenceReader interface {
+	GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error)
+}
@@ -25,6 +25,7 @@ type SpendReader interface {
 	FindWithCursor(ctx context.Context, spendFilter model.SpendFilter, filter paging.Cursor) ([]model.Spend, error)
 	FindWithCursorMultiPockets(ctx context.Context, spendFilter model.SpendFilterMultiPocket, filter paging.Cursor) ([]model.Spend, error)
 	CountAllPrice(ctx context.Context, pocketid xulid.ULID) (int64, error)
+	SummaryByCategory(ctx context.Context, spendFilter model.SpendFilter) ([]model.CategorySummary, error)
 }
 
 type Transactor interface {
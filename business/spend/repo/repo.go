@@ -204,7 +204,7 @@ func (r *Repo) GetByID(ctx context.Context, id xulid.ULID) (model.Spend, error)
 		db.A(keyCreatedAt),
 		db.A(keyUpdatedAt),
 		db.A(keyVersion),
-		db.B("name"),
+		db.CoalesceString(db.B("name"), ""),
 		db.C("pocket_name"),
 		db.CoalesceString(db.D("category_name"), ""),
 		db.CoalesceInt(db.D("category_icon"), 0),
@@ -280,7 +280,7 @@ func (r *Repo) Find(ctx context.Context, spendFilter model.SpendFilter, filter p
 		db.A(keyCreatedAt),
 		db.A(keyUpdatedAt),
 		db.A(keyVersion),
-		db.B("name"),
+		db.CoalesceString(db.B("name"), ""),
 		db.C("pocket_name"),
 		db.CoalesceString(db.D("category_name"), ""),
 		db.CoalesceInt(db.D("category_icon"), 0),
@@ -292,7 +292,7 @@ func (r *Repo) Find(ctx context.Context, spendFilter model.SpendFilter, filter p
 
 	// WHERE builder
 	// mapping where filter equal
-	whereMap := sq.Eq{db.A(keyPocketID): spendFilter.PocketID.ULID}
+	whereMap := sq.Eq{db.A(keyPocketID): spendFilter.PocketIDs()}
 	if spendFilter.User.Valid {
 		whereMap[db.A(keyUserID)] = spendFilter.User.ULID
 	}
@@ -306,9 +306,7 @@ func (r *Repo) Find(ctx context.Context, spendFilter model.SpendFilter, filter p
 	// building where clause
 	query = query.Where(whereMap)
 	if spendFilter.Category.Valid {
-		query = query.Where(
-			sq.Eq{db.A(keyCategoryID): spendFilter.Category.ULID},
-		)
+		query = query.Where(whereCategoryTree(spendFilter.Category.ULID))
 	}
 	if spendFilter.DateStart != nil {
 		query = query.Where(sq.GtOrEq{db.A(keyDate): *spendFilter.DateStart})
@@ -413,7 +411,7 @@ func (r *Repo) FindWithCursor(ctx context.Context, spendFilter model.SpendFilter
 		db.A(keyCreatedAt),
 		db.A(keyUpdatedAt),
 		db.A(keyVersion),
-		db.B("name"),
+		db.CoalesceString(db.B("name"), ""),
 		db.C("pocket_name"),
 		db.CoalesceString(db.D("category_name"), ""),
 		db.CoalesceInt(db.D("category_icon"), 0),
@@ -425,7 +423,7 @@ func (r *Repo) FindWithCursor(ctx context.Context, spendFilter model.SpendFilter
 
 	// WHERE builder
 	// mapping where filter equal
-	whereMap := sq.Eq{db.A(keyPocketID): spendFilter.PocketID.ULID}
+	whereMap := sq.Eq{db.A(keyPocketID): spendFilter.PocketIDs()}
 	if spendFilter.User.Valid {
 		whereMap[db.A(keyUserID)] = spendFilter.User.ULID
 	}
@@ -452,9 +450,7 @@ func (r *Repo) FindWithCursor(ctx context.Context, spendFilter model.SpendFilter
 	}
 
 	if spendFilter.Category.Valid {
-		query = query.Where(
-			sq.Eq{db.A(keyCategoryID): spendFilter.Category.ULID},
-		)
+		query = query.Where(whereCategoryTree(spendFilter.Category.ULID))
 	}
 	if spendFilter.DateStart != nil {
 		query = query.Where(sq.GtOrEq{db.A(keyDate): *spendFilter.DateStart})
@@ -565,7 +561,7 @@ func (r *Repo) FindWithCursorMultiPockets(ctx context.Context, spendFilter model
 		db.A(keyCreatedAt),
 		db.A(keyUpdatedAt),
 		db.A(keyVersion),
-		db.B("name"),
+		db.CoalesceString(db.B("name"), ""),
 		db.C("pocket_name"),
 		db.CoalesceString(db.D("category_name"), ""),
 		db.CoalesceInt(db.D("category_icon"), 0),
@@ -583,9 +579,6 @@ func (r *Repo) FindWithCursorMultiPockets(ctx context.Context, spendFilter model
 	if len(spendFilter.Users) != 0 {
 		whereMap[db.A(keyUserID)] = spendFilter.Users
 	}
-	if len(spendFilter.Categories) != 0 {
-		whereMap[db.A(keyCategoryID)] = spendFilter.Categories
-	}
 	if spendFilter.IsIncome != nil {
 		whereMap[db.A(keyIsIncome)] = *spendFilter.IsIncome
 	}
@@ -595,6 +588,9 @@ func (r *Repo) FindWithCursorMultiPockets(ctx context.Context, spendFilter model
 
 	// building where clause
 	query = query.Where(whereMap)
+	if len(spendFilter.Categories) != 0 {
+		query = query.Where(whereCategoryTree(spendFilter.Categories...))
+	}
 
 	// apply cursor value
 	if filter.GetCursor() != "" {
@@ -691,7 +687,7 @@ func (r *Repo) CountAllPrice(ctx context.Context, pocketID xulid.ULID) (int64, e
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	sqlStatement, args, err := r.sb.Select("sum(price)").
+	sqlStatement, args, err := r.sb.Select("COALESCE(sum(price), 0)").
 		From(keyTable).
 		Where(sq.Eq{"pocket_id": pocketID}).
 		ToSql()
@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// FindForExport get every spend created by userID or recorded in pocketIDs, ordered by date
+func (r *Repo) FindForExport(ctx context.Context, userID xulid.ULID, pocketIDs []xulid.ULID) ([]model.Spend, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "spend-repo-FindForExport")
+	defer span.End()
+
+	// export can be large, give more time than usual query
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// zero userID mean only spend in pocketIDs
+	var zero xulid.ULID
+	where := sq.Or{}
+	if userID != zero {
+		where = append(where, sq.Eq{db.A(keyUserID): userID})
+	}
+	if len(pocketIDs) != 0 {
+		where = append(where, sq.Eq{db.A(keyPocketID): pocketIDs})
+	}
+	if len(where) == 0 {
+		return []model.Spend{}, nil
+	}
+
+	sqlStatement, args, err := r.sb.Select(
+		db.A(keyID),
+		db.A(keyUserID),
+		db.A(keyPocketID),
+		db.A(keyCategoryID),
+		db.A(keyName),
+		db.A(keyPrice),
+		db.A(keyBalance),
+		db.A(keyIsIncome),
+		db.A(keyType),
+		db.A(keyDate),
+		db.A(keyCreatedAt),
+		db.A(keyUpdatedAt),
+		db.A(keyVersion),
+		db.CoalesceString(db.B("name"), ""),
+		db.CoalesceString(db.C("pocket_name"), ""),
+		db.CoalesceString(db.D("category_name"), ""),
+		db.CoalesceInt(db.D("category_icon"), 0),
+	).
+		From(keyTable+" A").
+		LeftJoin("users B ON A.user_id = B.id").
+		LeftJoin("pockets C ON A.pocket_id = C.id").
+		LeftJoin("categories D ON A.category_id = D.id").
+		Where(where).
+		OrderBy(db.A(keyDate), db.A(keyID)).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find spend for export: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	spends := make([]model.Spend, 0)
+	for rows.Next() {
+		var spend model.Spend
+		err := rows.Scan(
+			&spend.ID,
+			&spend.UserID,
+			&spend.PocketID,
+			&spend.CategoryID,
+			&spend.Name,
+			&spend.Price,
+			&spend.BalanceSnapshoot,
+			&spend.IsIncome,
+			&spend.SpendType,
+			&spend.Date,
+			&spend.CreatedAt,
+			&spend.UpdatedAt,
+			&spend.Version,
+			&spend.UserName,
+			&spend.PocketName,
+			&spend.CategoryName,
+			&spend.CategoryIcon,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		spends = append(spends, spend)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return spends, nil
+}
+
+// DeleteByPocket delete every spend in pocketID, return count of deleted spend
+func (r *Repo) DeleteByPocket(ctx context.Context, pocketID xulid.ULID) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "spend-repo-DeleteByPocket")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyTable).
+		Where(sq.Eq{keyPocketID: pocketID}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query delete spend by pocket: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// AnonymizeUser remove userID from every spend, spend is kept for other pocket member.
+// return count of updated spend
+func (r *Repo) AnonymizeUser(ctx context.Context, userID xulid.ULID) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "spend-repo-AnonymizeUser")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		Set(keyUserID, nil).
+		Set(keyUpdatedAt, time.Now()).
+		Set(keyVersion, sq.Expr(keyVersion+" + 1")).
+		Where(sq.Eq{keyUserID: userID}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query anonymize spend: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/constant"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// squirrel does not support recursive CTE, so category tree is written in raw SQL.
+// category cycle cannot make the queries run forever, UNION drop row already found and walk up is limited by depth.
+
+// querySubCategoryIDs select category ids and all of its sub-categories
+const querySubCategoryIDs = `A.category_id IN (
+	WITH RECURSIVE cat_tree AS (
+		SELECT id FROM categories WHERE id = ANY(?)
+		UNION
+		SELECT c.id FROM categories c JOIN cat_tree t ON c.parent_id = t.id
+	)
+	SELECT id FROM cat_tree
+)`
+
+// queryCategoryRoot map category used by spend of pockets to its root category as cat_root(id, root_id).
+// tree is walked up from used category so only their ancestors are read, depth guard against cycle
+const queryCategoryRoot = `WITH RECURSIVE cat_walk AS (
+	SELECT id, id AS ancestor_id, parent_id, 0 AS depth FROM categories
+	WHERE id IN (SELECT DISTINCT category_id FROM spends WHERE pocket_id = ANY(?))
+	UNION ALL
+	SELECT w.id, c.id, c.parent_id, w.depth + 1 FROM cat_walk w JOIN categories c ON c.id = w.parent_id
+	WHERE w.depth < ?
+), cat_root AS (
+	SELECT id, ancestor_id AS root_id FROM cat_walk WHERE parent_id IS NULL
+)`
+
+// whereCategoryTree filter spend by categories including their sub-categories
+func whereCategoryTree(categoryIDs ...xulid.ULID) sq.Sqlizer {
+	ids := make([]string, len(categoryIDs))
+	for i, id := range categoryIDs {
+		ids[i] = id.String()
+	}
+	return sq.Expr(querySubCategoryIDs, ids)
+}
+
+// categoryRoot is queryCategoryRoot of category used in pockets
+func categoryRoot(pocketIDs []xulid.ULID) sq.Sqlizer {
+	ids := make([]string, len(pocketIDs))
+	for i, id := range pocketIDs {
+		ids[i] = id.String()
+	}
+	return sq.Expr(queryCategoryRoot, ids, constant.CAT_MAX_DEPTH)
+}
+
+// SummaryByCategory sum spend price grouped by root category.
+// spend in sub-category is rolled up to its top-level parent
+func (r *Repo) SummaryByCategory(ctx context.Context, spendFilter model.SpendFilter) ([]model.CategorySummary, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "spend-repo-SummaryByCategory")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := r.sb.Select(
+		"R.root_id",
+		db.CoalesceString(db.E("category_name"), ""),
+		db.CoalesceInt(db.E("category_icon"), 0),
+		db.A(keyIsIncome),
+		"sum(A.price)",
+		"count(*)",
+	).
+		PrefixExpr(categoryRoot(spendFilter.PocketIDs())).
+		From("spends A").
+		LeftJoin("cat_root R ON A.category_id = R.id").
+		LeftJoin("categories E ON R.root_id = E.id")
+
+	whereMap := sq.Eq{db.A(keyPocketID): spendFilter.PocketIDs()}
+	if spendFilter.User.Valid {
+		whereMap[db.A(keyUserID)] = spendFilter.User.ULID
+	}
+	if spendFilter.IsIncome != nil {
+		whereMap[db.A(keyIsIncome)] = *spendFilter.IsIncome
+	}
+	if len(spendFilter.Type) != 0 {
+		whereMap[db.A(keyType)] = spendFilter.Type
+	}
+
+	query = query.Where(whereMap)
+	if spendFilter.Category.Valid {
+		query = query.Where(whereCategoryTree(spendFilter.Category.ULID))
+	}
+	if spendFilter.DateStart != nil {
+		query = query.Where(sq.GtOrEq{db.A(keyDate): *spendFilter.DateStart})
+	}
+	if spendFilter.DateEnd != nil {
+		query = query.Where(sq.Lt{db.A(keyDate): *spendFilter.DateEnd})
+	}
+
+	sqlStatement, args, err := query.
+		GroupBy("R.root_id", "E.category_name", "E.category_icon", db.A(keyIsIncome)).
+		OrderBy(db.A(keyIsIncome), "sum(A.price)").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query summary spend by category: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	summaries := make([]model.CategorySummary, 0)
+	for rows.Next() {
+		var summary model.CategorySummary
+		err := rows.Scan(
+			&summary.CategoryID,
+			&summary.CategoryName,
+			&summary.CategoryIcon,
+			&summary.IsIncome,
+			&summary.TotalPrice,
+			&summary.SpendCount,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
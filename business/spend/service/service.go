@@ -10,8 +10,10 @@ import (
 	"time"
 
 	notifModel "github.com/muchlist/moneymagnet/business/notification/model"
+	rtModel "github.com/muchlist/moneymagnet/business/realtime/model"
 	"github.com/muchlist/moneymagnet/business/spend/model"
 	"github.com/muchlist/moneymagnet/business/spend/port"
+	whModel "github.com/muchlist/moneymagnet/business/webhook/model"
 	"github.com/muchlist/moneymagnet/constant"
 	"github.com/muchlist/moneymagnet/pkg/bg"
 	"github.com/muchlist/moneymagnet/pkg/ctype"
@@ -29,17 +31,20 @@ import (
 // Set of error variables for CRUD operations.
 var (
 	ErrNotFound  = errors.New("data not found")
-	ErrInvalidID = errors.New("ID is not in its proper form")
+	ErrInvalidID = errr.T("error.invalid_id", nil)
 )
 
 // Core manages the set of APIs for user access.
 type Core struct {
-	log                mlogger.Logger
-	repo               port.SpendStorer
-	pocketRepo         port.PocketStorer
-	eTagRepo           port.ETagStorer
-	notificationSender port.NotificationSender
-	txManager          port.Transactor
+	log        mlogger.Logger
+	repo       port.SpendStorer
+	pocketRepo port.PocketStorer
+	eTagRepo   port.ETagStorer
+	jobQueue   port.JobEnqueuer
+	emitter    port.EventEmitter
+	publisher  port.ChangePublisher
+	prefReader port.PreferenceReader
+	txManager  port.Transactor
 }
 
 // NewCore constructs a core for user api access.
@@ -48,16 +53,22 @@ func NewCore(
 	repo port.SpendStorer,
 	pocketRepo port.PocketStorer,
 	eTagRepo port.ETagStorer,
-	notificationSender port.NotificationSender,
+	jobQueue port.JobEnqueuer,
+	emitter port.EventEmitter,
+	publisher port.ChangePublisher,
+	prefReader port.PreferenceReader,
 	txManager port.Transactor,
 ) *Core {
 	return &Core{
-		log:                log,
-		repo:               repo,
-		pocketRepo:         pocketRepo,
-		eTagRepo:           eTagRepo,
-		notificationSender: notificationSender,
-		txManager:          txManager,
+		log:        log,
+		repo:       repo,
+		pocketRepo: pocketRepo,
+		eTagRepo:   eTagRepo,
+		jobQueue:   jobQueue,
+		emitter:    emitter,
+		publisher:  publisher,
+		prefReader: prefReader,
+		txManager:  txManager,
 	}
 }
 
@@ -72,8 +83,9 @@ func (s *Core) CreateSpend(ctx context.Context, claims mjwt.CustomClaim, req mod
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.SpendResp{}, errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return model.SpendResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	timeNow := time.Now()
@@ -112,43 +124,45 @@ func (s *Core) CreateSpend(ctx context.Context, claims mjwt.CustomClaim, req mod
 		}
 		spend.BalanceSnapshoot = newBalance
 
-		return nil
+		err = s.emitter.Emit(ctx, whModel.EventSpendCreated, spend.PocketID, spend.ToResp())
+		if err != nil {
+			return fmt.Errorf("emit spend created: %w", err)
+		}
+
+		// send notification to other user if any
+		var notification *notifModel.SendMessage
+		otherUsers := pocketExisting.GetOtherUsers(claims.Identity)
+		if len(otherUsers) != 0 {
+			notification = &notifModel.SendMessage{
+				TitleKey:   "notification.spend_created.title",
+				MessageKey: "notification.spend.message",
+				Params: map[string]string{
+					"pocket": pocketExisting.PocketName,
+					"actor":  claims.Name,
+					"name":   req.Name,
+				},
+				Currency:   pocketExisting.Currency,
+				UserIds:    otherUsers,
+				Event:      notifModel.EventSpendCreated,
+				PocketID:   pocketExisting.ID.String(),
+				SpendID:    spend.ID.String(),
+				Amount:     req.Price,
+				PocketName: pocketExisting.PocketName,
+				ActorName:  claims.Name,
+			}
+		}
+
+		return s.enqueueSpendChanged(ctx, []xulid.ULID{pocketExisting.ID}, notification)
 	})
 
 	if transErr != nil {
 		return model.SpendResp{}, transErr
 	}
 
-	// updating eTag
-	bg.RunSafeBackground(ctx, bg.BackgroundJob{
-		JobTitle: "set etag for pocket",
-		Execute: func(ctx context.Context) {
-			err := s.eTagRepo.SetTagByPocketID(ctx, pocketExisting.ID.String(), time.Now().UnixMilli())
-			if err != nil {
-				s.log.ErrorT(ctx, fmt.Sprintf("error set eTag for pocket %s", pocketExisting.ID.String()), err)
-			}
-		},
-	})
-
-	// send notification to other user if any
-	otherUsers := pocketExisting.GetOtherUsers(claims.Identity)
-	if len(otherUsers) != 0 {
-		bg.RunSafeBackground(ctx, bg.BackgroundJob{
-			JobTitle: "Send Notification Create Spend",
-			Execute: func(ctx context.Context) {
-				err := s.notificationSender.SendNotificationToUser(ctx, notifModel.SendMessage{
-					Title:   fmt.Sprintf("Penambahan record pada %s oleh %s", pocketExisting.PocketName, claims.Name),
-					Message: fmt.Sprintf("%s %d", req.Name, req.Price),
-					UserIds: otherUsers,
-				})
-				if err != nil {
-					s.log.ErrorT(ctx, "error send notification to user", err)
-				}
-			},
-		})
-	}
+	result := spend.ToResp()
+	s.publisher.Publish(ctx, spend.PocketID, rtModel.EventSpendCreated, result)
 
-	return spend.ToResp(), nil
+	return result, nil
 }
 
 func (s *Core) TransferToPocketAsSpend(ctx context.Context, claims mjwt.CustomClaim, req model.TransferSpend) error {
@@ -162,8 +176,9 @@ func (s *Core) TransferToPocketAsSpend(ctx context.Context, claims mjwt.CustomCl
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), fromPocket.EditorID) {
-		return errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), fromPocket.EditorID) ||
+		!claims.CanAccessPocket(fromPocket.ID.String()) {
+		return errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	// Get existing Pocket (to)
@@ -173,20 +188,22 @@ func (s *Core) TransferToPocketAsSpend(ctx context.Context, claims mjwt.CustomCl
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), toPocket.EditorID) {
-		return errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), toPocket.EditorID) ||
+		!claims.CanAccessPocket(toPocket.ID.String()) {
+		return errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	// check balance and price value
 	if req.Price <= 0 {
-		return errr.New("the transfer value must be more than zero", 400)
+		return errr.NewT("error.transfer_not_positive", nil, 400)
 	}
 	if fromPocket.Balance < req.Price {
-		return errr.New("balance must be more than the transfer value", 400)
+		return errr.NewT("error.transfer_over_balance", nil, 400)
 	}
 
+	var created []model.SpendResp
 	transErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
-
+		created = created[:0]
 		timeNow := time.Now()
 
 		// spend for pocket-from
@@ -243,34 +260,29 @@ func (s *Core) TransferToPocketAsSpend(ctx context.Context, claims mjwt.CustomCl
 				return fmt.Errorf("insert spend to db - %s: %w", ss.PocketName, err)
 			}
 
-			_, err := s.pocketRepo.UpdateBalance(ctx, ss.PocketID, ss.Price, false)
+			newBalance, err := s.pocketRepo.UpdateBalance(ctx, ss.PocketID, ss.Price, false)
 			if err != nil {
 				return fmt.Errorf("fail to change balance - %s: %w", ss.PocketName, err)
 			}
+			ss.BalanceSnapshoot = newBalance
+
+			err = s.emitter.Emit(ctx, whModel.EventSpendCreated, ss.PocketID, ss.ToResp())
+			if err != nil {
+				return fmt.Errorf("emit spend created - %s: %w", ss.PocketName, err)
+			}
+			created = append(created, ss.ToResp())
 		}
 
-		return nil
+		return s.enqueueSpendChanged(ctx, []xulid.ULID{req.PocketIDFrom, req.PocketIDTo}, nil)
 	})
 
 	if transErr != nil {
 		return transErr
 	}
 
-	// updating eTag
-	bg.RunSafeBackground(ctx, bg.BackgroundJob{
-		JobTitle: "set etag for pockets",
-		Execute: func(ctx context.Context) {
-			err := s.eTagRepo.SetTagByPocketID(ctx, req.PocketIDFrom.String(), time.Now().UnixMilli())
-			if err != nil {
-				s.log.ErrorT(ctx, fmt.Sprintf("error set eTag for pocket %s", req.PocketIDFrom.String()), err)
-			}
-
-			err = s.eTagRepo.SetTagByPocketID(ctx, req.PocketIDTo.String(), time.Now().UnixMilli())
-			if err != nil {
-				s.log.ErrorT(ctx, fmt.Sprintf("error set eTag for pocket %s", req.PocketIDTo.String()), err)
-			}
-		},
-	})
+	for _, spend := range created {
+		s.publisher.Publish(ctx, spend.PocketID, rtModel.EventSpendCreated, spend)
+	}
 
 	return nil
 }
@@ -287,7 +299,7 @@ func (s *Core) UpdatePartialSpend(ctx context.Context, claims mjwt.CustomClaim,
 
 	// validate id creator
 	if spendExisting.UserID != claims.GetULID() {
-		return model.SpendResp{}, errr.New("user cannot edit this transaction", 400)
+		return model.SpendResp{}, errr.NewT("error.spend_edit_denied", nil, 400)
 	}
 
 	// Get existing Pocket
@@ -297,8 +309,9 @@ func (s *Core) UpdatePartialSpend(ctx context.Context, claims mjwt.CustomClaim,
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return model.SpendResp{}, errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return model.SpendResp{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	// Modify data
@@ -342,42 +355,44 @@ func (s *Core) UpdatePartialSpend(ctx context.Context, claims mjwt.CustomClaim,
 			spendExisting.BalanceSnapshoot = newBalance
 		}
 
-		return nil
+		err = s.emitter.Emit(ctx, whModel.EventSpendUpdated, spendExisting.PocketID, spendExisting.ToResp())
+		if err != nil {
+			return fmt.Errorf("emit spend updated: %w", err)
+		}
+
+		// send notification to other user if any
+		var notification *notifModel.SendMessage
+		otherUsers := pocketExisting.GetOtherUsers(claims.Identity)
+		if len(otherUsers) != 0 {
+			notification = &notifModel.SendMessage{
+				TitleKey:   "notification.spend_updated.title",
+				MessageKey: "notification.spend.message",
+				Params: map[string]string{
+					"pocket": pocketExisting.PocketName,
+					"actor":  claims.Name,
+					"name":   string(spendExisting.Name),
+				},
+				Currency:   pocketExisting.Currency,
+				UserIds:    otherUsers,
+				Event:      notifModel.EventSpendUpdated,
+				PocketID:   spendExisting.PocketID.String(),
+				SpendID:    spendExisting.ID.String(),
+				Amount:     spendExisting.Price,
+				PocketName: pocketExisting.PocketName,
+				ActorName:  claims.Name,
+			}
+		}
+
+		return s.enqueueSpendChanged(ctx, []xulid.ULID{spendExisting.PocketID}, notification)
 	})
 	if transErr != nil {
 		return model.SpendResp{}, transErr
 	}
 
-	// updating eTag
-	bg.RunSafeBackground(ctx, bg.BackgroundJob{
-		JobTitle: "set etag for pocket",
-		Execute: func(ctx context.Context) {
-			err := s.eTagRepo.SetTagByPocketID(ctx, spendExisting.PocketID.String(), time.Now().UnixMilli())
-			if err != nil {
-				s.log.ErrorT(ctx, fmt.Sprintf("error set eTag for pocket %s", spendExisting.PocketID.String()), err)
-			}
-		},
-	})
-
-	// send notification to other user if any
-	otherUsers := pocketExisting.GetOtherUsers(claims.Identity)
-	if len(otherUsers) != 0 {
-		bg.RunSafeBackground(ctx, bg.BackgroundJob{
-			JobTitle: "Send Notification Update Spend",
-			Execute: func(ctx context.Context) {
-				err := s.notificationSender.SendNotificationToUser(ctx, notifModel.SendMessage{
-					Title:   fmt.Sprintf("Perubahan record pada %s oleh %s", pocketExisting.PocketName, claims.Name),
-					Message: fmt.Sprintf("%s %d", spendExisting.Name, spendExisting.Price),
-					UserIds: otherUsers,
-				})
-				if err != nil {
-					s.log.ErrorT(ctx, "error send notification to user", err)
-				}
-			},
-		})
-	}
+	result := spendExisting.ToResp()
+	s.publisher.Publish(ctx, spendExisting.PocketID, rtModel.EventSpendUpdated, result)
 
-	return spendExisting.ToResp(), nil
+	return result, nil
 }
 
 func (s *Core) DeleteSpend(ctx context.Context, claims mjwt.CustomClaim, spendID xulid.ULID) error {
@@ -392,7 +407,7 @@ func (s *Core) DeleteSpend(ctx context.Context, claims mjwt.CustomClaim, spendID
 
 	// validate id creator
 	if spendExisting.UserID != claims.GetULID() {
-		return errr.New("user cannot delete this transaction", 400)
+		return errr.NewT("error.spend_delete_denied", nil, 400)
 	}
 
 	// Get existing Pocket
@@ -402,8 +417,9 @@ func (s *Core) DeleteSpend(ctx context.Context, claims mjwt.CustomClaim, spendID
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) {
-		return errr.New("not have access to this pocket", 400)
+	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	reverseExistingPriceToDelete := -spendExisting.Price
@@ -420,40 +436,41 @@ func (s *Core) DeleteSpend(ctx context.Context, claims mjwt.CustomClaim, spendID
 			return fmt.Errorf("fail to updating balance: %w", err)
 		}
 
-		return nil
+		err = s.emitter.Emit(ctx, whModel.EventSpendDeleted, spendExisting.PocketID, spendExisting.ToResp())
+		if err != nil {
+			return fmt.Errorf("emit spend deleted: %w", err)
+		}
+
+		// send notification to other user if any
+		var notification *notifModel.SendMessage
+		otherUsers := pocketExisting.GetOtherUsers(claims.Identity)
+		if len(otherUsers) != 0 {
+			notification = &notifModel.SendMessage{
+				TitleKey:   "notification.spend_deleted.title",
+				MessageKey: "notification.spend.message",
+				Params: map[string]string{
+					"pocket": pocketExisting.PocketName,
+					"actor":  claims.Name,
+					"name":   string(spendExisting.Name),
+				},
+				Currency:   pocketExisting.Currency,
+				UserIds:    otherUsers,
+				Event:      notifModel.EventSpendDeleted,
+				PocketID:   spendExisting.PocketID.String(),
+				SpendID:    spendExisting.ID.String(),
+				Amount:     spendExisting.Price,
+				PocketName: pocketExisting.PocketName,
+				ActorName:  claims.Name,
+			}
+		}
+
+		return s.enqueueSpendChanged(ctx, []xulid.ULID{spendExisting.PocketID}, notification)
 	})
 	if transErr != nil {
 		return transErr
 	}
 
-	// updating eTag
-	bg.RunSafeBackground(ctx, bg.BackgroundJob{
-		JobTitle: "set etag for pocket",
-		Execute: func(ctx context.Context) {
-			err := s.eTagRepo.SetTagByPocketID(ctx, spendExisting.PocketID.String(), time.Now().UnixMilli())
-			if err != nil {
-				s.log.ErrorT(ctx, fmt.Sprintf("error set eTag for pocket %s", spendExisting.PocketID.String()), err)
-			}
-		},
-	})
-
-	// send notification to other user if any
-	otherUsers := pocketExisting.GetOtherUsers(claims.Identity)
-	if len(otherUsers) != 0 {
-		bg.RunSafeBackground(ctx, bg.BackgroundJob{
-			JobTitle: "Send Notification Delete Spend",
-			Execute: func(ctx context.Context) {
-				err := s.notificationSender.SendNotificationToUser(ctx, notifModel.SendMessage{
-					Title:   fmt.Sprintf("Penghapusan record pada %s oleh %s", pocketExisting.PocketName, claims.Name),
-					Message: fmt.Sprintf("%s %d", spendExisting.Name, spendExisting.Price),
-					UserIds: otherUsers,
-				})
-				if err != nil {
-					s.log.ErrorT(ctx, "error send notification to user", err)
-				}
-			},
-		})
-	}
+	s.publisher.Publish(ctx, spendExisting.PocketID, rtModel.EventSpendDeleted, spendExisting.ToResp())
 
 	return nil
 }
@@ -484,9 +501,15 @@ func (s *Core) FindAllSpend(ctx context.Context, claims mjwt.CustomClaim, spendF
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
-		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID) {
-		return nil, paging.Metadata{}, errr.New("not have access to this pocket", 400)
+	if (!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
+		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID)) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return nil, paging.Metadata{}, errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
+	spendFilter, err = s.resolveSubPockets(ctx, claims, spendFilter)
+	if err != nil {
+		return nil, paging.Metadata{}, err
 	}
 
 	spends, metadata, err := s.repo.Find(ctx, spendFilter, filter)
@@ -514,9 +537,15 @@ func (s *Core) FindAllSpendByCursor(ctx context.Context, claims mjwt.CustomClaim
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
-		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID) {
-		return nil, paging.CursorMetadata{}, errr.New("not have access to this pocket", 400)
+	if (!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
+		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID)) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return nil, paging.CursorMetadata{}, errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
+	spendFilter, err = s.resolveSubPockets(ctx, claims, spendFilter)
+	if err != nil {
+		return nil, paging.CursorMetadata{}, err
 	}
 
 	spends, err := s.repo.FindWithCursor(ctx, spendFilter, filter)
@@ -551,21 +580,80 @@ func (s *Core) FindAllSpendByCursor(ctx context.Context, claims mjwt.CustomClaim
 	}, nil
 }
 
+// SummaryByCategory sum spend per top-level category, spend in sub-category is rolled up to its parent
+func (s *Core) SummaryByCategory(ctx context.Context, claims mjwt.CustomClaim, spendFilter model.SpendFilter) ([]model.CategorySummaryResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SummaryByCategory")
+	defer span.End()
+
+	// Get existing Pocket
+	pocketExisting, err := s.pocketRepo.GetByID(ctx, spendFilter.PocketID.ULID)
+	if err != nil {
+		return nil, fmt.Errorf("get pocket by id: %w", err)
+	}
+
+	// Validate Pocket Roles Editor
+	if !pocketExisting.IsMember(claims.Identity) || !claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return nil, errr.NewT("error.pocket_access_denied", nil, 400)
+	}
+
+	spendFilter, err = s.resolveSubPockets(ctx, claims, spendFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.repo.SummaryByCategory(ctx, spendFilter)
+	if err != nil {
+		return nil, fmt.Errorf("summary spend by category: %w", err)
+	}
+
+	result := make([]model.CategorySummaryResp, len(summaries))
+	for i := range summaries {
+		result[i] = summaries[i].ToResp()
+	}
+
+	return result, nil
+}
+
+// resolveSubPockets fill SubPocketIDs when IncludeChildren is requested.
+// only sub-pocket where user is editor or watcher will be included
+func (s *Core) resolveSubPockets(ctx context.Context, claims mjwt.CustomClaim, spendFilter model.SpendFilter) (model.SpendFilter, error) {
+	if !spendFilter.IncludeChildren {
+		return spendFilter, nil
+	}
+
+	descendants, err := s.pocketRepo.FindDescendants(ctx, spendFilter.PocketID.ULID)
+	if err != nil {
+		return spendFilter, fmt.Errorf("find sub pockets: %w", err)
+	}
+
+	subPocketIDs := make([]xulid.ULID, 0, len(descendants))
+	for _, sub := range descendants {
+		if sub.IsMember(claims.Identity) && claims.CanAccessPocket(sub.ID.String()) {
+			subPocketIDs = append(subPocketIDs, sub.ID)
+		}
+	}
+	spendFilter.SubPocketIDs = subPocketIDs
+
+	return spendFilter, nil
+}
+
 type AutoDateRangeParams struct {
-	PocketID  xulid.ULID
-	Claims    mjwt.CustomClaim
-	Filter    paging.Cursor
-	RangeType string
-	TimeZone  string
-	ETag      string
+	PocketID        xulid.ULID
+	IncludeChildren bool
+	Claims          mjwt.CustomClaim
+	Filter          paging.Cursor
+	RangeType       string
+	TimeZone        string
+	ETag            string
 }
 
 func (s *Core) FindAllSpendByCursorAutoDateRange(ctx context.Context, params AutoDateRangeParams) ([]model.SpendResp, paging.CursorMetadata, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "service-FindAllSpendByCursorAutoDateRange")
 	defer span.End()
 
-	// only support eTag for rangeType week right now
-	islast7daysType := params.RangeType == "last-7-days"
+	// only support eTag for rangeType week right now.
+	// eTag is stored per pocket, so changes in sub-pocket is not reflected when IncludeChildren
+	islast7daysType := params.RangeType == "last-7-days" && !params.IncludeChildren
 	isPageOne := params.Filter.GetCursor() == ""
 	var tagSaved int64 = 0
 
@@ -592,14 +680,25 @@ func (s *Core) FindAllSpendByCursorAutoDateRange(ctx context.Context, params Aut
 		}
 	}
 
-	dateRange, err := daterange.ParseDateRange(params.RangeType, params.TimeZone)
+	// timezone and first day of week from preference is used when client omit it
+	pref, err := s.prefReader.GetPreference(ctx, params.Claims.GetULID())
 	if err != nil {
-		return nil, paging.CursorMetadata{}, errr.New(err.Error(), 400)
+		return nil, paging.CursorMetadata{}, fmt.Errorf("get preference: %w", err)
+	}
+	timeZone := params.TimeZone
+	if timeZone == "" {
+		timeZone = pref.Timezone
+	}
+
+	dateRange, err := daterange.ParseDateRangeWeekStart(params.RangeType, timeZone, time.Weekday(pref.FirstDayOfWeek))
+	if err != nil {
+		return nil, paging.CursorMetadata{}, errr.Wrap(err, 400)
 	}
 
 	spendFilter := model.SpendFilter{
-		DateStart: &dateRange.StartDate,
-		DateEnd:   &dateRange.EndDate,
+		IncludeChildren: params.IncludeChildren,
+		DateStart:       &dateRange.StartDate,
+		DateEnd:         &dateRange.EndDate,
 	}
 	spendFilter.PocketID.ULID = params.PocketID
 
@@ -635,7 +734,14 @@ func (s *Core) FindAllSpendMultiPocketByCursor(ctx context.Context, claims mjwt.
 
 	// Must Have PocketID
 	if len(spendFilter.Pockets) == 0 {
-		return nil, paging.CursorMetadata{}, errr.New("pocket id is required", http.StatusBadRequest)
+		return nil, paging.CursorMetadata{}, errr.NewT("error.pocket_id_required", nil, http.StatusBadRequest)
+	}
+
+	// personal access token limited to some pockets must not read other pockets
+	for _, pocketID := range spendFilter.Pockets {
+		if !claims.CanAccessPocket(pocketID.String()) {
+			return nil, paging.CursorMetadata{}, errr.NewT("error.pocket_access_denied", nil, 400)
+		}
 	}
 
 	// Get existing Pocket
@@ -646,9 +752,10 @@ func (s *Core) FindAllSpendMultiPocketByCursor(ctx context.Context, claims mjwt.
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
-		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID) {
-		return nil, paging.CursorMetadata{}, errr.New("not have access to this pocket", 400)
+	if (!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
+		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID)) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return nil, paging.CursorMetadata{}, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	spends, err := s.repo.FindWithCursorMultiPockets(ctx, spendFilter, filter)
@@ -695,9 +802,10 @@ func (s *Core) SyncBalance(ctx context.Context, claims mjwt.CustomClaim, pocketI
 	}
 
 	// Validate Pocket Roles Editor
-	if !slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
-		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID) {
-		return 0, errr.New("not have access to this pocket", 400)
+	if (!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.EditorID) &&
+		!slicer.In(xulid.MustParse(claims.Identity).String(), pocketExisting.WatcherID)) ||
+		!claims.CanAccessPocket(pocketExisting.ID.String()) {
+		return 0, errr.NewT("error.pocket_access_denied", nil, 400)
 	}
 
 	balance, err := s.repo.CountAllPrice(ctx, pocketID)
@@ -712,3 +820,30 @@ func (s *Core) SyncBalance(ctx context.Context, claims mjwt.CustomClaim, pocketI
 
 	return newBalance, nil
 }
+
+// SyncAllBalance recompute balance of every pocket from its spends, used by admin tools
+func (s *Core) SyncAllBalance(ctx context.Context) ([]model.BalanceSyncResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SyncAllBalance")
+	defer span.End()
+
+	pocketIDs, err := s.pocketRepo.FindAllIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find all pocket id: %w", err)
+	}
+
+	results := make([]model.BalanceSyncResp, 0, len(pocketIDs))
+	for _, pocketID := range pocketIDs {
+		balance, err := s.repo.CountAllPrice(ctx, pocketID)
+		if err != nil {
+			return results, fmt.Errorf("aggregate all price on pocket %s: %w", pocketID.String(), err)
+		}
+
+		newBalance, err := s.pocketRepo.UpdateBalance(ctx, pocketID, balance, true)
+		if err != nil {
+			return results, fmt.Errorf("fail update balance of pocket %s: %w", pocketID.String(), err)
+		}
+		results = append(results, model.BalanceSyncResp{PocketID: pocketID, Balance: newBalance})
+	}
+
+	return results, nil
+}
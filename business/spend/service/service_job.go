@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jobModel "github.com/muchlist/moneymagnet/business/job/model"
+	notifModel "github.com/muchlist/moneymagnet/business/notification/model"
+	"github.com/muchlist/moneymagnet/business/spend/model"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// enqueueSpendChanged save eTag refresh and notification as durable job.
+// it must be called inside WithAtomic, so job exist only when spend change is committed
+func (s *Core) enqueueSpendChanged(ctx context.Context, pocketIDs []xulid.ULID, notification *notifModel.SendMessage) error {
+	err := s.jobQueue.Enqueue(ctx, jobModel.TypeSetPocketETag, model.ETagJob{PocketIDs: pocketIDs})
+	if err != nil {
+		return fmt.Errorf("enqueue set etag: %w", err)
+	}
+
+	if notification != nil {
+		notification.ID = xulid.Instance().NewULID().String()
+		err = s.jobQueue.Enqueue(ctx, jobModel.TypeSendNotification, notification)
+		if err != nil {
+			return fmt.Errorf("enqueue notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetPocketETag handle set etag job, eTag of every pocket is set to current time
+func (s *Core) SetPocketETag(ctx context.Context, job model.ETagJob) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SetPocketETag")
+	defer span.End()
+
+	for _, pocketID := range job.PocketIDs {
+		err := s.eTagRepo.SetTagByPocketID(ctx, pocketID.String(), time.Now().UnixMilli())
+		if err != nil {
+			return fmt.Errorf("set eTag for pocket %s: %w", pocketID.String(), err)
+		}
+	}
+	return nil
+}
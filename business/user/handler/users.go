@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/muchlist/moneymagnet/business/user/model"
 	"github.com/muchlist/moneymagnet/business/user/service"
@@ -45,7 +48,7 @@ func (usr userHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := usr.validator.Struct(req)
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		usr.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
@@ -81,15 +84,16 @@ func (usr userHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errMap, err := usr.validator.Struct(req)
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		usr.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
 		return
 	}
 
-	result, err := usr.service.Login(ctx, req.Email, req.Password)
+	result, err := usr.service.Login(ctx, req.Email, req.Password, readClientInfo(r))
 	if err != nil {
+		setRetryAfter(w, err)
 
 		// send metric
 		mmetric.AddLoginFailedCounter(ctx)
@@ -293,14 +297,14 @@ func (usr userHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	errMap, err := usr.validator.Struct(req)
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
 	if err != nil {
 		usr.log.WarnT(ctx, "request not valid", err)
 		web.ErrorPayloadResponse(w, err.Error(), errMap)
 		return
 	}
 
-	result, err := usr.service.Refresh(ctx, req.RefreshToken)
+	result, err := usr.service.Refresh(ctx, req.RefreshToken, readClientInfo(r))
 	if err != nil {
 		usr.log.ErrorT(ctx, "error refresh token", err)
 		statusCode, msg := zhelper.ParseError(err)
@@ -317,6 +321,165 @@ func (usr userHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (usr userHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-ForgotPassword")
+	defer span.End()
+
+	var req model.ForgotPasswordReq
+	err := web.ReadJSON(w, r, &req)
+	if err != nil {
+		usr.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		usr.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	err = usr.service.ForgotPassword(ctx, req.Email)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error forgot password", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "if the email is registered, reset password instruction has been sent",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (usr userHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-ResetPassword")
+	defer span.End()
+
+	var req model.ResetPasswordReq
+	err := web.ReadJSON(w, r, &req)
+	if err != nil {
+		usr.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		usr.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	err = usr.service.ResetPassword(ctx, req)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error reset password", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "success",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (usr userHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-Logout")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = usr.service.RevokeSession(ctx, claims, claims.SessionID)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error logout", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "success",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (usr userHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-RevokeSession")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	// Get data from url path
+	sessionID, err := web.ReadULIDParam(r)
+	if err != nil {
+		usr.log.WarnT(ctx, err.Error(), err, mlogger.String("identity", claims.Identity))
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = usr.service.RevokeSession(ctx, claims, sessionID.String())
+	if err != nil {
+		usr.log.ErrorT(ctx, "error revoke session", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "success",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (usr userHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-RevokeAllSessions")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	count, err := usr.service.RevokeAllSessions(ctx, claims)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error revoke all sessions", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": map[string]int64{"sessions_revoked": count},
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
 // ==================================================GET
 func (usr userHandler) Profile(w http.ResponseWriter, r *http.Request) {
 	ctx, span := observ.GetTracer().Start(r.Context(), "handler-Profile")
@@ -406,3 +569,117 @@ func (usr userHandler) FindByName(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func (usr userHandler) FindSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-FindSessions")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	result, err := usr.service.FindSessions(ctx, claims)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error find sessions", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// readClientInfo read device information stored in session
+func readClientInfo(r *http.Request) model.ClientInfo {
+	return model.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        web.ReadClientIP(r),
+	}
+}
+
+// setRetryAfter tell client when login can be tried again
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var lockedErr service.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+	}
+}
+
+func (usr userHandler) FindLoginHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-FindLoginHistory")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	// extract url query
+	qs := r.URL.Query()
+	filter := paging.Filters{
+		Page:     web.ReadInt(qs, "page", 0),
+		PageSize: web.ReadInt(qs, "page_size", 0),
+		Sort:     web.ReadString(qs, "sort", ""),
+	}
+
+	result, metadata, err := usr.service.FindLoginHistory(ctx, claims, filter)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error find login history", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"metadata": metadata,
+		"data":     result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (usr userHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-UnlockLogin")
+	defer span.End()
+
+	var req model.UnlockLoginReq
+	err := web.ReadJSON(w, r, &req)
+	if err != nil {
+		usr.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		usr.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	err = usr.service.UnlockLogin(ctx, req.Email, req.IP)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error unlock login", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "success",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/observ/mmetric"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+func (usr userHandler) OIDCAuthorize(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-OIDCAuthorize")
+	defer span.End()
+
+	result, err := usr.service.OIDCAuthorize(ctx)
+	if err != nil {
+		usr.log.ErrorT(ctx, "error start oidc login", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (usr userHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-OIDCCallback")
+	defer span.End()
+
+	var req model.OIDCCallbackReq
+	err := web.ReadJSON(w, r, &req)
+	if err != nil {
+		usr.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	errMap, err := usr.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		usr.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := usr.service.OIDCCallback(ctx, req, readClientInfo(r))
+	if err != nil {
+		// send metric
+		mmetric.AddLoginFailedCounter(ctx)
+
+		usr.log.ErrorT(ctx, "error login oidc", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
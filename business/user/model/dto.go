@@ -26,6 +26,10 @@ type UserResp struct {
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at"`
 	Version             int        `json:"version"`
+	TOTPEnabled         bool       `json:"totp_enabled"`
+	SessionID           string     `json:"session_id,omitempty"`
+	MFARequired         bool       `json:"mfa_required,omitempty"`    // login need second step with ChallengeToken
+	ChallengeToken      string     `json:"challenge_token,omitempty"` // exchanged in login/2fa with totp code
 	AccessToken         string     `json:"access_token,omitempty"`
 	RefreshToken        string     `json:"refresh_token,omitempty"`
 	AccessTokenExpired  int64      `json:"access_token_expired,omitempty"`
@@ -40,3 +44,111 @@ type UserUpdate struct {
 	Roles    []string   `json:"roles"`
 	Fcm      []string   `json:"fcm"`
 }
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// ClientInfo is information of device that do login or refresh
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type SessionResp struct {
+	ID         xulid.ULID `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiredAt  time.Time  `json:"expired_at"`
+	IsCurrent  bool       `json:"is_current"`
+}
+
+type TOTPSetupResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth uri, render as qr code
+}
+
+type TOTPCodeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type LoginTOTPReq struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // totp code or recovery code
+}
+
+type RecoveryCodesResp struct {
+	Codes []string `json:"codes"` // only shown once
+}
+
+type NewAccessTokenReq struct {
+	Name          string       `json:"name" validate:"required,max=100"`
+	Scopes        []string     `json:"scopes" validate:"required,min=1,dive,oneof=read spends:write"`
+	PocketIDs     []xulid.ULID `json:"pocket_ids"`                                         // empty mean all pocket
+	ExpiredInDays int          `json:"expired_in_days" validate:"omitempty,min=1,max=365"` // 0 mean never expired
+}
+
+type AccessTokenResp struct {
+	ID         xulid.ULID `json:"id"`
+	Name       string     `json:"name"`
+	TokenHint  string     `json:"token_hint"`
+	Scopes     []string   `json:"scopes"`
+	PocketIDs  []string   `json:"pocket_ids"`
+	ExpiredAt  *time.Time `json:"expired_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type AccessTokenCreatedResp struct {
+	AccessTokenResp
+	Token string `json:"token"` // only shown once
+}
+
+type LoginHistoryResp struct {
+	ID        xulid.ULID `json:"id"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	Outcome   string     `json:"outcome"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type UnlockLoginReq struct {
+	Email string `json:"email" validate:"required,email"`
+	IP    string `json:"ip" validate:"omitempty,ip"` // also unlock ip if filled
+}
+
+type OIDCAuthorizeResp struct {
+	AuthorizationURL string `json:"authorization_url"` // open in browser, provider redirect back with code and state
+	State            string `json:"state"`
+}
+
+type OIDCCallbackReq struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type PreferenceResp struct {
+	Timezone        string         `json:"timezone" example:"Asia/Makassar"`
+	Locale          string         `json:"locale" example:"id"`
+	DefaultPocketID xulid.NullULID `json:"default_pocket_id"`
+	HomeCurrency    string         `json:"home_currency" example:"IDR"`
+	FirstDayOfWeek  int            `json:"first_day_of_week" example:"1"` // 0:sunday ... 6:saturday
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// PreferenceUpdate ignore nil field, empty default_pocket_id remove default pocket
+type PreferenceUpdate struct {
+	Timezone        *string `json:"timezone" validate:"omitempty,max=64"`
+	Locale          *string `json:"locale" validate:"omitempty,oneof=id en"`
+	DefaultPocketID *string `json:"default_pocket_id"`
+	HomeCurrency    *string `json:"home_currency" validate:"omitempty,max=10"`
+	FirstDayOfWeek  *int    `json:"first_day_of_week" validate:"omitempty,min=0,max=6"`
+}
@@ -16,6 +16,37 @@ type User struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Version   int
+
+	TokenRevokedAt *time.Time // refresh token issued before this time is not valid
+	TOTPSecret     string     // filled on setup, only required on login after TOTPEnabled
+	TOTPEnabled    bool
+}
+
+// IsTokenRevoked return true if token issued at unix time iat is revoked
+func (u *User) IsTokenRevoked(iat int64) bool {
+	if u.TokenRevokedAt == nil {
+		return false
+	}
+	return iat < u.TokenRevokedAt.Unix()
+}
+
+// PasswordReset is single use token to reset password, only hash of token is stored
+type PasswordReset struct {
+	ID        xulid.ULID
+	UserID    xulid.ULID
+	TokenHash string
+	ExpiredAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RecoveryCode is single use backup code for two-factor login, only hash of code is stored
+type RecoveryCode struct {
+	ID        xulid.ULID
+	UserID    xulid.ULID
+	CodeHash  []byte
+	UsedAt    *time.Time
+	CreatedAt time.Time
 }
 
 func (u *User) ToUserResp() UserResp {
@@ -27,7 +58,178 @@ func (u *User) ToUserResp() UserResp {
 		CreatedAt:    u.CreatedAt,
 		UpdatedAt:    u.UpdatedAt,
 		Version:      u.Version,
+		TOTPEnabled:  u.TOTPEnabled,
 		AccessToken:  "",
 		RefreshToken: "",
 	}
 }
+
+// Session is server side record of login from one device.
+// only refresh token with RefreshJTI can be used, older token means reuse
+type Session struct {
+	ID         xulid.ULID
+	UserID     xulid.ULID
+	RefreshJTI string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiredAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// IsActive return true if session is not revoked and not expired
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiredAt)
+}
+
+func (s *Session) ToSessionResp(currentSessionID string) SessionResp {
+	return SessionResp{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiredAt:  s.ExpiredAt,
+		IsCurrent:  s.ID.String() == currentSessionID,
+	}
+}
+
+// PersonalAccessToken is long lived token for script and integration, only hash of token is stored
+type PersonalAccessToken struct {
+	ID         xulid.ULID
+	UserID     xulid.ULID
+	Name       string
+	TokenHash  string
+	TokenHint  string
+	Scopes     []string
+	PocketIDs  []string
+	ExpiredAt  *time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// IsActive return true if token is not revoked and not expired
+func (p *PersonalAccessToken) IsActive(now time.Time) bool {
+	if p.RevokedAt != nil {
+		return false
+	}
+	return p.ExpiredAt == nil || now.Before(*p.ExpiredAt)
+}
+
+func (p *PersonalAccessToken) ToAccessTokenResp() AccessTokenResp {
+	return AccessTokenResp{
+		ID:         p.ID,
+		Name:       p.Name,
+		TokenHint:  p.TokenHint,
+		Scopes:     p.Scopes,
+		PocketIDs:  p.PocketIDs,
+		ExpiredAt:  p.ExpiredAt,
+		LastUsedAt: p.LastUsedAt,
+		LastUsedIP: p.LastUsedIP,
+		CreatedAt:  p.CreatedAt,
+	}
+}
+
+// outcome of login attempt
+const (
+	LoginSuccess            = "success"
+	LoginMFARequired        = "mfa_required"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginInvalidCode        = "invalid_code"
+	LoginLocked             = "locked"
+)
+
+// LoginHistory record every login attempt
+type LoginHistory struct {
+	ID        xulid.ULID
+	UserID    xulid.NullULID
+	Email     string
+	IP        string
+	UserAgent string
+	Outcome   string
+	CreatedAt time.Time
+}
+
+func (l *LoginHistory) ToLoginHistoryResp() LoginHistoryResp {
+	return LoginHistoryResp{
+		ID:        l.ID,
+		IP:        l.IP,
+		UserAgent: l.UserAgent,
+		Outcome:   l.Outcome,
+		CreatedAt: l.CreatedAt,
+	}
+}
+
+// Identity link account of external identity provider to user
+type Identity struct {
+	ID          xulid.ULID
+	UserID      xulid.ULID
+	Provider    string
+	Subject     string
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
+
+// OIDCState is pending authorization request to identity provider, only hash of state is stored
+type OIDCState struct {
+	ID           xulid.ULID
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiredAt    time.Time
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
+
+// default value of preference not set by user
+const (
+	DefaultTimezone       = "Asia/Jakarta"
+	DefaultLocale         = "id"
+	DefaultFirstDayOfWeek = 1 // monday
+)
+
+// Preference is setting of user used when client does not send the value
+type Preference struct {
+	UserID          xulid.ULID
+	Timezone        string
+	Locale          string
+	DefaultPocketID xulid.NullULID
+	HomeCurrency    string
+	FirstDayOfWeek  int // 0:sunday ... 6:saturday
+	UpdatedAt       time.Time
+}
+
+// DefaultPreference return preference of user who never set it
+func DefaultPreference(userID xulid.ULID) Preference {
+	return Preference{
+		UserID:         userID,
+		Timezone:       DefaultTimezone,
+		Locale:         DefaultLocale,
+		FirstDayOfWeek: DefaultFirstDayOfWeek,
+	}
+}
+
+// ApplyDefault fill empty value with default
+func (p *Preference) ApplyDefault() {
+	if p.Timezone == "" {
+		p.Timezone = DefaultTimezone
+	}
+	if p.Locale == "" {
+		p.Locale = DefaultLocale
+	}
+}
+
+func (p *Preference) ToPreferenceResp() PreferenceResp {
+	return PreferenceResp{
+		Timezone:        p.Timezone,
+		Locale:          p.Locale,
+		DefaultPocketID: p.DefaultPocketID,
+		HomeCurrency:    p.HomeCurrency,
+		FirstDayOfWeek:  p.FirstDayOfWeek,
+		UpdatedAt:       p.UpdatedAt,
+	}
+}
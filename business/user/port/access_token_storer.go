@@ -0,0 +1,17 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type AccessTokenStorer interface {
+	InsertAccessToken(ctx context.Context, token *model.PersonalAccessToken) error
+	TouchAccessToken(ctx context.Context, id xulid.ULID, usedAt time.Time, ip string) error
+	RevokeAccessToken(ctx context.Context, userID xulid.ULID, id xulid.ULID, revokedAt time.Time) (int64, error)
+	GetAccessTokenByHash(ctx context.Context, tokenHash string) (model.PersonalAccessToken, error)
+	FindAccessTokens(ctx context.Context, userID xulid.ULID) ([]model.PersonalAccessToken, error)
+}
@@ -0,0 +1,24 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/oidc"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type IdentityStorer interface {
+	InsertIdentity(ctx context.Context, identity *model.Identity) error
+	GetIdentity(ctx context.Context, provider string, subject string) (model.Identity, error)
+	TouchIdentity(ctx context.Context, id xulid.ULID, lastLoginAt time.Time) error
+	InsertOIDCState(ctx context.Context, state *model.OIDCState) error
+	UseOIDCState(ctx context.Context, stateHash string, usedAt time.Time) (model.OIDCState, error)
+}
+
+// IdentityProvider is OpenID Connect provider, implemented by oidc.Provider
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (oidc.Claims, error)
+}
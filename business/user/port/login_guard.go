@@ -0,0 +1,23 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type LoginHistoryStorer interface {
+	InsertLoginHistory(ctx context.Context, history *model.LoginHistory) error
+	FindLoginHistory(ctx context.Context, userID xulid.ULID, filter paging.Filters) ([]model.LoginHistory, paging.Metadata, error)
+}
+
+// AttemptCounter store failed login attempt and lock, implemented by cache.Counter
+type AttemptCounter interface {
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Get(ctx context.Context, key string) (int64, error)
+	Set(ctx context.Context, key string, value int64, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
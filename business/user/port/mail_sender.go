@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/pkg/mailer"
+)
+
+type MailSender interface {
+	Send(ctx context.Context, msg mailer.Message) error
+}
+
+type Transactor interface {
+	WithAtomic(ctx context.Context, tFunc func(ctx context.Context) error) error
+}
@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type PasswordResetStorer interface {
+	InsertPasswordReset(ctx context.Context, reset *model.PasswordReset) error
+	UsePasswordReset(ctx context.Context, tokenHash string, usedAt time.Time) (model.PasswordReset, error)
+	InvalidatePasswordResets(ctx context.Context, userID xulid.ULID, usedAt time.Time) error
+}
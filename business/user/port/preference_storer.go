@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type PreferenceStorer interface {
+	UpsertPreference(ctx context.Context, pref *model.Preference) error
+	GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error)
+	GetPreferences(ctx context.Context, userIDs []string) (map[string]model.Preference, error)
+	IsPocketMember(ctx context.Context, userID xulid.ULID, pocketID xulid.ULID) (bool, error)
+}
@@ -0,0 +1,17 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type SessionStorer interface {
+	InsertSession(ctx context.Context, session *model.Session) error
+	RotateSession(ctx context.Context, session *model.Session, oldJTI string) error
+	RevokeSessions(ctx context.Context, userID xulid.ULID, sessionIDs []string, revokedAt time.Time) (int64, error)
+	GetSessionByID(ctx context.Context, id string) (model.Session, error)
+	FindActiveSessions(ctx context.Context, userID xulid.ULID) ([]model.Session, error)
+}
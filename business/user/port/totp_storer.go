@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type TOTPStorer interface {
+	UpdateTOTP(ctx context.Context, user *model.User) error
+	ReplaceRecoveryCodes(ctx context.Context, userID xulid.ULID, codes []model.RecoveryCode) error
+	// UseRecoveryCode return db.ErrDBNotFound when user does not have such unused code
+	UseRecoveryCode(ctx context.Context, userID xulid.ULID, codeHash []byte, usedAt time.Time) error
+	// UseTOTPStep return db.ErrDBNotFound when code of the step or later already accepted
+	UseTOTPStep(ctx context.Context, userID xulid.ULID, step int64) error
+}
@@ -11,6 +11,13 @@ import (
 type UserStorer interface {
 	UserSaver
 	UserReader
+	PasswordResetStorer
+	SessionStorer
+	TOTPStorer
+	AccessTokenStorer
+	LoginHistoryStorer
+	IdentityStorer
+	PreferenceStorer
 }
 
 type UserSaver interface {
@@ -27,6 +27,11 @@ const (
 	keyCreatedAt = "created_at"
 	keyUpdatedAt = "updated_at"
 	keyVersion   = "version"
+
+	keyTokenRevokedAt = "token_revoked_at"
+	keyTOTPSecret     = "totp_secret"
+	keyTOTPEnabled    = "totp_enabled"
+	keyTOTPLastStep   = "totp_last_step"
 )
 
 // Repo manages the set of APIs for user access.
@@ -104,6 +109,7 @@ func (r *Repo) Edit(ctx context.Context, user *model.User) error {
 		SetMap(sq.Eq{
 			keyName:      user.Name,
 			keyEmail:     user.Email,
+			keyPassword:  user.Password,
 			keyRoles:     user.Roles,
 			keyFCM:       user.Fcm,
 			keyUpdatedAt: time.Now(),
@@ -254,7 +260,7 @@ func (r *Repo) Delete(ctx context.Context, id xulid.ULID) error {
 	return nil
 }
 
-// ChangePassword ...
+// ChangePassword update password and time of token revocation
 func (r *Repo) ChangePassword(ctx context.Context, user *model.User) error {
 	ctx, span := observ.GetTracer().Start(ctx, "user-repo-ChangePassword")
 	defer span.End()
@@ -264,9 +270,10 @@ func (r *Repo) ChangePassword(ctx context.Context, user *model.User) error {
 
 	sqlStatement, args, err := r.sb.Update(keyTable).
 		SetMap(sq.Eq{
-			keyName:      user.Password,
-			keyUpdatedAt: time.Now(),
-			keyVersion:   user.Version + 1,
+			keyPassword:       user.Password,
+			keyTokenRevokedAt: user.TokenRevokedAt,
+			keyUpdatedAt:      time.Now(),
+			keyVersion:        user.Version + 1,
 		}).
 		Where(sq.Eq{keyID: user.ID}).
 		Suffix(db.Returning(keyVersion)).
@@ -308,6 +315,9 @@ func (r *Repo) GetByID(ctx context.Context, ulid xulid.ULID) (model.User, error)
 		keyCreatedAt,
 		keyUpdatedAt,
 		keyVersion,
+		keyTokenRevokedAt,
+		keyTOTPSecret,
+		keyTOTPEnabled,
 	).From(keyTable).Where(sq.Eq{keyID: ulid}).ToSql()
 
 	if err != nil {
@@ -327,7 +337,10 @@ func (r *Repo) GetByID(ctx context.Context, ulid xulid.ULID) (model.User, error)
 			&user.Fcm,
 			&user.CreatedAt,
 			&user.UpdatedAt,
-			&user.Version)
+			&user.Version,
+			&user.TokenRevokedAt,
+			&user.TOTPSecret,
+			&user.TOTPEnabled)
 	if err != nil {
 		r.log.InfoT(ctx, err.Error())
 		return model.User{}, db.ParseError(err)
@@ -412,6 +425,8 @@ func (r *Repo) GetByEmail(ctx context.Context, email string) (model.User, error)
 		keyCreatedAt,
 		keyUpdatedAt,
 		keyVersion,
+		keyTOTPSecret,
+		keyTOTPEnabled,
 	).From(keyTable).Where(sq.Eq{keyEmail: email}).ToSql()
 
 	if err != nil {
@@ -431,7 +446,9 @@ func (r *Repo) GetByEmail(ctx context.Context, email string) (model.User, error)
 			&user.Fcm,
 			&user.CreatedAt,
 			&user.UpdatedAt,
-			&user.Version)
+			&user.Version,
+			&user.TOTPSecret,
+			&user.TOTPEnabled)
 	if err != nil {
 		r.log.InfoT(ctx, err.Error())
 		return model.User{}, db.ParseError(err)
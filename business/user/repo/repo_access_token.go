@@ -0,0 +1,258 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyPATTable      = "personal_access_tokens"
+	keyPATID         = "id"
+	keyPATUserID     = "user_id"
+	keyPATName       = "name"
+	keyPATTokenHash  = "token_hash"
+	keyPATTokenHint  = "token_hint"
+	keyPATScopes     = "scopes"
+	keyPATPocketIDs  = "pocket_ids"
+	keyPATExpiredAt  = "expired_at"
+	keyPATLastUsedAt = "last_used_at"
+	keyPATLastUsedIP = "last_used_ip"
+	keyPATRevokedAt  = "revoked_at"
+	keyPATCreatedAt  = "created_at"
+)
+
+// InsertAccessToken ...
+func (r *Repo) InsertAccessToken(ctx context.Context, token *model.PersonalAccessToken) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InsertAccessToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyPATTable).
+		Columns(
+			keyPATID,
+			keyPATUserID,
+			keyPATName,
+			keyPATTokenHash,
+			keyPATTokenHint,
+			keyPATScopes,
+			keyPATPocketIDs,
+			keyPATExpiredAt,
+			keyPATCreatedAt,
+		).
+		Values(
+			token.ID,
+			token.UserID,
+			token.Name,
+			token.TokenHash,
+			token.TokenHint,
+			token.Scopes,
+			token.PocketIDs,
+			token.ExpiredAt,
+			token.CreatedAt,
+		).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query insert access token: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// TouchAccessToken update last used time and ip of token
+func (r *Repo) TouchAccessToken(ctx context.Context, id xulid.ULID, usedAt time.Time, ip string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-TouchAccessToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyPATTable).
+		SetMap(sq.Eq{
+			keyPATLastUsedAt: usedAt,
+			keyPATLastUsedIP: ip,
+		}).
+		Where(sq.Eq{keyPATID: id}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query touch access token: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken revoke token owned by user, return count of token revoked
+func (r *Repo) RevokeAccessToken(ctx context.Context, userID xulid.ULID, id xulid.ULID, revokedAt time.Time) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-RevokeAccessToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyPATTable).
+		Set(keyPATRevokedAt, revokedAt).
+		Where(sq.Eq{
+			keyPATID:        id,
+			keyPATUserID:    userID,
+			keyPATRevokedAt: nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("build query revoke access token: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// GetAccessTokenByHash ...
+func (r *Repo) GetAccessTokenByHash(ctx context.Context, tokenHash string) (model.PersonalAccessToken, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-GetAccessTokenByHash")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.selectAccessToken().
+		Where(sq.Eq{keyPATTokenHash: tokenHash}).
+		ToSql()
+
+	if err != nil {
+		return model.PersonalAccessToken{}, fmt.Errorf("build query get access token by hash: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var token model.PersonalAccessToken
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.TokenHint,
+		&token.Scopes,
+		&token.PocketIDs,
+		&token.ExpiredAt,
+		&token.LastUsedAt,
+		&token.LastUsedIP,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.PersonalAccessToken{}, db.ParseError(err)
+	}
+
+	return token, nil
+}
+
+// FindAccessTokens get not revoked token of user, newest first
+func (r *Repo) FindAccessTokens(ctx context.Context, userID xulid.ULID) ([]model.PersonalAccessToken, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-FindAccessTokens")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.selectAccessToken().
+		Where(sq.Eq{
+			keyPATUserID:    userID,
+			keyPATRevokedAt: nil,
+		}).
+		OrderBy(keyPATCreatedAt + " DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find access tokens: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	tokens := make([]model.PersonalAccessToken, 0)
+	for rows.Next() {
+		var token model.PersonalAccessToken
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.TokenHash,
+			&token.TokenHint,
+			&token.Scopes,
+			&token.PocketIDs,
+			&token.ExpiredAt,
+			&token.LastUsedAt,
+			&token.LastUsedIP,
+			&token.RevokedAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *Repo) selectAccessToken() sq.SelectBuilder {
+	return r.sb.Select(
+		keyPATID,
+		keyPATUserID,
+		keyPATName,
+		keyPATTokenHash,
+		keyPATTokenHint,
+		keyPATScopes,
+		keyPATPocketIDs,
+		keyPATExpiredAt,
+		keyPATLastUsedAt,
+		keyPATLastUsedIP,
+		keyPATRevokedAt,
+		keyPATCreatedAt,
+	).From(keyPATTable)
+}
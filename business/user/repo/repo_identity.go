@@ -0,0 +1,250 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyIdentityTable       = "user_identities"
+	keyIdentityID          = "id"
+	keyIdentityUserID      = "user_id"
+	keyIdentityProvider    = "provider"
+	keyIdentitySubject     = "subject"
+	keyIdentityEmail       = "email"
+	keyIdentityCreatedAt   = "created_at"
+	keyIdentityLastLoginAt = "last_login_at"
+)
+
+const (
+	keyOIDCStateTable        = "oidc_states"
+	keyOIDCStateID           = "id"
+	keyOIDCStateStateHash    = "state_hash"
+	keyOIDCStateNonce        = "nonce"
+	keyOIDCStateCodeVerifier = "code_verifier"
+	keyOIDCStateExpiredAt    = "expired_at"
+	keyOIDCStateUsedAt       = "used_at"
+	keyOIDCStateCreatedAt    = "created_at"
+)
+
+// InsertIdentity ...
+func (r *Repo) InsertIdentity(ctx context.Context, identity *model.Identity) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InsertIdentity")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyIdentityTable).
+		Columns(
+			keyIdentityID,
+			keyIdentityUserID,
+			keyIdentityProvider,
+			keyIdentitySubject,
+			keyIdentityEmail,
+			keyIdentityCreatedAt,
+			keyIdentityLastLoginAt,
+		).
+		Values(
+			identity.ID,
+			identity.UserID,
+			identity.Provider,
+			identity.Subject,
+			identity.Email,
+			identity.CreatedAt,
+			identity.LastLoginAt,
+		).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query insert identity: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// GetIdentity get identity by provider and subject
+func (r *Repo) GetIdentity(ctx context.Context, provider string, subject string) (model.Identity, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-GetIdentity")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyIdentityID,
+		keyIdentityUserID,
+		keyIdentityProvider,
+		keyIdentitySubject,
+		keyIdentityEmail,
+		keyIdentityCreatedAt,
+		keyIdentityLastLoginAt,
+	).
+		From(keyIdentityTable).
+		Where(sq.Eq{
+			keyIdentityProvider: provider,
+			keyIdentitySubject:  subject,
+		}).ToSql()
+
+	if err != nil {
+		return model.Identity{}, fmt.Errorf("build query get identity: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var identity model.Identity
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Identity{}, db.ParseError(err)
+	}
+
+	return identity, nil
+}
+
+// TouchIdentity update last login time of identity
+func (r *Repo) TouchIdentity(ctx context.Context, id xulid.ULID, lastLoginAt time.Time) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-TouchIdentity")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyIdentityTable).
+		Set(keyIdentityLastLoginAt, lastLoginAt).
+		Where(sq.Eq{keyIdentityID: id}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query touch identity: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// InsertOIDCState ...
+func (r *Repo) InsertOIDCState(ctx context.Context, state *model.OIDCState) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InsertOIDCState")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyOIDCStateTable).
+		Columns(
+			keyOIDCStateID,
+			keyOIDCStateStateHash,
+			keyOIDCStateNonce,
+			keyOIDCStateCodeVerifier,
+			keyOIDCStateExpiredAt,
+			keyOIDCStateCreatedAt,
+		).
+		Values(
+			state.ID,
+			state.StateHash,
+			state.Nonce,
+			state.CodeVerifier,
+			state.ExpiredAt,
+			state.CreatedAt,
+		).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query insert oidc state: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// UseOIDCState mark state as used and return it.
+// return db.ErrDBNotFound if state is unknown, expired or already used
+func (r *Repo) UseOIDCState(ctx context.Context, stateHash string, usedAt time.Time) (model.OIDCState, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-UseOIDCState")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyOIDCStateTable).
+		Set(keyOIDCStateUsedAt, usedAt).
+		Where(sq.And{
+			sq.Eq{keyOIDCStateStateHash: stateHash},
+			sq.Eq{keyOIDCStateUsedAt: nil},
+			sq.Gt{keyOIDCStateExpiredAt: usedAt},
+		}).
+		Suffix(db.Returning(
+			keyOIDCStateID,
+			keyOIDCStateStateHash,
+			keyOIDCStateNonce,
+			keyOIDCStateCodeVerifier,
+			keyOIDCStateExpiredAt,
+			keyOIDCStateUsedAt,
+			keyOIDCStateCreatedAt,
+		)).
+		ToSql()
+
+	if err != nil {
+		return model.OIDCState{}, fmt.Errorf("build query use oidc state: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var state model.OIDCState
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&state.ID,
+		&state.StateHash,
+		&state.Nonce,
+		&state.CodeVerifier,
+		&state.ExpiredAt,
+		&state.UsedAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.OIDCState{}, db.ParseError(err)
+	}
+
+	return state, nil
+}
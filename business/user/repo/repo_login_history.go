@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyLoginHistoryTable     = "login_histories"
+	keyLoginHistoryID        = "id"
+	keyLoginHistoryUserID    = "user_id"
+	keyLoginHistoryEmail     = "email"
+	keyLoginHistoryIP        = "ip"
+	keyLoginHistoryUserAgent = "user_agent"
+	keyLoginHistoryOutcome   = "outcome"
+	keyLoginHistoryCreatedAt = "created_at"
+)
+
+// InsertLoginHistory ...
+func (r *Repo) InsertLoginHistory(ctx context.Context, history *model.LoginHistory) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InsertLoginHistory")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyLoginHistoryTable).
+		Columns(
+			keyLoginHistoryID,
+			keyLoginHistoryUserID,
+			keyLoginHistoryEmail,
+			keyLoginHistoryIP,
+			keyLoginHistoryUserAgent,
+			keyLoginHistoryOutcome,
+			keyLoginHistoryCreatedAt,
+		).
+		Values(
+			history.ID,
+			history.UserID,
+			history.Email,
+			history.IP,
+			history.UserAgent,
+			history.Outcome,
+			history.CreatedAt,
+		).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query insert login history: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// FindLoginHistory get login attempt of user
+func (r *Repo) FindLoginHistory(ctx context.Context, userID xulid.ULID, filter paging.Filters) ([]model.LoginHistory, paging.Metadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-FindLoginHistory")
+	defer span.End()
+
+	// Validation filter
+	filter.SortSafelist = []string{"-created_at", "created_at"}
+	if err := filter.Validate(); err != nil {
+		return nil, paging.Metadata{}, db.ErrDBSortFilter
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		"count(*) OVER()",
+		keyLoginHistoryID,
+		keyLoginHistoryUserID,
+		keyLoginHistoryEmail,
+		keyLoginHistoryIP,
+		keyLoginHistoryUserAgent,
+		keyLoginHistoryOutcome,
+		keyLoginHistoryCreatedAt,
+	).From(keyLoginHistoryTable).
+		Where(sq.Eq{keyLoginHistoryUserID: userID}).
+		OrderBy(filter.SortColumnDirection()).
+		Limit(uint64(filter.Limit())).
+		Offset(uint64(filter.Offset())).
+		ToSql()
+
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("build query find login history: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, paging.Metadata{}, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	histories := make([]model.LoginHistory, 0)
+	for rows.Next() {
+		var history model.LoginHistory
+		err := rows.Scan(
+			&totalRecords,
+			&history.ID,
+			&history.UserID,
+			&history.Email,
+			&history.IP,
+			&history.UserAgent,
+			&history.Outcome,
+			&history.CreatedAt)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, paging.Metadata{}, db.ParseError(err)
+		}
+		histories = append(histories, history)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, paging.Metadata{}, err
+	}
+
+	metadata := paging.CalculateMetadata(totalRecords, filter.Page, filter.PageSize)
+
+	return histories, metadata, nil
+}
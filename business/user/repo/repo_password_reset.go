@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyResetTable     = "password_resets"
+	keyResetID        = "id"
+	keyResetUserID    = "user_id"
+	keyResetTokenHash = "token_hash"
+	keyResetExpiredAt = "expired_at"
+	keyResetUsedAt    = "used_at"
+	keyResetCreatedAt = "created_at"
+)
+
+// InsertPasswordReset ...
+func (r *Repo) InsertPasswordReset(ctx context.Context, reset *model.PasswordReset) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InsertPasswordReset")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyResetTable).
+		Columns(
+			keyResetID,
+			keyResetUserID,
+			keyResetTokenHash,
+			keyResetExpiredAt,
+			keyResetCreatedAt,
+		).
+		Values(
+			reset.ID,
+			reset.UserID,
+			reset.TokenHash,
+			reset.ExpiredAt,
+			reset.CreatedAt,
+		).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query insert password reset: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// UsePasswordReset mark token as used and return it.
+// return db.ErrDBNotFound if token is unknown, expired or already used
+func (r *Repo) UsePasswordReset(ctx context.Context, tokenHash string, usedAt time.Time) (model.PasswordReset, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-UsePasswordReset")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyResetTable).
+		Set(keyResetUsedAt, usedAt).
+		Where(sq.And{
+			sq.Eq{keyResetTokenHash: tokenHash},
+			sq.Eq{keyResetUsedAt: nil},
+			sq.Gt{keyResetExpiredAt: usedAt},
+		}).
+		Suffix(db.Returning(
+			keyResetID,
+			keyResetUserID,
+			keyResetTokenHash,
+			keyResetExpiredAt,
+			keyResetUsedAt,
+			keyResetCreatedAt,
+		)).
+		ToSql()
+
+	if err != nil {
+		return model.PasswordReset{}, fmt.Errorf("build query use password reset: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var reset model.PasswordReset
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&reset.ID,
+		&reset.UserID,
+		&reset.TokenHash,
+		&reset.ExpiredAt,
+		&reset.UsedAt,
+		&reset.CreatedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.PasswordReset{}, db.ParseError(err)
+	}
+
+	return reset, nil
+}
+
+// InvalidatePasswordResets mark all unused token of user as used
+func (r *Repo) InvalidatePasswordResets(ctx context.Context, userID xulid.ULID, usedAt time.Time) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InvalidatePasswordResets")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyResetTable).
+		Set(keyResetUsedAt, usedAt).
+		Where(sq.Eq{
+			keyResetUserID: userID,
+			keyResetUsedAt: nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query invalidate password reset: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyPrefTable          = "user_preferences"
+	keyPrefUserID         = "user_id"
+	keyPrefTimezone       = "timezone"
+	keyPrefLocale         = "locale"
+	keyPrefDefaultPocket  = "default_pocket_id"
+	keyPrefHomeCurrency   = "home_currency"
+	keyPrefFirstDayOfWeek = "first_day_of_week"
+	keyPrefUpdatedAt      = "updated_at"
+)
+
+// UpsertPreference insert or replace preference of user
+func (r *Repo) UpsertPreference(ctx context.Context, pref *model.Preference) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-UpsertPreference")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyPrefTable).
+		Columns(
+			keyPrefUserID,
+			keyPrefTimezone,
+			keyPrefLocale,
+			keyPrefDefaultPocket,
+			keyPrefHomeCurrency,
+			keyPrefFirstDayOfWeek,
+			keyPrefUpdatedAt,
+		).
+		Values(
+			pref.UserID,
+			pref.Timezone,
+			pref.Locale,
+			pref.DefaultPocketID,
+			pref.HomeCurrency,
+			pref.FirstDayOfWeek,
+			pref.UpdatedAt,
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s",
+			keyPrefUserID,
+			keyPrefTimezone, keyPrefTimezone,
+			keyPrefLocale, keyPrefLocale,
+			keyPrefDefaultPocket, keyPrefDefaultPocket,
+			keyPrefHomeCurrency, keyPrefHomeCurrency,
+			keyPrefFirstDayOfWeek, keyPrefFirstDayOfWeek,
+			keyPrefUpdatedAt, keyPrefUpdatedAt,
+		)).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query upsert preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// GetPreference get preference of user, return default preference if user never set it
+func (r *Repo) GetPreference(ctx context.Context, userID xulid.ULID) (model.Preference, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-GetPreference")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyPrefUserID,
+		keyPrefTimezone,
+		keyPrefLocale,
+		keyPrefDefaultPocket,
+		keyPrefHomeCurrency,
+		keyPrefFirstDayOfWeek,
+		keyPrefUpdatedAt,
+	).
+		From(keyPrefTable).
+		Where(sq.Eq{keyPrefUserID: userID}).ToSql()
+
+	if err != nil {
+		return model.Preference{}, fmt.Errorf("build query get preference: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var pref model.Preference
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&pref.UserID,
+		&pref.Timezone,
+		&pref.Locale,
+		&pref.DefaultPocketID,
+		&pref.HomeCurrency,
+		&pref.FirstDayOfWeek,
+		&pref.UpdatedAt,
+	)
+	if err != nil {
+		err = db.ParseError(err)
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.DefaultPreference(userID), nil
+		}
+		r.log.InfoT(ctx, err.Error())
+		return model.Preference{}, err
+	}
+
+	pref.ApplyDefault()
+	return pref, nil
+}
+
+// GetPreferences get preference of many user keyed by user id,
+// user who never set preference is not included
+func (r *Repo) GetPreferences(ctx context.Context, userIDs []string) (map[string]model.Preference, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-GetPreferences")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyPrefUserID,
+		keyPrefTimezone,
+		keyPrefLocale,
+		keyPrefDefaultPocket,
+		keyPrefHomeCurrency,
+		keyPrefFirstDayOfWeek,
+		keyPrefUpdatedAt,
+	).
+		From(keyPrefTable).
+		Where(sq.Eq{keyPrefUserID: userIDs}).ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query get preferences: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]model.Preference, len(userIDs))
+	for rows.Next() {
+		var pref model.Preference
+		err := rows.Scan(
+			&pref.UserID,
+			&pref.Timezone,
+			&pref.Locale,
+			&pref.DefaultPocketID,
+			&pref.HomeCurrency,
+			&pref.FirstDayOfWeek,
+			&pref.UpdatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		pref.ApplyDefault()
+		prefs[pref.UserID.String()] = pref
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// IsPocketMember check if user is registered in user_pocket of the pocket
+func (r *Repo) IsPocketMember(ctx context.Context, userID xulid.ULID, pocketID xulid.ULID) (bool, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-IsPocketMember")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select("COUNT(*)").
+		From("user_pocket").
+		Where(sq.Eq{
+			"user_id":   userID,
+			"pocket_id": pocketID,
+		}).ToSql()
+
+	if err != nil {
+		return false, fmt.Errorf("build query check pocket member: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var count int
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&count)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return false, db.ParseError(err)
+	}
+
+	return count > 0, nil
+}
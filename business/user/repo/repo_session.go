@@ -0,0 +1,260 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keySessionTable      = "user_sessions"
+	keySessionID         = "id"
+	keySessionUserID     = "user_id"
+	keySessionRefreshJTI = "refresh_jti"
+	keySessionUserAgent  = "user_agent"
+	keySessionIP         = "ip"
+	keySessionCreatedAt  = "created_at"
+	keySessionLastUsedAt = "last_used_at"
+	keySessionExpiredAt  = "expired_at"
+	keySessionRevokedAt  = "revoked_at"
+)
+
+// InsertSession ...
+func (r *Repo) InsertSession(ctx context.Context, session *model.Session) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-InsertSession")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keySessionTable).
+		Columns(
+			keySessionID,
+			keySessionUserID,
+			keySessionRefreshJTI,
+			keySessionUserAgent,
+			keySessionIP,
+			keySessionCreatedAt,
+			keySessionLastUsedAt,
+			keySessionExpiredAt,
+		).
+		Values(
+			session.ID,
+			session.UserID,
+			session.RefreshJTI,
+			session.UserAgent,
+			session.IP,
+			session.CreatedAt,
+			session.LastUsedAt,
+			session.ExpiredAt,
+		).ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query insert session: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// RotateSession replace refresh jti of session only if current jti is oldJTI.
+// return db.ErrDBNotFound if jti already changed by other request
+func (r *Repo) RotateSession(ctx context.Context, session *model.Session, oldJTI string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-RotateSession")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keySessionTable).
+		SetMap(sq.Eq{
+			keySessionRefreshJTI: session.RefreshJTI,
+			keySessionUserAgent:  session.UserAgent,
+			keySessionIP:         session.IP,
+			keySessionLastUsedAt: session.LastUsedAt,
+		}).
+		Where(sq.Eq{
+			keySessionID:         session.ID,
+			keySessionRefreshJTI: oldJTI,
+			keySessionRevokedAt:  nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query rotate session: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// RevokeSessions revoke sessions of user. if sessionIDs is empty all active session of user is revoked.
+// return count of session revoked
+func (r *Repo) RevokeSessions(ctx context.Context, userID xulid.ULID, sessionIDs []string, revokedAt time.Time) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-RevokeSessions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	where := sq.Eq{
+		keySessionUserID:    userID,
+		keySessionRevokedAt: nil,
+	}
+	if len(sessionIDs) != 0 {
+		where[keySessionID] = sessionIDs
+	}
+
+	sqlStatement, args, err := r.sb.Update(keySessionTable).
+		Set(keySessionRevokedAt, revokedAt).
+		Where(where).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("build query revoke session: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return 0, db.ParseError(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// GetSessionByID ...
+func (r *Repo) GetSessionByID(ctx context.Context, id string) (model.Session, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-GetSessionByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.selectSession().
+		Where(sq.Eq{keySessionID: id}).
+		ToSql()
+
+	if err != nil {
+		return model.Session{}, fmt.Errorf("build query get session by id: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var session model.Session
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshJTI,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiredAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Session{}, db.ParseError(err)
+	}
+
+	return session, nil
+}
+
+// FindActiveSessions get not revoked and not expired session of user, newest used first
+func (r *Repo) FindActiveSessions(ctx context.Context, userID xulid.ULID) ([]model.Session, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-FindActiveSessions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.selectSession().
+		Where(sq.Eq{
+			keySessionUserID:    userID,
+			keySessionRevokedAt: nil,
+		}).
+		Where(sq.Gt{keySessionExpiredAt: time.Now()}).
+		OrderBy(keySessionLastUsedAt + " DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("build query find session: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	sessions := make([]model.Session, 0)
+	for rows.Next() {
+		var session model.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.RefreshJTI,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiredAt,
+			&session.RevokedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *Repo) selectSession() sq.SelectBuilder {
+	return r.sb.Select(
+		keySessionID,
+		keySessionUserID,
+		keySessionRefreshJTI,
+		keySessionUserAgent,
+		keySessionIP,
+		keySessionCreatedAt,
+		keySessionLastUsedAt,
+		keySessionExpiredAt,
+		keySessionRevokedAt,
+	).From(keySessionTable)
+}
@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	keyRecoveryTable     = "user_recovery_codes"
+	keyRecoveryID        = "id"
+	keyRecoveryUserID    = "user_id"
+	keyRecoveryCodeHash  = "code_hash"
+	keyRecoveryUsedAt    = "used_at"
+	keyRecoveryCreatedAt = "created_at"
+)
+
+// UpdateTOTP update totp secret and totp status of user
+func (r *Repo) UpdateTOTP(ctx context.Context, user *model.User) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-UpdateTOTP")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		SetMap(sq.Eq{
+			keyTOTPSecret:  user.TOTPSecret,
+			keyTOTPEnabled: user.TOTPEnabled,
+			keyUpdatedAt:   time.Now(),
+			keyVersion:     user.Version + 1,
+		}).
+		Where(sq.Eq{keyID: user.ID}).
+		Suffix(db.Returning(keyVersion)).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query update totp user: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&user.Version)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes delete all recovery code of user and insert the new one.
+// empty codes only delete
+func (r *Repo) ReplaceRecoveryCodes(ctx context.Context, userID xulid.ULID, codes []model.RecoveryCode) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-ReplaceRecoveryCodes")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	sqlStatement, args, err := r.sb.Delete(keyRecoveryTable).
+		Where(sq.Eq{keyRecoveryUserID: userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query delete recovery codes: %w", err)
+	}
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	sqlInsert := r.sb.Insert(keyRecoveryTable).
+		Columns(
+			keyRecoveryID,
+			keyRecoveryUserID,
+			keyRecoveryCodeHash,
+			keyRecoveryCreatedAt,
+		)
+	for _, code := range codes {
+		sqlInsert = sqlInsert.Values(
+			code.ID,
+			code.UserID,
+			code.CodeHash,
+			code.CreatedAt,
+		)
+	}
+
+	sqlStatement, args, err = sqlInsert.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query insert recovery codes: %w", err)
+	}
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode mark unused recovery code of user as used.
+// return db.ErrDBNotFound if user does not have the code or it is already used by other request
+func (r *Repo) UseRecoveryCode(ctx context.Context, userID xulid.ULID, codeHash []byte, usedAt time.Time) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-UseRecoveryCode")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyRecoveryTable).
+		Set(keyRecoveryUsedAt, usedAt).
+		Where(sq.Eq{
+			keyRecoveryUserID:   userID,
+			keyRecoveryCodeHash: codeHash,
+			keyRecoveryUsedAt:   nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query use recovery code: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// UseTOTPStep save time step of accepted totp code.
+// return db.ErrDBNotFound if code of the step or later already accepted, so code cannot be replayed
+func (r *Repo) UseTOTPStep(ctx context.Context, userID xulid.ULID, step int64) error {
+	ctx, span := observ.GetTracer().Start(ctx, "user-repo-UseTOTPStep")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		Set(keyTOTPLastStep, step).
+		Where(sq.Eq{keyID: userID}).
+		Where(sq.Lt{keyTOTPLastStep: step}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("build query use totp step: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
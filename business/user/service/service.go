@@ -8,6 +8,7 @@ import (
 
 	"github.com/muchlist/moneymagnet/business/user/model"
 	"github.com/muchlist/moneymagnet/business/user/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
 	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/mjwt"
 	"github.com/muchlist/moneymagnet/pkg/observ"
@@ -21,7 +22,7 @@ import (
 // Set of error variables for CRUD operations.
 var (
 	ErrNotFound           = errors.New("data not found")
-	ErrInvalidID          = errors.New("ID is not in its proper form")
+	ErrInvalidID          = errr.T("error.invalid_id", nil)
 	ErrInvalidEmailOrPass = errors.New("email or password not valid")
 )
 
@@ -32,86 +33,136 @@ const (
 
 // Core manages the set of APIs for user access.
 type Core struct {
-	log    mlogger.Logger
-	repo   port.UserStorer
-	crypto mcrypto.Crypter
-	jwt    mjwt.TokenHandler
+	log         mlogger.Logger
+	repo        port.UserStorer
+	crypto      mcrypto.Crypter
+	jwt         mjwt.TokenHandler
+	mailer      port.MailSender
+	topicSyncer port.TopicSyncer
+	txManager   port.Transactor
+	attempts    port.AttemptCounter
+	resetURL    string
+
+	// oidc login, nil if not enabled
+	oidc            port.IdentityProvider
+	oidcName        string
+	oidcAllowSignup bool
 }
 
 // NewCore constructs a core for user api access.
+// resetURL is link sent in reset password email, can be empty
 func NewCore(
 	log mlogger.Logger,
 	repo port.UserStorer,
 	crypto mcrypto.Crypter,
 	jwt mjwt.TokenHandler,
+	mailer port.MailSender,
+	topicSyncer port.TopicSyncer,
+	txManager port.Transactor,
+	attempts port.AttemptCounter,
+	resetURL string,
 ) *Core {
 	return &Core{
-		log:    log,
-		repo:   repo,
-		crypto: crypto,
-		jwt:    jwt,
+		log:         log,
+		repo:        repo,
+		crypto:      crypto,
+		jwt:         jwt,
+		mailer:      mailer,
+		topicSyncer: topicSyncer,
+		txManager:   txManager,
+		attempts:    attempts,
+		resetURL:    resetURL,
 	}
 }
 
-// Login return detail user with access token and refresh token
-func (s *Core) Login(ctx context.Context, email, password string) (model.UserResp, error) {
+// Login return detail user with access token and refresh token.
+// every login create new session for the device.
+// if two-factor enabled, only challenge token returned
+func (s *Core) Login(ctx context.Context, email, password string, client model.ClientInfo) (model.UserResp, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "service-Login")
 	defer span.End()
 
+	// reject before touching password, so locked account can not be guessed
+	if err := s.checkLoginLock(ctx, email, client.IP); err != nil {
+		s.recordLogin(ctx, xulid.NullULID{}, email, client, model.LoginLocked)
+		return model.UserResp{}, err
+	}
+
 	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
+		s.registerLoginFailure(ctx, email, client.IP)
+		s.recordLogin(ctx, xulid.NullULID{}, email, client, model.LoginInvalidCredentials)
 		return model.UserResp{}, fmt.Errorf("%v: %w", err, ErrInvalidEmailOrPass)
 	}
+	userID := xulid.NullULID{ULID: user.ID, Valid: true}
 
 	if !s.crypto.IsPWAndHashPWMatch([]byte(password), user.Password) {
+		s.registerLoginFailure(ctx, email, client.IP)
+		s.recordLogin(ctx, userID, email, client, model.LoginInvalidCredentials)
 		return model.UserResp{}, fmt.Errorf("%v: %w", err, ErrInvalidEmailOrPass)
 	}
+	s.rehashPassword(ctx, &user, password)
 
-	expired := time.Now().Add(time.Minute * expiredJWTToken).Unix()
-
-	AccessClaims := mjwt.CustomClaim{
-		Identity: user.ID.String(),
-		Name:     user.Name,
-		Exp:      expired,
-		Type:     mjwt.Access,
-		Fresh:    true,
-		Roles:    user.Roles,
+	// second step required, tokens issued in LoginTOTP.
+	// failure counter is kept until code is valid
+	if user.TOTPEnabled {
+		s.recordLogin(ctx, userID, email, client, model.LoginMFARequired)
+		return s.challengeResp(user)
 	}
 
-	expiredRefresh := time.Now().Add(time.Minute * expiredJWTRefreshToken).Unix()
-	RefreshClaims := mjwt.CustomClaim{
-		Identity: user.ID.String(),
-		Name:     user.Name,
-		Exp:      expiredRefresh,
-		Type:     mjwt.Refresh,
-		Fresh:    false,
-		Roles:    user.Roles,
-	}
+	s.resetLoginFailure(ctx, email)
+	s.recordLogin(ctx, userID, email, client, model.LoginSuccess)
 
-	accessToken, err := s.jwt.GenerateToken(AccessClaims)
+	session, err := s.createSession(ctx, user, client)
 	if err != nil {
-		return model.UserResp{}, fmt.Errorf("fail to generate token when login: %w", err)
+		return model.UserResp{}, err
 	}
-	refreshToken, err := s.jwt.GenerateToken(RefreshClaims)
+
+	return s.issueTokens(user, session, true)
+}
+
+// rehashPassword upgrade stored hash when it use outdated algorithm or cost.
+// plain password only available on login, failure does not block login
+func (s *Core) rehashPassword(ctx context.Context, user *model.User, password string) {
+	if !s.crypto.NeedsRehash(user.Password) {
+		return
+	}
+	hashPassword, err := s.crypto.GenerateHash(password)
 	if err != nil {
-		return model.UserResp{}, fmt.Errorf("fail to generate token when login: %w", err)
+		s.log.ErrorT(ctx, "error generate hash when rehash password", err)
+		return
+	}
+	user.Password = hashPassword
+	if err := s.repo.ChangePassword(ctx, user); err != nil {
+		s.log.ErrorT(ctx, fmt.Sprintf("error rehash password user %s", user.ID.String()), err)
 	}
+}
 
-	response := model.UserResp{
-		ID:                  user.ID,
-		Email:               user.Email,
-		Name:                user.Name,
-		Roles:               user.Roles,
-		CreatedAt:           user.CreatedAt,
-		UpdatedAt:           user.UpdatedAt,
-		Version:             user.Version,
-		AccessToken:         accessToken,
-		AccessTokenExpired:  expired,
-		RefreshToken:        refreshToken,
-		RefreshTokenExpired: expiredRefresh,
+// checkPasswordPolicy wrap password policy error as bad request
+func checkPasswordPolicy(password string, userInputs ...string) error {
+	if err := mcrypto.CheckPasswordPolicy(password, userInputs...); err != nil {
+		return errr.Wrap(err, 400)
 	}
+	return nil
+}
 
-	return response, nil
+// createSession create new session for the device
+func (s *Core) createSession(ctx context.Context, user model.User, client model.ClientInfo) (model.Session, error) {
+	timeNow := time.Now()
+	session := model.Session{
+		ID:         xulid.Instance().NewULID(),
+		UserID:     user.ID,
+		RefreshJTI: xulid.Instance().NewULID().String(),
+		UserAgent:  truncate(client.UserAgent, 255),
+		IP:         truncate(client.IP, 64),
+		CreatedAt:  timeNow,
+		LastUsedAt: timeNow,
+		ExpiredAt:  timeNow.Add(time.Minute * expiredJWTRefreshToken),
+	}
+	if err := s.repo.InsertSession(ctx, &session); err != nil {
+		return model.Session{}, fmt.Errorf("insert session when login: %w", err)
+	}
+	return session, nil
 }
 
 // InsertUser used for register user
@@ -119,6 +170,10 @@ func (s *Core) InsertUser(ctx context.Context, req model.UserRegisterReq) (model
 	ctx, span := observ.GetTracer().Start(ctx, "service-InsertUser")
 	defer span.End()
 
+	if err := checkPasswordPolicy(req.Password, req.Name, req.Email); err != nil {
+		return model.UserResp{}, err
+	}
+
 	hashPassword, err := s.crypto.GenerateHash(req.Password)
 	if err != nil {
 		return model.UserResp{}, fmt.Errorf("generate hashpw when insert user: %w", err)
@@ -178,6 +233,9 @@ func (s *Core) PatchUser(ctx context.Context, req model.UserUpdate) (model.UserR
 		userExisting.Roles = req.Roles
 	}
 	if req.Password != nil {
+		if err := checkPasswordPolicy(*req.Password, userExisting.Name, userExisting.Email); err != nil {
+			return model.UserResp{}, err
+		}
 		hashPassword, err := s.crypto.GenerateHash(*req.Password)
 		if err != nil {
 			return model.UserResp{}, fmt.Errorf("generate hashpw when edit user: %w", err)
@@ -204,10 +262,16 @@ func (s *Core) UpdateFCM(ctx context.Context, id string, fcm string) error {
 	if err != nil {
 		return ErrInvalidID
 	}
-	if err := s.repo.AppendFCM(ctx, userID, fcm); err != nil {
-		return fmt.Errorf("edit fcm: %w", err)
-	}
-	return nil
+	// new token subscribe to push topic of user pockets
+	return s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		if err := s.repo.AppendFCM(ctx, userID, fcm); err != nil {
+			return fmt.Errorf("edit fcm: %w", err)
+		}
+		if err := s.topicSyncer.SyncTopics(ctx, []string{userID.String()}); err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
+		return nil
+	})
 }
 
 // Delete ...
@@ -216,14 +280,24 @@ func (s *Core) Delete(ctx context.Context, userIDToDelete xulid.ULID, userIDExec
 	defer span.End()
 
 	if userIDExecutor == userIDToDelete {
-		return errr.New("cannot delete self profile", 400)
+		return errr.NewT("error.delete_self", nil, 400)
 	}
-	return s.repo.Delete(ctx, userIDToDelete)
+	// token of deleted user is unsubscribed from every push topic
+	return s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, userIDToDelete); err != nil {
+			return err
+		}
+		if err := s.topicSyncer.SyncTopics(ctx, []string{userIDToDelete.String()}); err != nil {
+			return fmt.Errorf("sync push topic: %w", err)
+		}
+		return nil
+	})
 }
 
 // Refresh do refresh token,
-// access token in reslt is new but tagged as not fresh
-func (s *Core) Refresh(ctx context.Context, refreshToken string) (model.UserResp, error) {
+// access token in reslt is new but tagged as not fresh.
+// refresh token is rotated, using old refresh token again revoke the session
+func (s *Core) Refresh(ctx context.Context, refreshToken string, client model.ClientInfo) (model.UserResp, error) {
 	ctx, span := observ.GetTracer().Start(ctx, "service-Refresh")
 	defer span.End()
 
@@ -238,43 +312,104 @@ func (s *Core) Refresh(ctx context.Context, refreshToken string) (model.UserResp
 	}
 
 	// cek claims type token
-	if claims.Type != mjwt.Refresh {
+	if claims.Type != mjwt.Refresh || claims.SessionID == "" {
 		return model.UserResp{}, mjwt.ErrInvalidToken
 	}
 
-	userID, _ := xulid.Parse(claims.Identity)
-	user, err := s.repo.GetByID(ctx, userID)
+	timeNow := time.Now()
+	session, err := s.repo.GetSessionByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.UserResp{}, mjwt.ErrInvalidToken
+		}
+		return model.UserResp{}, fmt.Errorf("get session by id: %w", err)
+	}
+	if !session.IsActive(timeNow) || session.UserID.String() != claims.Identity {
+		return model.UserResp{}, mjwt.ErrInvalidToken
+	}
+
+	// refresh token already rotated, somebody else may hold the token
+	if claims.TokenID != session.RefreshJTI {
+		s.revokeReusedSession(ctx, session)
+		return model.UserResp{}, mjwt.ErrInvalidToken
+	}
+
+	user, err := s.repo.GetByID(ctx, session.UserID)
 	if err != nil {
 		return model.UserResp{}, fmt.Errorf("%v: %w", err, ErrInvalidEmailOrPass)
 	}
 
+	// refresh token issued before password reset is no longer valid
+	if user.IsTokenRevoked(claims.IssuedAt) {
+		return model.UserResp{}, mjwt.ErrInvalidToken
+	}
+
+	session.RefreshJTI = xulid.Instance().NewULID().String()
+	session.UserAgent = truncate(client.UserAgent, 255)
+	session.IP = truncate(client.IP, 64)
+	session.LastUsedAt = timeNow
+	err = s.repo.RotateSession(ctx, &session, claims.TokenID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			// other request rotate the same token at the same time
+			s.revokeReusedSession(ctx, session)
+			return model.UserResp{}, mjwt.ErrInvalidToken
+		}
+		return model.UserResp{}, fmt.Errorf("rotate session: %w", err)
+	}
+
+	return s.issueTokens(user, session, false)
+}
+
+// issueTokens generate access token and refresh token bound to session
+func (s *Core) issueTokens(user model.User, session model.Session, fresh bool) (model.UserResp, error) {
 	expired := time.Now().Add(time.Minute * expiredJWTToken).Unix()
 
 	AccessClaims := mjwt.CustomClaim{
-		Identity: user.ID.String(),
-		Name:     user.Name,
-		Exp:      expired,
-		Type:     mjwt.Access,
-		Fresh:    false,
-		Roles:    user.Roles,
+		Identity:  user.ID.String(),
+		Name:      user.Name,
+		Exp:       expired,
+		Type:      mjwt.Access,
+		Fresh:     fresh,
+		Roles:     user.Roles,
+		SessionID: session.ID.String(),
+	}
+
+	expiredRefresh := session.ExpiredAt.Unix()
+	RefreshClaims := mjwt.CustomClaim{
+		Identity:  user.ID.String(),
+		Name:      user.Name,
+		Exp:       expiredRefresh,
+		Type:      mjwt.Refresh,
+		Fresh:     false,
+		Roles:     user.Roles,
+		SessionID: session.ID.String(),
+		TokenID:   session.RefreshJTI,
 	}
 
 	accessToken, err := s.jwt.GenerateToken(AccessClaims)
 	if err != nil {
-		return model.UserResp{}, fmt.Errorf("fail to generate token when login: %w", err)
+		return model.UserResp{}, fmt.Errorf("fail to generate token: %w", err)
+	}
+	refreshToken, err := s.jwt.GenerateToken(RefreshClaims)
+	if err != nil {
+		return model.UserResp{}, fmt.Errorf("fail to generate token: %w", err)
 	}
 
 	response := model.UserResp{
-		ID:                 user.ID,
-		Email:              user.Email,
-		Name:               user.Name,
-		Roles:              user.Roles,
-		CreatedAt:          user.CreatedAt,
-		UpdatedAt:          user.UpdatedAt,
-		Version:            user.Version,
-		AccessToken:        accessToken,
-		AccessTokenExpired: expired,
-		RefreshToken:       refreshToken,
+		ID:                  user.ID,
+		Email:               user.Email,
+		Name:                user.Name,
+		Roles:               user.Roles,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
+		Version:             user.Version,
+		TOTPEnabled:         user.TOTPEnabled,
+		SessionID:           session.ID.String(),
+		AccessToken:         accessToken,
+		AccessTokenExpired:  expired,
+		RefreshToken:        refreshToken,
+		RefreshTokenExpired: expiredRefresh,
 	}
 
 	return response, nil
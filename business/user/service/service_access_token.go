@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const (
+	accessTokenLength      = 32          // bytes, token is hex encoded after prefix
+	accessTokenHintLength  = 12          // char of raw token shown in list
+	accessTokenTouchPeriod = time.Minute // last used is not updated more often than this
+)
+
+// CreateAccessToken create personal access token for script and integration.
+// raw token only returned once
+func (s *Core) CreateAccessToken(ctx context.Context, claims mjwt.CustomClaim, req model.NewAccessTokenReq) (model.AccessTokenCreatedResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-CreateAccessToken")
+	defer span.End()
+
+	raw, err := generateAccessToken()
+	if err != nil {
+		return model.AccessTokenCreatedResp{}, err
+	}
+
+	timeNow := time.Now()
+	var expiredAt *time.Time
+	if req.ExpiredInDays > 0 {
+		exp := timeNow.AddDate(0, 0, req.ExpiredInDays)
+		expiredAt = &exp
+	}
+
+	pocketIDs := make([]string, len(req.PocketIDs))
+	for i, id := range req.PocketIDs {
+		pocketIDs[i] = id.String()
+	}
+
+	token := model.PersonalAccessToken{
+		ID:        xulid.Instance().NewULID(),
+		UserID:    claims.GetULID(),
+		Name:      req.Name,
+		TokenHash: hashToken(raw),
+		TokenHint: raw[:accessTokenHintLength],
+		Scopes:    req.Scopes,
+		PocketIDs: pocketIDs,
+		ExpiredAt: expiredAt,
+		CreatedAt: timeNow,
+	}
+	if err := s.repo.InsertAccessToken(ctx, &token); err != nil {
+		return model.AccessTokenCreatedResp{}, fmt.Errorf("insert access token: %w", err)
+	}
+
+	return model.AccessTokenCreatedResp{
+		AccessTokenResp: token.ToAccessTokenResp(),
+		Token:           raw,
+	}, nil
+}
+
+// FindAccessTokens return not revoked personal access token of user
+func (s *Core) FindAccessTokens(ctx context.Context, claims mjwt.CustomClaim) ([]model.AccessTokenResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindAccessTokens")
+	defer span.End()
+
+	tokens, err := s.repo.FindAccessTokens(ctx, claims.GetULID())
+	if err != nil {
+		return nil, fmt.Errorf("find access tokens: %w", err)
+	}
+
+	result := make([]model.AccessTokenResp, len(tokens))
+	for i := range tokens {
+		result[i] = tokens[i].ToAccessTokenResp()
+	}
+
+	return result, nil
+}
+
+// RevokeAccessToken make personal access token unusable
+func (s *Core) RevokeAccessToken(ctx context.Context, claims mjwt.CustomClaim, tokenID xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-RevokeAccessToken")
+	defer span.End()
+
+	count, err := s.repo.RevokeAccessToken(ctx, claims.GetULID(), tokenID, time.Now())
+	if err != nil {
+		return fmt.Errorf("revoke access token: %w", err)
+	}
+	if count == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// AuthenticateToken used by middleware to resolve personal access token to claims
+func (s *Core) AuthenticateToken(ctx context.Context, raw string, ip string) (mjwt.CustomClaim, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-AuthenticateToken")
+	defer span.End()
+
+	token, err := s.repo.GetAccessTokenByHash(ctx, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return mjwt.CustomClaim{}, mjwt.ErrInvalidToken
+		}
+		return mjwt.CustomClaim{}, fmt.Errorf("get access token: %w", err)
+	}
+
+	timeNow := time.Now()
+	if !token.IsActive(timeNow) {
+		return mjwt.CustomClaim{}, mjwt.ErrInvalidToken
+	}
+
+	user, err := s.repo.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return mjwt.CustomClaim{}, mjwt.ErrInvalidToken
+		}
+		return mjwt.CustomClaim{}, fmt.Errorf("get user by id: %w", err)
+	}
+
+	// avoid write on every request
+	if token.LastUsedAt == nil || timeNow.Sub(*token.LastUsedAt) > accessTokenTouchPeriod || token.LastUsedIP != ip {
+		if err := s.repo.TouchAccessToken(ctx, token.ID, timeNow, truncate(ip, 64)); err != nil {
+			s.log.ErrorT(ctx, "error update last used access token", err)
+		}
+	}
+
+	var exp int64
+	if token.ExpiredAt != nil {
+		exp = token.ExpiredAt.Unix()
+	}
+
+	return mjwt.CustomClaim{
+		Identity:  user.ID.String(),
+		Name:      user.Name,
+		Exp:       exp,
+		Type:      mjwt.Personal,
+		Fresh:     false,
+		Roles:     user.Roles,
+		TokenID:   token.ID.String(),
+		Scopes:    token.Scopes,
+		PocketIDs: token.PocketIDs,
+	}, nil
+}
+
+// generateAccessToken return random token with personal token prefix
+func generateAccessToken() (string, error) {
+	b := make([]byte, accessTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate access token: %w", err)
+	}
+	return mjwt.PersonalTokenPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const (
+	loginFailWindow      = time.Hour // failed attempt counted within this window
+	accountFailThreshold = 5         // failed attempt per account before locked
+	ipFailThreshold      = 20        // failed attempt per ip before locked, ip can be shared
+	loginLockBase        = time.Minute
+	loginLockMax         = time.Hour
+)
+
+// LoginLockedError returned when account or ip is temporarily locked
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e LoginLockedError) Error() string {
+	return e.message().Error()
+}
+
+// Localize render message in lang
+func (e LoginLockedError) Localize(lang string) string {
+	return e.message().Localize(lang)
+}
+
+func (e LoginLockedError) message() *errr.Message {
+	return errr.T("error.login_locked", map[string]string{"retry_after": e.RetryAfter.Round(time.Second).String()})
+}
+
+// lockDuration return how long login is locked after failures,
+// doubled for every failure after threshold
+func lockDuration(failures int64, threshold int64) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	duration := loginLockBase
+	for i := threshold; i < failures; i++ {
+		duration *= 2
+		if duration >= loginLockMax {
+			return loginLockMax
+		}
+	}
+	return duration
+}
+
+func accountKey(email string) string {
+	return "login:acc:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// checkLoginLock return LoginLockedError if account or ip still locked
+func (s *Core) checkLoginLock(ctx context.Context, email string, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		until, err := s.attempts.Get(ctx, key+":lock")
+		if err != nil {
+			s.log.ErrorT(ctx, "error get login lock", err)
+			continue
+		}
+		if wait := time.Unix(until, 0).Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// registerLoginFailure count failure for account and ip, lock them when threshold reached
+func (s *Core) registerLoginFailure(ctx context.Context, email string, ip string) {
+	now := time.Now()
+	subjects := []struct {
+		key       string
+		threshold int64
+	}{
+		{key: accountKey(email), threshold: accountFailThreshold},
+		{key: ipKey(ip), threshold: ipFailThreshold},
+	}
+	for _, subject := range subjects {
+		failures, err := s.attempts.Incr(ctx, subject.key+":fail", loginFailWindow)
+		if err != nil {
+			s.log.ErrorT(ctx, "error count login failure", err)
+			continue
+		}
+		duration := lockDuration(failures, subject.threshold)
+		if duration == 0 {
+			continue
+		}
+		s.log.WarnT(ctx, fmt.Sprintf("login locked for %s after %d failures", subject.key, failures), nil)
+		if err := s.attempts.Set(ctx, subject.key+":lock", now.Add(duration).Unix(), duration); err != nil {
+			s.log.ErrorT(ctx, "error set login lock", err)
+		}
+	}
+}
+
+// resetLoginFailure clear failure of account after success login, ip counter is kept
+func (s *Core) resetLoginFailure(ctx context.Context, email string) {
+	key := accountKey(email)
+	if err := s.attempts.Delete(ctx, key+":fail", key+":lock"); err != nil {
+		s.log.ErrorT(ctx, "error reset login failure", err)
+	}
+}
+
+// UnlockLogin used by admin to clear lock of account and optionally ip
+func (s *Core) UnlockLogin(ctx context.Context, email string, ip string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-UnlockLogin")
+	defer span.End()
+
+	keys := []string{accountKey(email) + ":fail", accountKey(email) + ":lock"}
+	if ip != "" {
+		keys = append(keys, ipKey(ip)+":fail", ipKey(ip)+":lock")
+	}
+	if err := s.attempts.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("delete login lock: %w", err)
+	}
+	return nil
+}
+
+// recordLogin save login attempt, failing to save must not fail the login
+func (s *Core) recordLogin(ctx context.Context, userID xulid.NullULID, email string, client model.ClientInfo, outcome string) {
+	history := model.LoginHistory{
+		ID:        xulid.Instance().NewULID(),
+		UserID:    userID,
+		Email:     truncate(email, 255),
+		IP:        truncate(client.IP, 64),
+		UserAgent: truncate(client.UserAgent, 255),
+		Outcome:   outcome,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.InsertLoginHistory(ctx, &history); err != nil {
+		s.log.ErrorT(ctx, "error insert login history", err)
+	}
+}
+
+// FindLoginHistory return login attempt of user
+func (s *Core) FindLoginHistory(ctx context.Context, claims mjwt.CustomClaim, filter paging.Filters) ([]model.LoginHistoryResp, paging.Metadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindLoginHistory")
+	defer span.End()
+
+	histories, metadata, err := s.repo.FindLoginHistory(ctx, claims.GetULID(), filter)
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("find login history: %w", err)
+	}
+
+	result := make([]model.LoginHistoryResp, len(histories))
+	for i := range histories {
+		result[i] = histories[i].ToLoginHistoryResp()
+	}
+
+	return result, metadata, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int64
+		threshold int64
+		want      time.Duration
+	}{
+		{name: "below threshold", failures: 4, threshold: 5, want: 0},
+		{name: "at threshold", failures: 5, threshold: 5, want: time.Minute},
+		{name: "doubled", failures: 6, threshold: 5, want: 2 * time.Minute},
+		{name: "doubled twice", failures: 7, threshold: 5, want: 4 * time.Minute},
+		{name: "capped", failures: 50, threshold: 5, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockDuration(tt.failures, tt.threshold); got != tt.want {
+				t.Errorf("lockDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
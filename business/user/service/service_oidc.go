@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/business/user/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/oidc"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const expiredOIDCState = 10 * time.Minute
+
+var (
+	ErrOIDCDisabled     = errr.NewT("error.oidc_disabled", nil, 404)
+	ErrOIDCInvalidState = errr.NewT("error.oidc_invalid_state", nil, 400)
+)
+
+// EnableOIDC activate login with OpenID Connect provider.
+// name is stored with subject of user, so changing it will unlink every account.
+// if allowSignup is false, only email already registered can login
+func (s *Core) EnableOIDC(provider port.IdentityProvider, name string, allowSignup bool) {
+	s.oidc = provider
+	s.oidcName = name
+	s.oidcAllowSignup = allowSignup
+}
+
+// OIDCAuthorize start authorization code flow with PKCE.
+// verifier and nonce never leave the server, client only receive state inside url
+func (s *Core) OIDCAuthorize(ctx context.Context) (model.OIDCAuthorizeResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-OIDCAuthorize")
+	defer span.End()
+
+	if s.oidc == nil {
+		return model.OIDCAuthorizeResp{}, ErrOIDCDisabled
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return model.OIDCAuthorizeResp{}, err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return model.OIDCAuthorizeResp{}, err
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return model.OIDCAuthorizeResp{}, err
+	}
+
+	authURL, err := s.oidc.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		return model.OIDCAuthorizeResp{}, fmt.Errorf("build authorization url: %w", err)
+	}
+
+	timeNow := time.Now()
+	err = s.repo.InsertOIDCState(ctx, &model.OIDCState{
+		ID:           xulid.Instance().NewULID(),
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiredAt:    timeNow.Add(expiredOIDCState),
+		CreatedAt:    timeNow,
+	})
+	if err != nil {
+		return model.OIDCAuthorizeResp{}, fmt.Errorf("insert oidc state: %w", err)
+	}
+
+	return model.OIDCAuthorizeResp{
+		AuthorizationURL: authURL,
+		State:            state,
+	}, nil
+}
+
+// OIDCCallback finish authorization code flow and login the linked user.
+// return the same response as Login, including two-factor challenge
+func (s *Core) OIDCCallback(ctx context.Context, req model.OIDCCallbackReq, client model.ClientInfo) (model.UserResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-OIDCCallback")
+	defer span.End()
+
+	if s.oidc == nil {
+		return model.UserResp{}, ErrOIDCDisabled
+	}
+
+	state, err := s.repo.UseOIDCState(ctx, hashToken(req.State), time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.UserResp{}, ErrOIDCInvalidState
+		}
+		return model.UserResp{}, fmt.Errorf("use oidc state: %w", err)
+	}
+
+	claims, err := s.oidc.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		s.log.WarnT(ctx, "oidc exchange failed", err)
+		return model.UserResp{}, errr.NewT("error.oidc_failed", nil, 401)
+	}
+
+	user, err := s.findOrCreateOIDCUser(ctx, claims)
+	if err != nil {
+		return model.UserResp{}, err
+	}
+	userID := xulid.NullULID{ULID: user.ID, Valid: true}
+
+	if user.TOTPEnabled {
+		s.recordLogin(ctx, userID, user.Email, client, model.LoginMFARequired)
+		return s.challengeResp(user)
+	}
+
+	s.recordLogin(ctx, userID, user.Email, client, model.LoginSuccess)
+
+	session, err := s.createSession(ctx, user, client)
+	if err != nil {
+		return model.UserResp{}, err
+	}
+
+	return s.issueTokens(user, session, true)
+}
+
+// findOrCreateOIDCUser return user linked to subject.
+// unlinked subject is linked to user with the same verified email,
+// or new user is created if signup allowed
+func (s *Core) findOrCreateOIDCUser(ctx context.Context, claims oidc.Claims) (model.User, error) {
+	timeNow := time.Now()
+
+	identity, err := s.repo.GetIdentity(ctx, s.oidcName, claims.Subject)
+	if err == nil {
+		if err := s.repo.TouchIdentity(ctx, identity.ID, timeNow); err != nil {
+			return model.User{}, fmt.Errorf("touch identity: %w", err)
+		}
+		user, err := s.repo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return model.User{}, fmt.Errorf("get user by id: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, db.ErrDBNotFound) {
+		return model.User{}, fmt.Errorf("get identity: %w", err)
+	}
+
+	// unverified email can be set to anything by the user, never trust it for linking
+	if claims.Email == "" || !claims.EmailVerified {
+		return model.User{}, errr.NewT("error.oidc_unverified_email", nil, 403)
+	}
+
+	var user model.User
+	txErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		user, err = s.repo.GetByEmail(ctx, claims.Email)
+		if err != nil {
+			if !errors.Is(err, db.ErrDBNotFound) {
+				return fmt.Errorf("get user by email: %w", err)
+			}
+			if !s.oidcAllowSignup {
+				return errr.NewT("error.account_not_registered", nil, 403)
+			}
+			user, err = s.newOIDCUser(ctx, claims, timeNow)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.repo.InsertIdentity(ctx, &model.Identity{
+			ID:          xulid.Instance().NewULID(),
+			UserID:      user.ID,
+			Provider:    s.oidcName,
+			Subject:     claims.Subject,
+			Email:       claims.Email,
+			CreatedAt:   timeNow,
+			LastLoginAt: timeNow,
+		})
+		if err != nil {
+			return fmt.Errorf("insert identity: %w", err)
+		}
+		return nil
+	})
+
+	return user, txErr
+}
+
+// newOIDCUser insert user with random password, password can be set later with forgot password
+func (s *Core) newOIDCUser(ctx context.Context, claims oidc.Claims, timeNow time.Time) (model.User, error) {
+	randomPassword, _, err := generateResetToken()
+	if err != nil {
+		return model.User{}, fmt.Errorf("generate random password: %w", err)
+	}
+	hashPassword, err := s.crypto.GenerateHash(randomPassword)
+	if err != nil {
+		return model.User{}, fmt.Errorf("generate hashpw when insert oidc user: %w", err)
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	user := model.User{
+		ID:        xulid.Instance().NewULID(),
+		Email:     claims.Email,
+		Name:      name,
+		Password:  hashPassword,
+		Roles:     []string{},
+		Fcm:       []string{},
+		CreatedAt: timeNow,
+		UpdatedAt: timeNow,
+		Version:   1,
+	}
+	if err := s.repo.Insert(ctx, &user); err != nil {
+		return model.User{}, fmt.Errorf("insert user to db: %w", err)
+	}
+	return user, nil
+}
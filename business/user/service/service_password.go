@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/bg"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mailer"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const (
+	expiredResetToken = 30 * time.Minute
+	resetTokenLength  = 32 // bytes, token sent to user is hex encoded
+)
+
+// ForgotPassword create reset token and send it to user email.
+// always return nil for unknown email so caller cannot guess registered email
+func (s *Core) ForgotPassword(ctx context.Context, email string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-ForgotPassword")
+	defer span.End()
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get user by email: %w", err)
+	}
+
+	token, tokenHash, err := generateResetToken()
+	if err != nil {
+		return fmt.Errorf("generate reset token: %w", err)
+	}
+
+	timeNow := time.Now()
+	reset := model.PasswordReset{
+		ID:        xulid.Instance().NewULID(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiredAt: timeNow.Add(expiredResetToken),
+		CreatedAt: timeNow,
+	}
+	if err := s.repo.InsertPasswordReset(ctx, &reset); err != nil {
+		return fmt.Errorf("insert password reset: %w", err)
+	}
+
+	// sending email can be slow, response time should not tell whether email is registered
+	bg.RunSafeBackground(ctx, bg.BackgroundJob{
+		JobTitle: "send reset password email",
+		Execute: func(ctx context.Context) {
+			err := s.mailer.Send(ctx, s.resetPasswordMessage(user, token))
+			if err != nil {
+				s.log.ErrorT(ctx, fmt.Sprintf("error send reset password email to user %s", user.ID.String()), err)
+			}
+		},
+	})
+
+	return nil
+}
+
+// ResetPassword change password using token from ForgotPassword.
+// token can only be used once and all refresh token of user is revoked
+func (s *Core) ResetPassword(ctx context.Context, req model.ResetPasswordReq) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-ResetPassword")
+	defer span.End()
+
+	timeNow := time.Now()
+	txErr := s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		reset, err := s.repo.UsePasswordReset(ctx, hashToken(req.Token), timeNow)
+		if err != nil {
+			if errors.Is(err, db.ErrDBNotFound) {
+				return errr.NewT("error.reset_token_invalid", nil, 400)
+			}
+			return fmt.Errorf("use password reset: %w", err)
+		}
+
+		user, err := s.repo.GetByID(ctx, reset.UserID)
+		if err != nil {
+			return fmt.Errorf("get user by id: %w", err)
+		}
+
+		return s.replacePassword(ctx, &user, req.Password, timeNow)
+	})
+
+	return txErr
+}
+
+// SetPassword replace password of user without reset token, used by admin tools.
+// user is logged out from every device
+func (s *Core) SetPassword(ctx context.Context, userID xulid.ULID, password string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SetPassword")
+	defer span.End()
+
+	timeNow := time.Now()
+	return s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		user, err := s.repo.GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("get user by id: %w", err)
+		}
+		return s.replacePassword(ctx, &user, password, timeNow)
+	})
+}
+
+// replacePassword save new password, invalidate reset token and revoke all session of user
+func (s *Core) replacePassword(ctx context.Context, user *model.User, password string, timeNow time.Time) error {
+	if err := checkPasswordPolicy(password, user.Name, user.Email); err != nil {
+		return err
+	}
+	hashPassword, err := s.crypto.GenerateHash(password)
+	if err != nil {
+		return fmt.Errorf("generate hashpw when reset password: %w", err)
+	}
+
+	user.Password = hashPassword
+	user.TokenRevokedAt = &timeNow
+	if err := s.repo.ChangePassword(ctx, user); err != nil {
+		return fmt.Errorf("change password: %w", err)
+	}
+
+	// other token requested before this reset must not be usable anymore
+	if err := s.repo.InvalidatePasswordResets(ctx, user.ID, timeNow); err != nil {
+		return fmt.Errorf("invalidate password reset: %w", err)
+	}
+
+	// logout from every device
+	if _, err := s.repo.RevokeSessions(ctx, user.ID, nil, timeNow); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func (s *Core) resetPasswordMessage(user model.User, token string) mailer.Message {
+	body := fmt.Sprintf("Hi %s,\n\nUse this token to reset your password: %s\n", user.Name, token)
+	if s.resetURL != "" {
+		body += fmt.Sprintf("or open this link: %s?token=%s\n", s.resetURL, url.QueryEscape(token))
+	}
+	body += fmt.Sprintf("\nThe token will expire in %d minutes. Ignore this email if you did not request it.\n",
+		int(expiredResetToken.Minutes()))
+
+	return mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset Password",
+		Body:    body,
+	}
+}
+
+// generateResetToken return random token for user and its hash for database
+func generateResetToken() (token string, tokenHash string, err error) {
+	b := make([]byte, resetTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken return sha256 hex of random token, enough for high entropy token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
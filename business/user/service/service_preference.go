@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// GetPreference return preference of user, default value is used for unset field
+func (s *Core) GetPreference(ctx context.Context, claims mjwt.CustomClaim) (model.PreferenceResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-GetPreference")
+	defer span.End()
+
+	pref, err := s.repo.GetPreference(ctx, claims.GetULID())
+	if err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("get preference: %w", err)
+	}
+
+	return pref.ToPreferenceResp(), nil
+}
+
+// GetLocale return locale preference of user, used as response language
+func (s *Core) GetLocale(ctx context.Context, userID string) (string, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-GetLocale")
+	defer span.End()
+
+	id, err := xulid.Parse(userID)
+	if err != nil {
+		return "", fmt.Errorf("parse user id: %w", err)
+	}
+	pref, err := s.repo.GetPreference(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("get preference: %w", err)
+	}
+	return pref.Locale, nil
+}
+
+// PatchPreference update preference of user, nil field is not changed
+func (s *Core) PatchPreference(ctx context.Context, claims mjwt.CustomClaim, req model.PreferenceUpdate) (model.PreferenceResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-PatchPreference")
+	defer span.End()
+
+	pref, err := s.repo.GetPreference(ctx, claims.GetULID())
+	if err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("get preference: %w", err)
+	}
+
+	if err := applyPreferenceUpdate(&pref, req); err != nil {
+		return model.PreferenceResp{}, errr.Wrap(err, 400)
+	}
+
+	// default pocket must be pocket joined by user
+	if req.DefaultPocketID != nil && pref.DefaultPocketID.Valid {
+		member, err := s.repo.IsPocketMember(ctx, pref.UserID, pref.DefaultPocketID.ULID)
+		if err != nil {
+			return model.PreferenceResp{}, fmt.Errorf("check pocket member: %w", err)
+		}
+		if !member {
+			return model.PreferenceResp{}, errr.NewT("error.default_pocket_not_member", nil, 400)
+		}
+	}
+
+	pref.UpdatedAt = time.Now()
+	if err := s.repo.UpsertPreference(ctx, &pref); err != nil {
+		return model.PreferenceResp{}, fmt.Errorf("upsert preference: %w", err)
+	}
+
+	return pref.ToPreferenceResp(), nil
+}
+
+// applyPreferenceUpdate copy not nil field of req to pref and validate it
+func applyPreferenceUpdate(pref *model.Preference, req model.PreferenceUpdate) error {
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return fmt.Errorf("timezone %q is not valid IANA timezone", *req.Timezone)
+		}
+		pref.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		pref.Locale = *req.Locale
+	}
+	if req.DefaultPocketID != nil {
+		if *req.DefaultPocketID == "" {
+			pref.DefaultPocketID = xulid.NullULID{}
+		} else {
+			pocketID, err := xulid.Parse(*req.DefaultPocketID)
+			if err != nil {
+				return errr.T("error.default_pocket_invalid", nil)
+			}
+			pref.DefaultPocketID = xulid.NullULID{ULID: pocketID, Valid: true}
+		}
+	}
+	if req.HomeCurrency != nil {
+		pref.HomeCurrency = strings.ToUpper(strings.TrimSpace(*req.HomeCurrency))
+	}
+	if req.FirstDayOfWeek != nil {
+		if *req.FirstDayOfWeek < 0 || *req.FirstDayOfWeek > 6 {
+			return errr.T("error.first_day_of_week", nil)
+		}
+		pref.FirstDayOfWeek = *req.FirstDayOfWeek
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+func TestApplyPreferenceUpdate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	pocketID := xulid.Instance().NewULID()
+
+	tests := []struct {
+		name    string
+		req     model.PreferenceUpdate
+		wantErr bool
+		check   func(p model.Preference) bool
+	}{
+		{
+			name:  "valid timezone",
+			req:   model.PreferenceUpdate{Timezone: str("Asia/Makassar")},
+			check: func(p model.Preference) bool { return p.Timezone == "Asia/Makassar" },
+		},
+		{
+			name:    "invalid timezone",
+			req:     model.PreferenceUpdate{Timezone: str("Mars/Olympus")},
+			wantErr: true,
+		},
+		{
+			name:    "empty timezone",
+			req:     model.PreferenceUpdate{Timezone: str("")},
+			wantErr: true,
+		},
+		{
+			name:  "currency normalized",
+			req:   model.PreferenceUpdate{HomeCurrency: str(" idr ")},
+			check: func(p model.Preference) bool { return p.HomeCurrency == "IDR" },
+		},
+		{
+			name:  "set default pocket",
+			req:   model.PreferenceUpdate{DefaultPocketID: str(pocketID.String())},
+			check: func(p model.Preference) bool { return p.DefaultPocketID.Valid && p.DefaultPocketID.ULID == pocketID },
+		},
+		{
+			name:  "remove default pocket",
+			req:   model.PreferenceUpdate{DefaultPocketID: str("")},
+			check: func(p model.Preference) bool { return !p.DefaultPocketID.Valid },
+		},
+		{
+			name:    "invalid default pocket",
+			req:     model.PreferenceUpdate{DefaultPocketID: str("not-ulid")},
+			wantErr: true,
+		},
+		{
+			name:  "sunday first",
+			req:   model.PreferenceUpdate{FirstDayOfWeek: num(0)},
+			check: func(p model.Preference) bool { return p.FirstDayOfWeek == 0 },
+		},
+		{
+			name:    "weekday out of range",
+			req:     model.PreferenceUpdate{FirstDayOfWeek: num(7)},
+			wantErr: true,
+		},
+		{
+			name: "nil field unchanged",
+			req:  model.PreferenceUpdate{},
+			check: func(p model.Preference) bool {
+				return p.Timezone == model.DefaultTimezone && p.Locale == model.DefaultLocale && p.FirstDayOfWeek == model.DefaultFirstDayOfWeek
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pref := model.DefaultPreference(xulid.Instance().NewULID())
+			pref.DefaultPocketID = xulid.NullULID{ULID: xulid.Instance().NewULID(), Valid: true}
+			err := applyPreferenceUpdate(&pref, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyPreferenceUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(pref) {
+				t.Errorf("applyPreferenceUpdate() unexpected result %+v", pref)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// IsSessionActive used by middleware to reject token of revoked session
+func (s *Core) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-IsSessionActive")
+	defer span.End()
+
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get session by id: %w", err)
+	}
+
+	return session.IsActive(time.Now()), nil
+}
+
+// FindSessions return active session of user
+func (s *Core) FindSessions(ctx context.Context, claims mjwt.CustomClaim) ([]model.SessionResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindSessions")
+	defer span.End()
+
+	sessions, err := s.repo.FindActiveSessions(ctx, claims.GetULID())
+	if err != nil {
+		return nil, fmt.Errorf("find active sessions: %w", err)
+	}
+
+	result := make([]model.SessionResp, len(sessions))
+	for i := range sessions {
+		result[i] = sessions[i].ToSessionResp(claims.SessionID)
+	}
+
+	return result, nil
+}
+
+// RevokeSession logout one session of user
+func (s *Core) RevokeSession(ctx context.Context, claims mjwt.CustomClaim, sessionID string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-RevokeSession")
+	defer span.End()
+
+	count, err := s.repo.RevokeSessions(ctx, claims.GetULID(), []string{sessionID}, time.Now())
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	if count == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllSessions logout user from every device, return count of session revoked
+func (s *Core) RevokeAllSessions(ctx context.Context, claims mjwt.CustomClaim) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-RevokeAllSessions")
+	defer span.End()
+
+	return s.RevokeUserSessions(ctx, claims.GetULID())
+}
+
+// RevokeUserSessions logout userID from every device, used by admin tools
+func (s *Core) RevokeUserSessions(ctx context.Context, userID xulid.ULID) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-RevokeUserSessions")
+	defer span.End()
+
+	count, err := s.repo.RevokeSessions(ctx, userID, nil, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("revoke all session: %w", err)
+	}
+
+	return count, nil
+}
+
+// revokeReusedSession kill session when rotated refresh token used again
+func (s *Core) revokeReusedSession(ctx context.Context, session model.Session) {
+	s.log.WarnT(ctx, fmt.Sprintf("refresh token reuse detected, revoking session %s", session.ID.String()), nil)
+	_, err := s.repo.RevokeSessions(ctx, session.UserID, []string{session.ID.String()}, time.Now())
+	if err != nil {
+		s.log.ErrorT(ctx, "error revoke reused session", err)
+	}
+}
+
+func truncate(text string, max int) string {
+	if len(text) > max {
+		return text[:max]
+	}
+	return text
+}
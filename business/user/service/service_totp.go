@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/totp"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const (
+	totpIssuer             = "moneymagnet"
+	expiredChallengeToken  = 5 // minutes
+	recoveryCodeCount      = 10
+	recoveryCodeRandomSize = 5 // bytes, encoded to 8 base32 char
+)
+
+var (
+	ErrInvalidTOTPCode   = errr.NewT("error.totp_invalid_code", nil, 401)
+	ErrTOTPAlreadyActive = errr.NewT("error.totp_already_active", nil, 400)
+	ErrTOTPNotActive     = errr.NewT("error.totp_not_active", nil, 400)
+)
+
+// SetupTOTP generate new totp secret for user. two-factor is not active
+// until EnableTOTP called with valid code
+func (s *Core) SetupTOTP(ctx context.Context, claims mjwt.CustomClaim) (model.TOTPSetupResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-SetupTOTP")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, claims.GetULID())
+	if err != nil {
+		return model.TOTPSetupResp{}, fmt.Errorf("get user by id: %w", err)
+	}
+	if user.TOTPEnabled {
+		return model.TOTPSetupResp{}, ErrTOTPAlreadyActive
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TOTPSetupResp{}, err
+	}
+
+	user.TOTPSecret = secret
+	if err := s.repo.UpdateTOTP(ctx, &user); err != nil {
+		return model.TOTPSetupResp{}, fmt.Errorf("update totp: %w", err)
+	}
+
+	return model.TOTPSetupResp{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableTOTP activate two-factor after user prove the authenticator app works.
+// return recovery codes that only shown once
+func (s *Core) EnableTOTP(ctx context.Context, claims mjwt.CustomClaim, code string) (model.RecoveryCodesResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-EnableTOTP")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, claims.GetULID())
+	if err != nil {
+		return model.RecoveryCodesResp{}, fmt.Errorf("get user by id: %w", err)
+	}
+	if user.TOTPEnabled {
+		return model.RecoveryCodesResp{}, ErrTOTPAlreadyActive
+	}
+	if user.TOTPSecret == "" {
+		return model.RecoveryCodesResp{}, errr.NewT("error.totp_not_setup", nil, 400)
+	}
+
+	var result model.RecoveryCodesResp
+	err = s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		if err := s.useTOTPCode(ctx, user, code); err != nil {
+			return err
+		}
+		user.TOTPEnabled = true
+		if err := s.repo.UpdateTOTP(ctx, &user); err != nil {
+			return fmt.Errorf("update totp: %w", err)
+		}
+		result, err = s.replaceRecoveryCodes(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return model.RecoveryCodesResp{}, err
+	}
+
+	return result, nil
+}
+
+// DisableTOTP turn off two-factor, code can be totp code or recovery code
+func (s *Core) DisableTOTP(ctx context.Context, claims mjwt.CustomClaim, code string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-DisableTOTP")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, claims.GetULID())
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if !user.TOTPEnabled {
+		return ErrTOTPNotActive
+	}
+
+	return s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		if err := s.verifySecondFactor(ctx, user, code); err != nil {
+			return err
+		}
+		user.TOTPEnabled = false
+		user.TOTPSecret = ""
+		if err := s.repo.UpdateTOTP(ctx, &user); err != nil {
+			return fmt.Errorf("update totp: %w", err)
+		}
+		if err := s.repo.ReplaceRecoveryCodes(ctx, user.ID, nil); err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replace all recovery code, old code no longer valid
+func (s *Core) RegenerateRecoveryCodes(ctx context.Context, claims mjwt.CustomClaim, code string) (model.RecoveryCodesResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-RegenerateRecoveryCodes")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, claims.GetULID())
+	if err != nil {
+		return model.RecoveryCodesResp{}, fmt.Errorf("get user by id: %w", err)
+	}
+	if !user.TOTPEnabled {
+		return model.RecoveryCodesResp{}, ErrTOTPNotActive
+	}
+
+	var result model.RecoveryCodesResp
+	err = s.txManager.WithAtomic(ctx, func(ctx context.Context) error {
+		if err := s.useTOTPCode(ctx, user, code); err != nil {
+			return err
+		}
+		result, err = s.replaceRecoveryCodes(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return model.RecoveryCodesResp{}, err
+	}
+
+	return result, nil
+}
+
+// LoginTOTP is second step of login, exchange challenge token and code
+// with access token and refresh token
+func (s *Core) LoginTOTP(ctx context.Context, req model.LoginTOTPReq, client model.ClientInfo) (model.UserResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-LoginTOTP")
+	defer span.End()
+
+	token, err := s.jwt.ValidateToken(req.ChallengeToken)
+	if err != nil {
+		return model.UserResp{}, err
+	}
+	claims, err := s.jwt.ReadToken(token)
+	if err != nil {
+		return model.UserResp{}, err
+	}
+	if claims.Type != mjwt.Challenge {
+		return model.UserResp{}, mjwt.ErrInvalidToken
+	}
+
+	user, err := s.repo.GetByID(ctx, claims.GetULID())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.UserResp{}, mjwt.ErrInvalidToken
+		}
+		return model.UserResp{}, fmt.Errorf("get user by id: %w", err)
+	}
+	if !user.TOTPEnabled {
+		return model.UserResp{}, mjwt.ErrInvalidToken
+	}
+
+	// code is only 6 digit, guarded the same way as password
+	if err := s.checkLoginLock(ctx, user.Email, client.IP); err != nil {
+		s.recordLogin(ctx, xulid.NullULID{ULID: user.ID, Valid: true}, user.Email, client, model.LoginLocked)
+		return model.UserResp{}, err
+	}
+
+	if err := s.verifySecondFactor(ctx, user, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidTOTPCode) {
+			s.registerLoginFailure(ctx, user.Email, client.IP)
+			s.recordLogin(ctx, xulid.NullULID{ULID: user.ID, Valid: true}, user.Email, client, model.LoginInvalidCode)
+		}
+		return model.UserResp{}, err
+	}
+
+	s.resetLoginFailure(ctx, user.Email)
+	s.recordLogin(ctx, xulid.NullULID{ULID: user.ID, Valid: true}, user.Email, client, model.LoginSuccess)
+
+	session, err := s.createSession(ctx, user, client)
+	if err != nil {
+		return model.UserResp{}, err
+	}
+
+	return s.issueTokens(user, session, true)
+}
+
+// challengeResp return short lived token that only valid for LoginTOTP
+func (s *Core) challengeResp(user model.User) (model.UserResp, error) {
+	challengeClaims := mjwt.CustomClaim{
+		Identity: user.ID.String(),
+		Name:     user.Name,
+		Exp:      time.Now().Add(time.Minute * expiredChallengeToken).Unix(),
+		Type:     mjwt.Challenge,
+		Fresh:    false,
+		Roles:    []string{},
+	}
+
+	challengeToken, err := s.jwt.GenerateToken(challengeClaims)
+	if err != nil {
+		return model.UserResp{}, fmt.Errorf("fail to generate token: %w", err)
+	}
+
+	return model.UserResp{
+		ID:             user.ID,
+		Email:          user.Email,
+		Name:           user.Name,
+		TOTPEnabled:    true,
+		MFARequired:    true,
+		ChallengeToken: challengeToken,
+	}, nil
+}
+
+// verifySecondFactor accept totp code or unused recovery code.
+// recovery code is marked as used
+func (s *Core) verifySecondFactor(ctx context.Context, user model.User, code string) error {
+	err := s.useTOTPCode(ctx, user, code)
+	if !errors.Is(err, ErrInvalidTOTPCode) {
+		return err
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return ErrInvalidTOTPCode
+	}
+
+	// code is random enough to be stored as plain sha256, so it is checked with one indexed query
+	err = s.repo.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(normalized), time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return ErrInvalidTOTPCode
+		}
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	s.log.InfoT(ctx, fmt.Sprintf("recovery code used by user %s", user.ID.String()))
+	return nil
+}
+
+// useTOTPCode accept totp code only once, code of time step already accepted is rejected
+// so code seen by someone else cannot be replayed within its validity window
+func (s *Core) useTOTPCode(ctx context.Context, user model.User, code string) error {
+	step, ok := totp.Match(code, user.TOTPSecret, time.Now())
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+	if err := s.repo.UseTOTPStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return ErrInvalidTOTPCode
+		}
+		return fmt.Errorf("use totp step: %w", err)
+	}
+	return nil
+}
+
+// replaceRecoveryCodes generate new recovery codes, store the hash and return raw codes
+func (s *Core) replaceRecoveryCodes(ctx context.Context, userID xulid.ULID) (model.RecoveryCodesResp, error) {
+	timeNow := time.Now()
+	rawCodes := make([]string, recoveryCodeCount)
+	codes := make([]model.RecoveryCode, recoveryCodeCount)
+	for i := range rawCodes {
+		raw, err := generateRecoveryCode()
+		if err != nil {
+			return model.RecoveryCodesResp{}, err
+		}
+		rawCodes[i] = raw
+		codes[i] = model.RecoveryCode{
+			ID:        xulid.Instance().NewULID(),
+			UserID:    userID,
+			CodeHash:  hashRecoveryCode(normalizeRecoveryCode(raw)),
+			CreatedAt: timeNow,
+		}
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, codes); err != nil {
+		return model.RecoveryCodesResp{}, fmt.Errorf("replace recovery codes: %w", err)
+	}
+
+	return model.RecoveryCodesResp{Codes: rawCodes}, nil
+}
+
+// generateRecoveryCode return code formatted as xxxx-xxxx
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeRandomSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate recovery code: %w", err)
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+	return code[:4] + "-" + code[4:], nil
+}
+
+// normalizeRecoveryCode make code typed by user comparable with stored hash
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// hashRecoveryCode return sha256 of normalized code
+func hashRecoveryCode(normalized string) []byte {
+	sum := sha256.Sum256([]byte(normalized))
+	return sum[:]
+}
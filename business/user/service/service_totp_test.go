@@ -0,0 +1,78 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/user/model"
+	"github.com/muchlist/moneymagnet/business/user/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/totp"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// fakeTOTPRepo keep accepted step and unused recovery code in memory
+type fakeTOTPRepo struct {
+	port.UserStorer
+	lastStep     int64
+	recoveryHash []byte
+}
+
+func (f *fakeTOTPRepo) UseTOTPStep(_ context.Context, _ xulid.ULID, step int64) error {
+	if step <= f.lastStep {
+		return db.ErrDBNotFound
+	}
+	f.lastStep = step
+	return nil
+}
+
+func (f *fakeTOTPRepo) UseRecoveryCode(_ context.Context, _ xulid.ULID, codeHash []byte, _ time.Time) error {
+	if f.recoveryHash == nil || !bytes.Equal(f.recoveryHash, codeHash) {
+		return db.ErrDBNotFound
+	}
+	f.recoveryHash = nil
+	return nil
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+
+	repo := &fakeTOTPRepo{recoveryHash: hashRecoveryCode("abcde12345")}
+	s := &Core{
+		log:  mlogger.New(mlogger.Options{Level: mlogger.LevelError, Output: "stderr"}),
+		repo: repo,
+	}
+	user := model.User{ID: xulid.Instance().NewULID(), TOTPSecret: secret}
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "totp code", code: code},
+		{name: "totp code replayed", code: code, wantErr: ErrInvalidTOTPCode},
+		{name: "recovery code", code: "ABCDE-12345"},
+		{name: "recovery code used twice", code: "abcde12345", wantErr: ErrInvalidTOTPCode},
+		{name: "wrong code", code: "000000", wantErr: ErrInvalidTOTPCode},
+	}
+	// cases run in order, each one depends on state left by previous case
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.verifySecondFactor(context.Background(), user, tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifySecondFactor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/muchlist/moneymagnet/business/webhook/model"
+	"github.com/muchlist/moneymagnet/business/webhook/service"
+	"github.com/muchlist/moneymagnet/business/zhelper"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mid"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/validate"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+func NewWebhookHandler(log mlogger.Logger,
+	validator validate.Validator,
+	webhookService *service.Core) webhookHandler {
+	return webhookHandler{
+		log:       log,
+		validator: validator,
+		service:   webhookService,
+	}
+}
+
+type webhookHandler struct {
+	log       mlogger.Logger
+	validator validate.Validator
+	service   *service.Core
+}
+
+// @Summary      Create Webhook
+// @Description  Register webhook for pocket, secret to verify signature is only shown in this response. owner only
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		 pocket_id path string true "pocket_id"
+// @Param		 Body body model.NewWebhook true "Request Body"
+// @Success      201  {object}  misc.ResponseSuccess{data=model.WebhookCreatedResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      403  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /pockets/{pocket_id}/webhooks [post]
+func (wh webhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-CreateWebhook")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	pocketID, err := web.ReadULIDParam(r)
+	if err != nil {
+		wh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req model.NewWebhook
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		wh.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	errMap, err := wh.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		wh.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := wh.service.CreateWebhook(ctx, claims, pocketID, req)
+	if err != nil {
+		wh.log.ErrorT(ctx, "error create webhook", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+
+	err = web.WriteJSON(w, http.StatusCreated, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Find Pocket Webhooks
+// @Description  List webhook of pocket. owner only
+// @Tags         Webhook
+// @Produce      json
+// @Param		 pocket_id path string true "pocket_id"
+// @Success      200  {object}  misc.ResponseSuccess{data=[]model.WebhookResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      403  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /pockets/{pocket_id}/webhooks [get]
+func (wh webhookHandler) FindPocketWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-FindPocketWebhooks")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	pocketID, err := web.ReadULIDParam(r)
+	if err != nil {
+		wh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := wh.service.FindWebhooks(ctx, claims, pocketID)
+	if err != nil {
+		wh.log.ErrorT(ctx, "error find webhook", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Update Webhook
+// @Description  Change url, events or active status of webhook. owner only
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		 webhook_id path string true "webhook_id"
+// @Param		 Body body model.WebhookUpdate true "Request Body"
+// @Success      200  {object}  misc.ResponseSuccess{data=model.WebhookResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /webhooks/{webhook_id} [patch]
+func (wh webhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-UpdateWebhook")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	id, err := web.ReadULIDParam(r)
+	if err != nil {
+		wh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req model.WebhookUpdate
+	err = web.ReadJSON(w, r, &req)
+	if err != nil {
+		wh.log.WarnT(ctx, "bad json", err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	errMap, err := wh.validator.WithLang(web.ReadLanguage(r.Context())).Struct(req)
+	if err != nil {
+		wh.log.WarnT(ctx, "request not valid", err)
+		web.ErrorPayloadResponse(w, err.Error(), errMap)
+		return
+	}
+
+	result, err := wh.service.UpdateWebhook(ctx, claims, id, req)
+	if err != nil {
+		wh.log.ErrorT(ctx, "error update webhook", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Delete Webhook
+// @Description  Delete webhook and its delivery log. owner only
+// @Tags         Webhook
+// @Produce      json
+// @Param		 webhook_id path string true "webhook_id"
+// @Success      200  {object}  misc.ResponseMessage
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /webhooks/{webhook_id} [delete]
+func (wh webhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-DeleteWebhook")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	id, err := web.ReadULIDParam(r)
+	if err != nil {
+		wh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = wh.service.DeleteWebhook(ctx, claims, id)
+	if err != nil {
+		wh.log.ErrorT(ctx, "error delete webhook", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "webhook deleted",
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Find Webhook Deliveries
+// @Description  Delivery log of webhook, one row per attempt. owner only
+// @Tags         Webhook
+// @Produce      json
+// @Param		 webhook_id path string true "webhook_id"
+// @Param 		 page query int false "page"
+// @Param 		 page_size query int false "page-size"
+// @Param 		 sort query string false "sort"
+// @Success      200  {object}  misc.ResponseSuccessList{data=[]model.DeliveryResp}
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /webhooks/{webhook_id}/deliveries [get]
+func (wh webhookHandler) FindDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-FindDeliveries")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	id, err := web.ReadULIDParam(r)
+	if err != nil {
+		wh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// extract url query
+	sort := web.ReadString(r.URL.Query(), "sort", "")
+	page := web.ReadInt(r.URL.Query(), "page", 0)
+	pageSize := web.ReadInt(r.URL.Query(), "page_size", 0)
+
+	result, metadata, err := wh.service.FindDeliveries(ctx, claims, id, paging.Filters{
+		Page:     page,
+		PageSize: pageSize,
+		Sort:     sort,
+	})
+	if err != nil {
+		wh.log.ErrorT(ctx, "error find webhook delivery", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"metadata": metadata,
+		"data":     result,
+	}
+	err = web.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// @Summary      Redeliver Webhook
+// @Description  Send payload of delivery again with the same event id. owner only
+// @Tags         Webhook
+// @Produce      json
+// @Param		 webhook_id path string true "webhook_id"
+// @Param		 delivery_id path int true "delivery_id"
+// @Success      202  {object}  misc.ResponseMessage
+// @Failure      400  {object}  misc.ResponseErr
+// @Failure      404  {object}  misc.ResponseErr
+// @Failure      500  {object}  misc.Response500Err
+// @Router       /webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [post]
+func (wh webhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observ.GetTracer().Start(r.Context(), "handler-Redeliver")
+	defer span.End()
+
+	claims, err := mid.GetClaims(ctx)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	id, err := web.ReadULIDParam(r)
+	if err != nil {
+		wh.log.WarnT(ctx, err.Error(), err)
+		web.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(chi.URLParam(r, "delivery_id"), 10, 64)
+	if err != nil || deliveryID < 1 {
+		web.ErrorResponse(w, http.StatusBadRequest, errr.T("error.invalid_param", map[string]string{"name": "delivery_id"}))
+		return
+	}
+
+	err = wh.service.Redeliver(ctx, claims, id, deliveryID)
+	if err != nil {
+		wh.log.ErrorT(ctx, "error redeliver webhook", err)
+		statusCode, msg := zhelper.ParseError(err)
+		web.ErrorResponse(w, statusCode, msg)
+		return
+	}
+	env := web.Envelope{
+		"data": "delivery is queued",
+	}
+	err = web.WriteJSON(w, http.StatusAccepted, env, nil)
+	if err != nil {
+		web.ServerErrorResponse(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type NewWebhook struct {
+	URL    string   `json:"url" validate:"required,url,max=2048" example:"https://example.com/hooks/moneymagnet"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=spend.created spend.updated spend.deleted member.joined" example:"spend.created,spend.deleted"`
+}
+
+type WebhookUpdate struct {
+	URL      *string  `json:"url" validate:"omitempty,url,max=2048" example:"https://example.com/hooks/moneymagnet"`
+	Events   []string `json:"events" validate:"omitempty,min=1,dive,oneof=spend.created spend.updated spend.deleted member.joined" example:"spend.created"`
+	IsActive *bool    `json:"is_active" example:"false"`
+}
+
+type WebhookResp struct {
+	ID        xulid.ULID `json:"id" example:"01J4EXF94QDMR5XT9KN527XEP9"`
+	PocketID  xulid.ULID `json:"pocket_id" example:"01J4EXF94QDMR5XT9KN527XEP8"`
+	URL       string     `json:"url" example:"https://example.com/hooks/moneymagnet"`
+	Events    []string   `json:"events" example:"spend.created,spend.deleted"`
+	IsActive  bool       `json:"is_active" example:"true"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-09-10T17:03:15.091267+08:00"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2022-09-10T17:03:15.091267+08:00"`
+}
+
+// WebhookCreatedResp contain secret, it is only shown once
+type WebhookCreatedResp struct {
+	WebhookResp
+	Secret string `json:"secret" example:"whsec_4f9c..."`
+}
+
+type DeliveryResp struct {
+	ID           uint64          `json:"id" example:"1"`
+	EventID      xulid.ULID      `json:"event_id" example:"01J4EXF94QDMR5XT9KN527XEPA"`
+	Event        string          `json:"event" example:"spend.created"`
+	Payload      json.RawMessage `json:"payload" swaggertype:"object"`
+	StatusCode   int             `json:"status_code" example:"200"`
+	ResponseBody string          `json:"response_body" example:"ok"`
+	Error        string          `json:"error" example:""`
+	DurationMs   int             `json:"duration_ms" example:"120"`
+	IsSuccess    bool            `json:"is_success" example:"true"`
+	CreatedAt    time.Time       `json:"created_at" example:"2022-09-10T17:03:15.091267+08:00"`
+}
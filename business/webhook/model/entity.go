@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/slicer"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// Event type that can be subscribed by webhook
+const (
+	EventSpendCreated = "spend.created" // data is spend
+	EventSpendUpdated = "spend.updated" // data is spend
+	EventSpendDeleted = "spend.deleted" // data is spend before deleted
+	EventMemberJoined = "member.joined" // data is MemberJoined
+)
+
+// role of member in member.joined event
+const (
+	RoleEditor  = "editor"
+	RoleWatcher = "watcher"
+)
+
+type Webhook struct {
+	ID        xulid.ULID
+	PocketID  xulid.ULID
+	URL       string
+	Secret    string
+	Events    []string
+	IsActive  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Subscribe return true if webhook is active and want the event
+func (w *Webhook) Subscribe(event string) bool {
+	return w.IsActive && slicer.In(event, w.Events)
+}
+
+func (w *Webhook) ToWebhookResp() WebhookResp {
+	return WebhookResp{
+		ID:        w.ID,
+		PocketID:  w.PocketID,
+		URL:       w.URL,
+		Events:    w.Events,
+		IsActive:  w.IsActive,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// Delivery is log of one delivery attempt
+type Delivery struct {
+	ID           uint64
+	WebhookID    xulid.ULID
+	EventID      xulid.ULID
+	Event        string
+	Payload      json.RawMessage
+	StatusCode   int
+	ResponseBody string
+	Error        string
+	DurationMs   int
+	IsSuccess    bool
+	CreatedAt    time.Time
+}
+
+func (d *Delivery) ToDeliveryResp() DeliveryResp {
+	return DeliveryResp{
+		ID:           d.ID,
+		EventID:      d.EventID,
+		Event:        d.Event,
+		Payload:      d.Payload,
+		StatusCode:   d.StatusCode,
+		ResponseBody: d.ResponseBody,
+		Error:        d.Error,
+		DurationMs:   d.DurationMs,
+		IsSuccess:    d.IsSuccess,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+// Payload is body sent to webhook url
+type Payload struct {
+	ID        xulid.ULID `json:"id"`
+	Event     string     `json:"event"`
+	PocketID  xulid.ULID `json:"pocket_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Data      any        `json:"data"`
+}
+
+// DeliverJob is payload of durable job that send one event to one webhook
+type DeliverJob struct {
+	WebhookID xulid.ULID      `json:"webhook_id"`
+	EventID   xulid.ULID      `json:"event_id"`
+	Event     string          `json:"event"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// MemberJoined is data of member.joined event
+type MemberJoined struct {
+	UserID xulid.ULID `json:"user_id"`
+	Role   string     `json:"role"`
+}
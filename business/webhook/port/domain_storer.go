@@ -0,0 +1,24 @@
+package port
+
+import (
+	"context"
+
+	ptmodel "github.com/muchlist/moneymagnet/business/pocket/model"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+// PocketReader implemented by pocket repo
+type PocketReader interface {
+	GetByID(ctx context.Context, id xulid.ULID) (ptmodel.Pocket, error)
+}
+
+// JobEnqueuer implemented by job service
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, jobType string, payload any) error
+}
+
+// Sender implemented by mwebhook client
+type Sender interface {
+	Send(ctx context.Context, req mwebhook.Request) (mwebhook.Response, error)
+}
@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+
+	"github.com/muchlist/moneymagnet/business/webhook/model"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type WebhookStorer interface {
+	WebhookSaver
+	WebhookReader
+}
+
+type WebhookSaver interface {
+	Insert(ctx context.Context, webhook *model.Webhook) error
+	Edit(ctx context.Context, webhook *model.Webhook) error
+	Delete(ctx context.Context, id xulid.ULID) error
+	InsertDelivery(ctx context.Context, delivery *model.Delivery) error
+}
+
+type WebhookReader interface {
+	GetByID(ctx context.Context, id xulid.ULID) (model.Webhook, error)
+	FindByPocket(ctx context.Context, pocketID xulid.ULID) ([]model.Webhook, error)
+	GetDelivery(ctx context.Context, id uint64) (model.Delivery, error)
+	FindDeliveries(ctx context.Context, webhookID xulid.ULID, filter paging.Filters) ([]model.Delivery, paging.Metadata, error)
+}
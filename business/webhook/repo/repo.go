@@ -0,0 +1,466 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/webhook/model"
+	"github.com/muchlist/moneymagnet/business/webhook/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	keyTable     = "webhooks"
+	keyID        = "id"
+	keyPocketID  = "pocket_id"
+	keyURL       = "url"
+	keySecret    = "secret"
+	keyEvents    = "events"
+	keyIsActive  = "is_active"
+	keyCreatedAt = "created_at"
+	keyUpdatedAt = "updated_at"
+
+	keyDeliveryTable = "webhook_deliveries"
+	keyWebhookID     = "webhook_id"
+	keyEventID       = "event_id"
+	keyEvent         = "event"
+	keyPayload       = "payload"
+	keyStatusCode    = "status_code"
+	keyResponseBody  = "response_body"
+	keyError         = "error"
+	keyDurationMs    = "duration_ms"
+	keyIsSuccess     = "is_success"
+)
+
+// make sure the implementation satisfies the interface
+var _ port.WebhookStorer = (*Repo)(nil)
+
+// Repo manages the set of APIs for webhook access.
+type Repo struct {
+	db  *pgxpool.Pool
+	log mlogger.Logger
+	sb  sq.StatementBuilderType
+}
+
+// NewRepo constructs a data for api access..
+func NewRepo(sqlDB *pgxpool.Pool, log mlogger.Logger) *Repo {
+	return &Repo{
+		db:  sqlDB,
+		log: log,
+		sb:  sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// =========================================================================
+// MANIPULATOR
+
+// Insert ...
+func (r *Repo) Insert(ctx context.Context, webhook *model.Webhook) error {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-Insert")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyTable).
+		Columns(
+			keyID,
+			keyPocketID,
+			keyURL,
+			keySecret,
+			keyEvents,
+			keyIsActive,
+			keyCreatedAt,
+			keyUpdatedAt,
+		).
+		Values(
+			webhook.ID,
+			webhook.PocketID,
+			webhook.URL,
+			webhook.Secret,
+			webhook.Events,
+			webhook.IsActive,
+			webhook.CreatedAt,
+			webhook.UpdatedAt,
+		).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query insert webhook: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	_, err = dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// Edit change url, events and active status
+func (r *Repo) Edit(ctx context.Context, webhook *model.Webhook) error {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-Edit")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Update(keyTable).
+		SetMap(sq.Eq{
+			keyURL:       webhook.URL,
+			keyEvents:    webhook.Events,
+			keyIsActive:  webhook.IsActive,
+			keyUpdatedAt: webhook.UpdatedAt,
+		}).
+		Where(sq.Eq{keyID: webhook.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query edit webhook: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// Delete webhook, its delivery log is deleted by cascade
+func (r *Repo) Delete(ctx context.Context, id xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-Delete")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Delete(keyTable).
+		Where(sq.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query delete webhook: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	res, err := dbtx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return db.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// InsertDelivery log one delivery attempt
+func (r *Repo) InsertDelivery(ctx context.Context, delivery *model.Delivery) error {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-InsertDelivery")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Insert(keyDeliveryTable).
+		Columns(
+			keyWebhookID,
+			keyEventID,
+			keyEvent,
+			keyPayload,
+			keyStatusCode,
+			keyResponseBody,
+			keyError,
+			keyDurationMs,
+			keyIsSuccess,
+			keyCreatedAt,
+		).
+		Values(
+			delivery.WebhookID,
+			delivery.EventID,
+			delivery.Event,
+			delivery.Payload,
+			delivery.StatusCode,
+			delivery.ResponseBody,
+			delivery.Error,
+			delivery.DurationMs,
+			delivery.IsSuccess,
+			delivery.CreatedAt,
+		).
+		Suffix(db.Returning(keyID)).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query insert webhook delivery: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(&delivery.ID)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return db.ParseError(err)
+	}
+
+	return nil
+}
+
+// =========================================================================
+// GETTER
+
+// GetByID get one webhook by id
+func (r *Repo) GetByID(ctx context.Context, id xulid.ULID) (model.Webhook, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-GetByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyID,
+		keyPocketID,
+		keyURL,
+		keySecret,
+		keyEvents,
+		keyIsActive,
+		keyCreatedAt,
+		keyUpdatedAt,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("build query get webhook by id: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var webhook model.Webhook
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&webhook.ID,
+		&webhook.PocketID,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.Events,
+		&webhook.IsActive,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Webhook{}, db.ParseError(err)
+	}
+
+	return webhook, nil
+}
+
+// FindByPocket get every webhook of pocket, oldest first
+func (r *Repo) FindByPocket(ctx context.Context, pocketID xulid.ULID) ([]model.Webhook, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-FindByPocket")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyID,
+		keyPocketID,
+		keyURL,
+		keySecret,
+		keyEvents,
+		keyIsActive,
+		keyCreatedAt,
+		keyUpdatedAt,
+	).
+		From(keyTable).
+		Where(sq.Eq{keyPocketID: pocketID}).
+		OrderBy(keyCreatedAt).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query find webhook by pocket: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		var webhook model.Webhook
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.PocketID,
+			&webhook.URL,
+			&webhook.Secret,
+			&webhook.Events,
+			&webhook.IsActive,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, db.ParseError(err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetDelivery get one delivery log by id
+func (r *Repo) GetDelivery(ctx context.Context, id uint64) (model.Delivery, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-GetDelivery")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		keyID,
+		keyWebhookID,
+		keyEventID,
+		keyEvent,
+		keyPayload,
+		keyStatusCode,
+		keyResponseBody,
+		keyError,
+		keyDurationMs,
+		keyIsSuccess,
+		keyCreatedAt,
+	).
+		From(keyDeliveryTable).
+		Where(sq.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return model.Delivery{}, fmt.Errorf("build query get webhook delivery: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	var delivery model.Delivery
+	err = dbtx.QueryRow(ctx, sqlStatement, args...).Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.StatusCode,
+		&delivery.ResponseBody,
+		&delivery.Error,
+		&delivery.DurationMs,
+		&delivery.IsSuccess,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return model.Delivery{}, db.ParseError(err)
+	}
+
+	return delivery, nil
+}
+
+// FindDeliveries get delivery log of webhook, newest first by default
+func (r *Repo) FindDeliveries(ctx context.Context, webhookID xulid.ULID, filter paging.Filters) ([]model.Delivery, paging.Metadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "webhook-repo-FindDeliveries")
+	defer span.End()
+
+	// Validation filter
+	filter.SortSafelist = []string{"-id", "id"}
+	if err := filter.Validate(); err != nil {
+		return nil, paging.Metadata{}, db.ErrDBSortFilter
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sqlStatement, args, err := r.sb.Select(
+		"count(*) OVER()",
+		keyID,
+		keyWebhookID,
+		keyEventID,
+		keyEvent,
+		keyPayload,
+		keyStatusCode,
+		keyResponseBody,
+		keyError,
+		keyDurationMs,
+		keyIsSuccess,
+		keyCreatedAt,
+	).
+		From(keyDeliveryTable).
+		Where(sq.Eq{keyWebhookID: webhookID}).
+		OrderBy(filter.SortColumnDirection()).
+		Limit(uint64(filter.Limit())).
+		Offset(uint64(filter.Offset())).
+		ToSql()
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("build query find webhook delivery: %w", err)
+	}
+
+	dbtx := db.ExtractTx(ctx, r.db)
+
+	rows, err := dbtx.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		r.log.InfoT(ctx, err.Error())
+		return nil, paging.Metadata{}, db.ParseError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	deliveries := make([]model.Delivery, 0)
+	for rows.Next() {
+		var delivery model.Delivery
+		err := rows.Scan(
+			&totalRecords,
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.StatusCode,
+			&delivery.ResponseBody,
+			&delivery.Error,
+			&delivery.DurationMs,
+			&delivery.IsSuccess,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			r.log.InfoT(ctx, err.Error())
+			return nil, paging.Metadata{}, db.ParseError(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, paging.Metadata{}, err
+	}
+
+	metadata := paging.CalculateMetadata(totalRecords, filter.Page, filter.PageSize)
+
+	return deliveries, metadata, nil
+}
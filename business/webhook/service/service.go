@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	jobModel "github.com/muchlist/moneymagnet/business/job/model"
+	"github.com/muchlist/moneymagnet/business/webhook/model"
+	"github.com/muchlist/moneymagnet/business/webhook/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/ds"
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/muchlist/moneymagnet/pkg/paging"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+const maxWebhookPerPocket = 10
+
+var ErrWebhookNotFound = errr.NewT("error.webhook_not_found", nil, 404)
+
+// Core manages the set of APIs for webhook access.
+type Core struct {
+	log        mlogger.Logger
+	repo       port.WebhookStorer
+	pocketRepo port.PocketReader
+	jobQueue   port.JobEnqueuer
+	sender     port.Sender
+}
+
+// NewCore constructs a core for webhook api access.
+func NewCore(
+	log mlogger.Logger,
+	repo port.WebhookStorer,
+	pocketRepo port.PocketReader,
+	jobQueue port.JobEnqueuer,
+	sender port.Sender,
+) *Core {
+	return &Core{
+		log:        log,
+		repo:       repo,
+		pocketRepo: pocketRepo,
+		jobQueue:   jobQueue,
+		sender:     sender,
+	}
+}
+
+// Emit enqueue delivery of event to every webhook of pocket which subscribe it.
+// called inside WithAtomic, delivery is only sent when the change is committed
+func (s *Core) Emit(ctx context.Context, event string, pocketID xulid.ULID, data any) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-Emit")
+	defer span.End()
+
+	webhooks, err := s.repo.FindByPocket(ctx, pocketID)
+	if err != nil {
+		return fmt.Errorf("find webhook by pocket: %w", err)
+	}
+
+	subscribers := subscribed(webhooks, event)
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	eventID := xulid.Instance().NewULID()
+	body, err := json.Marshal(model.Payload{
+		ID:        eventID,
+		Event:     event,
+		PocketID:  pocketID,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	for _, webhook := range subscribers {
+		err := s.jobQueue.Enqueue(ctx, jobModel.TypeDeliverWebhook, model.DeliverJob{
+			WebhookID: webhook.ID,
+			EventID:   eventID,
+			Event:     event,
+			Body:      body,
+		})
+		if err != nil {
+			return fmt.Errorf("enqueue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// Deliver handle deliver job, every attempt is logged.
+// error is returned when receiver does not accept it so the job is retried with backoff
+func (s *Core) Deliver(ctx context.Context, job model.DeliverJob) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-Deliver")
+	defer span.End()
+
+	webhook, err := s.repo.GetByID(ctx, job.WebhookID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return nil // webhook is deleted after event is emitted
+		}
+		return fmt.Errorf("get webhook by id: %w", err)
+	}
+	if !webhook.IsActive {
+		return nil
+	}
+
+	resp, sendErr := s.sender.Send(ctx, mwebhook.Request{
+		URL:        webhook.URL,
+		Secret:     webhook.Secret,
+		Event:      job.Event,
+		DeliveryID: job.EventID.String(),
+		Body:       job.Body,
+	})
+
+	delivery := model.Delivery{
+		WebhookID:    webhook.ID,
+		EventID:      job.EventID,
+		Event:        job.Event,
+		Payload:      job.Body,
+		StatusCode:   resp.StatusCode,
+		ResponseBody: resp.Body,
+		DurationMs:   int(resp.Duration.Milliseconds()),
+		IsSuccess:    sendErr == nil,
+		CreatedAt:    time.Now(),
+	}
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	if err := s.repo.InsertDelivery(ctx, &delivery); err != nil {
+		s.log.ErrorT(ctx, fmt.Sprintf("error log delivery of webhook %s", webhook.ID), err)
+	}
+
+	return sendErr
+}
+
+// CreateWebhook register webhook for pocket, only owner of pocket can do it
+func (s *Core) CreateWebhook(ctx context.Context, claims mjwt.CustomClaim, pocketID xulid.ULID, req model.NewWebhook) (model.WebhookCreatedResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-CreateWebhook")
+	defer span.End()
+
+	if err := s.checkOwner(ctx, claims, pocketID); err != nil {
+		return model.WebhookCreatedResp{}, err
+	}
+	if err := validateURL(req.URL); err != nil {
+		return model.WebhookCreatedResp{}, err
+	}
+
+	existing, err := s.repo.FindByPocket(ctx, pocketID)
+	if err != nil {
+		return model.WebhookCreatedResp{}, fmt.Errorf("find webhook by pocket: %w", err)
+	}
+	if len(existing) >= maxWebhookPerPocket {
+		return model.WebhookCreatedResp{}, errr.NewT("error.webhook_limit", map[string]string{"max": strconv.Itoa(maxWebhookPerPocket)}, 400)
+	}
+
+	secret, err := mwebhook.GenerateSecret()
+	if err != nil {
+		return model.WebhookCreatedResp{}, err
+	}
+
+	timeNow := time.Now()
+	webhook := model.Webhook{
+		ID:        xulid.Instance().NewULID(),
+		PocketID:  pocketID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    uniqueEvents(req.Events),
+		IsActive:  true,
+		CreatedAt: timeNow,
+		UpdatedAt: timeNow,
+	}
+	if err := s.repo.Insert(ctx, &webhook); err != nil {
+		return model.WebhookCreatedResp{}, fmt.Errorf("insert webhook: %w", err)
+	}
+
+	return model.WebhookCreatedResp{
+		WebhookResp: webhook.ToWebhookResp(),
+		Secret:      secret,
+	}, nil
+}
+
+// UpdateWebhook change url, events or active status
+func (s *Core) UpdateWebhook(ctx context.Context, claims mjwt.CustomClaim, id xulid.ULID, req model.WebhookUpdate) (model.WebhookResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-UpdateWebhook")
+	defer span.End()
+
+	webhook, err := s.getOwnedWebhook(ctx, claims, id)
+	if err != nil {
+		return model.WebhookResp{}, err
+	}
+
+	if req.URL != nil {
+		if err := validateURL(*req.URL); err != nil {
+			return model.WebhookResp{}, err
+		}
+		webhook.URL = *req.URL
+	}
+	if len(req.Events) != 0 {
+		webhook.Events = uniqueEvents(req.Events)
+	}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+	webhook.UpdatedAt = time.Now()
+
+	if err := s.repo.Edit(ctx, &webhook); err != nil {
+		return model.WebhookResp{}, fmt.Errorf("edit webhook: %w", err)
+	}
+	return webhook.ToWebhookResp(), nil
+}
+
+// DeleteWebhook delete webhook and its delivery log
+func (s *Core) DeleteWebhook(ctx context.Context, claims mjwt.CustomClaim, id xulid.ULID) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-DeleteWebhook")
+	defer span.End()
+
+	if _, err := s.getOwnedWebhook(ctx, claims, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	return nil
+}
+
+// FindWebhooks list webhook of pocket, secret is not included
+func (s *Core) FindWebhooks(ctx context.Context, claims mjwt.CustomClaim, pocketID xulid.ULID) ([]model.WebhookResp, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindWebhooks")
+	defer span.End()
+
+	if err := s.checkOwner(ctx, claims, pocketID); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.repo.FindByPocket(ctx, pocketID)
+	if err != nil {
+		return nil, fmt.Errorf("find webhook by pocket: %w", err)
+	}
+
+	result := make([]model.WebhookResp, len(webhooks))
+	for i := range webhooks {
+		result[i] = webhooks[i].ToWebhookResp()
+	}
+	return result, nil
+}
+
+// FindDeliveries list delivery log of webhook
+func (s *Core) FindDeliveries(ctx context.Context, claims mjwt.CustomClaim, webhookID xulid.ULID, filter paging.Filters) ([]model.DeliveryResp, paging.Metadata, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "service-FindDeliveries")
+	defer span.End()
+
+	if _, err := s.getOwnedWebhook(ctx, claims, webhookID); err != nil {
+		return nil, paging.Metadata{}, err
+	}
+
+	deliveries, metadata, err := s.repo.FindDeliveries(ctx, webhookID, filter)
+	if err != nil {
+		return nil, paging.Metadata{}, fmt.Errorf("find webhook delivery: %w", err)
+	}
+
+	result := make([]model.DeliveryResp, len(deliveries))
+	for i := range deliveries {
+		result[i] = deliveries[i].ToDeliveryResp()
+	}
+	return result, metadata, nil
+}
+
+// Redeliver send payload of logged delivery again with the same event id
+func (s *Core) Redeliver(ctx context.Context, claims mjwt.CustomClaim, webhookID xulid.ULID, deliveryID uint64) error {
+	ctx, span := observ.GetTracer().Start(ctx, "service-Redeliver")
+	defer span.End()
+
+	webhook, err := s.getOwnedWebhook(ctx, claims, webhookID)
+	if err != nil {
+		return err
+	}
+	if !webhook.IsActive {
+		return errr.NewT("error.webhook_inactive", nil, 400)
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return errr.NewT("error.delivery_not_found", nil, 404)
+		}
+		return fmt.Errorf("get webhook delivery: %w", err)
+	}
+	if delivery.WebhookID != webhook.ID {
+		return errr.NewT("error.delivery_not_found", nil, 404)
+	}
+
+	err = s.jobQueue.Enqueue(ctx, jobModel.TypeDeliverWebhook, model.DeliverJob{
+		WebhookID: webhook.ID,
+		EventID:   delivery.EventID,
+		Event:     delivery.Event,
+		Body:      delivery.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// checkOwner make sure user is owner of pocket
+func (s *Core) checkOwner(ctx context.Context, claims mjwt.CustomClaim, pocketID xulid.ULID) error {
+	pocket, err := s.pocketRepo.GetByID(ctx, pocketID)
+	if err != nil {
+		return fmt.Errorf("get pocket by id: %w", err)
+	}
+
+	if pocket.OwnerID != claims.GetULID() || !claims.CanAccessPocket(pocketID.String()) {
+		return errr.NewT("error.webhook_owner_only", nil, 403)
+	}
+	return nil
+}
+
+func (s *Core) getOwnedWebhook(ctx context.Context, claims mjwt.CustomClaim, id xulid.ULID) (model.Webhook, error) {
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return model.Webhook{}, ErrWebhookNotFound
+		}
+		return model.Webhook{}, fmt.Errorf("get webhook by id: %w", err)
+	}
+
+	if err := s.checkOwner(ctx, claims, webhook.PocketID); err != nil {
+		return model.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+// subscribed filter webhook which want the event
+func subscribed(webhooks []model.Webhook, event string) []model.Webhook {
+	result := make([]model.Webhook, 0, len(webhooks))
+	for i := range webhooks {
+		if webhooks[i].Subscribe(event) {
+			result = append(result, webhooks[i])
+		}
+	}
+	return result
+}
+
+func uniqueEvents(events []string) []string {
+	set := ds.NewStringSet()
+	set.AddAll(events)
+	return set.RevealSorted()
+}
+
+// validateURL accept absolute http or https url only
+func validateURL(raw string) error {
+	if err := mwebhook.ValidateURL(raw); err != nil {
+		return errr.Wrap(err, 400)
+	}
+	return nil
+}
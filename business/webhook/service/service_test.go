@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/muchlist/moneymagnet/business/webhook/model"
+	"github.com/muchlist/moneymagnet/business/webhook/port"
+	"github.com/muchlist/moneymagnet/pkg/db"
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/mwebhook"
+	"github.com/muchlist/moneymagnet/pkg/xulid"
+)
+
+type fakeRepo struct {
+	port.WebhookStorer
+	webhooks   []model.Webhook
+	deliveries []model.Delivery
+}
+
+func (f *fakeRepo) FindByPocket(ctx context.Context, pocketID xulid.ULID) ([]model.Webhook, error) {
+	result := make([]model.Webhook, 0)
+	for _, w := range f.webhooks {
+		if w.PocketID == pocketID {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeRepo) GetByID(ctx context.Context, id xulid.ULID) (model.Webhook, error) {
+	for _, w := range f.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return model.Webhook{}, db.ErrDBNotFound
+}
+
+func (f *fakeRepo) InsertDelivery(ctx context.Context, delivery *model.Delivery) error {
+	f.deliveries = append(f.deliveries, *delivery)
+	return nil
+}
+
+type fakeQueue struct {
+	jobs []model.DeliverJob
+}
+
+func (f *fakeQueue) Enqueue(ctx context.Context, jobType string, payload any) error {
+	f.jobs = append(f.jobs, payload.(model.DeliverJob))
+	return nil
+}
+
+func TestEmitAndDeliver(t *testing.T) {
+	var received []http.Header
+	var receivedBody []byte
+	fail := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		received = append(received, r.Header.Clone())
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	pocketID := xulid.Instance().NewULID()
+	subscriber := model.Webhook{
+		ID:       xulid.Instance().NewULID(),
+		PocketID: pocketID,
+		URL:      receiver.URL,
+		Secret:   "secret",
+		Events:   []string{model.EventSpendCreated},
+		IsActive: true,
+	}
+	repo := &fakeRepo{webhooks: []model.Webhook{
+		subscriber,
+		{ID: xulid.Instance().NewULID(), PocketID: pocketID, URL: receiver.URL, Events: []string{model.EventSpendDeleted}, IsActive: true},
+		{ID: xulid.Instance().NewULID(), PocketID: pocketID, URL: receiver.URL, Events: []string{model.EventSpendCreated}, IsActive: false},
+	}}
+	queue := &fakeQueue{}
+	log := mlogger.New(mlogger.Options{Level: mlogger.LevelError, Output: "stderr"})
+	s := NewCore(log, repo, nil, queue, mwebhook.NewClient(time.Second, true))
+
+	err := s.Emit(context.Background(), model.EventSpendCreated, pocketID, map[string]any{"name": "KOPI"})
+	if err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if len(queue.jobs) != 1 || queue.jobs[0].WebhookID != subscriber.ID {
+		t.Fatalf("Emit() enqueued %+v, want one job for active subscriber", queue.jobs)
+	}
+
+	// success
+	job := queue.jobs[0]
+	if err := s.Deliver(context.Background(), job); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	header := received[0]
+	timestamp, _ := strconv.ParseInt(header.Get(mwebhook.HeaderTimestamp), 10, 64)
+	if !mwebhook.Verify("secret", header.Get(mwebhook.HeaderSignature), timestamp, receivedBody) {
+		t.Error("receiver cannot verify signature")
+	}
+	if header.Get(mwebhook.HeaderDelivery) != job.EventID.String() {
+		t.Errorf("delivery header = %s, want event id %s", header.Get(mwebhook.HeaderDelivery), job.EventID)
+	}
+	var payload model.Payload
+	if err := json.Unmarshal(receivedBody, &payload); err != nil || payload.Event != model.EventSpendCreated {
+		t.Errorf("receiver got payload %s, err %v", receivedBody, err)
+	}
+
+	// failure is logged and returned so job is retried
+	fail = true
+	if err := s.Deliver(context.Background(), job); err == nil {
+		t.Error("Deliver() must return error when receiver respond 500")
+	}
+
+	if len(repo.deliveries) != 2 {
+		t.Fatalf("delivery log = %d row, want 2", len(repo.deliveries))
+	}
+	if !repo.deliveries[0].IsSuccess || repo.deliveries[0].StatusCode != http.StatusNoContent {
+		t.Errorf("first delivery = %+v, want success 204", repo.deliveries[0])
+	}
+	if repo.deliveries[1].IsSuccess || repo.deliveries[1].StatusCode != http.StatusInternalServerError || repo.deliveries[1].Error == "" {
+		t.Errorf("second delivery = %+v, want failed 500 with error", repo.deliveries[1])
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	valid := []string{"https://example.com/hook", "http://localhost:8080/hook"}
+	invalid := []string{"ftp://example.com", "/relative", "https://", "javascript:alert(1)"}
+
+	for _, u := range valid {
+		if err := validateURL(u); err != nil {
+			t.Errorf("validateURL(%q) error = %v", u, err)
+		}
+	}
+	for _, u := range invalid {
+		if err := validateURL(u); err == nil {
+			t.Errorf("validateURL(%q) must return error", u)
+		}
+	}
+}
@@ -8,12 +8,15 @@ import (
 	"github.com/muchlist/moneymagnet/pkg/db"
 	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/web"
 )
 
-func ParseError(err error) (int, string) {
+// ParseError return status code and message of err, message from i18n catalog is
+// rendered by web.ErrorResponse in language of user
+func ParseError(err error) (int, interface{}) {
 	var stcErr errr.StatusCodeError
 	if errors.As(err, &stcErr) {
-		return stcErr.StatusCode, err.Error()
+		return stcErr.StatusCode, web.ErrorMessage(err)
 	}
 
 	if errors.Is(err, db.ErrDBDuplicatedEntry) ||
@@ -21,15 +24,20 @@ func ParseError(err error) (int, string) {
 		errors.Is(err, db.ErrDBRelationNotFound) ||
 		errors.Is(err, service.ErrInvalidID) ||
 		errors.Is(err, db.ErrDBSortFilter) {
-		return http.StatusBadRequest, err.Error()
+		return http.StatusBadRequest, web.ErrorMessage(err)
+	}
+
+	var lockedErr service.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		return http.StatusTooManyRequests, web.ErrorMessage(err)
 	}
 
 	if errors.Is(err, mjwt.ErrInvalidToken) {
-		return http.StatusUnauthorized, err.Error()
+		return http.StatusUnauthorized, web.ErrorMessage(err)
 	}
 
 	if errors.Is(err, service.ErrInvalidEmailOrPass) {
-		return http.StatusBadRequest, "invalid email or password"
+		return http.StatusBadRequest, errr.T("error.invalid_email_or_password", nil)
 	}
 
 	return http.StatusInternalServerError, err.Error()
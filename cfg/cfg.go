@@ -1,11 +1,15 @@
 package cfg
 
 import (
+	"errors"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/muchlist/moneymagnet/pkg/env"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/oidc"
 )
 
 type Config struct {
@@ -15,8 +19,16 @@ type Config struct {
 	Google    GoogleConfig
 	Telemetry Telemetry
 	Toggle    Toggle
+	Mail      MailConfig
+	Notif     NotificationConfig
+	Webhook   WebhookConfig
+	JWT       JWTConfig
+	OIDC      OIDCConfig
 }
 
+// DefaultSecret is fallback of APP_SECRET, only for local development
+const DefaultSecret = "xoxoxoxo"
+
 func Load() *Config {
 	if err := godotenv.Load(); err != nil {
 		// dont panic, because in prod we will not use this.
@@ -30,7 +42,7 @@ func Load() *Config {
 			Port:         env.Get("APP_PORT", 8081),
 			DebugPort:    env.Get("APP_DEBUG_PORT", 0),
 			Env:          env.Get("APP_ENV", "dev"),
-			Secret:       env.Get("APP_SECRET", "xoxoxoxo"),
+			Secret:       env.Get("APP_SECRET", DefaultSecret),
 			LoggerOutput: env.Get("APP_LOGGER_OUTPUT", "stdout"),
 		},
 		DB: DbConfig{
@@ -53,10 +65,117 @@ func Load() *Config {
 			Insecure: env.Get("OTEL_INSECURE", true),
 		},
 		Toggle: Toggle{
-			TraceON:  env.Get("TRACE_ON", false),
-			MetricON: env.Get("METRIC_ON", false),
-			CacheON:  env.Get("CACHE_ON", true),
+			TraceON:     env.Get("TRACE_ON", false),
+			MetricON:    env.Get("METRIC_ON", false),
+			CacheON:     env.Get("CACHE_ON", true),
+			PubSubRedis: env.Get("PUBSUB_REDIS", true),
+		},
+		Mail: MailConfig{
+			Driver:           env.Get("MAIL_DRIVER", "log"),
+			Host:             env.Get("MAIL_HOST", "localhost"),
+			Port:             env.Get("MAIL_PORT", 587),
+			Username:         env.Get("MAIL_USERNAME", ""),
+			Password:         env.Get("MAIL_PASSWORD", ""),
+			From:             env.Get("MAIL_FROM", "no-reply@moneymagnet.local"),
+			FileDir:          env.Get("MAIL_FILE_DIR", "mails"),
+			ResetPasswordURL: env.Get("MAIL_RESET_PASSWORD_URL", ""),
+		},
+		Notif: NotificationConfig{
+			Channels:        env.Get("NOTIFICATION_CHANNELS", "push"),
+			DefaultChannels: env.Get("NOTIFICATION_DEFAULT_CHANNELS", "push"),
+			DigestWindow:    env.Get("NOTIFICATION_DIGEST_WINDOW", time.Duration(time.Minute)),
+			PushTopic:       env.Get("NOTIFICATION_PUSH_TOPIC", false),
+		},
+		Webhook: WebhookConfig{
+			AllowPrivateNetwork: env.Get("WEBHOOK_ALLOW_PRIVATE_NETWORK", false),
+		},
+		JWT: JWTConfig{
+			Algorithm:      env.Get("JWT_ALGORITHM", "HS256"),
+			KeyID:          env.Get("JWT_KEY_ID", ""),
+			PrivateKeyFile: env.Get("JWT_PRIVATE_KEY_FILE", ""),
+			PreviousKeys:   env.Get("JWT_PREVIOUS_KEYS", ""),
+			AcceptLegacy:   env.Get("JWT_ACCEPT_LEGACY_HS256", false),
+		},
+		OIDC: OIDCConfig{
+			ProviderName: env.Get("OIDC_PROVIDER_NAME", "default"),
+			IssuerURL:    env.Get("OIDC_ISSUER_URL", ""),
+			ClientID:     env.Get("OIDC_CLIENT_ID", ""),
+			ClientSecret: env.Get("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  env.Get("OIDC_REDIRECT_URL", ""),
+			Scopes:       env.Get("OIDC_SCOPES", "email,profile"),
+			AllowSignup:  env.Get("OIDC_ALLOW_SIGNUP", false),
 		},
 	}
 
 }
+
+// IsProduction return true if APP_ENV is prod or production
+func (c *Config) IsProduction() bool {
+	return c.App.Env == "prod" || c.App.Env == "production"
+}
+
+// Validate return error if config is not safe to run
+func (c *Config) Validate() error {
+	if c.IsProduction() && c.App.Secret == DefaultSecret {
+		return errors.New("APP_SECRET must be changed from default value in production")
+	}
+	if c.JWT.AcceptLegacy && c.App.Secret == DefaultSecret {
+		return errors.New("JWT_ACCEPT_LEGACY_HS256 cannot be used with default APP_SECRET")
+	}
+	if c.IsProduction() && c.Webhook.AllowPrivateNetwork {
+		return errors.New("WEBHOOK_ALLOW_PRIVATE_NETWORK must be false in production")
+	}
+	if c.OIDCEnabled() && (c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "") {
+		return errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER_URL is set")
+	}
+	return nil
+}
+
+// JWTOption return option to build jwt keyring
+func (c *Config) JWTOption() mjwt.Option {
+	return mjwt.Option{
+		Secret:         c.App.Secret,
+		Algorithm:      c.JWT.Algorithm,
+		KeyID:          c.JWT.KeyID,
+		PrivateKeyFile: c.JWT.PrivateKeyFile,
+		PreviousKeys:   c.JWT.PreviousKeys,
+		AcceptLegacy:   c.JWT.AcceptLegacy,
+	}
+}
+
+// OIDCEnabled return true if OIDC_ISSUER_URL is set
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDC.IssuerURL != ""
+}
+
+// OIDCProviderConfig return config of oidc provider
+func (c *Config) OIDCProviderConfig() oidc.Config {
+	return oidc.Config{
+		IssuerURL:    c.OIDC.IssuerURL,
+		ClientID:     c.OIDC.ClientID,
+		ClientSecret: c.OIDC.ClientSecret,
+		RedirectURL:  c.OIDC.RedirectURL,
+		Scopes:       strings.Split(c.OIDC.Scopes, ","),
+	}
+}
+
+// NotificationChannels return enabled notification channel
+func (c *Config) NotificationChannels() []string {
+	return splitList(c.Notif.Channels)
+}
+
+// NotificationDefaultChannels return channel of user who never choose channel
+func (c *Config) NotificationDefaultChannels() []string {
+	return splitList(c.Notif.DefaultChannels)
+}
+
+// splitList split comma separated value, empty item is skipped
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
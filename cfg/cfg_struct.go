@@ -38,4 +38,46 @@ type Toggle struct {
 	TraceON  bool
 	MetricON bool
 	CacheON  bool
+	// PubSubRedis share realtime event between instance through redis, in-process only when false
+	PubSubRedis bool
+}
+
+type MailConfig struct {
+	Driver           string // smtp, file or log
+	Host             string
+	Port             int
+	Username         string
+	Password         string
+	From             string
+	FileDir          string
+	ResetPasswordURL string // token is appended as query param, can be empty
+}
+
+type NotificationConfig struct {
+	Channels        string        // comma separated enabled channel : push, email, webhook, log
+	DefaultChannels string        // comma separated, used for user who never choose channel
+	DigestWindow    time.Duration // new spend is sent as one digest when no spend is added in this window, 0 disable it
+	PushTopic       bool          // push pocket message to firebase topic of the pocket instead of every member token
+}
+
+type WebhookConfig struct {
+	AllowPrivateNetwork bool // allow webhook delivery to loopback and private address, for local development only
+}
+
+type JWTConfig struct {
+	Algorithm      string // HS256 (use APP_SECRET), RS256 or EdDSA
+	KeyID          string // kid header of issued token
+	PrivateKeyFile string // PEM file, required for RS256 and EdDSA
+	PreviousKeys   string // comma separated kid:alg:file, only used to verify token during rotation
+	AcceptLegacy   bool   // verify token without kid with APP_SECRET when algorithm is not HS256
+}
+
+type OIDCConfig struct {
+	ProviderName string // stored with subject of linked account, do not change after used
+	IssuerURL    string // empty mean oidc login disabled
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       string // comma separated, openid always requested
+	AllowSignup  bool   // create account on first login if email is not registered
 }
@@ -7,3 +7,8 @@ const (
 	CAT_SAVING_IN_ID    = "01ARZ3NDEKTSV4RRFFQ69G5FAX"
 	CAT_SAVING_OUT_ID   = "01ARZ3NDEKTSV4RRFFQ69G5FAY"
 )
+
+// category hierarchy
+const (
+	CAT_MAX_DEPTH = 32 // deepest level walked by category tree query, guard against cycle
+)
@@ -3,3 +3,9 @@ package constant
 const (
 	POCK_MAIN_ID = "00000000000000000000000000" // used for get main pocket even dont known id
 )
+
+// pocket hierarchy
+const (
+	POCK_ROOT_LEVEL = 1 // level of pocket without parent
+	POCK_MAX_LEVEL  = 4 // deepest level allowed for sub-pocket
+)
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "pocket_parent_id";
+
+ALTER TABLE IF EXISTS "pockets"
+DROP COLUMN IF EXISTS "parent_id";
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "pockets"
+ADD COLUMN IF NOT EXISTS "parent_id" varchar(26) NULL; -- ULID stored as varchar
+
+ALTER TABLE "pockets" ADD FOREIGN KEY ("parent_id") REFERENCES "pockets" ("id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "pocket_parent_id" ON "pockets" ("parent_id");
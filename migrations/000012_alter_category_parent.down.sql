@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "category_parent_id";
+
+ALTER TABLE IF EXISTS "categories"
+DROP COLUMN IF EXISTS "parent_id";
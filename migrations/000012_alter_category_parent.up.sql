@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "categories"
+ADD COLUMN IF NOT EXISTS "parent_id" varchar(26) NULL; -- ULID stored as varchar
+
+ALTER TABLE "categories" ADD FOREIGN KEY ("parent_id") REFERENCES "categories" ("id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "category_parent_id" ON "categories" ("parent_id");
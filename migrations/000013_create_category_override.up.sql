@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "category_overrides" (
+  "pocket_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "category_id" varchar(26) NOT NULL, -- ULID stored as varchar, system category only
+  "category_name" varchar(100) NULL, -- NULL means use system value
+  "category_icon" int NULL,
+  "default_spend_type" int NULL,
+  "is_hidden" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("pocket_id", "category_id")
+);
+
+ALTER TABLE "category_overrides" ADD FOREIGN KEY ("pocket_id") REFERENCES "pockets" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "category_overrides" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "users"
+DROP COLUMN IF EXISTS "token_revoked_at";
+
+DROP TABLE IF EXISTS "password_resets";
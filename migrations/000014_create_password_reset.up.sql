@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "password_resets" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "token_hash" varchar(64) NOT NULL, -- sha256 hex of token, raw token only sent to user email
+  "expired_at" timestamp NOT NULL,
+  "used_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "password_resets_token_hash" ON "password_resets" ("token_hash");
+CREATE INDEX IF NOT EXISTS "password_resets_user_id" ON "password_resets" ("user_id");
+
+-- refresh token issued before this time is rejected
+ALTER TABLE IF EXISTS "users"
+ADD COLUMN IF NOT EXISTS "token_revoked_at" timestamp NULL;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "user_sessions" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar, sid claim in token
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "refresh_jti" varchar(26) NOT NULL, -- jti of the only refresh token that can be used
+  "user_agent" varchar(255) NOT NULL DEFAULT '',
+  "ip" varchar(64) NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "last_used_at" timestamp NOT NULL DEFAULT (now()),
+  "expired_at" timestamp NOT NULL,
+  "revoked_at" timestamp NULL
+);
+
+ALTER TABLE "user_sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "user_sessions_user_id" ON "user_sessions" ("user_id");
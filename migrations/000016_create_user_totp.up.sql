@@ -0,0 +1,18 @@
+-- totp secret is set on setup, login only require code after totp_enabled is true.
+-- totp_last_step is time step of last accepted code, code of that step or before is rejected
+ALTER TABLE IF EXISTS "users"
+ADD COLUMN IF NOT EXISTS "totp_secret" varchar(64) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS "totp_enabled" boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "user_recovery_codes" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "code_hash" bytea NOT NULL, -- sha256 of normalized code, raw code only shown once to user
+  "used_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "user_recovery_codes_user_id_code_hash" ON "user_recovery_codes" ("user_id", "code_hash");
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "personal_access_tokens" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "name" varchar(100) NOT NULL,
+  "token_hash" varchar(64) NOT NULL, -- sha256 hex of token, raw token only shown once
+  "token_hint" varchar(16) NOT NULL, -- prefix of raw token to help user recognize the token
+  "scopes" varchar(50)[] NOT NULL DEFAULT '{}',
+  "pocket_ids" varchar(26)[] NOT NULL DEFAULT '{}', -- empty mean all pocket of user
+  "expired_at" timestamp NULL, -- null mean never expired
+  "last_used_at" timestamp NULL,
+  "last_used_ip" varchar(64) NOT NULL DEFAULT '',
+  "revoked_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "personal_access_tokens_token_hash" ON "personal_access_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "personal_access_tokens_user_id" ON "personal_access_tokens" ("user_id");
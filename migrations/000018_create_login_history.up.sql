@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "login_histories" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "user_id" varchar(26) NULL, -- null when email is not registered
+  "email" varchar(255) NOT NULL,
+  "ip" varchar(64) NOT NULL DEFAULT '',
+  "user_agent" varchar(255) NOT NULL DEFAULT '',
+  "outcome" varchar(30) NOT NULL, -- success, mfa_required, invalid_credentials, invalid_code, locked
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "login_histories" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "login_histories_user_id_created_at" ON "login_histories" ("user_id", "created_at");
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS "user_identities" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "provider" varchar(50) NOT NULL, -- name of identity provider in config
+  "subject" varchar(255) NOT NULL, -- sub claim of id token
+  "email" varchar(255) NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "last_login_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "user_identities_provider_subject" ON "user_identities" ("provider", "subject");
+CREATE INDEX IF NOT EXISTS "user_identities_user_id" ON "user_identities" ("user_id");
+
+-- pending authorization request, deleted data is not required because it expire quickly
+CREATE TABLE IF NOT EXISTS "oidc_states" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "state_hash" varchar(64) NOT NULL, -- sha256 hex of state, raw state only sent to client
+  "nonce" varchar(64) NOT NULL,
+  "code_verifier" varchar(128) NOT NULL, -- PKCE verifier, never sent to client
+  "expired_at" timestamp NOT NULL,
+  "used_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "oidc_states_state_hash" ON "oidc_states" ("state_hash");
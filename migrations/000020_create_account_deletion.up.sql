@@ -0,0 +1,11 @@
+-- account deletion requested by user, account is purged after purge_after
+CREATE TABLE IF NOT EXISTS "account_deletions" (
+  "user_id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "transfers" jsonb NOT NULL DEFAULT '[]', -- [{pocket_id, new_owner_id}] chosen by user
+  "requested_at" timestamp NOT NULL DEFAULT (now()),
+  "purge_after" timestamp NOT NULL
+);
+
+ALTER TABLE "account_deletions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "account_deletions_purge_after" ON "account_deletions" ("purge_after");
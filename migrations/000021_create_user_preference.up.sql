@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "user_preferences" (
+  "user_id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "timezone" varchar(64) NOT NULL DEFAULT '', -- IANA name, empty mean default
+  "locale" varchar(10) NOT NULL DEFAULT '',
+  "default_pocket_id" varchar(26) NULL, -- ULID stored as varchar, used for main pocket
+  "home_currency" varchar(10) NOT NULL DEFAULT '',
+  "first_day_of_week" int NOT NULL DEFAULT 1, -- 0:sunday, 1:monday ... 6:saturday
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_preferences" ADD FOREIGN KEY ("default_pocket_id") REFERENCES "pockets" ("id") ON DELETE SET NULL;
@@ -0,0 +1,16 @@
+-- durable background job, consumed by worker with SELECT ... FOR UPDATE SKIP LOCKED
+CREATE TABLE IF NOT EXISTS "jobs" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "job_type" varchar(64) NOT NULL,
+  "payload" jsonb NOT NULL DEFAULT '{}',
+  "status" varchar(16) NOT NULL DEFAULT 'pending', -- pending, running, done, dead
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL DEFAULT 5,
+  "last_error" text NOT NULL DEFAULT '',
+  "run_at" timestamp NOT NULL DEFAULT (now()), -- job is not claimed before run_at
+  "locked_until" timestamp NULL, -- running job with expired lock is claimed again
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "jobs_status_run_at" ON "jobs" ("status", "run_at");
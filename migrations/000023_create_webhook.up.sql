@@ -0,0 +1,34 @@
+-- webhook registered by pocket owner, secret is used to sign payload
+CREATE TABLE IF NOT EXISTS "webhooks" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "pocket_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "url" varchar(2048) NOT NULL,
+  "secret" varchar(128) NOT NULL,
+  "events" varchar(32)[] NOT NULL DEFAULT '{}', -- spend.created, spend.updated, spend.deleted, member.joined
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("pocket_id") REFERENCES "pockets" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "webhooks_pocket_id" ON "webhooks" ("pocket_id");
+
+-- one row per delivery attempt
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "webhook_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "event_id" varchar(26) NOT NULL, -- the same for every attempt of one event
+  "event" varchar(32) NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status_code" int NOT NULL DEFAULT 0, -- 0 when receiver is not reachable
+  "response_body" text NOT NULL DEFAULT '',
+  "error" text NOT NULL DEFAULT '',
+  "duration_ms" int NOT NULL DEFAULT 0,
+  "is_success" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id", "id");
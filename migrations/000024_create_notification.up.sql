@@ -0,0 +1,18 @@
+-- in-app inbox, one row per recipient of a notification
+CREATE TABLE IF NOT EXISTS "notifications" (
+  "id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "message_id" varchar(26) NOT NULL, -- the same for every recipient, keep retried send from duplicating inbox
+  "title" varchar(255) NOT NULL,
+  "message" text NOT NULL,
+  "read_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "notifications_user_id_message_id" ON "notifications" ("user_id", "message_id");
+
+CREATE INDEX IF NOT EXISTS "notifications_user_id_id" ON "notifications" ("user_id", "id");
+
+CREATE INDEX IF NOT EXISTS "notifications_unread" ON "notifications" ("user_id") WHERE "read_at" IS NULL;
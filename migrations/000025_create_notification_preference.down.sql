@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "notification_pocket_preferences";
+DROP TABLE IF EXISTS "notification_preferences";
@@ -0,0 +1,26 @@
+-- push notification preference of user, inbox is not affected
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+  "user_id" varchar(26) NOT NULL PRIMARY KEY, -- ULID stored as varchar
+  "events" varchar(32)[] NOT NULL DEFAULT '{}', -- pushed event, empty mean every event
+  "min_amount" bigint NOT NULL DEFAULT 0, -- spend below this amount is not pushed, 0 mean no threshold
+  "quiet_start" varchar(5) NOT NULL DEFAULT '', -- HH:MM in user timezone, empty mean no quiet hours
+  "quiet_end" varchar(5) NOT NULL DEFAULT '',
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- push notification preference of user for one pocket, override notification_preferences
+CREATE TABLE IF NOT EXISTS "notification_pocket_preferences" (
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "pocket_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "is_muted" boolean NOT NULL DEFAULT false,
+  "min_amount" bigint NULL, -- null follow notification_preferences
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "pocket_id")
+);
+
+ALTER TABLE "notification_pocket_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "notification_pocket_preferences" ADD FOREIGN KEY ("pocket_id") REFERENCES "pockets" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "notification_pocket_preferences_pocket_id" ON "notification_pocket_preferences" ("pocket_id");
@@ -0,0 +1,2 @@
+ALTER TABLE "notification_preferences" DROP COLUMN IF EXISTS "webhook_url";
+ALTER TABLE "notification_preferences" DROP COLUMN IF EXISTS "channels";
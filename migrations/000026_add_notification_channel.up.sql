@@ -0,0 +1,4 @@
+-- channel chosen by user, empty mean default channel of server
+ALTER TABLE "notification_preferences" ADD COLUMN IF NOT EXISTS "channels" varchar(16)[] NOT NULL DEFAULT '{}';
+-- chat webhook url used by webhook channel, ex: slack or discord incoming webhook
+ALTER TABLE "notification_preferences" ADD COLUMN IF NOT EXISTS "webhook_url" varchar(2048) NOT NULL DEFAULT '';
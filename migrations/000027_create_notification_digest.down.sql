@@ -0,0 +1,3 @@
+ALTER TABLE "notification_preferences" DROP COLUMN IF EXISTS "digest_time";
+ALTER TABLE "notification_preferences" DROP COLUMN IF EXISTS "digest";
+DROP TABLE IF EXISTS "notification_digests";
@@ -0,0 +1,21 @@
+-- spend notification waiting to be sent as one digest message, deleted when flushed
+CREATE TABLE IF NOT EXISTS "notification_digests" (
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "digest_key" varchar(32) NOT NULL, -- pocket id for burst digest, daily or weekly for summary
+  "pocket_names" varchar(255)[] NOT NULL DEFAULT '{}',
+  "actor_names" varchar(255)[] NOT NULL DEFAULT '{}',
+  "message_ids" varchar(64)[] NOT NULL DEFAULT '{}', -- keep retried job from counting message twice
+  "count" int NOT NULL DEFAULT 0,
+  "total" bigint NOT NULL DEFAULT 0,
+  "first_message" jsonb NOT NULL, -- sent as is when digest only has one message
+  "flush_at" timestamp NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "digest_key")
+);
+
+ALTER TABLE "notification_digests" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- empty digest send spend notification shortly after burst, daily or weekly send summary
+ALTER TABLE "notification_preferences" ADD COLUMN IF NOT EXISTS "digest" varchar(8) NOT NULL DEFAULT '';
+-- HH:MM in user timezone when summary is sent, empty mean 08:00
+ALTER TABLE "notification_preferences" ADD COLUMN IF NOT EXISTS "digest_time" varchar(5) NOT NULL DEFAULT '';
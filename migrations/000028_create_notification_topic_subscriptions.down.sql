@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "notification_topic_subscriptions";
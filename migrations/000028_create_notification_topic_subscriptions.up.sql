@@ -0,0 +1,11 @@
+-- firebase topic subscribed by fcm token, so sync only send the difference to firebase.
+-- no foreign key to users, row of deleted user is kept until its token is unsubscribed
+CREATE TABLE IF NOT EXISTS "notification_topic_subscriptions" (
+  "token" text NOT NULL,
+  "topic" varchar(64) NOT NULL, -- pocket.<pocket id> or user.<user id>
+  "user_id" varchar(26) NOT NULL, -- ULID stored as varchar
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("token", "topic")
+);
+
+CREATE INDEX IF NOT EXISTS "notification_topic_subscriptions_user_id_idx" ON "notification_topic_subscriptions" ("user_id");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "jobs_dedupe_key";
+ALTER TABLE "jobs" DROP COLUMN IF EXISTS "dedupe_key";
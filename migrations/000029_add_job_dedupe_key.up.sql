@@ -0,0 +1,5 @@
+-- job enqueued with the same key is saved once, so retried caller does not enqueue it twice.
+-- NULL key is never unique, job without key is always saved
+ALTER TABLE "jobs" ADD COLUMN IF NOT EXISTS "dedupe_key" varchar(128) NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "jobs_dedupe_key" ON "jobs" ("dedupe_key");
@@ -0,0 +1,8 @@
+// Package migrations embed sql migration so it can be applied by admin tools
+// without golang-migrate binary. file name must follow golang-migrate format.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,198 @@
+package bg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/observ/mmetric"
+)
+
+// ErrRunnerClosed returned when job is submitted after Shutdown is called
+var ErrRunnerClosed = errors.New("background runner is shutting down")
+
+// Option configure Runner, zero value use default
+type Option struct {
+	Workers    int           // count of job executed at the same time
+	QueueSize  int           // job waiting for worker, Submit block when it is full
+	JobTimeout time.Duration // deadline of job context, can be overridden per job
+}
+
+var DefaultOption = Option{
+	Workers:    16,
+	QueueSize:  1024,
+	JobTimeout: 30 * time.Second,
+}
+
+type queuedJob struct {
+	ctx context.Context
+	job BackgroundJob
+}
+
+// Runner execute background job with bounded worker pool
+// and keep track of them so it can be drained before process exit.
+type Runner struct {
+	opt Option
+
+	mu      sync.RWMutex // guard closed, so no job is tracked after Shutdown start waiting
+	closed  bool
+	jobs    chan queuedJob
+	pending sync.WaitGroup // queued, running and long living job
+
+	// ctx is cancelled when Shutdown gives up waiting, every job context is derived from it.
+	// daemonCtx is cancelled as soon as Shutdown is called
+	ctx          context.Context
+	cancel       context.CancelFunc
+	daemonCtx    context.Context
+	daemonCancel context.CancelFunc
+}
+
+// NewRunner create runner and start its workers
+func NewRunner(opt Option) *Runner {
+	if opt.Workers <= 0 {
+		opt.Workers = DefaultOption.Workers
+	}
+	if opt.QueueSize < 0 {
+		opt.QueueSize = 0
+	}
+	if opt.JobTimeout <= 0 {
+		opt.JobTimeout = DefaultOption.JobTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	daemonCtx, daemonCancel := context.WithCancel(ctx)
+	r := &Runner{
+		opt:          opt,
+		jobs:         make(chan queuedJob, opt.QueueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+		daemonCtx:    daemonCtx,
+		daemonCancel: daemonCancel,
+	}
+
+	for i := 0; i < opt.Workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+// Submit queue job to be executed by worker. job context keep values of ctx
+// but is not cancelled with it, it has JobTimeout deadline instead.
+func (r *Runner) Submit(ctx context.Context, job BackgroundJob) error {
+	if !r.track() {
+		return ErrRunnerClosed
+	}
+
+	select {
+	case r.jobs <- queuedJob{ctx: ctx, job: job}:
+		return nil
+	case <-r.ctx.Done():
+		r.pending.Done()
+		return ErrRunnerClosed
+	}
+}
+
+// RunUntilShutdown run long living job like periodic loop in its own goroutine.
+// its context is cancelled when Shutdown is called and Shutdown wait for it to return
+func (r *Runner) RunUntilShutdown(ctx context.Context, job BackgroundJob) error {
+	if !r.track() {
+		return ErrRunnerClosed
+	}
+
+	jobCtx, cancel := context.WithCancel(NewDetachContext(ctx))
+	stop := context.AfterFunc(r.daemonCtx, cancel)
+
+	go func() {
+		defer r.pending.Done()
+		defer stop()
+		defer cancel()
+		execute(jobCtx, job)
+	}()
+	return nil
+}
+
+// Shutdown stop accepting job, then wait until queued and running job is finished.
+// when ctx is done before that, context of remaining job is cancelled and ctx error is returned
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	r.daemonCancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel() // stop idle worker
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return fmt.Errorf("%d job still queued: %w", len(r.jobs), ctx.Err())
+	}
+}
+
+// track count job as pending, false if runner is closed
+func (r *Runner) track() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return false
+	}
+	r.pending.Add(1)
+	return true
+}
+
+func (r *Runner) work() {
+	for {
+		select {
+		case queued := <-r.jobs:
+			r.run(queued)
+			r.pending.Done()
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Runner) run(queued queuedJob) {
+	timeout := r.opt.JobTimeout
+	if queued.job.Timeout > 0 {
+		timeout = queued.job.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(NewDetachContext(queued.ctx), timeout)
+	defer cancel()
+	stop := context.AfterFunc(r.ctx, cancel)
+	defer stop()
+
+	execute(ctx, queued.job)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("background job %s exceed timeout %s", queued.job.JobTitle, timeout)
+	}
+}
+
+// execute run job and recover its panic
+func execute(ctx context.Context, job BackgroundJob) {
+	defer func() {
+		if err := recover(); err != nil {
+			mmetric.AddBackgroundPanicCounter(context.Background(), job.JobTitle)
+			log.Printf("recover panic when run %s : %v", job.JobTitle, err)
+		}
+	}()
+
+	job.Execute(ctx)
+}
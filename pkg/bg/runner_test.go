@@ -0,0 +1,156 @@
+package bg
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunnerShutdownDrainJob(t *testing.T) {
+	r := NewRunner(Option{Workers: 2, QueueSize: 10})
+
+	var done int64
+	for i := 0; i < 10; i++ {
+		err := r.Submit(context.Background(), BackgroundJob{
+			JobTitle: "sleep",
+			Execute: func(ctx context.Context) {
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt64(&done, 1)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := atomic.LoadInt64(&done); got != 10 {
+		t.Errorf("finished job = %d, want 10", got)
+	}
+
+	err := r.Submit(context.Background(), BackgroundJob{JobTitle: "late", Execute: func(ctx context.Context) {}})
+	if !errors.Is(err, ErrRunnerClosed) {
+		t.Errorf("Submit() after shutdown error = %v, want ErrRunnerClosed", err)
+	}
+}
+
+func TestRunnerRecoverPanic(t *testing.T) {
+	r := NewRunner(Option{Workers: 1})
+
+	var done int64
+	_ = r.Submit(context.Background(), BackgroundJob{
+		JobTitle: "panic",
+		Execute:  func(ctx context.Context) { panic("boom") },
+	})
+	_ = r.Submit(context.Background(), BackgroundJob{
+		JobTitle: "after panic",
+		Execute:  func(ctx context.Context) { atomic.AddInt64(&done, 1) },
+	})
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if atomic.LoadInt64(&done) != 1 {
+		t.Error("worker must keep running after job panic")
+	}
+}
+
+func TestRunnerJobTimeout(t *testing.T) {
+	r := NewRunner(Option{Workers: 1, JobTimeout: time.Hour})
+
+	var gotErr atomic.Value
+	_ = r.Submit(context.Background(), BackgroundJob{
+		JobTitle: "slow",
+		Timeout:  20 * time.Millisecond,
+		Execute: func(ctx context.Context) {
+			<-ctx.Done()
+			gotErr.Store(ctx.Err())
+		},
+	})
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err, _ := gotErr.Load().(error); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("job context error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRunnerJobIgnoreParentCancel(t *testing.T) {
+	r := NewRunner(Option{Workers: 1})
+
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey("k"), "v"))
+	cancel()
+
+	var gotValue atomic.Value
+	_ = r.Submit(parent, BackgroundJob{
+		JobTitle: "detached",
+		Execute: func(ctx context.Context) {
+			if ctx.Err() == nil {
+				gotValue.Store(ctx.Value(ctxKey("k")))
+			}
+		},
+	})
+
+	_ = r.Shutdown(context.Background())
+	if v, _ := gotValue.Load().(string); v != "v" {
+		t.Errorf("job must keep parent value and ignore its cancellation, got %q", v)
+	}
+}
+
+func TestRunnerShutdownCancelLongRunning(t *testing.T) {
+	r := NewRunner(Option{Workers: 1})
+
+	stopped := make(chan struct{})
+	_ = r.RunUntilShutdown(context.Background(), BackgroundJob{
+		JobTitle: "loop",
+		Execute: func(ctx context.Context) {
+			<-ctx.Done()
+			close(stopped)
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("long running job must be stopped before Shutdown return")
+	}
+}
+
+func TestRunnerShutdownTimeout(t *testing.T) {
+	r := NewRunner(Option{Workers: 1, JobTimeout: time.Hour})
+
+	cancelled := make(chan struct{})
+	_ = r.Submit(context.Background(), BackgroundJob{
+		JobTitle: "stuck",
+		Execute: func(ctx context.Context) {
+			<-ctx.Done()
+			close(cancelled)
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := r.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("job context must be cancelled when shutdown give up")
+	}
+}
+
+type ctxKey string
@@ -6,22 +6,45 @@ package bg
 import (
 	"context"
 	"log"
+	"sync"
+	"time"
 )
 
 type BackgroundJob struct {
 	JobTitle string
 	Execute  func(ctx context.Context)
+	Timeout  time.Duration // zero mean runner JobTimeout, ignored by RunUntilShutdown
 }
 
+var (
+	defaultOnce   sync.Once
+	defaultRunner *Runner
+)
+
+// Default return runner used by RunSafeBackground, created with DefaultOption on first use
+func Default() *Runner {
+	defaultOnce.Do(func() {
+		defaultRunner = NewRunner(DefaultOption)
+	})
+	return defaultRunner
+}
+
+// RunSafeBackground run job in default runner, panic is recovered.
+// job is dropped if server is shutting down
 func RunSafeBackground(ctx context.Context, job BackgroundJob) {
-	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("recover panic when run %s : %v", job.JobTitle, err)
-			}
-		}()
+	if err := Default().Submit(ctx, job); err != nil {
+		log.Printf("background job %s is not executed: %v", job.JobTitle, err)
+	}
+}
+
+// RunUntilShutdown run long living job in default runner, see Runner.RunUntilShutdown
+func RunUntilShutdown(ctx context.Context, job BackgroundJob) {
+	if err := Default().RunUntilShutdown(ctx, job); err != nil {
+		log.Printf("background job %s is not executed: %v", job.JobTitle, err)
+	}
+}
 
-		detachedCtx := NewDetachContext(ctx)
-		job.Execute(detachedCtx)
-	}()
+// Shutdown drain default runner
+func Shutdown(ctx context.Context) error {
+	return Default().Shutdown(ctx)
 }
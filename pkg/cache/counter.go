@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/redis/go-redis/v9"
+)
+
+// Counter is expiring int64 storage, used for rate limit and similar.
+type Counter interface {
+	// Incr increase value of key by 1, ttl only applied when key created
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Get return 0 if key not exist
+	Get(ctx context.Context, key string) (int64, error)
+	Set(ctx context.Context, key string, value int64, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// NewCounter return counter stored in redis, if redis is nil or not reachable
+// in-memory counter is used so the caller keep working (not shared between instance)
+func NewCounter(rds *redis.Client) Counter {
+	return &fallbackCounter{
+		rds:    rds,
+		memory: NewMemoryCounter(),
+	}
+}
+
+type fallbackCounter struct {
+	rds    *redis.Client
+	memory *MemoryCounter
+}
+
+func (c *fallbackCounter) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "cache.Incr")
+	defer span.End()
+
+	key = preKey + key
+	if c.rds == nil {
+		return c.memory.Incr(ctx, key, ttl)
+	}
+
+	pipe := c.rds.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return c.memory.Incr(ctx, key, ttl)
+	}
+	return incr.Val(), nil
+}
+
+func (c *fallbackCounter) Get(ctx context.Context, key string) (int64, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "cache.GetCounter")
+	defer span.End()
+
+	key = preKey + key
+	if c.rds == nil {
+		return c.memory.Get(ctx, key)
+	}
+
+	res, err := c.rds.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return c.memory.Get(ctx, key)
+	}
+	return strconv.ParseInt(res, 10, 64)
+}
+
+func (c *fallbackCounter) Set(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	ctx, span := observ.GetTracer().Start(ctx, "cache.SetCounter")
+	defer span.End()
+
+	key = preKey + key
+	if c.rds == nil {
+		return c.memory.Set(ctx, key, value, ttl)
+	}
+
+	if err := c.rds.Set(ctx, key, value, ttl).Err(); err != nil {
+		return c.memory.Set(ctx, key, value, ttl)
+	}
+	return nil
+}
+
+func (c *fallbackCounter) Delete(ctx context.Context, keys ...string) error {
+	ctx, span := observ.GetTracer().Start(ctx, "cache.DeleteCounter")
+	defer span.End()
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = preKey + key
+	}
+	// value may be written to memory when redis was down
+	_ = c.memory.Delete(ctx, prefixed...)
+	if c.rds == nil {
+		return nil
+	}
+	return c.rds.Del(ctx, prefixed...).Err()
+}
+
+// MemoryCounter is Counter stored in process memory
+type MemoryCounter struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	now   func() time.Time
+}
+
+type memoryItem struct {
+	value     int64
+	expiredAt time.Time
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{
+		items: make(map[string]memoryItem),
+		now:   time.Now,
+	}
+}
+
+func (m *MemoryCounter) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evictExpired()
+	item, ok := m.items[key]
+	if !ok {
+		item = memoryItem{expiredAt: m.now().Add(ttl)}
+	}
+	item.value++
+	m.items[key] = item
+	return item.value, nil
+}
+
+func (m *MemoryCounter) Get(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[key]
+	if !ok || !m.now().Before(item.expiredAt) {
+		return 0, nil
+	}
+	return item.value, nil
+}
+
+func (m *MemoryCounter) Set(_ context.Context, key string, value int64, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evictExpired()
+	m.items[key] = memoryItem{value: value, expiredAt: m.now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryCounter) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.items, key)
+	}
+	return nil
+}
+
+// evictExpired must be called with lock held
+func (m *MemoryCounter) evictExpired() {
+	now := m.now()
+	for key, item := range m.items {
+		if !now.Before(item.expiredAt) {
+			delete(m.items, key)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCounter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	counter := NewMemoryCounter()
+	counter.now = func() time.Time { return now }
+
+	for i := int64(1); i <= 3; i++ {
+		got, _ := counter.Incr(ctx, "a", time.Minute)
+		if got != i {
+			t.Errorf("Incr() = %d, want %d", got, i)
+		}
+	}
+
+	// ttl is not extended by next incr
+	now = now.Add(time.Minute)
+	if got, _ := counter.Get(ctx, "a"); got != 0 {
+		t.Errorf("Get() after expired = %d, want 0", got)
+	}
+	if got, _ := counter.Incr(ctx, "a", time.Minute); got != 1 {
+		t.Errorf("Incr() after expired = %d, want 1", got)
+	}
+
+	_ = counter.Set(ctx, "b", 10, time.Second)
+	if got, _ := counter.Get(ctx, "b"); got != 10 {
+		t.Errorf("Get() = %d, want 10", got)
+	}
+	_ = counter.Delete(ctx, "a", "b")
+	if got, _ := counter.Get(ctx, "b"); got != 0 {
+		t.Errorf("Get() after delete = %d, want 0", got)
+	}
+}
+
+func TestCounterWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	counter := NewCounter(nil)
+
+	_, _ = counter.Incr(ctx, "a", time.Minute)
+	got, _ := counter.Incr(ctx, "a", time.Minute)
+	if got != 2 {
+		t.Errorf("Incr() = %d, want 2", got)
+	}
+	_ = counter.Delete(ctx, "a")
+	if got, _ := counter.Get(ctx, "a"); got != 0 {
+		t.Errorf("Get() after delete = %d, want 0", got)
+	}
+}
@@ -15,8 +15,15 @@ type DateRange struct {
 
 // ParseDateRange parses the date range type and calculates the start and end dates.
 // zone example : Asia/Makassar , Asia/Jakarta
-// rangeType example : last-7-days. 2024-1, 2024-2
+// rangeType example : last-7-days. this-week, last-week, 2024-1, 2024-2
+// week is started on monday, use ParseDateRangeWeekStart to change it.
 func ParseDateRange(rangeType string, zone string) (DateRange, error) {
+	return ParseDateRangeWeekStart(rangeType, zone, time.Monday)
+}
+
+// ParseDateRangeWeekStart is ParseDateRange with custom first day of week
+// used by this-week and last-week range type.
+func ParseDateRangeWeekStart(rangeType string, zone string, weekStart time.Weekday) (DateRange, error) {
 	loc, err := time.LoadLocation(zone)
 	if err != nil {
 		return DateRange{}, fmt.Errorf("invalid timezone: %v", err)
@@ -28,6 +35,10 @@ func ParseDateRange(rangeType string, zone string) (DateRange, error) {
 	case rangeType == "last-7-days":
 		year, month, day := now.Date()
 		return calculateLast7Days(year, month, day, loc), nil
+	case rangeType == "this-week":
+		return calculateWeekRange(now, weekStart), nil
+	case rangeType == "last-week":
+		return calculateWeekRange(now.AddDate(0, 0, -7), weekStart), nil
 	case strings.Contains(rangeType, "-"): // Assume format is "yyyy-mm"
 		year, month, err := parseYearMonth(rangeType)
 		if err != nil {
@@ -72,3 +83,12 @@ func calculateLast7Days(year int, month time.Month, day int, loc *time.Location)
 	end := time.Date(year, month, day, 23, 59, 59, 0, loc)
 	return DateRange{StartDate: start, EndDate: end}
 }
+
+// calculateWeekRange calculates the start and end dates of the week containing t.
+func calculateWeekRange(t time.Time, weekStart time.Weekday) DateRange {
+	offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	year, month, day := t.Date()
+	start := time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	end := time.Date(year, month, day-offset+6, 23, 59, 59, 0, t.Location())
+	return DateRange{StartDate: start, EndDate: end}
+}
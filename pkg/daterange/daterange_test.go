@@ -61,3 +61,30 @@ func TestCalculateMonthRange(t *testing.T) {
 			dr.StartDate, dr.EndDate, expectedStart, expectedEnd)
 	}
 }
+
+func TestCalculateWeekRange(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	// 2024-01-10 is wednesday
+	day := time.Date(2024, time.January, 10, 15, 0, 0, 0, loc)
+
+	tests := []struct {
+		name      string
+		weekStart time.Weekday
+		wantStart time.Time
+	}{
+		{name: "monday", weekStart: time.Monday, wantStart: time.Date(2024, time.January, 8, 0, 0, 0, 0, loc)},
+		{name: "sunday", weekStart: time.Sunday, wantStart: time.Date(2024, time.January, 7, 0, 0, 0, 0, loc)},
+		{name: "same day", weekStart: time.Wednesday, wantStart: time.Date(2024, time.January, 10, 0, 0, 0, 0, loc)},
+		{name: "thursday", weekStart: time.Thursday, wantStart: time.Date(2024, time.January, 4, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dr := calculateWeekRange(day, tt.weekStart)
+			wantEnd := time.Date(tt.wantStart.Year(), tt.wantStart.Month(), tt.wantStart.Day()+6, 23, 59, 59, 0, loc)
+			if !dr.StartDate.Equal(tt.wantStart) || !dr.EndDate.Equal(wantEnd) {
+				t.Errorf("calculateWeekRange returned incorrect dates: got %v to %v, want %v to %v",
+					dr.StartDate, dr.EndDate, tt.wantStart, wantEnd)
+			}
+		})
+	}
+}
@@ -3,19 +3,21 @@ package db
 import (
 	"errors"
 
+	"github.com/muchlist/moneymagnet/pkg/errr"
+
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 )
 
 var (
-	ErrDBNotFound          = errors.New("not found")
-	ErrDBDuplicatedEntry   = errors.New("duplicated entry")
-	ErrDBRelationNotFound  = errors.New("invalid relation")
-	ErrDBInvalidInput      = errors.New("invalid input syntax")
+	ErrDBNotFound          = errr.T("error.not_found", nil)
+	ErrDBDuplicatedEntry   = errr.T("error.duplicated_entry", nil)
+	ErrDBRelationNotFound  = errr.T("error.invalid_relation", nil)
+	ErrDBInvalidInput      = errr.T("error.invalid_input", nil)
 	ErrDBBuildQuery        = errors.New("query not valid")
-	ErrDBSortFilter        = errors.New("invalid filter or sort value")
-	ErrDBInvalidCursorType = errors.New("invalid cursor type")
+	ErrDBSortFilter        = errr.T("error.invalid_sort_filter", nil)
+	ErrDBInvalidCursorType = errr.T("error.invalid_cursor_type", nil)
 )
 
 func ParseError(err error) error {
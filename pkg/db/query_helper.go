@@ -73,3 +73,8 @@ func CoalesceFloat64(text string, def float64) string {
 func CoalesceString(text string, def string) string {
 	return fmt.Sprintf("Coalesce(%s,'%s')", text, def)
 }
+
+// CoalesceBool Coalesce(null,default)
+func CoalesceBool(text string, def bool) string {
+	return fmt.Sprintf("Coalesce(%s,%t)", text, def)
+}
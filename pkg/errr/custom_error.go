@@ -1,6 +1,10 @@
 package errr
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/muchlist/moneymagnet/pkg/i18n"
+)
 
 // StatusCodeError implement error interface
 type StatusCodeError struct {
@@ -12,6 +16,10 @@ func (s StatusCodeError) Error() string {
 	return s.Err.Error()
 }
 
+func (s StatusCodeError) Unwrap() error {
+	return s.Err
+}
+
 // New return StatusCodeError with same message, use this if we know exactly
 // what error it is and what status code to return
 func New(message string, statusCode int) StatusCodeError {
@@ -21,6 +29,14 @@ func New(message string, statusCode int) StatusCodeError {
 	}
 }
 
+// NewT is New with message from i18n catalog, so it is sent in language of user
+func NewT(key string, params map[string]string, statusCode int) StatusCodeError {
+	return StatusCodeError{
+		Err:        T(key, params),
+		StatusCode: statusCode,
+	}
+}
+
 // Wrap transform error to StatusCodeError with same message,
 // use this if we know exactly what status code to return
 func Wrap(err error, statusCode int) StatusCodeError {
@@ -29,3 +45,30 @@ func Wrap(err error, statusCode int) StatusCodeError {
 		StatusCode: statusCode,
 	}
 }
+
+// Localizer is error which message can be rendered in language of user
+type Localizer interface {
+	error
+	Localize(lang string) string
+}
+
+// Message is error which message is i18n catalog key rendered with params,
+// Error render it in i18n.Source language
+type Message struct {
+	Key    string
+	Params map[string]string
+}
+
+// T return error of i18n catalog key, params fill {name} placeholder of the message
+func T(key string, params map[string]string) *Message {
+	return &Message{Key: key, Params: params}
+}
+
+func (m *Message) Error() string {
+	return m.Localize(i18n.Source)
+}
+
+// Localize render message in lang
+func (m *Message) Localize(lang string) string {
+	return i18n.T(lang, m.Key, m.Params)
+}
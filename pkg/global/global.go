@@ -9,3 +9,6 @@ const RequestIDKey KeyRequestIDType = "Request-Id"
 // TraceIDKey used for key context to set-get traceID from otel tracer
 // use global variable bacause ctx value must be passing to different lib
 const TraceIDKey KeyRequestIDType = "Trace-Id"
+
+// LanguageKey used for key context to set-get language negotiated from Accept-Language
+const LanguageKey KeyRequestIDType = "Language"
@@ -0,0 +1,134 @@
+// Package i18n hold message catalog of every supported language.
+// language is added by adding locales/<lang>.json, no code change needed.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Indonesian = "id"
+	English    = "en"
+
+	// Default is used for message of language without catalog
+	Default = Indonesian
+	// Source is language used when message is written outside request, ex: error in log
+	Source = English
+)
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+type catalog struct {
+	// Messages is template with {param} placeholder, key is dotted name
+	Messages map[string]string `json:"messages"`
+	// GroupSeparator separate thousand of number
+	GroupSeparator string `json:"group_separator"`
+}
+
+var catalogs = mustLoad()
+
+func mustLoad() map[string]*catalog {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("read locales: %s", err))
+	}
+
+	result := make(map[string]*catalog, len(files))
+	for _, file := range files {
+		raw, err := localeFS.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("read locale %s: %s", file.Name(), err))
+		}
+		var c catalog
+		if err := json.Unmarshal(raw, &c); err != nil {
+			panic(fmt.Sprintf("decode locale %s: %s", file.Name(), err))
+		}
+		result[strings.TrimSuffix(file.Name(), ".json")] = &c
+	}
+	return result
+}
+
+// Languages return every language having catalog
+func Languages() []string {
+	result := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		result = append(result, lang)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Supported return true when lang has catalog
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// T render message template of key in lang, {name} placeholder is replaced by params.
+// template of Default language is used when lang has no such key, key is returned when none has it
+func T(lang, key string, params map[string]string) string {
+	template, ok := lookupMessage(lang, key)
+	if !ok {
+		template, ok = lookupMessage(Default, key)
+	}
+	if !ok {
+		return key
+	}
+
+	oldnew := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		oldnew = append(oldnew, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(oldnew...).Replace(template)
+}
+
+func lookupMessage(lang, key string) (string, bool) {
+	c, ok := catalogs[lang]
+	if !ok {
+		return "", false
+	}
+	template, ok := c.Messages[key]
+	return template, ok
+}
+
+// Match return supported language most preferred by Accept-Language header value,
+// ex: "en-US,en;q=0.9,id;q=0.8". empty string is returned when none is supported
+func Match(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := parseLanguageRange(part)
+		if q <= bestQ {
+			continue
+		}
+		base := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if Supported(base) {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+func parseLanguageRange(part string) (string, float64) {
+	fields := strings.Split(strings.TrimSpace(part), ";")
+	tag := strings.TrimSpace(fields[0])
+	q := 1.0
+	for _, param := range fields[1:] {
+		name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || strings.TrimSpace(name) != "q" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return tag, 0
+		}
+		q = parsed
+	}
+	return tag, q
+}
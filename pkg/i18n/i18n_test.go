@@ -0,0 +1,83 @@
+package i18n
+
+import "testing"
+
+func TestT(t *testing.T) {
+	params := map[string]string{"pocket": "Dompet", "actor": "Budi"}
+
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		want string
+	}{
+		{name: "indonesian", lang: Indonesian, key: "notification.spend_created.title", want: "Penambahan record pada Dompet oleh Budi"},
+		{name: "english", lang: English, key: "notification.spend_created.title", want: "New record in Dompet by Budi"},
+		{name: "unknown language use default", lang: "fr", key: "notification.spend_created.title", want: "Penambahan record pada Dompet oleh Budi"},
+		{name: "unknown key return key", lang: English, key: "unknown.key", want: "unknown.key"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := T(tc.lang, tc.key, params); got != tc.want {
+				t.Errorf("T() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// every key must be in every catalog, missing key fall back to other language
+func TestCatalogKeys(t *testing.T) {
+	for lang, c := range catalogs {
+		for other, o := range catalogs {
+			for key := range o.Messages {
+				if _, ok := c.Messages[key]; !ok {
+					t.Errorf("key %q of %s is missing in %s", key, other, lang)
+				}
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "id", want: Indonesian},
+		{header: "en-US,en;q=0.9,id;q=0.8", want: English},
+		{header: "fr-FR,id;q=0.5,en;q=0.7", want: English},
+		{header: "fr, ID-id;q=0.1", want: Indonesian},
+		{header: "fr,de;q=0.5", want: ""},
+		{header: "en;q=0", want: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			if got := Match(tc.header); got != tc.want {
+				t.Errorf("Match(%q) = %q, want %q", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		lang     string
+		currency string
+		amount   int64
+		want     string
+	}{
+		{lang: Indonesian, currency: "Rp", amount: 1500000, want: "Rp 1.500.000"},
+		{lang: English, currency: "Rp", amount: 1500000, want: "Rp 1,500,000"},
+		{lang: Indonesian, currency: "", amount: -25000, want: "-25.000"},
+		{lang: English, currency: " ", amount: 999, want: "999"},
+		{lang: "fr", currency: "", amount: 100000, want: "100,000"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := FormatMoney(tc.lang, tc.currency, tc.amount); got != tc.want {
+				t.Errorf("FormatMoney() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
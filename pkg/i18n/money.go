@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatNumber group thousand of n with separator of lang, ex: 1.500.000 for id and 1,500,000 for en
+func FormatNumber(lang string, n int64) string {
+	separator := ","
+	if c, ok := catalogs[lang]; ok && c.GroupSeparator != "" {
+		separator = c.GroupSeparator
+	}
+
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteRune(digit)
+	}
+	return sb.String()
+}
+
+// FormatMoney write amount with currency of pocket in front, currency is optional
+func FormatMoney(lang, currency string, amount int64) string {
+	currency = strings.TrimSpace(currency)
+	if currency == "" {
+		return FormatNumber(lang, amount)
+	}
+	return currency + " " + FormatNumber(lang, amount)
+}
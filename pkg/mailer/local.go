@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+)
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer create mailer that write every email as .eml file inside dir.
+// used for local development
+func NewFileMailer(dir string, from string) (Mailer, error) {
+	if dir == "" {
+		dir = "mails"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail dir: %w", err)
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+func (m *fileMailer) Send(_ context.Context, msg Message) error {
+	fileName := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.Join(msg.To, "_"))
+	err := os.WriteFile(filepath.Join(m.dir, filepath.Base(fileName)), buildRFC822(m.from, msg), 0o644)
+	if err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	return nil
+}
+
+type logMailer struct {
+	log mlogger.Logger
+}
+
+// NewLogMailer create mailer that only print email to log.
+// used for local development
+func NewLogMailer(log mlogger.Logger) Mailer {
+	return &logMailer{log: log}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	m.log.InfoT(ctx, "mail sent to log",
+		mlogger.String("to", strings.Join(msg.To, ",")),
+		mlogger.String("subject", msg.Subject),
+		mlogger.String("body", msg.Body),
+	)
+	return nil
+}
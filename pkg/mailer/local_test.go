@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerSend(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "no-reply@example.com")
+	if err != nil {
+		t.Fatalf("NewFileMailer() error = %v", err)
+	}
+
+	err = m.Send(context.Background(), Message{
+		To:      []string{"user@example.com"},
+		Subject: "Reset Password",
+		Body:    "token: abc",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("want 1 mail file, got %d", len(entries))
+	}
+
+	content, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{
+		"From: no-reply@example.com\r\n",
+		"To: user@example.com\r\n",
+		"Subject: Reset Password\r\n",
+		"\r\n\r\ntoken: abc",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("mail content does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+)
+
+// Mailer defines the interface for sending email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Option is config for mailer, Driver can be smtp, file or log
+type Option struct {
+	Driver   string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	FileDir  string
+}
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+// New create mailer based on option driver
+func New(opt Option, logger mlogger.Logger) (Mailer, error) {
+	switch opt.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(opt), nil
+	case DriverFile:
+		return NewFileMailer(opt.FileDir, opt.From)
+	case DriverLog, "":
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", opt.Driver)
+	}
+}
+
+// buildRFC822 create raw email content
+func buildRFC822(from string, msg Message) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	sb.WriteString("Subject: " + msg.Subject + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(msg.Body)
+	return []byte(sb.String())
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer create mailer that send email through SMTP server.
+// auth is skipped when username is empty
+func NewSMTPMailer(opt Option) Mailer {
+	var auth smtp.Auth
+	if opt.Username != "" {
+		auth = smtp.PlainAuth("", opt.Username, opt.Password, opt.Host)
+	}
+	return &smtpMailer{
+		addr: net.JoinHostPort(opt.Host, strconv.Itoa(opt.Port)),
+		auth: auth,
+		from: opt.From,
+	}
+}
+
+// Send send email, net/smtp does not support context so ctx only checked before sending
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := smtp.SendMail(m.addr, m.auth, m.from, msg.To, buildRFC822(m.from, msg))
+	if err != nil {
+		return fmt.Errorf("send mail via smtp: %w", err)
+	}
+	return nil
+}
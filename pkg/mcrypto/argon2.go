@@ -0,0 +1,123 @@
+package mcrypto
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var ErrInvalidHash = errors.New("hash is not in the correct format")
+
+// Argon2Params parameter of argon2id, stored in the hash so it can be changed later
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow OWASP recommendation for argon2id
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2Prefix = "$argon2id$"
+
+// NewArgon2id return argon2id Crypter, hash is encoded as
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func NewArgon2id(params Argon2Params) argon2Core {
+	return argon2Core{params: params}
+}
+
+type argon2Core struct {
+	params Argon2Params
+}
+
+// GenerateHash return encoded argon2id hash with random salt
+func (c argon2Core) GenerateHash(password string) ([]byte, error) {
+	salt := make([]byte, c.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate hash error: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, c.params.Iterations, c.params.Memory, c.params.Parallelism, c.params.KeyLength)
+
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix,
+		argon2.Version,
+		c.params.Memory,
+		c.params.Iterations,
+		c.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+	return []byte(encoded), nil
+}
+
+// IsPWAndHashPWMatch compare password using parameter stored in the hash
+func (c argon2Core) IsPWAndHashPWMatch(password []byte, hashPass []byte) bool {
+	params, salt, key, err := decodeArgon2(hashPass)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+// NeedsRehash return true if hash is not argon2id or parameter is different from configured
+func (c argon2Core) NeedsRehash(hashPass []byte) bool {
+	params, _, _, err := decodeArgon2(hashPass)
+	if err != nil {
+		return true
+	}
+	return params.Memory != c.params.Memory ||
+		params.Iterations != c.params.Iterations ||
+		params.Parallelism != c.params.Parallelism ||
+		params.KeyLength != c.params.KeyLength ||
+		params.SaltLength != c.params.SaltLength
+}
+
+func isArgon2Hash(hashPass []byte) bool {
+	return strings.HasPrefix(string(hashPass), argon2Prefix)
+}
+
+func decodeArgon2(hashPass []byte) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(string(hashPass), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -6,16 +6,22 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func New() core {
-	return core{}
+// NewBcrypt return bcrypt Crypter, cost below bcrypt.MinCost is raised to bcrypt.MinCost
+func NewBcrypt(cost int) bcryptCore {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.MinCost
+	}
+	return bcryptCore{cost: cost}
 }
 
-type core struct{}
+type bcryptCore struct {
+	cost int
+}
 
 // GenerateHash membuat hashpassword, hash password 1 dengan yang lainnya akan berbeda meskipun
 // inputannya sama, sehingga untuk membandingkan hashpassword memerlukan method lain IsPWAndHashPWMatch
-func (c core) GenerateHash(password string) ([]byte, error) {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+func (c bcryptCore) GenerateHash(password string) ([]byte, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), c.cost)
 	if err != nil {
 		return nil, fmt.Errorf("generate hash error: %w", err)
 	}
@@ -23,7 +29,16 @@ func (c core) GenerateHash(password string) ([]byte, error) {
 }
 
 // IsPWAndHashPWMatch return true jika inputan password dan hashpassword sesuai
-func (c core) IsPWAndHashPWMatch(password []byte, hashPass []byte) bool {
+func (c bcryptCore) IsPWAndHashPWMatch(password []byte, hashPass []byte) bool {
 	err := bcrypt.CompareHashAndPassword(hashPass, password)
 	return err == nil
 }
+
+// NeedsRehash return true if hash is not bcrypt or the cost is lower than configured
+func (c bcryptCore) NeedsRehash(hashPass []byte) bool {
+	cost, err := bcrypt.Cost(hashPass)
+	if err != nil {
+		return true
+	}
+	return cost < c.cost
+}
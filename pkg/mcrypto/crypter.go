@@ -3,4 +3,6 @@ package mcrypto
 type Crypter interface {
 	GenerateHash(password string) ([]byte, error)
 	IsPWAndHashPWMatch(password []byte, hashPass []byte) bool
+	// NeedsRehash return true if hash should be generated again with current algorithm and cost
+	NeedsRehash(hashPass []byte) bool
 }
@@ -0,0 +1,106 @@
+package mcrypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// small parameter to keep test fast
+var testArgon2Params = Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2id(t *testing.T) {
+	c := NewArgon2id(testArgon2Params)
+
+	hash, err := c.GenerateHash("secret123")
+	if err != nil {
+		t.Fatalf("GenerateHash() error = %v", err)
+	}
+	if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("GenerateHash() = %s, unexpected format", hash)
+	}
+	if !c.IsPWAndHashPWMatch([]byte("secret123"), hash) {
+		t.Error("IsPWAndHashPWMatch() = false, want true")
+	}
+	if c.IsPWAndHashPWMatch([]byte("secret124"), hash) {
+		t.Error("IsPWAndHashPWMatch() wrong password = true, want false")
+	}
+	if c.NeedsRehash(hash) {
+		t.Error("NeedsRehash() same params = true, want false")
+	}
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	if !NewArgon2id(stronger).NeedsRehash(hash) {
+		t.Error("NeedsRehash() outdated params = false, want true")
+	}
+	// hash still verified with parameter encoded in it
+	if !NewArgon2id(stronger).IsPWAndHashPWMatch([]byte("secret123"), hash) {
+		t.Error("IsPWAndHashPWMatch() with other params = false, want true")
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	bc := NewBcrypt(4)
+	d := NewDispatcher(NewArgon2id(testArgon2Params), bc)
+
+	oldHash, err := bc.GenerateHash("secret123")
+	if err != nil {
+		t.Fatalf("GenerateHash() error = %v", err)
+	}
+	if !d.IsPWAndHashPWMatch([]byte("secret123"), oldHash) {
+		t.Error("IsPWAndHashPWMatch() bcrypt hash = false, want true")
+	}
+	if d.IsPWAndHashPWMatch([]byte("secret124"), oldHash) {
+		t.Error("IsPWAndHashPWMatch() bcrypt wrong password = true, want false")
+	}
+	if !d.NeedsRehash(oldHash) {
+		t.Error("NeedsRehash() bcrypt hash = false, want true")
+	}
+
+	newHash, err := d.GenerateHash("secret123")
+	if err != nil {
+		t.Fatalf("GenerateHash() error = %v", err)
+	}
+	if !d.IsPWAndHashPWMatch([]byte("secret123"), newHash) {
+		t.Error("IsPWAndHashPWMatch() argon2id hash = false, want true")
+	}
+	if d.NeedsRehash(newHash) {
+		t.Error("NeedsRehash() argon2id hash = true, want false")
+	}
+	if d.IsPWAndHashPWMatch([]byte("secret123"), []byte("$argon2id$broken")) {
+		t.Error("IsPWAndHashPWMatch() broken hash = true, want false")
+	}
+}
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		userInputs []string
+		want       error
+	}{
+		{name: "valid", password: "kopi-susu-77", want: nil},
+		{name: "too short", password: "ab12", want: ErrPasswordTooShort},
+		{name: "no number", password: "onlyletters", want: ErrPasswordTooWeak},
+		{name: "no letter", password: "1234567890", want: ErrPasswordTooWeak},
+		{name: "common", password: "Password123", want: ErrPasswordTooCommon},
+		{name: "contain name", password: "muchlis2024", userInputs: []string{"Muchlis Ahmad"}, want: ErrPasswordPersonInfo},
+		{name: "contain email", password: "x9muchlisx9", userInputs: []string{"muchlis.dev@mail.com"}, want: ErrPasswordPersonInfo},
+		{name: "short name ignored", password: "kopi-susu-77", userInputs: []string{"Ko"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckPasswordPolicy(tt.password, tt.userInputs...)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("CheckPasswordPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
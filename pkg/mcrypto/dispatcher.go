@@ -0,0 +1,37 @@
+package mcrypto
+
+// New return Crypter hashing new password with argon2id
+// and still able to verify old bcrypt hash
+func New() dispatcher {
+	return NewDispatcher(NewArgon2id(DefaultArgon2Params), NewBcrypt(12))
+}
+
+// NewDispatcher return Crypter that verify hash with crypter matching the hash format.
+// new hash always generated by argon2id crypter
+func NewDispatcher(argon argon2Core, bcrypt bcryptCore) dispatcher {
+	return dispatcher{
+		argon:  argon,
+		bcrypt: bcrypt,
+	}
+}
+
+type dispatcher struct {
+	argon  argon2Core
+	bcrypt bcryptCore
+}
+
+func (d dispatcher) GenerateHash(password string) ([]byte, error) {
+	return d.argon.GenerateHash(password)
+}
+
+func (d dispatcher) IsPWAndHashPWMatch(password []byte, hashPass []byte) bool {
+	if isArgon2Hash(hashPass) {
+		return d.argon.IsPWAndHashPWMatch(password, hashPass)
+	}
+	return d.bcrypt.IsPWAndHashPWMatch(password, hashPass)
+}
+
+// NeedsRehash return true for every non argon2id hash or outdated argon2id parameter
+func (d dispatcher) NeedsRehash(hashPass []byte) bool {
+	return d.argon.NeedsRehash(hashPass)
+}
@@ -0,0 +1,95 @@
+package mcrypto
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const MinPasswordLength = 8
+
+var (
+	ErrPasswordTooShort   = errors.New("password must be at least 8 characters")
+	ErrPasswordTooWeak    = errors.New("password must contain letter and number")
+	ErrPasswordTooCommon  = errors.New("password is too common")
+	ErrPasswordPersonInfo = errors.New("password must not contain name or email")
+)
+
+// commonPasswords is small list of most used password which still pass length and character rule
+var commonPasswords = map[string]struct{}{
+	"password1":   {},
+	"password12":  {},
+	"password123": {},
+	"passw0rd":    {},
+	"p4ssw0rd":    {},
+	"qwerty123":   {},
+	"qwerty12":    {},
+	"abc12345":    {},
+	"abcd1234":    {},
+	"1q2w3e4r":    {},
+	"1qaz2wsx":    {},
+	"iloveyou1":   {},
+	"welcome1":    {},
+	"welcome123":  {},
+	"admin123":    {},
+	"letmein1":    {},
+	"trustno1":    {},
+	"sunshine1":   {},
+	"football1":   {},
+	"monkey123":   {},
+}
+
+// CheckPasswordPolicy return error if password is too weak.
+// userInputs is data of the user like name or email which must not be part of password
+func CheckPasswordPolicy(password string, userInputs ...string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+
+	var hasLetter, hasNumber bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLetter(c):
+			hasLetter = true
+		case unicode.IsDigit(c):
+			hasNumber = true
+		}
+	}
+	if !hasLetter || !hasNumber {
+		return ErrPasswordTooWeak
+	}
+
+	lower := strings.ToLower(password)
+	if _, found := commonPasswords[lower]; found {
+		return ErrPasswordTooCommon
+	}
+
+	for _, input := range userInputs {
+		for _, part := range personalParts(input) {
+			if strings.Contains(lower, part) {
+				return ErrPasswordPersonInfo
+			}
+		}
+	}
+
+	return nil
+}
+
+// personalParts split name and email to lowercase word with at least 3 characters
+// "John Doe" -> john, doe | "john.doe@mail.com" -> john, doe
+func personalParts(input string) []string {
+	input = strings.ToLower(input)
+	if at := strings.Index(input, "@"); at >= 0 {
+		input = input[:at]
+	}
+	words := strings.FieldsFunc(input, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	parts := make([]string, 0, len(words))
+	for _, w := range words {
+		if len([]rune(w)) >= 3 {
+			parts = append(parts, w)
+		}
+	}
+	return parts
+}
@@ -9,9 +9,17 @@ import (
 	"github.com/muchlist/moneymagnet/pkg/ds"
 )
 
+// max topic in one condition allowed by firebase
+const MaxConditionTopics = 5
+
 // FCMSender defines the interface for FCM client operations.
 type FCMSender interface {
 	SendMessage(ctx context.Context, payload Payload) ([]string /*invalid token*/, error)
+	// SendCondition send payload to device subscribed to topics matching payload.Condition
+	SendCondition(ctx context.Context, payload Payload) error
+	// Subscribe and Unsubscribe change topic of tokens, token rejected by firebase is returned
+	Subscribe(ctx context.Context, tokens []string, topic string) ([]string /*invalid token*/, error)
+	Unsubscribe(ctx context.Context, tokens []string, topic string) ([]string /*invalid token*/, error)
 }
 
 type fcmClient struct {
@@ -59,6 +67,63 @@ func (c *fcmClient) SendMessage(ctx context.Context, payload Payload) ([]string,
 	return invalidTokens, nil
 }
 
+// SendCondition sends a notification message to devices subscribed to topics matching the condition.
+func (c *fcmClient) SendCondition(ctx context.Context, payload Payload) error {
+	if payload.Condition == "" {
+		return fmt.Errorf("condition cannot be empty")
+	}
+
+	message := &messaging.Message{
+		Notification: &messaging.Notification{
+			Title: payload.Title,
+			Body:  payload.Message,
+		},
+		Data:      payload.Data,
+		Android:   androidConfig(payload),
+		APNS:      apnsConfig(payload),
+		Condition: payload.Condition,
+	}
+	if _, err := c.client.Send(ctx, message); err != nil {
+		return fmt.Errorf("failed to send condition message: %w", err)
+	}
+	return nil
+}
+
+// Subscribe subscribes tokens to topic.
+func (c *fcmClient) Subscribe(ctx context.Context, tokens []string, topic string) ([]string, error) {
+	return c.manageTopic(ctx, tokens, topic, c.client.SubscribeToTopic)
+}
+
+// Unsubscribe unsubscribes tokens from topic.
+func (c *fcmClient) Unsubscribe(ctx context.Context, tokens []string, topic string) ([]string, error) {
+	return c.manageTopic(ctx, tokens, topic, c.client.UnsubscribeFromTopic)
+}
+
+type topicFunc func(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+
+func (c *fcmClient) manageTopic(ctx context.Context, tokens []string, topic string, fn topicFunc) ([]string, error) {
+	validTokens, err := validateTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var invalidTokens []string
+	// firebase accept at most 1000 token for topic management
+	for _, chunk := range chunkTokens(validTokens, 1000) {
+		resp, err := fn(ctx, chunk, topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to manage topic %s: %w", topic, err)
+		}
+		for _, info := range resp.Errors {
+			if info.Index >= 0 && info.Index < len(chunk) {
+				invalidTokens = append(invalidTokens, chunk[info.Index])
+			}
+		}
+	}
+
+	return invalidTokens, nil
+}
+
 // validateTokens filters out invalid or empty tokens from the list.
 func validateTokens(tokens []string) ([]string, error) {
 	if len(tokens) == 0 {
@@ -107,6 +172,51 @@ func createMulticastMessage(payload Payload, tokens []string) *messaging.Multica
 			Title: payload.Title,
 			Body:  payload.Message,
 		},
-		Tokens: uniqueToken.RevealNotEmpty(),
+		Data:    payload.Data,
+		Android: androidConfig(payload),
+		APNS:    apnsConfig(payload),
+		Tokens:  uniqueToken.RevealNotEmpty(),
+	}
+}
+
+// androidConfig return nil when payload has no android option
+func androidConfig(payload Payload) *messaging.AndroidConfig {
+	if payload.Priority == "" && payload.CollapseKey == "" {
+		return nil
+	}
+	return &messaging.AndroidConfig{
+		Priority:    payload.Priority,
+		CollapseKey: payload.CollapseKey,
+	}
+}
+
+// apnsConfig map payload option to apns header and aps dictionary, nil when payload has none
+func apnsConfig(payload Payload) *messaging.APNSConfig {
+	headers := make(map[string]string)
+	switch payload.Priority {
+	case PriorityHigh:
+		headers["apns-priority"] = "10"
+	case PriorityNormal:
+		headers["apns-priority"] = "5"
+	}
+	if payload.CollapseKey != "" {
+		headers["apns-collapse-id"] = payload.CollapseKey
+	}
+
+	if len(headers) == 0 && payload.Badge == nil && payload.ThreadID == "" {
+		return nil
+	}
+
+	config := &messaging.APNSConfig{
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				Badge:    payload.Badge,
+				ThreadID: payload.ThreadID,
+			},
+		},
+	}
+	if len(headers) != 0 {
+		config.Headers = headers
 	}
+	return config
 }
@@ -1,7 +1,29 @@
 package mfirebase
 
+// message priority, high wake sleeping device to show the notification immediately
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+)
+
 type Payload struct {
 	Title          string
 	Message        string
 	ReceiverTokens []string
+
+	// Condition send to device subscribed to topics instead of ReceiverTokens,
+	// ex: "'pocket.A' in topics && !('user.B' in topics)"
+	Condition string
+
+	// Data is delivered to the app with the notification, used for deep linking
+	Data map[string]string
+
+	// Priority is PriorityHigh or PriorityNormal, empty use firebase default
+	Priority string
+	// CollapseKey replace previous message with the same key that is not yet shown
+	CollapseKey string
+	// ThreadID group notification on ios
+	ThreadID string
+	// Badge set number on app icon on ios, nil keep current badge
+	Badge *int
 }
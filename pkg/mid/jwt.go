@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/muchlist/moneymagnet/pkg/i18n"
 	"github.com/muchlist/moneymagnet/pkg/mjwt"
 
 	"github.com/muchlist/moneymagnet/pkg/slicer"
@@ -20,7 +21,10 @@ const (
 
 // index
 // 1. claims jwt validator
-// 2. context jwt
+// 2. session checker
+// 3. personal access token
+// 4. user language
+// 5. context jwt
 
 // ==========================================================================
 // claims jwt validator
@@ -34,20 +38,26 @@ func RequiredRoles(rolesReq ...string) func(next http.Handler) http.Handler {
 			authStr := r.Header.Get(headerKey)
 
 			// validate Authentication
-			claims, err := validateAuthentication(authStr, false)
+			claims, err := validateAuthentication(r, authStr, false)
 			if err != nil {
-				web.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+				web.ErrorResponse(w, http.StatusUnauthorized, web.ErrorMessage(err))
+				return
+			}
+
+			// validate scope of personal access token
+			if err := validateScope(r, claims); err != nil {
+				web.ErrorResponse(w, http.StatusForbidden, web.ErrorMessage(err))
 				return
 			}
 
 			// validate roles
 			if err := validateAuthorizationRole(claims.Roles, rolesReq); err != nil {
-				web.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+				web.ErrorResponse(w, http.StatusUnauthorized, web.ErrorMessage(err))
 				return
 			}
 
 			ctx := setClaims(r.Context(), claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, withUserLanguage(w, r.WithContext(ctx), claims))
 		}
 		return http.HandlerFunc(fn)
 	}
@@ -61,26 +71,26 @@ func RequiredFreshRoles(rolesReq ...string) func(next http.Handler) http.Handler
 			authStr := r.Header.Get(headerKey)
 
 			// validate Authentication
-			claims, err := validateAuthentication(authStr, true)
+			claims, err := validateAuthentication(r, authStr, true)
 			if err != nil {
-				web.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+				web.ErrorResponse(w, http.StatusUnauthorized, web.ErrorMessage(err))
 				return
 			}
 
 			// validate roles
 			if err := validateAuthorizationRole(claims.Roles, rolesReq); err != nil {
-				web.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+				web.ErrorResponse(w, http.StatusUnauthorized, web.ErrorMessage(err))
 				return
 			}
 
 			ctx := setClaims(r.Context(), claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, withUserLanguage(w, r.WithContext(ctx), claims))
 		}
 		return http.HandlerFunc(fn)
 	}
 }
 
-func validateAuthentication(authHeader string, mustFresh bool) (mjwt.CustomClaim, error) {
+func validateAuthentication(r *http.Request, authHeader string, mustFresh bool) (mjwt.CustomClaim, error) {
 	if !strings.Contains(authHeader, bearerKey) {
 		err := errors.New("expected authorization header format: bearer <token>")
 		return mjwt.CustomClaim{}, err
@@ -90,6 +100,13 @@ func validateAuthentication(authHeader string, mustFresh bool) (mjwt.CustomClaim
 		err := errors.New("expected authorization header format: bearer <token>")
 		return mjwt.CustomClaim{}, err
 	}
+	if strings.HasPrefix(tokenString[1], mjwt.PersonalTokenPrefix) {
+		// personal access token never fresh
+		if mustFresh {
+			return mjwt.CustomClaim{}, errors.New("expected fresh token")
+		}
+		return validatePersonalToken(r, tokenString[1])
+	}
 	token, err := mjwt.Glob.ValidateToken(tokenString[1])
 	if err != nil {
 		return mjwt.CustomClaim{}, err
@@ -98,12 +115,20 @@ func validateAuthentication(authHeader string, mustFresh bool) (mjwt.CustomClaim
 	if err != nil {
 		return mjwt.CustomClaim{}, err
 	}
+	// refresh token carry roles and session too, but only access token is accepted as bearer
+	// so rotated refresh token cannot be used anymore
+	if claims.Type != mjwt.Access {
+		return mjwt.CustomClaim{}, mjwt.ErrInvalidToken
+	}
 	if mustFresh {
 		if !claims.Fresh {
 			err := errors.New("expected fresh token")
 			return mjwt.CustomClaim{}, err
 		}
 	}
+	if err := validateSession(r.Context(), claims); err != nil {
+		return mjwt.CustomClaim{}, err
+	}
 	return claims, nil
 }
 
@@ -121,6 +146,118 @@ func validateAuthorizationRole(rolesHave []string, rolesAllowed []string) error
 	return nil
 }
 
+// ==========================================================================
+// session checker
+// ==========================================================================
+
+// SessionChecker check whether session of token is still active (not revoked or expired)
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// sessionChecker is nil until SetSessionChecker called, session is not checked in that case
+var sessionChecker SessionChecker
+
+// SetSessionChecker register checker used by RequiredRoles and RequiredFreshRoles
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
+func validateSession(ctx context.Context, claims mjwt.CustomClaim) error {
+	if sessionChecker == nil {
+		return nil
+	}
+	if claims.SessionID == "" {
+		return errors.New("session not found, please login again")
+	}
+	active, err := sessionChecker.IsSessionActive(ctx, claims.SessionID)
+	if err != nil {
+		return fmt.Errorf("check session: %w", err)
+	}
+	if !active {
+		return errors.New("session has been revoked, please login again")
+	}
+	return nil
+}
+
+// ==========================================================================
+// personal access token
+// ==========================================================================
+
+// TokenAuthenticator resolve personal access token to claims, ip used for last-used tracking
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string, ip string) (mjwt.CustomClaim, error)
+}
+
+// tokenAuthenticator is nil until SetTokenAuthenticator called, personal access token is rejected in that case
+var tokenAuthenticator TokenAuthenticator
+
+// SetTokenAuthenticator register authenticator used by RequiredRoles
+func SetTokenAuthenticator(authenticator TokenAuthenticator) {
+	tokenAuthenticator = authenticator
+}
+
+func validatePersonalToken(r *http.Request, token string) (mjwt.CustomClaim, error) {
+	if tokenAuthenticator == nil {
+		return mjwt.CustomClaim{}, mjwt.ErrInvalidToken
+	}
+	return tokenAuthenticator.AuthenticateToken(r.Context(), token, web.ReadClientIP(r))
+}
+
+// validateScope only limit personal access token.
+// read method need read scope or any write scope, other method need "<resource>:write"
+// where resource is first segment of url path
+func validateScope(r *http.Request, claims mjwt.CustomClaim) error {
+	if claims.Type != mjwt.Personal {
+		return nil
+	}
+
+	resource := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+	writeScope := resource + ":write"
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if claims.HasScope(mjwt.ScopeRead) || claims.HasScope(writeScope) {
+			return nil
+		}
+		return fmt.Errorf("expected token scope : %s", mjwt.ScopeRead)
+	default:
+		if claims.HasScope(writeScope) {
+			return nil
+		}
+		return fmt.Errorf("expected token scope : %s", writeScope)
+	}
+}
+
+// ==========================================================================
+// user language
+// ==========================================================================
+
+// LocaleReader return locale preference of user
+type LocaleReader interface {
+	GetLocale(ctx context.Context, userID string) (string, error)
+}
+
+// localeReader is nil until SetLocaleReader called, response language only follow Accept-Language in that case
+var localeReader LocaleReader
+
+// SetLocaleReader register reader used by RequiredRoles and RequiredFreshRoles
+func SetLocaleReader(reader LocaleReader) {
+	localeReader = reader
+}
+
+// withUserLanguage set response language to locale of user when request does not ask language by Accept-Language
+func withUserLanguage(w http.ResponseWriter, r *http.Request, claims mjwt.CustomClaim) *http.Request {
+	if localeReader == nil || i18n.Match(r.Header.Get("Accept-Language")) != "" {
+		return r
+	}
+	locale, err := localeReader.GetLocale(r.Context(), claims.Identity)
+	if err != nil || !i18n.Supported(locale) {
+		return r
+	}
+	return web.SetLanguage(w, r, locale)
+}
+
 // ==========================================================================
 // context jwt
 // ==========================================================================
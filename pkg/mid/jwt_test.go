@@ -0,0 +1,160 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/i18n"
+	"github.com/muchlist/moneymagnet/pkg/mjwt"
+	"github.com/muchlist/moneymagnet/pkg/web"
+)
+
+func TestValidateScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		claims  mjwt.CustomClaim
+		wantErr bool
+	}{
+		{
+			name:   "jwt not limited",
+			method: "POST",
+			path:   "/pockets",
+			claims: mjwt.CustomClaim{Type: mjwt.Access},
+		},
+		{
+			name:   "read scope can read",
+			method: "GET",
+			path:   "/spends/from-pocket/01ARZ3NDEKTSV4RRFFQ69G5FAV",
+			claims: mjwt.CustomClaim{Type: mjwt.Personal, Scopes: []string{mjwt.ScopeRead}},
+		},
+		{
+			name:    "read scope can not write",
+			method:  "POST",
+			path:    "/spends",
+			claims:  mjwt.CustomClaim{Type: mjwt.Personal, Scopes: []string{mjwt.ScopeRead}},
+			wantErr: true,
+		},
+		{
+			name:   "spends write can write spends",
+			method: "PATCH",
+			path:   "/spends/01ARZ3NDEKTSV4RRFFQ69G5FAV",
+			claims: mjwt.CustomClaim{Type: mjwt.Personal, Scopes: []string{mjwt.ScopeSpendsWrite}},
+		},
+		{
+			name:   "spends write can read spends",
+			method: "GET",
+			path:   "/spends",
+			claims: mjwt.CustomClaim{Type: mjwt.Personal, Scopes: []string{mjwt.ScopeSpendsWrite}},
+		},
+		{
+			name:    "spends write can not read pockets",
+			method:  "GET",
+			path:    "/pockets",
+			claims:  mjwt.CustomClaim{Type: mjwt.Personal, Scopes: []string{mjwt.ScopeSpendsWrite}},
+			wantErr: true,
+		},
+		{
+			name:    "spends write can not write user",
+			method:  "DELETE",
+			path:    "/user/sessions",
+			claims:  mjwt.CustomClaim{Type: mjwt.Personal, Scopes: []string{mjwt.ScopeSpendsWrite}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if err := validateScope(r, tt.claims); (err != nil) != tt.wantErr {
+				t.Errorf("validateScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type fakeLocaleReader struct {
+	locale string
+}
+
+func (f fakeLocaleReader) GetLocale(_ context.Context, _ string) (string, error) {
+	return f.locale, nil
+}
+
+func TestWithUserLanguage(t *testing.T) {
+	SetLocaleReader(fakeLocaleReader{locale: "id"})
+	defer SetLocaleReader(nil)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "locale of user without accept language", want: "id"},
+		{name: "accept language win over locale", acceptLanguage: "en-US", want: "en"},
+		{name: "unsupported accept language use locale", acceptLanguage: "fr", want: "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/user/profile", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			// language middleware of web server already set accept language
+			if lang := i18n.Match(tt.acceptLanguage); lang != "" {
+				r = web.SetLanguage(w, r, lang)
+			}
+
+			r = withUserLanguage(w, r, mjwt.CustomClaim{Identity: "01ARZ3NDEKTSV4RRFFQ69G5FAV"})
+			if got := web.ReadLanguage(r.Context()); got != tt.want {
+				t.Errorf("language = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.want {
+				t.Errorf("Content-Language = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredRolesTokenType(t *testing.T) {
+	jwt := mjwt.New("secret")
+	handler := RequiredRoles()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name      string
+		tokenType mjwt.TokenType
+		want      int
+	}{
+		{name: "access token", tokenType: mjwt.Access, want: http.StatusOK},
+		{name: "refresh token", tokenType: mjwt.Refresh, want: http.StatusUnauthorized},
+		{name: "challenge token", tokenType: mjwt.Challenge, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.GenerateToken(mjwt.CustomClaim{
+				Identity:  "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+				Roles:     []string{"normal"},
+				Exp:       time.Now().Add(time.Hour).Unix(),
+				Type:      tt.tokenType,
+				SessionID: "session-1",
+			})
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/user/profile", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d, body %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
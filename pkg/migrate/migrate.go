@@ -0,0 +1,253 @@
+// Package migrate apply sql migration written in golang-migrate format
+// (000001_name.up.sql, 000001_name.down.sql) from fs.FS.
+// version is stored in schema_migrations table same as golang-migrate,
+// so both tools can be used on the same database.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const versionTable = "schema_migrations"
+
+var (
+	ErrDirty     = errors.New("database is dirty, fix it manually then force the version")
+	ErrNoChange  = errors.New("no migration to apply")
+	fileNameRule = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+)
+
+// Migration is pair of up and down file with same version
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string // file name
+	Down    string // file name
+}
+
+// Result is applied migration
+type Result struct {
+	Version   uint   `json:"version"`
+	Name      string `json:"name"`
+	Direction string `json:"direction"`
+}
+
+type Migrator struct {
+	db         *pgxpool.Pool
+	fsys       fs.FS
+	migrations []Migration
+}
+
+// New read migration file from fsys
+func New(db *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := ReadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		fsys:       fsys,
+		migrations: migrations,
+	}, nil
+}
+
+// ReadMigrations list migration in root of fsys sorted by version.
+// file that does not follow the name format is ignored
+func ReadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migration dir: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNameRule.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse version of %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("version %d is used by %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = entry.Name()
+		} else {
+			m.Down = entry.Name()
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Version return current version, 0 mean no migration applied
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return 0, false, err
+	}
+
+	var version int64
+	var dirty bool
+	err := m.db.QueryRow(ctx, "SELECT version, dirty FROM "+versionTable+" LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("read version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// Up apply all pending migration, each migration run in its own transaction
+func (m *Migrator) Up(ctx context.Context) ([]Result, error) {
+	current, err := m.cleanVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0)
+	for _, migration := range pending(m.migrations, current) {
+		if err := m.apply(ctx, migration.Up, int64(migration.Version)); err != nil {
+			return results, fmt.Errorf("apply %s: %w", migration.Up, err)
+		}
+		results = append(results, Result{Version: migration.Version, Name: migration.Name, Direction: "up"})
+	}
+	if len(results) == 0 {
+		return results, ErrNoChange
+	}
+	return results, nil
+}
+
+// Down rollback n latest applied migration
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Result, error) {
+	current, err := m.cleanVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0)
+	for _, migration := range applied(m.migrations, current, steps) {
+		if migration.Down == "" {
+			return results, fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+		previous := previousVersion(m.migrations, migration.Version)
+		if err := m.apply(ctx, migration.Down, previous); err != nil {
+			return results, fmt.Errorf("apply %s: %w", migration.Down, err)
+		}
+		results = append(results, Result{Version: migration.Version, Name: migration.Name, Direction: "down"})
+	}
+	if len(results) == 0 {
+		return results, ErrNoChange
+	}
+	return results, nil
+}
+
+func (m *Migrator) cleanVersion(ctx context.Context) (uint, error) {
+	current, dirty, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("version %d: %w", current, ErrDirty)
+	}
+	return current, nil
+}
+
+// apply run file and set version in one transaction, version < 0 mean no version
+func (m *Migrator) apply(ctx context.Context, file string, version int64) error {
+	query, err := fs.ReadFile(m.fsys, file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// no argument, so it is sent with simple protocol and can hold many statement
+	if _, err := tx.Exec(ctx, string(query)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM "+versionTable); err != nil {
+		return err
+	}
+	if version >= 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO "+versionTable+" (version, dirty) VALUES ($1, false)", version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+versionTable+" (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("create version table: %w", err)
+	}
+	return nil
+}
+
+// pending return migration newer than current version
+func pending(migrations []Migration, current uint) []Migration {
+	result := make([]Migration, 0)
+	for _, m := range migrations {
+		if m.Version > current {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// applied return up to steps migration not newer than current version, newest first
+func applied(migrations []Migration, current uint, steps int) []Migration {
+	result := make([]Migration, 0, steps)
+	for i := len(migrations) - 1; i >= 0 && len(result) < steps; i-- {
+		if migrations[i].Version <= current {
+			result = append(result, migrations[i])
+		}
+	}
+	return result
+}
+
+// previousVersion return version before v, -1 if v is the first one
+func previousVersion(migrations []Migration, v uint) int64 {
+	previous := int64(-1)
+	for _, m := range migrations {
+		if m.Version >= v {
+			break
+		}
+		previous = int64(m.Version)
+	}
+	return previous
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/muchlist/moneymagnet/migrations"
+)
+
+func TestReadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_create_pocket.up.sql":   {Data: []byte("CREATE TABLE b();")},
+		"000002_create_pocket.down.sql": {Data: []byte("DROP TABLE b;")},
+		"000001_create_user.up.sql":     {Data: []byte("CREATE TABLE a();")},
+		"000001_create_user.down.sql":   {Data: []byte("DROP TABLE a;")},
+		"000010_seed.up.sql":            {Data: []byte("SELECT 1;")},
+		"README.md":                     {Data: []byte("readme")},
+	}
+
+	got, err := ReadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("ReadMigrations() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("ReadMigrations() got %d migration, want 3", len(got))
+	}
+	wantVersions := []uint{1, 2, 10}
+	for i, v := range wantVersions {
+		if got[i].Version != v {
+			t.Errorf("migration %d version = %d, want %d", i, got[i].Version, v)
+		}
+	}
+	if got[2].Down != "" {
+		t.Errorf("migration 10 down = %q, want empty", got[2].Down)
+	}
+}
+
+func TestReadMigrationsMissingUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_create_user.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+	if _, err := ReadMigrations(fsys); err == nil {
+		t.Error("ReadMigrations() should return error when up file is missing")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	got, err := ReadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("ReadMigrations() error = %v", err)
+	}
+	for i, m := range got {
+		if m.Version != uint(i+1) {
+			t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func TestPendingAndApplied(t *testing.T) {
+	list := []Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 5}}
+
+	if got := pending(list, 2); len(got) != 2 || got[0].Version != 3 || got[1].Version != 5 {
+		t.Errorf("pending() = %v, want version 3 and 5", got)
+	}
+	if got := pending(list, 5); len(got) != 0 {
+		t.Errorf("pending() = %v, want empty", got)
+	}
+
+	if got := applied(list, 3, 2); len(got) != 2 || got[0].Version != 3 || got[1].Version != 2 {
+		t.Errorf("applied() = %v, want version 3 and 2", got)
+	}
+	if got := applied(list, 0, 1); len(got) != 0 {
+		t.Errorf("applied() = %v, want empty", got)
+	}
+
+	if got := previousVersion(list, 5); got != 3 {
+		t.Errorf("previousVersion(5) = %d, want 3", got)
+	}
+	if got := previousVersion(list, 1); got != -1 {
+		t.Errorf("previousVersion(1) = %d, want -1", got)
+	}
+}
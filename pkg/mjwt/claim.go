@@ -9,15 +9,35 @@ type TokenType string
 const (
 	Access  TokenType = "Access"
 	Refresh TokenType = "Refresh"
+	// Challenge only can be exchanged with second factor code to get Access and Refresh token
+	Challenge TokenType = "Challenge"
+	// Personal is long lived token created by user for script and integration, not a jwt
+	Personal TokenType = "Personal"
+)
+
+// PersonalTokenPrefix used to differentiate personal access token from jwt
+const PersonalTokenPrefix = "mmpat_"
+
+// scope of personal access token, "<resource>:write" allow write on /<resource>
+const (
+	ScopeRead        = "read"
+	ScopeSpendsWrite = "spends:write"
 )
 
 type CustomClaim struct {
-	Identity string
-	Name     string
-	Exp      int64
-	Type     TokenType
-	Fresh    bool
-	Roles    []string
+	Identity  string
+	Name      string
+	Exp       int64
+	Type      TokenType
+	Fresh     bool
+	Roles     []string
+	IssuedAt  int64  // filled by GenerateToken when empty
+	SessionID string // server side session, used for revocation
+	TokenID   string // unique id of refresh token, used for rotation
+
+	// only filled for personal access token, not written in jwt
+	Scopes    []string
+	PocketIDs []string // empty mean all pocket
 }
 
 func (c CustomClaim) GetULID() xulid.ULID {
@@ -25,3 +45,29 @@ func (c CustomClaim) GetULID() xulid.ULID {
 	// maybe secret key got hacked. it's okay server panic
 	return xulid.MustParse(c.Identity)
 }
+
+// HasScope return true if token allowed to do scope. jwt from login is not limited
+func (c CustomClaim) HasScope(scope string) bool {
+	if c.Type != Personal {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessPocket return true if token not limited to some pockets or pocketID is one of them
+func (c CustomClaim) CanAccessPocket(pocketID string) bool {
+	if len(c.PocketIDs) == 0 {
+		return true
+	}
+	for _, id := range c.PocketIDs {
+		if id == pocketID {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/slicer"
 )
 
@@ -18,33 +20,53 @@ const (
 	tokenTypeKey   = "type"
 	expKey         = "exp"
 	freshKey       = "fresh"
+	issuedAtKey    = "iat"
+	sessionIDKey   = "sid"
+	tokenIDKey     = "jti"
 )
 
 var (
 	// Glob is Global variable JWT
 	Glob             = &core{}
 	ErrCastingClaims = errors.New("fail to type casting")
-	ErrInvalidToken  = errors.New("token not valid")
+	ErrInvalidToken  = errr.T("error.invalid_token", nil)
 )
 
+// New create token handler signing with HS256 secret
 func New(secretKey string) *core {
 	if secretKey == "" {
 		log.Fatal("secret key cannot be empty")
 	}
+	key, _ := NewHMACKey("", secretKey)
+	keyring, _ := NewKeyring(key)
+	return NewWithKeyring(keyring)
+}
+
+// NewWithKeyring create token handler signing with current key of keyring
+// and verifying with any key in keyring
+func NewWithKeyring(keyring *Keyring) *core {
 	newCore := &core{
-		secretKey: []byte(secretKey),
+		keyring: keyring,
 	}
 	Glob = newCore
 	return newCore
 }
 
 type core struct {
-	secretKey []byte
+	keyring *Keyring
+}
+
+// JWKS return public keys for other service to verify token
+func (j *core) JWKS() []JWK {
+	return j.keyring.JWKS()
 }
 
 // GenerateToken membuat token jwt untuk login header, untuk menguji nilai payloadnya
 // dapat menggunakan situs jwt.io
 func (j *core) GenerateToken(claims CustomClaim) (string, error) {
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = time.Now().Unix()
+	}
 
 	jwtClaim := jwt.MapClaims{
 		identityKey:  claims.Identity,
@@ -53,11 +75,20 @@ func (j *core) GenerateToken(claims CustomClaim) (string, error) {
 		expKey:       claims.Exp,
 		tokenTypeKey: claims.Type,
 		freshKey:     claims.Fresh,
+		issuedAtKey:  claims.IssuedAt,
+		sessionIDKey: claims.SessionID,
+	}
+	if claims.TokenID != "" {
+		jwtClaim[tokenIDKey] = claims.TokenID
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaim)
+	current := j.keyring.current
+	token := jwt.NewWithClaims(current.method, jwtClaim)
+	if current.ID != "" {
+		token.Header["kid"] = current.ID
+	}
 
-	signedToken, err := token.SignedString(j.secretKey)
+	signedToken, err := token.SignedString(current.signKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to signed token: %w", err)
 	}
@@ -93,18 +124,25 @@ func (j *core) ReadToken(token *jwt.Token) (CustomClaim, error) {
 	if !ok {
 		return CustomClaim{}, ErrCastingClaims
 	}
+	// token generated before iat introduced does not have iat
+	issuedAt, _ := claims[issuedAtKey].(float64)
+	sessionID, _ := claims[sessionIDKey].(string)
+	tokenID, _ := claims[tokenIDKey].(string)
 	roles, err := slicer.ToStringSlice(claims[rolesKey])
 	if err != nil {
 		return CustomClaim{}, fmt.Errorf("%v: %w", err.Error(), ErrCastingClaims)
 	}
 
 	customClaim := CustomClaim{
-		Identity: identity,
-		Name:     name,
-		Exp:      int64(exp),
-		Roles:    roles,
-		Type:     TokenType(tokenType),
-		Fresh:    fresh,
+		Identity:  identity,
+		Name:      name,
+		Exp:       int64(exp),
+		Roles:     roles,
+		Type:      TokenType(tokenType),
+		Fresh:     fresh,
+		IssuedAt:  int64(issuedAt),
+		SessionID: sessionID,
+		TokenID:   tokenID,
 	}
 
 	return customClaim, nil
@@ -113,11 +151,16 @@ func (j *core) ReadToken(token *jwt.Token) (CustomClaim, error) {
 // ValidateToken memvalidasi apakah token string masukan valid, termasuk memvalidasi apabila field exp nya kadaluarsa
 func (j *core) ValidateToken(tokenString string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := j.keyring.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		// algorithm must follow the key, not the token header
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, ErrInvalidToken
 		}
-		return j.secretKey, nil
+		return key.verifyKey, nil
 	})
 
 	// Jika expired akan muncul disini asalkan ada claims exp
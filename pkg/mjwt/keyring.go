@@ -0,0 +1,225 @@
+package mjwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// supported algorithm
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var ErrUnknownKey = errors.New("signing key not found")
+
+// Key is one signing key identified by kid header.
+// key loaded from public key file only used to verify token
+type Key struct {
+	ID        string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// CanSign return false for verify only key
+func (k Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// NewHMACKey create HS256 key from secret
+func NewHMACKey(kid string, secret string) (Key, error) {
+	if secret == "" {
+		return Key{}, errors.New("secret key cannot be empty")
+	}
+	return Key{
+		ID:        kid,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}, nil
+}
+
+// LoadKeyFile create key from file. for HS256 the file contains the secret,
+// for RS256 and EdDSA the file is PEM encoded private key or public key (verify only)
+func LoadKeyFile(kid string, alg string, path string) (Key, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("read key file %s: %w", path, err)
+	}
+	return ParseKey(kid, alg, raw)
+}
+
+// ParseKey create key from content of key file, see LoadKeyFile
+func ParseKey(kid string, alg string, raw []byte) (Key, error) {
+	switch alg {
+	case AlgHS256:
+		return NewHMACKey(kid, strings.TrimSpace(string(raw)))
+	case AlgRS256:
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(raw); err == nil {
+			return Key{ID: kid, method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(raw)
+		if err != nil {
+			return Key{}, fmt.Errorf("parse rsa key %s: %w", kid, err)
+		}
+		return Key{ID: kid, method: jwt.SigningMethodRS256, verifyKey: public}, nil
+	case AlgEdDSA:
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(raw); err == nil {
+			edPrivate := private.(ed25519.PrivateKey)
+			return Key{ID: kid, method: jwt.SigningMethodEdDSA, signKey: edPrivate, verifyKey: edPrivate.Public()}, nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(raw)
+		if err != nil {
+			return Key{}, fmt.Errorf("parse ed25519 key %s: %w", kid, err)
+		}
+		return Key{ID: kid, method: jwt.SigningMethodEdDSA, verifyKey: public}, nil
+	default:
+		return Key{}, fmt.Errorf("algorithm %q not supported", alg)
+	}
+}
+
+// Keyring hold current key used for signing and previous keys still accepted
+// for verification during rotation
+type Keyring struct {
+	current Key
+	keys    map[string]Key
+	order   []string
+}
+
+// NewKeyring current must be able to sign, previous keys only used to verify
+func NewKeyring(current Key, previous ...Key) (*Keyring, error) {
+	if !current.CanSign() {
+		return nil, fmt.Errorf("current key %q must be private key", current.ID)
+	}
+	k := &Keyring{
+		current: current,
+		keys:    make(map[string]Key, len(previous)+1),
+	}
+	for _, key := range append([]Key{current}, previous...) {
+		if _, exist := k.keys[key.ID]; exist {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		k.keys[key.ID] = key
+		k.order = append(k.order, key.ID)
+	}
+	return k, nil
+}
+
+// lookup return key by kid, token without kid is looked up with empty kid
+func (k *Keyring) lookup(kid string) (Key, error) {
+	key, ok := k.keys[kid]
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// JWK is public key in json web key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS return public keys of keyring, symmetric key is never published
+func (k *Keyring) JWKS() []JWK {
+	result := make([]JWK, 0, len(k.order))
+	for _, kid := range k.order {
+		key := k.keys[kid]
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			result = append(result, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: AlgRS256,
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			result = append(result, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: AlgEdDSA,
+				Kid: kid,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return result
+}
+
+// Option used to build keyring from config
+type Option struct {
+	Secret         string // HS256 secret, when algorithm is not HS256 only used if AcceptLegacy is set
+	Algorithm      string // HS256, RS256 or EdDSA
+	KeyID          string // kid of current key
+	PrivateKeyFile string // required for RS256 and EdDSA
+	PreviousKeys   string // comma separated kid:alg:file, verify only
+	AcceptLegacy   bool   // verify token without kid with Secret, only while token issued before rotation not expired
+}
+
+// NewKeyringFromOption build keyring, previous keys format is kid:alg:file
+func NewKeyringFromOption(opt Option) (*Keyring, error) {
+	if opt.Algorithm == "" {
+		opt.Algorithm = AlgHS256
+	}
+
+	var current Key
+	var err error
+	if opt.Algorithm == AlgHS256 {
+		current, err = NewHMACKey(opt.KeyID, opt.Secret)
+	} else {
+		if opt.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("private key file is required for %s", opt.Algorithm)
+		}
+		current, err = LoadKeyFile(opt.KeyID, opt.Algorithm, opt.PrivateKeyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make([]Key, 0)
+	for _, spec := range strings.Split(opt.PreviousKeys, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		parts := strings.SplitN(spec, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("previous key %q must be kid:alg:file", spec)
+		}
+		key, err := LoadKeyFile(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+
+	// token issued before kid introduced was signed with secret
+	if opt.AcceptLegacy && opt.Algorithm != AlgHS256 && opt.KeyID != "" && opt.Secret != "" {
+		legacy, err := NewHMACKey("", opt.Secret)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, legacy)
+	}
+
+	return NewKeyring(current, previous...)
+}
@@ -0,0 +1,194 @@
+package mjwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func rsaPEM(t *testing.T) []byte {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+}
+
+func edPEM(t *testing.T) (private []byte, public []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+}
+
+func testClaim() CustomClaim {
+	return CustomClaim{
+		Identity: "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+		Name:     "john",
+		Exp:      time.Now().Add(time.Minute).Unix(),
+		Type:     Access,
+		Roles:    []string{},
+	}
+}
+
+func mustHandler(t *testing.T, current Key, previous ...Key) *core {
+	t.Helper()
+	keyring, err := NewKeyring(current, previous...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWithKeyring(keyring)
+}
+
+func TestKeyringSignAndVerify(t *testing.T) {
+	edPrivate, _ := edPEM(t)
+	tests := []struct {
+		name string
+		alg  string
+		raw  []byte
+	}{
+		{name: "hs256", alg: AlgHS256, raw: []byte("secret")},
+		{name: "rs256", alg: AlgRS256, raw: rsaPEM(t)},
+		{name: "eddsa", alg: AlgEdDSA, raw: edPrivate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey("key-1", tt.alg, tt.raw)
+			if err != nil {
+				t.Fatalf("ParseKey() error = %v", err)
+			}
+			handler := mustHandler(t, key)
+
+			signed, err := handler.GenerateToken(testClaim())
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			token, err := handler.ValidateToken(signed)
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			if token.Header["kid"] != "key-1" || token.Method.Alg() != tt.alg {
+				t.Errorf("header = %v, want kid key-1 alg %s", token.Header, tt.alg)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	edPrivate, edPublic := edPEM(t)
+	oldKey, _ := ParseKey("old", AlgEdDSA, edPrivate)
+	oldSigned, err := mustHandler(t, oldKey).GenerateToken(testClaim())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey, _ := ParseKey("new", AlgRS256, rsaPEM(t))
+
+	// old key kept as public key only, token signed by it is still valid
+	oldPublic, err := ParseKey("old", AlgEdDSA, edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mustHandler(t, newKey, oldPublic).ValidateToken(oldSigned); err != nil {
+		t.Errorf("ValidateToken() with previous key error = %v", err)
+	}
+
+	// old key removed from keyring
+	if _, err := mustHandler(t, newKey).ValidateToken(oldSigned); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken() without previous key error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	// public key can not be current key
+	if _, err := NewKeyring(oldPublic); err == nil {
+		t.Errorf("NewKeyring() with public key must error")
+	}
+}
+
+func TestKeyringRejectAlgorithmMismatch(t *testing.T) {
+	hsKey, _ := NewHMACKey("", "secret")
+	hsSigned, err := mustHandler(t, hsKey).GenerateToken(testClaim())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same kid registered with other algorithm
+	rsKey, _ := ParseKey("", AlgRS256, rsaPEM(t))
+	if _, err := mustHandler(t, rsKey).ValidateToken(hsSigned); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	edPrivate, _ := edPEM(t)
+	rsKey, _ := ParseKey("rs", AlgRS256, rsaPEM(t))
+	edKey, _ := ParseKey("ed", AlgEdDSA, edPrivate)
+	hsKey, _ := NewHMACKey("", "secret")
+
+	keys := mustHandler(t, rsKey, edKey, hsKey).JWKS()
+	if len(keys) != 2 {
+		t.Fatalf("JWKS() len = %d, want 2, secret must not be published", len(keys))
+	}
+	if keys[0].Kid != "rs" || keys[0].Kty != "RSA" || keys[0].N == "" || keys[0].E != "AQAB" {
+		t.Errorf("JWKS()[0] = %+v", keys[0])
+	}
+	if keys[1].Kid != "ed" || keys[1].Kty != "OKP" || keys[1].Crv != "Ed25519" || keys[1].X == "" {
+		t.Errorf("JWKS()[1] = %+v", keys[1])
+	}
+}
+
+func TestKeyringFromOptionLegacySecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rs.pem")
+	if err := os.WriteFile(file, rsaPEM(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hsKey, _ := NewHMACKey("", "secret")
+	legacySigned, err := mustHandler(t, hsKey).GenerateToken(testClaim())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		acceptLegacy bool
+		wantErr      error
+	}{
+		{name: "legacy not accepted by default", acceptLegacy: false, wantErr: ErrInvalidToken},
+		{name: "legacy accepted", acceptLegacy: true, wantErr: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyringFromOption(Option{
+				Secret:         "secret",
+				Algorithm:      AlgRS256,
+				KeyID:          "rs",
+				PrivateKeyFile: file,
+				AcceptLegacy:   tt.acceptLegacy,
+			})
+			if err != nil {
+				t.Fatalf("NewKeyringFromOption() error = %v", err)
+			}
+			if _, err := NewWithKeyring(keyring).ValidateToken(legacySigned); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
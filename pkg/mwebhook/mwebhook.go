@@ -0,0 +1,204 @@
+// Package mwebhook sign and send webhook request.
+//
+// receiver verify request by computing HMAC-SHA256 of "<timestamp>.<body>"
+// with the webhook secret and comparing it to the signature header :
+//
+//	X-Moneymagnet-Timestamp: 1700000000
+//	X-Moneymagnet-Signature: sha256=<hex hmac>
+package mwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/errr"
+)
+
+const (
+	HeaderEvent     = "X-Moneymagnet-Event"
+	HeaderDelivery  = "X-Moneymagnet-Delivery" // id of event, the same on retry so receiver can ignore duplicate
+	HeaderTimestamp = "X-Moneymagnet-Timestamp"
+	HeaderSignature = "X-Moneymagnet-Signature"
+
+	signaturePrefix = "sha256="
+	maxResponseBody = 1024
+)
+
+// ErrForbiddenAddress returned when receiver resolve to loopback, private or other non public address
+var ErrForbiddenAddress = errors.New("webhook receiver address is not public")
+
+// nonPublicPrefixes are special purpose range not covered by netip.Addr method
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier grade nat
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // nat64 can reach private ipv4
+}
+
+// IsPublicIP return false for loopback, private, link-local, multicast and other special purpose address
+func IsPublicIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL accept absolute http or https url only,
+// address of the host is checked when client connect to it
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errr.T("error.invalid_url", nil)
+	}
+	return nil
+}
+
+// GenerateSecret return random hex secret used to sign payload
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign return signature header value of body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify check signature header value in constant time
+func Verify(secret string, signature string, timestamp int64, body []byte) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Request is one delivery attempt
+type Request struct {
+	URL        string
+	Secret     string // empty secret send request without timestamp and signature header
+	Event      string
+	DeliveryID string
+	Body       []byte
+}
+
+// Response of receiver, body is truncated to 1KB
+type Response struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// Client send signed webhook request
+type Client struct {
+	http *http.Client
+	now  func() time.Time
+}
+
+// NewClient create client, receiver that does not respond within timeout is failed.
+// connection to non public address is refused unless allowPrivate is true,
+// the check is done on resolved ip when dialing so dns rebinding cannot bypass it
+func NewClient(timeout time.Duration, allowPrivate bool) *Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = publicOnly
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// proxy would be dialed instead of receiver and bypass the address check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Client{
+		http: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// redirect is not followed so signed payload is not sent to unexpected host
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// publicOnly is dialer control refusing connection to non public address
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// Send post body as json to request url. error is returned when request cannot be sent
+// or receiver does not respond with 2xx, response is filled whenever receiver responds
+func (c *Client) Send(ctx context.Context, req Request) (Response, error) {
+	timestamp := c.now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return Response{}, fmt.Errorf("create webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "moneymagnet-webhook/1")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	if req.Secret != "" {
+		httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+	}
+
+	start := time.Now()
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return Response{Duration: time.Since(start)}, fmt.Errorf("send webhook: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseBody))
+	resp := Response{
+		StatusCode: httpResp.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return resp, fmt.Errorf("webhook receiver respond with status %d", httpResp.StatusCode)
+	}
+	return resp, nil
+}
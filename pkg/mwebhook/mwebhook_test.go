@@ -0,0 +1,144 @@
+package mwebhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"spend.created"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	if !Verify("secret", signature, 1700000000, body) {
+		t.Error("Verify() must accept its own signature")
+	}
+	if Verify("other", signature, 1700000000, body) {
+		t.Error("Verify() must reject other secret")
+	}
+	if Verify("secret", signature, 1700000001, body) {
+		t.Error("Verify() must reject other timestamp")
+	}
+	if Verify("secret", signature, 1700000000, []byte(`{}`)) {
+		t.Error("Verify() must reject other body")
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	var verified bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified = Verify("secret", r.Header.Get(HeaderSignature), timestamp, body) &&
+			r.Header.Get(HeaderEvent) == "spend.created" &&
+			r.Header.Get(HeaderDelivery) == "evt-1"
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("busy"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	client := NewClient(time.Second, true)
+	req := Request{
+		URL:        receiver.URL,
+		Secret:     "secret",
+		Event:      "spend.created",
+		DeliveryID: "evt-1",
+		Body:       []byte(`{"id":"evt-1"}`),
+	}
+
+	resp, err := client.Send(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !verified {
+		t.Error("receiver cannot verify request")
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body != "ok" {
+		t.Errorf("Send() response = %+v, want 200 ok", resp)
+	}
+
+	req.URL = receiver.URL + "/fail"
+	resp, err = client.Send(context.Background(), req)
+	if err == nil {
+		t.Error("Send() must return error when receiver respond 503")
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Body != "busy" {
+		t.Errorf("Send() response = %+v, want 503 busy", resp)
+	}
+}
+
+func TestClientSendUnsigned(t *testing.T) {
+	var header http.Header
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer receiver.Close()
+
+	_, err := NewClient(time.Second, true).Send(context.Background(), Request{URL: receiver.URL, Event: "notification", Body: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	// signature of empty key would be verifiable by anyone
+	if header.Get(HeaderSignature) != "" || header.Get(HeaderTimestamp) != "" {
+		t.Errorf("request without secret is signed, header = %v", header)
+	}
+	if header.Get(HeaderEvent) != "notification" {
+		t.Errorf("event header = %q, want notification", header.Get(HeaderEvent))
+	}
+}
+
+func TestClientRefusePrivateAddress(t *testing.T) {
+	var called bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer receiver.Close()
+
+	client := NewClient(time.Second, false)
+	// localhost is resolved before dialing, the same as a public name rebinding to loopback
+	urls := []string{receiver.URL, strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)}
+	for _, u := range urls {
+		resp, err := client.Send(context.Background(), Request{URL: u, Secret: "secret", Body: []byte(`{}`)})
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Send(%q) error = %v, want ErrForbiddenAddress", u, err)
+		}
+		if resp.Body != "" {
+			t.Errorf("Send(%q) response body = %q, want empty", u, resp.Body)
+		}
+	}
+	if called {
+		t.Error("receiver on loopback address is called")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	public := []string{"8.8.8.8", "1.1.1.1", "2606:4700:4700::1111"}
+	nonPublic := []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1",
+		"0.0.0.0", "224.0.0.1", "::1", "fe80::1", "fc00::1", "::ffff:127.0.0.1", "64:ff9b::a00:1",
+	}
+
+	for _, ip := range public {
+		if !IsPublicIP(netip.MustParseAddr(ip)) {
+			t.Errorf("IsPublicIP(%s) = false, want true", ip)
+		}
+	}
+	for _, ip := range nonPublic {
+		if IsPublicIP(netip.MustParseAddr(ip)) {
+			t.Errorf("IsPublicIP(%s) = true, want false", ip)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package mmetric
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var backgroundPanicCounter metric.Int64Counter
+
+func init() {
+	var err error
+	backgroundPanicCounter, err = meter.Int64Counter(
+		"background.panic",
+		metric.WithDescription("number of background job recovered from panic"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func AddBackgroundPanicCounter(ctx context.Context, jobTitle string) {
+	backgroundPanicCounter.Add(ctx, 1,
+		metric.WithAttributes(
+			uniquePerNodeID,
+			attribute.String("job", jobTitle),
+		),
+	)
+}
@@ -0,0 +1,31 @@
+package mmetric
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var deadJobCounter metric.Int64Counter
+
+func init() {
+	var err error
+	deadJobCounter, err = meter.Int64Counter(
+		"job.dead",
+		metric.WithDescription("number of durable job moved to dead status"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func AddDeadJobCounter(ctx context.Context, jobType string) {
+	deadJobCounter.Add(ctx, 1,
+		metric.WithAttributes(
+			uniquePerNodeID,
+			attribute.String("job_type", jobType),
+		),
+	)
+}
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muchlist/moneymagnet/pkg/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("moneymagnet")
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	t.Cleanup(issuer.Close)
+
+	provider := NewProvider(Config{
+		IssuerURL:   issuer.URL,
+		ClientID:    "moneymagnet",
+		RedirectURL: "http://localhost:8081/user/oidc/callback",
+		Scopes:      []string{"email", "profile"},
+	})
+	return provider, issuer
+}
+
+func TestS256Challenge(t *testing.T) {
+	// example from RFC 7636 appendix B
+	got := S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if got != want {
+		t.Errorf("S256Challenge() = %s, want %s", got, want)
+	}
+}
+
+func TestProviderLogin(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, _ := GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", S256Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	code, state, err := issuer.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if state != "state-1" {
+		t.Errorf("state = %s, want state-1", state)
+	}
+
+	claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("Exchange() = %+v, unexpected claims", claims)
+	}
+
+	// code is single use
+	if _, err := provider.Exchange(ctx, code, verifier, "nonce-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("Exchange() reuse code error = %v, want ErrExchangeFailed", err)
+	}
+}
+
+func TestProviderExchangeWrongVerifier(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, _ := GenerateVerifier()
+	authURL, _ := provider.AuthCodeURL(ctx, "state", "nonce", S256Challenge(verifier))
+	code, _, err := issuer.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+
+	other, _ := GenerateVerifier()
+	if _, err := provider.Exchange(ctx, code, other, "nonce"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("Exchange() error = %v, want ErrExchangeFailed", err)
+	}
+}
+
+func TestProviderVerifyIDToken(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	valid := jwt.MapClaims{
+		"iss":   issuer.URL,
+		"aud":   "moneymagnet",
+		"sub":   "subject-1",
+		"nonce": "nonce",
+		"exp":   now.Add(time.Minute).Unix(),
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		c := jwt.MapClaims{}
+		for k, v := range valid {
+			c[k] = v
+		}
+		c[key] = value
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		nonce   string
+		wantErr bool
+	}{
+		{name: "valid", claims: valid, nonce: "nonce"},
+		{name: "wrong nonce", claims: valid, nonce: "other", wantErr: true},
+		{name: "wrong issuer", claims: with("iss", "http://evil"), nonce: "nonce", wantErr: true},
+		{name: "wrong audience", claims: with("aud", "other-client"), nonce: "nonce", wantErr: true},
+		{name: "expired", claims: with("exp", now.Add(-time.Hour).Unix()), nonce: "nonce", wantErr: true},
+		{name: "missing subject", claims: with("sub", ""), nonce: "nonce", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := issuer.SignIDToken(tt.claims)
+			if err != nil {
+				t.Fatalf("SignIDToken() error = %v", err)
+			}
+			_, err = provider.VerifyIDToken(ctx, raw, tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/muchlist/moneymagnet/pkg/errr"
 	"github.com/muchlist/moneymagnet/pkg/slicer"
 )
 
@@ -61,7 +62,7 @@ func (f *Cursor) Validate() error {
 	}
 
 	if !slicer.In(f.cursorType, f.cursorList) {
-		return errors.New("invalid cursor type")
+		return errr.T("error.invalid_cursor_type", nil)
 	}
 
 	return nil
@@ -23,7 +23,10 @@ func (f *Filters) setDefault() {
 	if f.Sort == "" {
 		f.Sort = f.SortSafelist[0]
 	}
+	f.setPageDefault()
+}
 
+func (f *Filters) setPageDefault() {
 	if f.Page == 0 {
 		f.Page = 1
 	}
@@ -98,6 +101,15 @@ func (f *Filters) Offset() int {
 	return (f.Page - 1) * f.PageSize
 }
 
+// PageSlice return items in the page of filter with its metadata,
+// used when result must be fully loaded before it can be paged
+func PageSlice[T any](items []T, f Filters) ([]T, Metadata) {
+	f.setPageDefault()
+	start := min(max(f.Offset(), 0), len(items))
+	end := min(start+max(f.Limit(), 0), len(items))
+	return items[start:end], CalculateMetadata(len(items), f.Page, f.PageSize)
+}
+
 // ===========================================================Metadata Pagination
 
 // Metadata for metadata pagination
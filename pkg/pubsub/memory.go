@@ -0,0 +1,76 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// MemoryHub is Hub inside one process, subscriber in other instance does not receive the event
+type MemoryHub struct {
+	fanout  *fanout
+	mu      sync.Mutex
+	lastID  uint64
+	history map[string][]Event
+}
+
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		fanout:  newFanout(),
+		history: make(map[string][]Event),
+	}
+}
+
+var _ Hub = (*MemoryHub)(nil)
+
+func (m *MemoryHub) Publish(_ context.Context, topic string, eventType string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.lastID++
+	ev := Event{
+		ID:    strconv.FormatUint(m.lastID, 10),
+		Topic: topic,
+		Type:  eventType,
+		Data:  raw,
+	}
+	history := append(m.history[topic], ev)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	m.history[topic] = history
+	// send while holding lock so subscriber get event in id order
+	m.fanout.send(ev)
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *MemoryHub) Subscribe(ctx context.Context, topic string, lastID string) (<-chan Event, error) {
+	m.mu.Lock()
+	live, err := m.fanout.add(topic)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	var history []Event
+	if validID(lastID) {
+		for _, ev := range m.history[topic] {
+			if CompareID(ev.ID, lastID) > 0 {
+				history = append(history, ev)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	return m.fanout.stream(ctx, topic, live, history), nil
+}
+
+func (m *MemoryHub) Close() error {
+	m.fanout.close()
+	return nil
+}
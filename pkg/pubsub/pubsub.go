@@ -0,0 +1,200 @@
+// Package pubsub broadcast small event to subscriber of a topic.
+// event is kept for a while so subscriber that reconnect can resume from the last event id it got.
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrClosed returned when hub is already closed
+var ErrClosed = errors.New("pubsub: hub is closed")
+
+const (
+	// historySize is number of event kept per topic for resuming subscriber
+	historySize = 100
+	// subscriberBuffer is number of event waiting to be read before subscriber considered too slow
+	subscriberBuffer = 64
+)
+
+type Event struct {
+	ID    string          `json:"id"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type Hub interface {
+	// Publish send event to every subscriber of topic
+	Publish(ctx context.Context, topic string, eventType string, data any) error
+	// Subscribe return event of topic published after lastID, empty lastID mean only new event.
+	// channel is closed when ctx is done, hub is closed or subscriber is too slow to read,
+	// subscriber can subscribe again with id of last event it got.
+	Subscribe(ctx context.Context, topic string, lastID string) (<-chan Event, error)
+	// Close stop hub and close every subscriber channel
+	Close() error
+}
+
+// fanout deliver event to local subscriber, shared by every hub implementation
+type fanout struct {
+	mu     sync.Mutex
+	subs   map[string]map[chan Event]struct{}
+	closed bool
+}
+
+func newFanout() *fanout {
+	return &fanout{
+		subs: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+func (f *fanout) add(topic string) (chan Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, ErrClosed
+	}
+	ch := make(chan Event, subscriberBuffer)
+	if f.subs[topic] == nil {
+		f.subs[topic] = make(map[chan Event]struct{})
+	}
+	f.subs[topic][ch] = struct{}{}
+	return ch, nil
+}
+
+// remove close ch, it is no-op when ch is already removed
+func (f *fanout) remove(topic string, ch chan Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subs[topic][ch]; !ok {
+		return
+	}
+	delete(f.subs[topic], ch)
+	if len(f.subs[topic]) == 0 {
+		delete(f.subs, topic)
+	}
+	close(ch)
+}
+
+// send never block, subscriber that can not keep up is dropped and must resume
+func (f *fanout) send(ev Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subs[ev.Topic] {
+		select {
+		case ch <- ev:
+		default:
+			delete(f.subs[ev.Topic], ch)
+			close(ch)
+		}
+	}
+	if len(f.subs[ev.Topic]) == 0 {
+		delete(f.subs, ev.Topic)
+	}
+}
+
+func (f *fanout) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.closed = true
+	for topic, chans := range f.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(f.subs, topic)
+	}
+}
+
+// stream write history then live event to out, event already in history is not sent twice.
+// live must be registered before history is read so no event is lost in between
+func (f *fanout) stream(ctx context.Context, topic string, live chan Event, history []Event) <-chan Event {
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		defer f.remove(topic, live)
+
+		lastID := ""
+		for _, ev := range history {
+			select {
+			case out <- ev:
+				lastID = ev.ID
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-live:
+				if !ok {
+					return
+				}
+				if lastID != "" && CompareID(ev.ID, lastID) <= 0 {
+					continue
+				}
+				select {
+				case out <- ev:
+					lastID = ev.ID
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// CompareID compare event id in form of "n" or "n-m", return -1, 0 or 1.
+// unparsable id is treated as zero
+func CompareID(a, b string) int {
+	aMajor, aMinor := splitID(a)
+	bMajor, bMinor := splitID(b)
+	switch {
+	case aMajor < bMajor:
+		return -1
+	case aMajor > bMajor:
+		return 1
+	case aMinor < bMinor:
+		return -1
+	case aMinor > bMinor:
+		return 1
+	}
+	return 0
+}
+
+func splitID(id string) (uint64, uint64) {
+	majorStr, minorStr, _ := strings.Cut(id, "-")
+	major, _ := strconv.ParseUint(majorStr, 10, 64)
+	minor, _ := strconv.ParseUint(minorStr, 10, 64)
+	return major, minor
+}
+
+// validID return true if id is in form of event id, "n" or "n-m"
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	major, minor, hasMinor := strings.Cut(id, "-")
+	if _, err := strconv.ParseUint(major, 10, 64); err != nil {
+		return false
+	}
+	if hasMinor {
+		if _, err := strconv.ParseUint(minor, 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting event")
+	}
+	return Event{}
+}
+
+func TestMemoryHubPublishSubscribe(t *testing.T) {
+	hub := NewMemoryHub()
+	defer hub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := hub.Subscribe(ctx, "pocket:1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// other topic is not received
+	_ = hub.Publish(ctx, "pocket:2", "spend.created", map[string]int{"price": 1})
+	_ = hub.Publish(ctx, "pocket:1", "spend.created", map[string]int{"price": 2})
+
+	ev := receive(t, events)
+	if ev.Type != "spend.created" || string(ev.Data) != `{"price":2}` {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if ev.ID != "2" {
+		t.Errorf("expected id 2, got %s", ev.ID)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel closed after ctx done")
+		}
+	case <-time.After(time.Second):
+		t.Error("channel not closed after ctx done")
+	}
+}
+
+func TestMemoryHubResume(t *testing.T) {
+	hub := NewMemoryHub()
+	defer hub.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_ = hub.Publish(ctx, "pocket:1", "spend.created", i)
+	}
+
+	events, err := hub.Subscribe(ctx, "pocket:1", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = hub.Publish(ctx, "pocket:1", "spend.deleted", 3)
+
+	for _, want := range []string{"2", "3", "4"} {
+		if ev := receive(t, events); ev.ID != want {
+			t.Errorf("expected id %s, got %s", want, ev.ID)
+		}
+	}
+}
+
+func TestMemoryHubDropSlowSubscriber(t *testing.T) {
+	hub := NewMemoryHub()
+	defer hub.Close()
+
+	ctx := context.Background()
+	live, err := hub.fanout.add("pocket:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= subscriberBuffer; i++ {
+		_ = hub.Publish(ctx, "pocket:1", "spend.created", i)
+	}
+
+	count := 0
+	for range live {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("expected %d buffered event before dropped, got %d", subscriberBuffer, count)
+	}
+}
+
+func TestMemoryHubClose(t *testing.T) {
+	hub := NewMemoryHub()
+
+	events, err := hub.Subscribe(context.Background(), "pocket:1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = hub.Close()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel closed after hub closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("channel not closed after hub closed")
+	}
+
+	if _, err := hub.Subscribe(context.Background(), "pocket:1", ""); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestCompareID(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"5", "5", 0},
+		{"1700000000000-0", "1700000000000-1", -1},
+		{"1700000000001-0", "1700000000000-9", 1},
+		{"1700000000000", "1700000000000-0", 0},
+	}
+	for _, tc := range tests {
+		if got := CompareID(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareID(%s, %s) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestValidID(t *testing.T) {
+	for _, id := range []string{"1", "1700000000000-0"} {
+		if !validID(id) {
+			t.Errorf("expected %s valid", id)
+		}
+	}
+	for _, id := range []string{"", "abc", "1-", "-1", "1-a"} {
+		if validID(id) {
+			t.Errorf("expected %s invalid", id)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/muchlist/moneymagnet/pkg/mlogger"
+	"github.com/muchlist/moneymagnet/pkg/observ"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisChannel   = "MAG:events"
+	redisStreamKey = "MAG:events:"
+	// historyTTL remove history of topic that has no new event
+	historyTTL = 24 * time.Hour
+)
+
+// publishScript save event to stream of topic and publish it in one step,
+// so every instance receive event in the same order as their id
+var publishScript = redis.NewScript(`
+local id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[1], '*', 'type', ARGV[2], 'data', ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+redis.call('PUBLISH', ARGV[5], id .. ' ' .. ARGV[6])
+return id
+`)
+
+// RedisHub is Hub shared by every instance connected to the same redis.
+// event is saved in redis stream for resuming and broadcasted with redis pub/sub
+type RedisHub struct {
+	rds    *redis.Client
+	log    mlogger.Logger
+	fanout *fanout
+	ps     *redis.PubSub
+	done   chan struct{}
+}
+
+func NewRedisHub(rds *redis.Client, log mlogger.Logger) *RedisHub {
+	h := &RedisHub{
+		rds:    rds,
+		log:    log,
+		fanout: newFanout(),
+		ps:     rds.Subscribe(context.Background(), redisChannel),
+		done:   make(chan struct{}),
+	}
+	go h.receive()
+	return h
+}
+
+var _ Hub = (*RedisHub)(nil)
+
+// receive forward event from redis to local subscriber until pubsub is closed,
+// go-redis reconnect by itself when connection is lost
+func (h *RedisHub) receive() {
+	defer close(h.done)
+	for msg := range h.ps.Channel() {
+		id, payload, ok := strings.Cut(msg.Payload, " ")
+		if !ok {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(payload), &ev); err != nil {
+			h.log.Warn("decode pubsub event", err)
+			continue
+		}
+		ev.ID = id
+		h.fanout.send(ev)
+	}
+}
+
+func (h *RedisHub) Publish(ctx context.Context, topic string, eventType string, data any) error {
+	ctx, span := observ.GetTracer().Start(ctx, "pubsub.Publish")
+	defer span.End()
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(Event{
+		Topic: topic,
+		Type:  eventType,
+		Data:  raw,
+	})
+	if err != nil {
+		return err
+	}
+
+	return publishScript.Run(ctx, h.rds, []string{redisStreamKey + topic},
+		historySize,
+		eventType,
+		string(raw),
+		int(historyTTL.Seconds()),
+		redisChannel,
+		string(payload),
+	).Err()
+}
+
+func (h *RedisHub) Subscribe(ctx context.Context, topic string, lastID string) (<-chan Event, error) {
+	live, err := h.fanout.add(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []Event
+	if validID(lastID) {
+		history, err = h.history(ctx, topic, lastID)
+		if err != nil {
+			h.fanout.remove(topic, live)
+			return nil, err
+		}
+	}
+
+	return h.fanout.stream(ctx, topic, live, history), nil
+}
+
+// history return saved event of topic after lastID
+func (h *RedisHub) history(ctx context.Context, topic string, lastID string) ([]Event, error) {
+	ctx, span := observ.GetTracer().Start(ctx, "pubsub.History")
+	defer span.End()
+
+	msgs, err := h.rds.XRange(ctx, redisStreamKey+topic, "("+lastID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(msgs))
+	for _, msg := range msgs {
+		eventType, _ := msg.Values["type"].(string)
+		data, _ := msg.Values["data"].(string)
+		events = append(events, Event{
+			ID:    msg.ID,
+			Topic: topic,
+			Type:  eventType,
+			Data:  json.RawMessage(data),
+		})
+	}
+	return events, nil
+}
+
+func (h *RedisHub) Close() error {
+	h.fanout.close()
+	err := h.ps.Close()
+	<-h.done
+	return err
+}
@@ -0,0 +1,115 @@
+// Package totp implement time based one time password (RFC 6238)
+// compatible with google authenticator and similar app.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	digits     = 6
+	period     = 30 // seconds
+	secretSize = 20 // bytes, recommended by RFC 4226
+	skew       = 1  // accept code from previous and next period
+)
+
+var ErrInvalidSecret = errors.New("totp secret not valid")
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret return random base32 secret without padding
+func GenerateSecret() (string, error) {
+	raw := make([]byte, secretSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate totp secret: %w", err)
+	}
+	return b32.EncodeToString(raw), nil
+}
+
+// URI return otpauth uri, usually rendered as qr code
+// otpauth://totp/issuer:account?secret=xxx&issuer=issuer&algorithm=SHA1&digits=6&period=30
+func URI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(digits))
+	q.Set("period", fmt.Sprint(period))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+// GenerateCode return code for secret at time t
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix()/period)), nil
+}
+
+// Validate return true if code match secret at time t,
+// one period before and after is accepted to tolerate clock drift
+func Validate(code, secret string, t time.Time) bool {
+	_, ok := Match(code, secret, t)
+	return ok
+}
+
+// Match is Validate returning time step of matched code. code is single use
+// when caller only accept step after the last accepted one
+func Match(code, secret string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != digits {
+		return 0, false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	counter := t.Unix() / period
+	for i := int64(-skew); i <= skew; i++ {
+		if hmac.Equal([]byte(hotp(key, uint64(counter+i))), []byte(code)) {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := b32.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
+
+// hotp is RFC 4226 with dynamic truncation
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
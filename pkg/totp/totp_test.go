@@ -0,0 +1,98 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret "12345678901234567890" from RFC 6238 appendix B
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "287082"},
+		{name: "1111111109", unix: 1111111109, want: "081804"},
+		{name: "1111111111", unix: 1111111111, want: "050471"},
+		{name: "1234567890", unix: 1234567890, want: "005924"},
+		{name: "2000000000", unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateCode(rfcSecret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("GenerateCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := GenerateCode(rfcSecret, now)
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{name: "same period", code: code, at: now, want: true},
+		{name: "previous period", code: code, at: now.Add(30 * time.Second), want: true},
+		{name: "next period", code: code, at: now.Add(-30 * time.Second), want: true},
+		{name: "too old", code: code, at: now.Add(90 * time.Second), want: false},
+		{name: "wrong code", code: "000000", at: now, want: false},
+		{name: "wrong length", code: "12345", at: now, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Validate(tt.code, rfcSecret, tt.at); got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := GenerateCode(rfcSecret, now)
+
+	// the same code match the same step from every accepted period
+	for _, at := range []time.Time{now.Add(-30 * time.Second), now, now.Add(30 * time.Second)} {
+		step, ok := Match(code, rfcSecret, at)
+		if !ok || step != now.Unix()/30 {
+			t.Errorf("Match() at %v = %d, %v, want step %d", at, step, ok, now.Unix()/30)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	code, err := GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if !Validate(code, secret, time.Now()) {
+		t.Errorf("Validate() generated code not valid")
+	}
+}
+
+func TestURI(t *testing.T) {
+	got := URI("moneymagnet", "john@mail.com", rfcSecret)
+	if !strings.HasPrefix(got, "otpauth://totp/moneymagnet:john@mail.com?") {
+		t.Errorf("URI() = %v", got)
+	}
+	if !strings.Contains(got, "secret="+rfcSecret) {
+		t.Errorf("URI() secret missing: %v", got)
+	}
+}
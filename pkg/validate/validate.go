@@ -7,9 +7,11 @@ import (
 	"strings"
 
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
 )
 
 type Validator interface {
@@ -30,11 +32,16 @@ type Validator interface {
 	// var i int
 	// validate.Var(i, "gt=1,lt=10")
 	Var(input interface{}, tag string) (map[string]string, error)
+
+	// WithLang mengembalikan validator dengan pesan error dalam bahasa lang,
+	// bahasa yang tidak didukung menggunakan bahasa inggris
+	WithLang(lang string) Validator
 }
 
 type mValidator struct {
-	instance   *validator.Validate
-	translator ut.Translator
+	instance    *validator.Validate
+	translator  ut.Translator
+	translators map[string]ut.Translator
 }
 
 type envelop map[string]string
@@ -58,14 +65,23 @@ func New(regs ...Register) *mValidator {
 	// init default translator
 	validate := validator.New()
 	english := en.New()
-	uni := ut.New(english, english)
+	uni := ut.New(english, english, id.New())
 	trans, found := uni.GetTranslator("en")
 	if !found {
 		log.Panic("translator not found")
 	}
+	transID, found := uni.GetTranslator("id")
+	if !found {
+		log.Panic("translator not found")
+	}
 
 	// register default translation
 	_ = enTranslations.RegisterDefaultTranslations(validate, trans)
+	_ = idTranslations.RegisterDefaultTranslations(validate, transID)
+	translators := map[string]ut.Translator{
+		"en": trans,
+		"id": transID,
+	}
 
 	// register tag e.Field() use json tag
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -93,18 +109,26 @@ func New(regs ...Register) *mValidator {
 	// })
 
 	for _, v := range regs {
-		_ = validate.RegisterTranslation(v.Key, trans, func(ut ut.Translator) error {
-			return ut.Add(v.Key, v.Translate, true)
-		}, func(ut ut.Translator, fe validator.FieldError) string {
-			t, _ := ut.T(v.Key, fe.Field())
-			return t
-		})
+		v := v
+		for lang, translator := range translators {
+			text := v.Translate
+			if translated, ok := v.Translations[lang]; ok {
+				text = translated
+			}
+			_ = validate.RegisterTranslation(v.Key, translator, func(ut ut.Translator) error {
+				return ut.Add(v.Key, text, true)
+			}, func(ut ut.Translator, fe validator.FieldError) string {
+				t, _ := ut.T(v.Key, fe.Field())
+				return t
+			})
+		}
 		_ = validate.RegisterValidation(v.Key, v.ValidFunc)
 	}
 
 	return &mValidator{
-		instance:   validate,
-		translator: trans,
+		instance:    validate,
+		translator:  trans,
+		translators: translators,
 	}
 }
 
@@ -113,6 +137,8 @@ type Register struct {
 	Key string
 	// Translate example: "{0} must be valid date format"
 	Translate string
+	// Translations override Translate for other language, example: {"id": "{0} harus format tanggal yang valid"}
+	Translations map[string]string
 	// ValidFunc like you add validator to golang validator/v10
 	ValidFunc func(fl validator.FieldLevel) bool
 }
@@ -128,6 +154,20 @@ func (m *mValidator) Engine() interface{} {
 	return m.instance
 }
 
+// WithLang mengembalikan validator dengan pesan error dalam bahasa lang,
+// bahasa yang tidak didukung menggunakan bahasa inggris
+func (m *mValidator) WithLang(lang string) Validator {
+	translator, ok := m.translators[lang]
+	if !ok {
+		return m
+	}
+	return &mValidator{
+		instance:    m.instance,
+		translator:  translator,
+		translators: m.translators,
+	}
+}
+
 // SliceStruct menerima payload []struct dan mengembalikan validasi error yang
 // didapatkan dalam bentuk map string, dan error aslinya jika valid akan mengembalikan nil
 func (m *mValidator) SliceStruct(input interface{}) (map[string]string, error) {
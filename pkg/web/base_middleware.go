@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/muchlist/moneymagnet/pkg/global"
+	"github.com/muchlist/moneymagnet/pkg/i18n"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -104,3 +105,31 @@ func panicRecovery(l mlogger.Logger) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// Timeout cancel request context after d,
+// route that is meant to be kept open such as event stream must be mounted without it
+func Timeout(d time.Duration) func(next http.Handler) http.Handler {
+	return middleware.Timeout(d)
+}
+
+// language negotiate response language from Accept-Language header.
+// the language is written to Content-Language so error response written through any wrapped writer can read it
+func language(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Match(r.Header.Get("Accept-Language"))
+		if lang == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, SetLanguage(w, r, lang))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// SetLanguage set language of response and return request carrying it, read by ReadLanguage
+func SetLanguage(w http.ResponseWriter, r *http.Request, lang string) *http.Request {
+	w.Header().Set("Content-Language", lang)
+	ctx := context.WithValue(r.Context(), global.LanguageKey, lang)
+	return r.WithContext(ctx)
+}
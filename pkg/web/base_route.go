@@ -2,7 +2,6 @@ package web
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -24,9 +23,10 @@ func (ws *webServer) setupRoutes(injectRoute http.Handler) http.Handler {
 	router.Use(requestAndTraceID)
 	router.Use(middleware.RealIP)
 	router.Use(midLogger(ws.logger))
+	router.Use(language)
 	router.Use(panicRecovery(ws.logger))
 	// router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(10 * time.Second))
+	// request timeout is applied by injected route, so long lived route can be left out
 
 	router.Mount("/", injectRoute)
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,6 +15,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/muchlist/moneymagnet/pkg/global"
+	"github.com/muchlist/moneymagnet/pkg/i18n"
 	"github.com/muchlist/moneymagnet/pkg/xulid"
 )
 
@@ -192,3 +194,25 @@ func ReadTraceID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// ReadLanguage helper reads language negotiated from Accept-Language header or locale of authenticated user,
+// i18n.Source is returned when neither is supported language.
+func ReadLanguage(ctx context.Context) string {
+	if ctx == nil {
+		return i18n.Source
+	}
+	if lang, ok := ctx.Value(global.LanguageKey).(string); ok {
+		return lang
+	}
+	return i18n.Source
+}
+
+// ReadClientIP helper reads ip of client from r.RemoteAddr.
+// the value already resolved from X-Forwarded-For by chi middleware.RealIP.
+func ReadClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,12 +1,16 @@
 package web
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/i18n"
 )
 
+// ErrorResponse write error message, message from i18n catalog is rendered in language of Content-Language header
 func ErrorResponse(w http.ResponseWriter, status int, message interface{}) {
-	env := Envelope{"error": message}
+	env := Envelope{"error": translate(w, message)}
 	err := WriteJSON(w, status, env, nil)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -15,7 +19,7 @@ func ErrorResponse(w http.ResponseWriter, status int, message interface{}) {
 
 func ErrorPayloadResponse(w http.ResponseWriter, message interface{}, errorField map[string]string) {
 	env := Envelope{
-		"error":       message,
+		"error":       translate(w, message),
 		"error_field": errorField,
 	}
 	err := WriteJSON(w, http.StatusBadRequest, env, nil)
@@ -25,16 +29,39 @@ func ErrorPayloadResponse(w http.ResponseWriter, message interface{}, errorField
 }
 
 func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
-	message := "the requested resource could not be found"
-	ErrorResponse(w, http.StatusNotFound, message)
+	ErrorResponse(w, http.StatusNotFound, errr.T("error.resource_not_found", nil))
 }
 
 func MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
-	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
+	message := errr.T("error.method_not_allowed", map[string]string{"method": r.Method})
 	ErrorResponse(w, http.StatusMethodNotAllowed, message)
 }
 
 func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	message := fmt.Sprintf("the server encountered a problem and could not process your request: %s", err.Error())
+	message := errr.T("error.server", map[string]string{"error": err.Error()})
 	ErrorResponse(w, http.StatusInternalServerError, message)
 }
+
+// ErrorMessage return message of err for ErrorResponse, message from i18n catalog is kept
+// so it is rendered in language of user. err wrapped with more context is sent as text
+func ErrorMessage(err error) interface{} {
+	var localizer errr.Localizer
+	if errors.As(err, &localizer) && localizer.Error() == err.Error() {
+		return localizer
+	}
+	return err.Error()
+}
+
+// translate render message from i18n catalog, language is set by language middleware
+// or by auth middleware from locale of user. other message is written as is
+func translate(w http.ResponseWriter, message interface{}) interface{} {
+	localizer, ok := message.(errr.Localizer)
+	if !ok {
+		return message
+	}
+	lang := w.Header().Get("Content-Language")
+	if lang == "" {
+		lang = i18n.Source
+	}
+	return localizer.Localize(lang)
+}
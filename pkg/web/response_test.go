@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muchlist/moneymagnet/pkg/errr"
+	"github.com/muchlist/moneymagnet/pkg/i18n"
+)
+
+func TestErrorResponseLanguage(t *testing.T) {
+	notOwned := errr.NewT("error.pocket_not_owned", map[string]string{"pocket": "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, 400)
+
+	tests := []struct {
+		name string
+		lang string
+		err  error
+		want string
+	}{
+		{name: "indonesian", lang: i18n.Indonesian, err: notOwned, want: "pocket 01ARZ3NDEKTSV4RRFFQ69G5FAV bukan milik anda"},
+		{name: "english", lang: i18n.English, err: notOwned, want: "pocket 01ARZ3NDEKTSV4RRFFQ69G5FAV is not owned by you"},
+		{name: "no language use source", lang: "", err: notOwned, want: "pocket 01ARZ3NDEKTSV4RRFFQ69G5FAV is not owned by you"},
+		{name: "wrapped is sent as text", lang: i18n.Indonesian, err: fmt.Errorf("transfer pocket: %w", notOwned), want: "transfer pocket: pocket 01ARZ3NDEKTSV4RRFFQ69G5FAV is not owned by you"},
+		{name: "not from catalog", lang: i18n.Indonesian, err: errr.New("something else happened", 400), want: "something else happened"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if tc.lang != "" {
+				rec.Header().Set("Content-Language", tc.lang)
+			}
+			ErrorResponse(rec, http.StatusBadRequest, ErrorMessage(tc.err))
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tc.want {
+				t.Errorf("error = %q, want %q", body.Error, tc.want)
+			}
+		})
+	}
+}
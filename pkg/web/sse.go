@@ -0,0 +1,74 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// EventStream write server-sent event to client
+type EventStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// NewEventStream write event stream header and remove write deadline of server,
+// so the connection can be kept open longer than normal request
+func NewEventStream(w http.ResponseWriter) (*EventStream, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disable proxy buffering, nginx hold the response until buffer is full
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &EventStream{w: w, rc: rc}
+	return s, s.flush()
+}
+
+// Send write one event, data is split per line as required by the spec
+func (s *EventStream) Send(id string, event string, data []byte) error {
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// Retry tell client how long to wait before reconnecting
+func (s *EventStream) Retry(d time.Duration) error {
+	if _, err := fmt.Fprintf(s.w, "retry: %d\n\n", d.Milliseconds()); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// Ping write comment that is ignored by client, it keep proxy from closing idle connection
+func (s *EventStream) Ping() error {
+	if _, err := s.w.Write([]byte(": ping\n\n")); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+func (s *EventStream) flush() error {
+	return s.rc.Flush()
+}
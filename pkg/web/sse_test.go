@@ -0,0 +1,35 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventStream(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	stream, err := NewEventStream(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Retry(3 * time.Second)
+	_ = stream.Send("1700000000000-0", "spend.created", []byte(`{"price":10}`))
+	_ = stream.Send("", "", []byte("a\nb"))
+	_ = stream.Ping()
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("unexpected content type %s", got)
+	}
+	if !rec.Flushed {
+		t.Error("expected response flushed")
+	}
+
+	want := "retry: 3000\n\n" +
+		"id: 1700000000000-0\nevent: spend.created\ndata: {\"price\":10}\n\n" +
+		"data: a\ndata: b\n\n" +
+		": ping\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected body\n got: %q\nwant: %q", got, want)
+	}
+}
@@ -11,13 +11,26 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/muchlist/moneymagnet/pkg/bg"
 	"github.com/muchlist/moneymagnet/pkg/mlogger"
 	"go.uber.org/zap"
 )
 
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func()
+)
+
+// OnShutdown register fn to be called when server start shutting down.
+// used to end long lived connection such as event stream, because shutdown wait for every active connection
+func OnShutdown(fn func()) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
 type webServer struct {
 	logger      mlogger.Logger
-	wg          sync.WaitGroup // waitgroup for gracefully shutdown background process
 	port        int
 	env         string
 	serviceName string
@@ -26,7 +39,6 @@ type webServer struct {
 func New(logger mlogger.Logger, port int, env string, serviceName string) *webServer {
 	return &webServer{
 		logger:      logger,
-		wg:          sync.WaitGroup{},
 		port:        port,
 		env:         env,
 		serviceName: serviceName,
@@ -43,6 +55,12 @@ func (ws *webServer) Serve(injectRoute http.Handler) error {
 		WriteTimeout: 30 * time.Second,
 	}
 
+	shutdownMu.Lock()
+	for _, fn := range shutdownHooks {
+		srv.RegisterOnShutdown(fn)
+	}
+	shutdownMu.Unlock()
+
 	// to receive any errows returned
 	// by the graceful Shutdown() function.
 	shutdownError := make(chan error)
@@ -60,12 +78,15 @@ func (ws *webServer) Serve(injectRoute http.Handler) error {
 		err := srv.Shutdown(ctx)
 		if err != nil {
 			shutdownError <- err
+			return
 		}
 
 		ws.logger.Info("completing background tasks")
 
-		ws.wg.Wait()
-		shutdownError <- nil
+		// request is stopped, so no new job is submitted after this point
+		bgCtx, bgCancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer bgCancel()
+		shutdownError <- bg.Shutdown(bgCtx)
 	}()
 
 	ws.logger.Info("starting server", zap.String("addr", srv.Addr), zap.String("env", ws.env))